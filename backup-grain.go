@@ -0,0 +1,563 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/mirror"
+
+	grain_capnp "zenhack.net/go/sandstorm/capnp/grain"
+	bridge_capnp "zenhack.net/go/sandstorm/capnp/sandstormhttpbridge"
+	"zenhack.net/go/sandstorm/exp/sandstormhttpbridge"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+const (
+	backupConfigFile = "/var/backup.json"
+	backupRunsFile   = "/var/backup-runs.json"
+
+	// How many runs, and errors per run, we remember.
+	maxBackupRuns = 50
+	maxRunErrors  = 20
+
+	// Where things go in the destination directory. current holds the
+	// latest copy of the source, and each run then snapshots it. If the
+	// destination can't take snapshots, the run copies current to a new
+	// directory under snapshots instead, named for the time the run
+	// started.
+	backupCurrentDir   = "current"
+	backupSnapshotsDir = "snapshots"
+	snapshotTimeFormat = "2006-01-02T150405Z"
+)
+
+// The backup grain's settings, as saved in backupConfigFile.
+type backupConfig struct {
+	// Sturdy refs for the source Directory and the destination
+	// RwDirectory, as returned by SandstormApi.save.
+	SourceToken, DestToken []byte
+
+	// How often to back up. Zero means only when asked to.
+	IntervalHours int
+
+	// How many daily and weekly snapshots to keep; see
+	// snapshotsToPrune.
+	KeepDaily, KeepWeekly int
+}
+
+func (c backupConfig) configured() bool {
+	return c.SourceToken != nil && c.DestToken != nil
+}
+
+// A record of one backup run, for the UI.
+type backupRun struct {
+	Start, End time.Time
+
+	// The time of the snapshot taken, formatted with
+	// snapshotTimeFormat, which is also the name of the copy under
+	// snapshots if the destination couldn't take it; or "" if the run
+	// failed before getting that far.
+	Snapshot string
+
+	// Whether Snapshot was copied by us, rather than taken by the
+	// destination.
+	Copied bool
+
+	Stats mirror.Stats
+
+	// The first maxRunErrors errors, and how many more there were.
+	Errors     []string
+	MoreErrors int
+}
+
+func (r *backupRun) addError(err error) {
+	if len(r.Errors) < maxRunErrors {
+		r.Errors = append(r.Errors, err.Error())
+	} else {
+		r.MoreErrors++
+	}
+}
+
+func (r backupRun) Duration() time.Duration {
+	return r.End.Sub(r.Start).Round(time.Second)
+}
+
+type Backup struct {
+	bridge bridge_capnp.SandstormHttpBridge
+
+	mu     sync.Mutex
+	config backupConfig
+
+	// Past runs, most recent first.
+	runs []backupRun
+
+	// When the run in progress started, or the zero time if there
+	// isn't one.
+	runningSince time.Time
+}
+
+func loadBackupConfig() backupConfig {
+	config := backupConfig{
+		IntervalHours: 24,
+		KeepDaily:     7,
+		KeepWeekly:    4,
+	}
+	data, err := ioutil.ReadFile(backupConfigFile)
+	if err == nil {
+		err = json.Unmarshal(data, &config)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Print("Error loading backup settings: ", err)
+	}
+	return config
+}
+
+func saveBackupConfig(config backupConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(backupConfigFile, data, 0600)
+}
+
+func loadBackupRuns() []backupRun {
+	var runs []backupRun
+	data, err := ioutil.ReadFile(backupRunsFile)
+	if err == nil {
+		err = json.Unmarshal(data, &runs)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Print("Error loading backup history: ", err)
+	}
+	return runs
+}
+
+func saveBackupRuns(runs []backupRun) error {
+	data, err := json.Marshal(runs)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(backupRunsFile, data, 0600)
+}
+
+// Report when the next scheduled run is due, or the zero time if none
+// is scheduled. b.mu must be held.
+func (b *Backup) nextRun() time.Time {
+	if !b.config.configured() || b.config.IntervalHours <= 0 {
+		return time.Time{}
+	}
+	if len(b.runs) == 0 {
+		return time.Now()
+	}
+	return b.runs[0].Start.Add(time.Duration(b.config.IntervalHours) * time.Hour)
+}
+
+// Start scheduled runs as they come due. Sandstorm shuts grains down
+// when nobody is using them, so a run may well come due while we aren't
+// running; it starts when the grain is next started.
+func (b *Backup) schedule() {
+	for {
+		b.mu.Lock()
+		next := b.nextRun()
+		b.mu.Unlock()
+		if !next.IsZero() && !time.Now().Before(next) {
+			b.start()
+		}
+		time.Sleep(time.Minute)
+	}
+}
+
+// Start a run in the background, unless one is already in progress.
+func (b *Backup) start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.runningSince.IsZero() || !b.config.configured() {
+		return
+	}
+	b.runningSince = time.Now()
+	config := b.config
+	go func() {
+		run := b.run(context.Background(), config)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.runningSince = time.Time{}
+		b.runs = append([]backupRun{run}, b.runs...)
+		if len(b.runs) > maxBackupRuns {
+			b.runs = b.runs[:maxBackupRuns]
+		}
+		if err := saveBackupRuns(b.runs); err != nil {
+			log.Print("Error saving backup history: ", err)
+		}
+	}()
+}
+
+// Report whether the copies under snapshots are pruned, and so whether
+// KeepDaily and KeepWeekly mean anything: they don't if the last run to
+// take a snapshot had the destination take it. b.mu must be held.
+func (b *Backup) prunesCopies() bool {
+	for _, run := range b.runs {
+		if run.Snapshot != "" {
+			return run.Copied
+		}
+	}
+	return true
+}
+
+func (b *Backup) run(ctx context.Context, config backupConfig) (run backupRun) {
+	run.Start = time.Now()
+	defer func() {
+		run.End = time.Now()
+	}()
+
+	src, err := b.restore(ctx, config.SourceToken)
+	if err != nil {
+		run.addError(fmt.Errorf("restoring source: %v", err))
+		return
+	}
+	defer src.Client.Release()
+	dst, err := b.restore(ctx, config.DestToken)
+	if err != nil {
+		run.addError(fmt.Errorf("restoring destination: %v", err))
+		return
+	}
+	defer dst.Client.Release()
+	backUp(ctx, filesystem.Directory{Client: src.Client}, filesystem.RwDirectory{Client: dst.Client}, config, &run)
+	return
+}
+
+// Back src up to dst, recording what happened in run, whose Start is
+// already set.
+func backUp(ctx context.Context, src filesystem.Directory, dst filesystem.RwDirectory, config backupConfig, run *backupRun) {
+	collectErrors := &mirror.Options{
+		Progress: func(e mirror.Event) {
+			if e.Op == mirror.Failed {
+				run.addError(e.Err)
+			}
+		},
+	}
+
+	// Bring current up to date. Only what has changed since the last
+	// run is copied.
+	current, err := client.MkdirAll(ctx, dst, backupCurrentDir)
+	if err != nil {
+		run.addError(err)
+		return
+	}
+	defer current.Client.Release()
+	mirrorOpts := *collectErrors
+	mirrorOpts.Delete = true
+	run.Stats, err = mirror.Mirror(ctx, src, current, &mirrorOpts)
+	if err != nil && run.Stats.Failed == 0 {
+		// Failures were reported to Progress already; anything else
+		// wasn't.
+		run.addError(err)
+	}
+
+	// Then snapshot it, by asking the destination to if it can, or else
+	// by copying it within the destination. This happens even if some
+	// files failed, so that what did change is kept; the failures are
+	// recorded with the run, and the next run tries them again.
+	// Destinations which can't take snapshots, such as local filesystems
+	// without a version directory (local.SnapshotsNotSupported), report
+	// that as unimplemented.
+	res, release := current.Snapshot(ctx, nil)
+	results, err := res.Struct()
+	var snapshot filesystem.Directory_Snapshot
+	if err == nil {
+		snapshot, err = results.Snapshot()
+	}
+	switch {
+	case err == nil:
+		run.Snapshot = time.Unix(0, snapshot.Time()).UTC().Format(snapshotTimeFormat)
+	case capnp.IsUnimplemented(err):
+		run.Copied = true
+		run.Snapshot, err = copySnapshot(ctx, dst, current, run.Start, collectErrors)
+		if err != nil {
+			run.addError(err)
+		}
+	default:
+		run.addError(fmt.Errorf("taking snapshot: %v", err))
+	}
+	release()
+
+	if err = pruneSnapshots(ctx, dst, config); err != nil {
+		run.addError(err)
+	}
+}
+
+// Snapshot current, in dst, by copying it to a directory under
+// snapshots named for start, and return the directory's name. Files which
+// fail to copy are reported to opts.Progress, and left out.
+func copySnapshot(ctx context.Context, dst, current filesystem.RwDirectory, start time.Time, opts *mirror.Options) (string, error) {
+	name := start.UTC().Format(snapshotTimeFormat)
+	snap, err := client.MkdirAll(ctx, dst, backupSnapshotsDir+"/"+name)
+	if err != nil {
+		return "", err
+	}
+	defer snap.Client.Release()
+	mirror.Mirror(ctx, filesystem.Directory{Client: current.Client}, snap, opts)
+	return name, nil
+}
+
+// Delete the snapshots in dst which config says we needn't keep. These
+// are only the copies made by copySnapshot; the destination decides how
+// long to keep the snapshots it takes itself, and there's no way for us to
+// delete them.
+func pruneSnapshots(ctx context.Context, dst filesystem.RwDirectory, config backupConfig) error {
+	entries, err := client.ReadDir(ctx, filesystem.Directory{Client: dst.Client}, backupSnapshotsDir)
+	if fserrors.CodeOf(err) == filesystem.ErrorCode_notFound {
+		// We've never had to copy a snapshot.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var times []time.Time
+	for _, ent := range entries {
+		t, err := time.Parse(snapshotTimeFormat, ent.Name())
+		if err != nil || !ent.IsDir() {
+			// Not one of ours; leave it alone.
+			continue
+		}
+		times = append(times, t)
+	}
+	for _, t := range snapshotsToPrune(times, config.KeepDaily, config.KeepWeekly) {
+		if err = client.RemoveAll(ctx, dst, backupSnapshotsDir+"/"+t.Format(snapshotTimeFormat)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return which of the snapshots taken at times to delete. We keep the
+// most recent snapshot from each of the last keepDaily days on which
+// one was taken, and likewise for the last keepWeekly weeks, as well as
+// the most recent snapshot overall. If both are zero, we keep
+// everything.
+func snapshotsToPrune(times []time.Time, keepDaily, keepWeekly int) []time.Time {
+	if keepDaily <= 0 && keepWeekly <= 0 {
+		return nil
+	}
+	sorted := append([]time.Time(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].After(sorted[j])
+	})
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	var prune []time.Time
+	for i, t := range sorted {
+		keep := i == 0
+		day := t.UTC().Format("2006-01-02")
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep = true
+		}
+		year, week := t.UTC().ISOWeek()
+		weekKey := fmt.Sprintf("%d-%d", year, week)
+		if !weeks[weekKey] && len(weeks) < keepWeekly {
+			weeks[weekKey] = true
+			keep = true
+		}
+		if !keep {
+			prune = append(prune, t)
+		}
+	}
+	return prune
+}
+
+func (b *Backup) sandstormApi(ctx context.Context) (grain_capnp.SandstormApi, capnp.ReleaseFunc) {
+	res, release := b.bridge.GetSandstormApi(ctx, nil)
+	return res.Api(), release
+}
+
+// Restore the capability saved as token.
+func (b *Backup) restore(ctx context.Context, token []byte) (filesystem.Node, error) {
+	api, releaseApi := b.sandstormApi(ctx)
+	defer releaseApi()
+	res, release := api.Restore(ctx, func(p grain_capnp.SandstormApi_restore_Params) error {
+		return p.SetToken(token)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return filesystem.Node{}, err
+	}
+	capability, err := results.Cap()
+	if err != nil {
+		return filesystem.Node{}, err
+	}
+	return filesystem.Node{Client: capability.Interface().Client().AddRef()}, nil
+}
+
+// Claim the capability for the powerbox request token in req's body,
+// and save it, returning the token to restore it with.
+func (b *Backup) claim(req *http.Request, label string) ([]byte, error) {
+	ctx := req.Context()
+	buf, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	sessionCtx := sandstormhttpbridge.GetSessionContext(b.bridge, req)
+	claimRes, releaseClaim := sessionCtx.ClaimRequest(
+		ctx,
+		func(p grain_capnp.SessionContext_claimRequest_Params) error {
+			p.SetRequestToken(string(buf))
+			return nil
+		})
+	defer releaseClaim()
+	claimed, err := claimRes.Struct()
+	if err != nil {
+		return nil, err
+	}
+	capability, err := claimed.Cap()
+	if err != nil {
+		return nil, err
+	}
+
+	api, releaseApi := b.sandstormApi(ctx)
+	defer releaseApi()
+	saveRes, releaseSave := api.Save(ctx, func(p grain_capnp.SandstormApi_save_Params) error {
+		capId := p.Struct.Segment().Message().AddCap(capability.Interface().Client().AddRef())
+		if err := p.SetCap(capnp.NewInterface(p.Struct.Segment(), capId).ToPtr()); err != nil {
+			return err
+		}
+		l, err := p.NewLabel()
+		if err != nil {
+			return err
+		}
+		return l.SetDefaultText(label)
+	})
+	defer releaseSave()
+	saved, err := saveRes.Struct()
+	if err != nil {
+		return nil, err
+	}
+	token, err := saved.Token()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), token...), nil
+}
+
+// Returns a "backup" grain, which copies a directory from one grain to
+// another on a schedule, keeping snapshots of past copies.
+func initBackup(bridge bridge_capnp.SandstormHttpBridge) *Backup {
+	b := &Backup{
+		bridge: bridge,
+		config: loadBackupConfig(),
+		runs:   loadBackupRuns(),
+	}
+	go b.schedule()
+	http.Handle("/", withLock(b.ui()))
+	return b
+}
+
+func (b *Backup) ui() http.Handler {
+	r := mux.NewRouter()
+
+	serverError := func(w http.ResponseWriter, err error) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+	}
+
+	// Handle the token for a capability the user picked in the
+	// powerbox, storing the sturdy ref with set.
+	claimHandler := func(label string, set func(*backupConfig, []byte)) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			token, err := b.claim(req, label)
+			if err != nil {
+				log.Print("Error claiming ", label, ": ", err)
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("Bad Request"))
+				return
+			}
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			set(&b.config, token)
+			if err = saveBackupConfig(b.config); err != nil {
+				serverError(w, err)
+			}
+		}
+	}
+
+	r.Methods("POST").Path("/source-cap").
+		HandlerFunc(claimHandler("Backup source", func(c *backupConfig, token []byte) {
+			c.SourceToken = token
+		}))
+
+	r.Methods("POST").Path("/dest-cap").
+		HandlerFunc(claimHandler("Backup destination", func(c *backupConfig, token []byte) {
+			c.DestToken = token
+		}))
+
+	r.Methods("GET").Path("/").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			tpls.ExecuteTemplate(w, "backup-index.html", struct {
+				HaveSource, HaveDest bool
+				Config               backupConfig
+				PrunesCopies         bool
+				RunningSince         time.Time
+				NextRun              time.Time
+				Runs                 []backupRun
+			}{
+				HaveSource:   b.config.SourceToken != nil,
+				HaveDest:     b.config.DestToken != nil,
+				Config:       b.config,
+				PrunesCopies: b.prunesCopies(),
+				RunningSince: b.runningSince,
+				NextRun:      b.nextRun(),
+				Runs:         b.runs,
+			})
+		})
+
+	r.Methods("POST").Path("/settings").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			interval, err1 := strconv.Atoi(req.FormValue("interval-hours"))
+			daily, err2 := strconv.Atoi(req.FormValue("keep-daily"))
+			weekly, err3 := strconv.Atoi(req.FormValue("keep-weekly"))
+			if err1 != nil || err2 != nil || err3 != nil || interval < 0 || daily < 0 || weekly < 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("Bad Request"))
+				return
+			}
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.config.IntervalHours = interval
+			b.config.KeepDaily = daily
+			b.config.KeepWeekly = weekly
+			if err := saveBackupConfig(b.config); err != nil {
+				serverError(w, err)
+				return
+			}
+			http.Redirect(w, req, "/", http.StatusSeeOther)
+		})
+
+	r.Methods("POST").Path("/run").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			b.start()
+			http.Redirect(w, req, "/", http.StatusSeeOther)
+		})
+
+	r.Methods("GET").Path("/pb-request-source.js").
+		Handler(PbRequestButton(DirectoryReq, 0, "source-btn", "/source-cap"))
+
+	r.Methods("GET").Path("/pb-request-dest.js").
+		Handler(PbRequestButton(RwDirectoryReq, 1, "dest-btn", "/dest-cap"))
+
+	return r
+}
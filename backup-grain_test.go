@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/local"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+)
+
+// Make a memfs to back up, containing a.txt and d/b.txt.
+func newBackupSource(ctx context.Context, t *testing.T) filesystem.Directory {
+	t.Helper()
+	root := memfs.New(0).Root()
+	t.Cleanup(root.Client.Release)
+	for _, p := range []string{"a.txt", "d/b.txt"} {
+		if err := client.WriteFile(ctx, root, p, []byte(p), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filesystem.Directory{Client: root.Client}
+}
+
+// Check that p in dir holds a copy of newBackupSource's files.
+func checkBackedUp(ctx context.Context, t *testing.T, dir filesystem.Directory, p string) {
+	t.Helper()
+	for _, name := range []string{"a.txt", "d/b.txt"} {
+		data, err := client.ReadFile(ctx, dir, p+"/"+name)
+		if err != nil {
+			t.Error(err)
+		} else if string(data) != name {
+			t.Errorf("%s/%s contains %q, want %q", p, name, data, name)
+		}
+	}
+}
+
+func checkRun(t *testing.T, run backupRun, copied bool) {
+	t.Helper()
+	if len(run.Errors) != 0 || run.MoreErrors != 0 {
+		t.Errorf("got errors %v", run.Errors)
+	}
+	if run.Snapshot == "" {
+		t.Error("took no snapshot")
+	}
+	if run.Copied != copied {
+		t.Errorf("got Copied = %v, want %v", run.Copied, copied)
+	}
+	if run.Stats.Copied != 3 {
+		t.Errorf("copied %d nodes, want 3", run.Stats.Copied)
+	}
+}
+
+// memfs takes snapshots itself, so nothing is put under snapshots.
+func TestBackUpMemfs(t *testing.T) {
+	ctx := context.Background()
+	src := newBackupSource(ctx, t)
+	dst := memfs.New(0).Root()
+	defer dst.Client.Release()
+
+	run := backupRun{Start: time.Now()}
+	backUp(ctx, src, dst, backupConfig{KeepDaily: 1}, &run)
+	checkRun(t, run, false)
+	dstDir := filesystem.Directory{Client: dst.Client}
+	checkBackedUp(ctx, t, dstDir, backupCurrentDir)
+	if _, err := client.Stat(ctx, dstDir, backupSnapshotsDir); err == nil {
+		t.Errorf("made %s, though the destination takes snapshots", backupSnapshotsDir)
+	}
+
+	current, err := client.Walk(ctx, dstDir, backupCurrentDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer current.Client.Release()
+	res, release := filesystem.Directory{Client: current.Client}.Snapshots(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := results.Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	} else if snapshots.Len() != 1 {
+		t.Fatalf("current has %d snapshots, want 1", snapshots.Len())
+	}
+	checkBackedUp(ctx, t, snapshots.At(0).Dir(), "")
+}
+
+// A local filesystem without a version directory can't take snapshots, so
+// current is copied under snapshots, and old copies are pruned.
+func TestBackUpLocal(t *testing.T) {
+	ctx := context.Background()
+	src := newBackupSource(ctx, t)
+	n, err := local.NewNode(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filesystem.RwDirectory{Client: n.MakeClient().Client}
+	defer dst.Client.Release()
+
+	start := time.Now()
+	old := []string{
+		start.Add(-48 * time.Hour).UTC().Format(snapshotTimeFormat),
+		start.Add(-24 * time.Hour).UTC().Format(snapshotTimeFormat),
+		"not-a-snapshot",
+	}
+	for _, name := range old {
+		dir, err := client.MkdirAll(ctx, dst, backupSnapshotsDir+"/"+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir.Client.Release()
+	}
+
+	run := backupRun{Start: start}
+	backUp(ctx, src, dst, backupConfig{KeepDaily: 2}, &run)
+	checkRun(t, run, true)
+	dstDir := filesystem.Directory{Client: dst.Client}
+	checkBackedUp(ctx, t, dstDir, backupCurrentDir)
+	if want := start.UTC().Format(snapshotTimeFormat); run.Snapshot != want {
+		t.Errorf("got snapshot %q, want %q", run.Snapshot, want)
+	}
+	checkBackedUp(ctx, t, dstDir, backupSnapshotsDir+"/"+run.Snapshot)
+
+	entries, err := client.ReadDir(ctx, dstDir, backupSnapshotsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ent := range entries {
+		got = append(got, ent.Name())
+	}
+	if want := []string{old[1], run.Snapshot, old[2]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("%s contains %v, want %v", backupSnapshotsDir, got, want)
+	}
+}
+
+func TestSnapshotsToPrune(t *testing.T) {
+	at := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	// 2024-01-10 is a Wednesday, in the same ISO week as the 8th and
+	// 9th; the 3rd and 2023-12-27 are each in the weeks before.
+	times := []time.Time{
+		at("2024-01-03T12:00:00Z"),
+		at("2024-01-10T08:00:00Z"),
+		at("2023-12-27T12:00:00Z"),
+		at("2024-01-10T12:00:00Z"),
+		at("2024-01-08T12:00:00Z"),
+		at("2024-01-09T12:00:00Z"),
+	}
+	tests := []struct {
+		name                  string
+		keepDaily, keepWeekly int
+		want                  []time.Time
+	}{
+		{
+			name: "keep everything",
+		},
+		{
+			name:      "latest only",
+			keepDaily: 1,
+			want: []time.Time{
+				at("2024-01-10T08:00:00Z"),
+				at("2024-01-09T12:00:00Z"),
+				at("2024-01-08T12:00:00Z"),
+				at("2024-01-03T12:00:00Z"),
+				at("2023-12-27T12:00:00Z"),
+			},
+		},
+		{
+			name:       "daily and weekly",
+			keepDaily:  2,
+			keepWeekly: 2,
+			want: []time.Time{
+				at("2024-01-10T08:00:00Z"),
+				at("2024-01-08T12:00:00Z"),
+				at("2023-12-27T12:00:00Z"),
+			},
+		},
+		{
+			name:       "weekly only",
+			keepWeekly: 5,
+			want: []time.Time{
+				at("2024-01-10T08:00:00Z"),
+				at("2024-01-09T12:00:00Z"),
+				at("2024-01-08T12:00:00Z"),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := snapshotsToPrune(times, test.keepDaily, test.keepWeekly)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// Package audit records the operations performed through filesystem
+// capabilities.
+//
+// Wrap puts a capability inside a membrane (see package membrane) which
+// appends a Record to a Log for every call made on it, or on anything
+// derived from it.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/membrane"
+)
+
+// A Record describes one operation.
+type Record struct {
+	Time time.Time
+
+	// Identifies the share (i.e. the capability passed to Wrap) through
+	// which the operation was performed.
+	Share string
+
+	// As in membrane.Op.
+	Path   string
+	Method string
+	Arg    string `json:",omitempty"`
+	Bytes  int64  `json:",omitempty"`
+
+	// The error the operation failed with, if any.
+	Error string `json:",omitempty"`
+}
+
+// Once a log's file grows past this many bytes, it is rotated: the file
+// is renamed to have a ".1" suffix, replacing the previous one, and a new
+// file is started. So a log never takes up much more than twice this.
+const MaxSize = 4 << 20
+
+// How much of a log file Tail reads at a time.
+const tailChunkSize = 64 << 10
+
+// A Log is an append-only file of Records, one JSON object per line,
+// which is rotated as it grows; see MaxSize.
+type Log struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	size    int64
+	maxSize int64
+}
+
+// Open the log at path, creating it if it does not exist.
+func Open(path string) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &Log{path: path, file: file, size: fi.Size(), maxSize: MaxSize}, nil
+}
+
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Append r to the log.
+func (l *Log) Append(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil || l.size < l.maxSize {
+		return err
+	}
+	return l.rotate()
+}
+
+// Move the current file aside, and start a new one. Must be called with
+// l.mu held.
+func (l *Log) rotate() error {
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		// Keep appending to the old file, rather than losing records.
+		return err
+	}
+	l.file.Close()
+	l.file = file
+	l.size = 0
+	return nil
+}
+
+// Return the last n records in the log (all of them, if n <= 0), most
+// recent first. The files are read backwards from the end, so this takes
+// time proportional to n rather than to the size of the log.
+func (l *Log) Tail(n int) ([]Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records, err := tailFile(l.path, n)
+	if err != nil || (n > 0 && len(records) >= n) {
+		return records, err
+	}
+	rest := 0
+	if n > 0 {
+		rest = n - len(records)
+	}
+	older, err := tailFile(l.path+".1", rest)
+	return append(records, older...), err
+}
+
+// Like Tail, but for a single file, which need not exist.
+func tailFile(path string, n int) ([]Record, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		records []Record
+		// Read but not yet parsed; unless pos is 0, this starts part
+		// way through a line.
+		partial []byte
+		pos     = fi.Size()
+	)
+	for pos > 0 && (n <= 0 || len(records) < n) {
+		size := int64(tailChunkSize)
+		if size > pos {
+			size = pos
+		}
+		pos -= size
+		buf := make([]byte, size, size+int64(len(partial)))
+		if _, err := file.ReadAt(buf, pos); err != nil {
+			return nil, err
+		}
+		lines := append(buf, partial...)
+		partial = nil
+		if pos > 0 {
+			i := bytes.IndexByte(lines, '\n')
+			if i < 0 {
+				partial = lines
+				continue
+			}
+			partial, lines = lines[:i], lines[i+1:]
+		}
+		split := bytes.Split(lines, []byte{'\n'})
+		for i := len(split) - 1; i >= 0 && (n <= 0 || len(records) < n); i-- {
+			var r Record
+			if err := json.Unmarshal(split[i], &r); err != nil {
+				// Most likely a partially written line, or the
+				// empty "line" after the last newline; skip it.
+				continue
+			}
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+// Hooks returns membrane hooks which log operations to l, as having been
+// performed through share.
+func Hooks(share string, l *Log) membrane.Hooks {
+	return membrane.Hooks{
+		Log: func(op membrane.Op) {
+			r := Record{
+				Time:   op.Time,
+				Share:  share,
+				Path:   op.Path,
+				Method: op.Method,
+				Arg:    op.Arg,
+				Bytes:  op.Bytes,
+			}
+			if op.Err != nil {
+				r.Error = op.Err.Error()
+			}
+			if err := l.Append(r); err != nil {
+				log.Print("Error writing audit log: ", err)
+			}
+		},
+	}
+}
+
+// Wrap returns a proxy for node, which implements the interface given by
+// kind, which logs everything done through it to l, as having been
+// performed through share. Wrap takes ownership of node.Client.
+func Wrap(node filesystem.Node, kind membrane.Kind, share string, l *Log) filesystem.Node {
+	return membrane.Wrap(node, kind, Hooks(share, l))
+}
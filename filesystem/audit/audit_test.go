@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/local"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/membrane"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+
+	"zenhack.net/go/sandstorm/exp/util/bytestream"
+)
+
+func openLog(t *testing.T) *Log {
+	t.Helper()
+	l, err := Open(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// Tail returns the most recent records first, across rotations, and
+// rotation bounds the size of the log.
+func TestTail(t *testing.T) {
+	l := openLog(t)
+	l.maxSize = 3 * tailChunkSize
+	const count = 10000
+	for i := 0; i < count; i++ {
+		if err := l.Append(Record{Share: "s", Path: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, n := range []int{1, 10, 3000} {
+		records, err := l.Tail(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != n {
+			t.Fatalf("Tail(%d) returned %d records", n, len(records))
+		}
+		for i, r := range records {
+			if want := fmt.Sprint(count - 1 - i); r.Path != want {
+				t.Fatalf("Tail(%d)[%d] is record %s, want %s", n, i, r.Path, want)
+			}
+		}
+	}
+
+	all, err := l.Tail(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == count {
+		t.Error("nothing was rotated away")
+	}
+	for _, suffix := range []string{"", ".1"} {
+		fi, err := os.Stat(l.path + suffix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() > l.maxSize+1000 {
+			t.Errorf("%s is %d bytes, more than the maximum of %d", l.path+suffix, fi.Size(), l.maxSize)
+		}
+	}
+}
+
+// Records from before the log was reopened are still there, and
+// partially written lines are skipped.
+func TestTailReopen(t *testing.T) {
+	l := openLog(t)
+	if err := l.Append(Record{Path: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	l.file.Write([]byte(`{"Path": "b`))
+	l.Close()
+	l, err := Open(l.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	records, err := l.Tail(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Path != "a" {
+		t.Errorf("got %+v, want just a", records)
+	}
+}
+
+// Find the record for method, failing if there isn't exactly one.
+func findRecord(t *testing.T, records []Record, method string) Record {
+	t.Helper()
+	var found []Record
+	for _, r := range records {
+		if r.Method == method {
+			found = append(found, r)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("found %d records for %s, want 1: %+v", len(found), method, found)
+	}
+	return found[0]
+}
+
+func checkRecord(t *testing.T, got, want Record) {
+	t.Helper()
+	if got.Share != want.Share || got.Path != want.Path || got.Method != want.Method ||
+		got.Arg != want.Arg || got.Bytes != want.Bytes || got.Error != want.Error {
+		t.Errorf("got record %+v, want %+v", got, want)
+	}
+}
+
+func TestRecords(t *testing.T) {
+	ctx := context.Background()
+	l := openLog(t)
+	root := filesystem.RwDirectory{
+		Client: Wrap(filesystem.Node{Client: memfs.New(0).Root().Client}, membrane.KindRwDirectory, "share", l).Client,
+	}
+	defer root.Client.Release()
+
+	tail := func() []Record {
+		t.Helper()
+		records, err := l.Tail(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return records
+	}
+
+	dir, err := client.MkdirAll(ctx, root, "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dir.Client.Release()
+	checkRecord(t, findRecord(t, tail(), "mkdir"), Record{Share: "share", Method: "mkdir", Arg: "d"})
+
+	file, err := client.Create(ctx, dir, "a.txt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Client.Release()
+	checkRecord(t, findRecord(t, tail(), "create"), Record{Share: "share", Path: "d", Method: "create", Arg: "a.txt"})
+
+	w := client.NewWriter(ctx, file, 0)
+	if _, err = w.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	checkRecord(t, findRecord(t, tail(), "write"), Record{Share: "share", Path: "d/a.txt", Method: "write", Bytes: 5})
+
+	before := len(tail())
+	data, err := client.ReadFile(ctx, filesystem.Directory{Client: root.Client}, "d/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	} else if string(data) != "hello" {
+		t.Fatalf("read %q, want %q", data, "hello")
+	}
+	records := tail()
+	records = records[:len(records)-before]
+	checkRecord(t, findRecord(t, records, "read"), Record{Share: "share", Path: "d/a.txt", Method: "read", Bytes: 5})
+	var walks []string
+	for _, r := range records {
+		if r.Method == "walk" {
+			walks = append(walks, r.Path+":"+r.Arg)
+		}
+	}
+	if got := strings.Join(walks, " "); got != "d:a.txt :d" {
+		t.Errorf("got walks %q, want %q", got, "d:a.txt :d")
+	}
+
+	// A failed walk records the error.
+	if _, err = client.Walk(ctx, filesystem.Directory{Client: root.Client}, "missing"); err == nil {
+		t.Fatal("walked to a missing file")
+	}
+	r := tail()[0]
+	if r.Method != "walk" || r.Arg != "missing" || r.Error == "" {
+		t.Errorf("got record %+v for a failed walk", r)
+	}
+}
+
+func TestAppendRecords(t *testing.T) {
+	ctx := context.Background()
+	l := openLog(t)
+	n, err := local.NewNode(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.AppendOnly = true
+	root := filesystem.AppendOnlyDirectory{
+		Client: Wrap(n.MakeClient(), membrane.KindAppendOnlyDirectory, "share", l).Client,
+	}
+	defer root.Client.Release()
+
+	createRes, release := root.Create(ctx, func(p filesystem.AppendOnlyDirectory_create_Params) error {
+		return p.SetName("log.txt")
+	})
+	defer release()
+	appendRes, release := createRes.File().Append(ctx, nil)
+	defer release()
+	w := bytestream.ToWriteCloser(ctx, appendRes.Sink())
+	if _, err = w.Write([]byte("some text")); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := l.Tail(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRecord(t, findRecord(t, records, "create"), Record{Share: "share", Method: "create", Arg: "log.txt"})
+	checkRecord(t, findRecord(t, records, "append"), Record{Share: "share", Path: "log.txt", Method: "append", Bytes: 9})
+}
@@ -0,0 +1,358 @@
+// Package client provides a path-based interface to filesystem
+// capabilities, in the style of package os.
+//
+// Paths are slash-separated and relative to the root capability passed to
+// each function; "", "." and "/" all refer to the root itself. A leading
+// slash is ignored. Errors are returned as *fs.PathError; errors from the
+// server are decoded with fserrors.Decode, so errors.Is(err,
+// fs.ErrNotExist) and friends work, as do fserrors.Errno and HTTPStatus.
+//
+// Capabilities returned by this package are owned by the caller, who must
+// release them; everything else is released before returning.
+package client
+
+import (
+	"context"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+// The extended attribute holding a node's MIME type; see Node.getXattr in
+// filesystem.capnp.
+const MimeTypeXattr = "mime_type"
+
+var errNotADirectory = fserrors.New(filesystem.ErrorCode_notADirectory, "Not a directory")
+
+// Split p into its components. Returns fs.ErrInvalid if p is malformed.
+func splitPath(p string) ([]string, error) {
+	p = strings.TrimPrefix(p, "/")
+	if p == "" || p == "." {
+		return nil, nil
+	}
+	if !fs.ValidPath(p) {
+		return nil, fs.ErrInvalid
+	}
+	return strings.Split(p, "/"), nil
+}
+
+// Split p into the path of its parent and its last component. The root
+// has no parent, so this returns fs.ErrInvalid for it.
+func splitParent(p string) (string, string, error) {
+	parts, err := splitPath(p)
+	if err != nil {
+		return "", "", err
+	}
+	if len(parts) == 0 {
+		return "", "", fs.ErrInvalid
+	}
+	return strings.Join(parts[:len(parts)-1], "/"), parts[len(parts)-1], nil
+}
+
+// Walk returns the node at p. The walks for each component of p are
+// pipelined, so this takes one round trip regardless of p's length.
+func Walk(ctx context.Context, root filesystem.Directory, p string) (filesystem.Node, error) {
+	parts, err := splitPath(p)
+	if err != nil {
+		return filesystem.Node{}, &fs.PathError{Op: "walk", Path: p, Err: err}
+	}
+	if len(parts) == 0 {
+		return filesystem.Node{Client: root.Client.AddRef()}, nil
+	}
+
+	var releases []capnp.ReleaseFunc
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+	dir := root
+	var res filesystem.Directory_walk_Results_Future
+	for _, name := range parts {
+		var release capnp.ReleaseFunc
+		res, release = dir.Walk(ctx, func(p filesystem.Directory_walk_Params) error {
+			return p.SetName(name)
+		})
+		releases = append(releases, release)
+		dir = filesystem.Directory{Client: res.Node().Client}
+	}
+	results, err := res.Struct()
+	if capnp.IsUnimplemented(err) {
+		// One of the nodes along the way doesn't implement walk.
+		err = errNotADirectory
+	}
+	if err != nil {
+		return filesystem.Node{}, &fs.PathError{Op: "walk", Path: p, Err: fserrors.Decode(err)}
+	}
+	return filesystem.Node{Client: results.Node().Client.AddRef()}, nil
+}
+
+// Like Walk, but returns a Directory.
+func WalkDir(ctx context.Context, root filesystem.Directory, p string) (filesystem.Directory, error) {
+	node, err := Walk(ctx, root, p)
+	return filesystem.Directory{Client: node.Client}, err
+}
+
+// Like Walk, but returns an RwDirectory.
+func WalkRwDir(ctx context.Context, root filesystem.RwDirectory, p string) (filesystem.RwDirectory, error) {
+	node, err := Walk(ctx, filesystem.Directory{Client: root.Client}, p)
+	return filesystem.RwDirectory{Client: node.Client}, err
+}
+
+// Stat returns information about the node at p.
+func Stat(ctx context.Context, root filesystem.Directory, p string) (*FileInfo, error) {
+	node, err := Walk(ctx, root, p)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Client.Release()
+	fi, err := StatNode(ctx, node, baseName(p))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: p, Err: fserrors.Decode(err)}
+	}
+	return fi, nil
+}
+
+// StatNode returns information about node, which is called name.
+func StatNode(ctx context.Context, node filesystem.Node, name string) (*FileInfo, error) {
+	res, release := node.Stat(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return nil, err
+	}
+	info, err := results.Info()
+	if err != nil {
+		return nil, err
+	}
+	return NewFileInfo(name, info)
+}
+
+func baseName(p string) string {
+	p = strings.TrimSuffix(p, "/")
+	return p[strings.LastIndex(p, "/")+1:]
+}
+
+// A FileInfo describes a node. It implements fs.FileInfo.
+type FileInfo struct {
+	name       string
+	isDir      bool
+	size       int64
+	executable bool
+	writable   bool
+	mimeType   string
+}
+
+// NewFileInfo returns a FileInfo for a node called name, described by
+// info. The FileInfo does not refer to info after this returns.
+func NewFileInfo(name string, info filesystem.StatInfo) (*FileInfo, error) {
+	mimeType, err := info.MimeType()
+	if err != nil {
+		return nil, err
+	}
+	fi := &FileInfo{
+		name:       name,
+		isDir:      info.Which() == filesystem.StatInfo_Which_dir,
+		executable: info.Executable(),
+		writable:   info.Writable(),
+		mimeType:   mimeType,
+	}
+	if !fi.isDir {
+		fi.size = info.File().Size()
+	}
+	return fi, nil
+}
+
+func (fi *FileInfo) Name() string {
+	return fi.name
+}
+
+// Size returns the size of a file, or zero for a directory.
+func (fi *FileInfo) Size() int64 {
+	return fi.size
+}
+
+func (fi *FileInfo) Mode() fs.FileMode {
+	mode := fs.FileMode(0444)
+	if fi.isDir {
+		mode |= fs.ModeDir | 0111
+	}
+	if fi.executable {
+		mode |= 0111
+	}
+	if fi.writable {
+		mode |= 0200
+	}
+	return mode
+}
+
+// The schema doesn't carry modification times, so this is always the
+// zero time.
+func (fi *FileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (fi *FileInfo) IsDir() bool {
+	return fi.isDir
+}
+
+func (fi *FileInfo) Sys() interface{} {
+	return nil
+}
+
+func (fi *FileInfo) Executable() bool {
+	return fi.executable
+}
+
+func (fi *FileInfo) Writable() bool {
+	return fi.writable
+}
+
+// MimeType returns the MIME type recorded for the file, or "" if there is
+// none.
+func (fi *FileInfo) MimeType() string {
+	return fi.mimeType
+}
+
+// ReadDir returns the entries of the directory at p, sorted by name.
+func ReadDir(ctx context.Context, root filesystem.Directory, p string) ([]fs.DirEntry, error) {
+	dir, err := WalkDir(ctx, root, p)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Client.Release()
+	entries, err := List(ctx, dir)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: p, Err: fserrors.Decode(err)}
+	}
+	return entries, nil
+}
+
+// List returns the entries of dir, sorted by name.
+func List(ctx context.Context, dir filesystem.Directory) ([]fs.DirEntry, error) {
+	c := &entryCollector{}
+	res, release := dir.List(ctx, func(p filesystem.Directory_list_Params) error {
+		return p.SetStream(filesystem.Directory_Entry_Stream_ServerToClient(c, nil))
+	})
+	defer release()
+	if _, err := res.Struct(); err != nil {
+		return nil, err
+	}
+	sort.Slice(c.entries, func(i, j int) bool {
+		return c.entries[i].Name() < c.entries[j].Name()
+	})
+	return c.entries, nil
+}
+
+// Collects the entries pushed by list. The RPC system delivers calls to a
+// capability one at a time, so this needs no locking.
+type entryCollector struct {
+	entries []fs.DirEntry
+}
+
+func (c *entryCollector) Push(ctx context.Context, p filesystem.Directory_Entry_Stream_push) error {
+	entries, err := p.Args().Entries()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < entries.Len(); i++ {
+		name, err := entries.At(i).Name()
+		if err != nil {
+			return err
+		}
+		info, err := entries.At(i).Info()
+		if err != nil {
+			return err
+		}
+		fi, err := NewFileInfo(name, info)
+		if err != nil {
+			return err
+		}
+		c.entries = append(c.entries, fs.FileInfoToDirEntry(fi))
+	}
+	return nil
+}
+
+func (c *entryCollector) Done(ctx context.Context, p filesystem.Directory_Entry_Stream_done) error {
+	return nil
+}
+
+// WalkTree walks the tree rooted at p, calling fn for each node, like
+// fs.WalkDir.
+func WalkTree(ctx context.Context, root filesystem.Directory, p string, fn fs.WalkDirFunc) error {
+	node, err := Walk(ctx, root, p)
+	if err != nil {
+		return fn(p, nil, err)
+	}
+	defer node.Client.Release()
+	fi, err := StatNode(ctx, node, baseName(p))
+	if err != nil {
+		err = fn(p, nil, &fs.PathError{Op: "stat", Path: p, Err: fserrors.Decode(err)})
+	} else {
+		err = walkTree(ctx, filesystem.Directory{Client: node.Client}, p, fs.FileInfoToDirEntry(fi), fn)
+	}
+	if err == fs.SkipDir {
+		return nil
+	}
+	return err
+}
+
+// dir is the node at p, which is described by d.
+func walkTree(ctx context.Context, dir filesystem.Directory, p string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(p, d, nil); err != nil || !d.IsDir() {
+		if err == fs.SkipDir && d.IsDir() {
+			// Successfully skipped directory.
+			err = nil
+		}
+		return err
+	}
+
+	entries, err := List(ctx, dir)
+	if err != nil {
+		// Second call, to report the error.
+		err = fn(p, d, &fs.PathError{Op: "readdir", Path: p, Err: fserrors.Decode(err)})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ent := range entries {
+		childPath := path(p, ent.Name())
+		var child filesystem.Node
+		if ent.IsDir() {
+			child, err = Walk(ctx, dir, ent.Name())
+			if err != nil {
+				if err = fn(childPath, ent, err); err != nil && err != fs.SkipDir {
+					return err
+				}
+				continue
+			}
+		}
+		err = walkTree(ctx, filesystem.Directory{Client: child.Client}, childPath, ent, fn)
+		if child.Client != nil {
+			child.Client.Release()
+		}
+		if err != nil {
+			if err == fs.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Join a path and the name of a child.
+func path(parent, name string) string {
+	parent = strings.TrimSuffix(parent, "/")
+	if parent == "" || parent == "." {
+		return name
+	}
+	return parent + "/" + name
+}
@@ -0,0 +1,617 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"sync"
+	"syscall"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+
+	"zenhack.net/go/sandstorm/capnp/util"
+	"zenhack.net/go/sandstorm/exp/util/bytestream"
+	"zombiezen.com/go/capnproto2"
+)
+
+// ReadFile returns the contents of the file at p.
+func ReadFile(ctx context.Context, root filesystem.Directory, p string) ([]byte, error) {
+	node, err := Walk(ctx, root, p)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Client.Release()
+	b := &buffer{}
+	if err = ReadTo(ctx, filesystem.File{Client: node.Client}, b); err != nil {
+		return nil, &fs.PathError{Op: "read", Path: p, Err: fserrors.Decode(err)}
+	}
+	return b.buf.Bytes(), nil
+}
+
+// ReadTo copies the contents of file to w, and then closes w.
+func ReadTo(ctx context.Context, file filesystem.File, w io.WriteCloser) error {
+	res, release := file.Read(ctx, func(p filesystem.File_read_Params) error {
+		return p.SetSink(bytestream.FromWriteCloser(w, nil))
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+// Hash returns the digest of the contents of the file at p, as computed
+// by the server using algorithm.
+func Hash(ctx context.Context, root filesystem.Directory, p string, algorithm filesystem.File_HashAlgorithm) ([]byte, error) {
+	node, err := Walk(ctx, root, p)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Client.Release()
+	res, release := filesystem.File{Client: node.Client}.Hash(ctx, func(p filesystem.File_hash_Params) error {
+		p.SetAlgorithm(algorithm)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return nil, &fs.PathError{Op: "hash", Path: p, Err: fserrors.Decode(err)}
+	}
+	digest, err := results.Digest()
+	if err != nil {
+		return nil, &fs.PathError{Op: "hash", Path: p, Err: err}
+	}
+	// digest points into the message, which release frees.
+	return append([]byte(nil), digest...), nil
+}
+
+// A bytes.Buffer which can be used as a read sink.
+type buffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *buffer) Close() error {
+	return nil
+}
+
+// NewWriter returns a writer which writes to file starting at startAt. The
+// data is not guaranteed to have been written until Close returns.
+func NewWriter(ctx context.Context, file filesystem.RwFile, startAt int64) io.WriteCloser {
+	res, release := file.Write(ctx, func(p filesystem.RwFile_write_Params) error {
+		p.SetStartAt(startAt)
+		return nil
+	})
+	return &writer{
+		WriteCloser: bytestream.ToWriteCloser(ctx, res.Sink()),
+		release:     release,
+	}
+}
+
+type writer struct {
+	io.WriteCloser
+	release func()
+}
+
+func (w *writer) Close() error {
+	defer w.release()
+	return w.WriteCloser.Close()
+}
+
+// Create creates the file at p, along with any missing parent directories.
+// If the file already exists, it is truncated.
+func Create(ctx context.Context, root filesystem.RwDirectory, p string, executable bool) (filesystem.RwFile, error) {
+	parentPath, name, err := splitParent(p)
+	if err != nil {
+		return filesystem.RwFile{}, &fs.PathError{Op: "create", Path: p, Err: err}
+	}
+	parent, err := MkdirAll(ctx, root, parentPath)
+	if err != nil {
+		return filesystem.RwFile{}, err
+	}
+	defer parent.Client.Release()
+
+	createRes, releaseCreate := parent.Create(ctx, func(p filesystem.RwDirectory_create_Params) error {
+		p.SetExecutable(executable)
+		return p.SetName(name)
+	})
+	defer releaseCreate()
+	// Create opens existing files rather than failing, so make sure we
+	// start from empty. This is pipelined on the create.
+	truncRes, releaseTrunc := createRes.File().Truncate(ctx, func(p filesystem.RwFile_truncate_Params) error {
+		p.SetSize(0)
+		return nil
+	})
+	defer releaseTrunc()
+	if _, err = truncRes.Struct(); err != nil {
+		return filesystem.RwFile{}, &fs.PathError{Op: "create", Path: p, Err: fserrors.Decode(err)}
+	}
+	results, err := createRes.Struct()
+	if err != nil {
+		return filesystem.RwFile{}, &fs.PathError{Op: "create", Path: p, Err: fserrors.Decode(err)}
+	}
+	return filesystem.RwFile{Client: results.File().Client.AddRef()}, nil
+}
+
+// WriteFile writes data to the file at p, creating it and any missing
+// parent directories if needed, and replacing its previous contents.
+func WriteFile(ctx context.Context, root filesystem.RwDirectory, p string, data []byte, executable bool) error {
+	file, err := Create(ctx, root, p, executable)
+	if err != nil {
+		return err
+	}
+	defer file.Client.Release()
+	w := NewWriter(ctx, file, 0)
+	_, err = w.Write(data)
+	if err2 := w.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return &fs.PathError{Op: "write", Path: p, Err: fserrors.Decode(err)}
+	}
+	return nil
+}
+
+// MkdirAll creates the directory at p, along with any missing parents, and
+// returns it. It is not an error if the directory already exists.
+func MkdirAll(ctx context.Context, root filesystem.RwDirectory, p string) (filesystem.RwDirectory, error) {
+	parts, err := splitPath(p)
+	if err != nil {
+		return filesystem.RwDirectory{}, &fs.PathError{Op: "mkdir", Path: p, Err: err}
+	}
+	if len(parts) == 0 {
+		return filesystem.RwDirectory{Client: root.Client.AddRef()}, nil
+	}
+
+	var releases []func()
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+	dir := root
+	var res filesystem.Directory_walk_Results_Future
+	for _, name := range parts {
+		// Mkdir fails if the directory already exists, so we ignore its
+		// result and walk to the child either way. Both calls go out
+		// without waiting, so this still takes one round trip overall.
+		_, releaseMkdir := dir.Mkdir(ctx, func(p filesystem.RwDirectory_mkdir_Params) error {
+			return p.SetName(name)
+		})
+		var releaseWalk func()
+		res, releaseWalk = filesystem.Directory{Client: dir.Client}.Walk(ctx, func(p filesystem.Directory_walk_Params) error {
+			return p.SetName(name)
+		})
+		releases = append(releases, releaseMkdir, releaseWalk)
+		dir = filesystem.RwDirectory{Client: res.Node().Client}
+	}
+	statRes, releaseStat := filesystem.Node{Client: dir.Client}.Stat(ctx, nil)
+	releases = append(releases, releaseStat)
+	statResults, err := statRes.Struct()
+	if err != nil {
+		return filesystem.RwDirectory{}, &fs.PathError{Op: "mkdir", Path: p, Err: fserrors.Decode(err)}
+	}
+	info, err := statResults.Info()
+	if err != nil {
+		return filesystem.RwDirectory{}, &fs.PathError{Op: "mkdir", Path: p, Err: fserrors.Decode(err)}
+	}
+	if info.Which() != filesystem.StatInfo_Which_dir {
+		return filesystem.RwDirectory{}, &fs.PathError{Op: "mkdir", Path: p, Err: fs.ErrExist}
+	}
+	results, err := res.Struct()
+	if err != nil {
+		return filesystem.RwDirectory{}, &fs.PathError{Op: "mkdir", Path: p, Err: fserrors.Decode(err)}
+	}
+	return filesystem.RwDirectory{Client: results.Node().Client.AddRef()}, nil
+}
+
+// Remove deletes the file or empty directory at p.
+func Remove(ctx context.Context, root filesystem.RwDirectory, p string) error {
+	parentPath, name, err := splitParent(p)
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: p, Err: err}
+	}
+	parent, err := WalkRwDir(ctx, root, parentPath)
+	if err != nil {
+		return err
+	}
+	defer parent.Client.Release()
+	if err = remove(ctx, parent, name); err != nil {
+		return &fs.PathError{Op: "remove", Path: p, Err: fserrors.Decode(err)}
+	}
+	return nil
+}
+
+// RemoveAll deletes the node at p, and everything under it if it is a
+// directory. It is not an error if p does not exist.
+func RemoveAll(ctx context.Context, root filesystem.RwDirectory, p string) error {
+	parentPath, name, err := splitParent(p)
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: p, Err: err}
+	}
+	parent, err := WalkRwDir(ctx, root, parentPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer parent.Client.Release()
+	node, err := Walk(ctx, filesystem.Directory{Client: parent.Client}, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return &fs.PathError{Op: "remove", Path: p, Err: errors.Unwrap(err)}
+	}
+	defer node.Client.Release()
+	if err = removeAll(ctx, parent, name, node); err != nil {
+		return &fs.PathError{Op: "remove", Path: p, Err: fserrors.Decode(err)}
+	}
+	return nil
+}
+
+// Remove node, which is called name in parent.
+func removeAll(ctx context.Context, parent filesystem.RwDirectory, name string, node filesystem.Node) error {
+	fi, err := StatNode(ctx, node, name)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		dir := filesystem.RwDirectory{Client: node.Client}
+		entries, err := List(ctx, filesystem.Directory{Client: node.Client})
+		if err != nil {
+			return err
+		}
+		for _, ent := range entries {
+			child, err := Walk(ctx, filesystem.Directory{Client: node.Client}, ent.Name())
+			if err != nil {
+				return err
+			}
+			err = removeAll(ctx, dir, ent.Name(), child)
+			child.Client.Release()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return remove(ctx, parent, name)
+}
+
+func remove(ctx context.Context, dir filesystem.RwDirectory, name string) error {
+	res, release := dir.Delete(ctx, func(p filesystem.RwDirectory_delete_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+// Copy copies the node at srcPath under src to dstPath under dst,
+// recursively if it is a directory. Existing files under dstPath are
+// overwritten; other existing files are left alone. Executable bits and
+// files' extended attributes, including their MIME types, are preserved.
+// Directories' attributes are not, since the schema has no way to set
+// them.
+//
+// File contents are streamed directly from src to dst, so if they are
+// hosted elsewhere the data need not pass through the caller.
+func Copy(ctx context.Context, src filesystem.Directory, srcPath string, dst filesystem.RwDirectory, dstPath string) error {
+	node, err := Walk(ctx, src, srcPath)
+	if err != nil {
+		return err
+	}
+	defer node.Client.Release()
+	return copyNode(ctx, node, srcPath, dst, dstPath)
+}
+
+func copyNode(ctx context.Context, node filesystem.Node, srcPath string, dst filesystem.RwDirectory, dstPath string) error {
+	fi, err := StatNode(ctx, node, baseName(srcPath))
+	if err != nil {
+		return &fs.PathError{Op: "copy", Path: srcPath, Err: fserrors.Decode(err)}
+	}
+	if !fi.IsDir() {
+		return copyFile(ctx, filesystem.File{Client: node.Client}, srcPath, fi, dst, dstPath)
+	}
+
+	dir, err := MkdirAll(ctx, dst, dstPath)
+	if err != nil {
+		return err
+	}
+	dir.Client.Release()
+	entries, err := List(ctx, filesystem.Directory{Client: node.Client})
+	if err != nil {
+		return &fs.PathError{Op: "copy", Path: srcPath, Err: fserrors.Decode(err)}
+	}
+	for _, ent := range entries {
+		child, err := Walk(ctx, filesystem.Directory{Client: node.Client}, ent.Name())
+		if err != nil {
+			return &fs.PathError{Op: "copy", Path: path(srcPath, ent.Name()), Err: fserrors.Decode(err)}
+		}
+		err = copyNode(ctx, child, path(srcPath, ent.Name()), dst, path(dstPath, ent.Name()))
+		child.Client.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(ctx context.Context, file filesystem.File, srcPath string, fi *FileInfo, dst filesystem.RwDirectory, dstPath string) error {
+	out, err := Create(ctx, dst, dstPath, fi.Executable())
+	if err != nil {
+		return err
+	}
+	defer out.Client.Release()
+	if err = copyData(ctx, file, srcPath, fi.Size(), out, dstPath); err != nil {
+		return err
+	}
+	return copyXattrs(ctx, filesystem.Node{Client: file.Client}, srcPath, fi.MimeType(), out, dstPath)
+}
+
+// Copy the contents of file, which is size bytes long, to out, which is
+// empty. Only the parts of file which hold data are copied, so holes in
+// a sparse file aren't sent over the wire, and stay holes if out's server
+// supports them.
+func copyData(ctx context.Context, file filesystem.File, srcPath string, size int64, out filesystem.RwFile, dstPath string) error {
+	var offset int64
+	for {
+		start, err := seek(ctx, file, offset, false)
+		if capnp.IsUnimplemented(err) && offset == 0 {
+			// The server can't tell us where the holes are, so copy
+			// the whole file.
+			return copyRange(ctx, file, srcPath, 0, 0, out, dstPath)
+		} else if err != nil {
+			return &fs.PathError{Op: "copy", Path: srcPath, Err: fserrors.Decode(err)}
+		} else if start < 0 {
+			break
+		}
+		end, err := seek(ctx, file, start, true)
+		if err != nil {
+			return &fs.PathError{Op: "copy", Path: srcPath, Err: fserrors.Decode(err)}
+		} else if end < 0 {
+			// The file has shrunk since we found the data.
+			break
+		}
+		if err = copyRange(ctx, file, srcPath, start, uint64(end-start), out, dstPath); err != nil {
+			return err
+		}
+		offset = end
+	}
+	if offset >= size {
+		return nil
+	}
+	// A hole at the end of the file still counts towards its size.
+	res, release := out.Truncate(ctx, func(p filesystem.RwFile_truncate_Params) error {
+		p.SetSize(uint64(size))
+		return nil
+	})
+	defer release()
+	if _, err := res.Struct(); err != nil {
+		return &fs.PathError{Op: "copy", Path: dstPath, Err: fserrors.Decode(err)}
+	}
+	return nil
+}
+
+// Copy amount bytes of file, starting at startAt, to the same place in
+// out. As for read, an amount of 0 means the rest of the file.
+func copyRange(ctx context.Context, file filesystem.File, srcPath string, startAt int64, amount uint64, out filesystem.RwFile, dstPath string) error {
+	writeRes, releaseWrite := out.Write(ctx, func(p filesystem.RwFile_write_Params) error {
+		p.SetStartAt(startAt)
+		return nil
+	})
+	defer releaseWrite()
+	readRes, releaseRead := file.Read(ctx, func(p filesystem.File_read_Params) error {
+		p.SetStartAt(startAt)
+		p.SetAmount(amount)
+		return p.SetSink(util.ByteStream{Client: writeRes.Sink().Client.AddRef()})
+	})
+	defer releaseRead()
+	if _, err := readRes.Struct(); err != nil {
+		return &fs.PathError{Op: "copy", Path: srcPath, Err: fserrors.Decode(err)}
+	}
+	if _, err := writeRes.Struct(); err != nil {
+		return &fs.PathError{Op: "copy", Path: dstPath, Err: fserrors.Decode(err)}
+	}
+	return nil
+}
+
+// Return the offset of the first byte of data (or, if hole is true, of
+// the first hole) at or after offset, or -1 if there is none; see
+// File.seekData and File.seekHole.
+func seek(ctx context.Context, file filesystem.File, offset int64, hole bool) (int64, error) {
+	if hole {
+		res, release := file.SeekHole(ctx, func(p filesystem.File_seekHole_Params) error {
+			p.SetOffset(offset)
+			return nil
+		})
+		defer release()
+		results, err := res.Struct()
+		if err != nil {
+			return 0, err
+		}
+		return results.Offset(), nil
+	}
+	res, release := file.SeekData(ctx, func(p filesystem.File_seekData_Params) error {
+		p.SetOffset(offset)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return 0, err
+	}
+	return results.Offset(), nil
+}
+
+// Copy the extended attributes of src to dst, and remove any others
+// that dst has. mimeType is the MIME type src reports; if it isn't stored
+// in an attribute of src, it is stored in dst's anyway, so it isn't lost.
+//
+// Nodes which don't support attributes are treated as having none: the
+// copy goes ahead without them, rather than failing.
+func copyXattrs(ctx context.Context, src filesystem.Node, srcPath, mimeType string, dst filesystem.RwFile, dstPath string) error {
+	names, err := listXattrs(ctx, src)
+	if xattrsUnsupported(err) {
+		names, err = nil, nil
+	}
+	if err != nil {
+		return &fs.PathError{Op: "copy", Path: srcPath, Err: fserrors.Decode(err)}
+	}
+	copied := make(map[string]bool, len(names)+1)
+	for _, name := range names {
+		value, err := getXattr(ctx, src, name)
+		if xattrsUnsupported(err) {
+			continue
+		}
+		if err != nil {
+			return &fs.PathError{Op: "copy", Path: srcPath, Err: fserrors.Decode(err)}
+		}
+		err = setXattr(ctx, dst, name, value)
+		if xattrsUnsupported(err) {
+			return nil
+		}
+		if err != nil {
+			return &fs.PathError{Op: "copy", Path: dstPath, Err: fserrors.Decode(err)}
+		}
+		copied[name] = true
+	}
+	if mimeType != "" && !copied[MimeTypeXattr] {
+		err = setXattr(ctx, dst, MimeTypeXattr, []byte(mimeType))
+		if xattrsUnsupported(err) {
+			return nil
+		}
+		if err != nil {
+			return &fs.PathError{Op: "copy", Path: dstPath, Err: fserrors.Decode(err)}
+		}
+		copied[MimeTypeXattr] = true
+	}
+
+	existing, err := listXattrs(ctx, filesystem.Node{Client: dst.Client})
+	if xattrsUnsupported(err) {
+		return nil
+	}
+	if err != nil {
+		return &fs.PathError{Op: "copy", Path: dstPath, Err: fserrors.Decode(err)}
+	}
+	for _, name := range existing {
+		if copied[name] {
+			continue
+		}
+		res, release := dst.RemoveXattr(ctx, func(p filesystem.RwFile_removeXattr_Params) error {
+			return p.SetName(name)
+		})
+		_, err = res.Struct()
+		release()
+		if err != nil {
+			return &fs.PathError{Op: "copy", Path: dstPath, Err: fserrors.Decode(err)}
+		}
+	}
+	return nil
+}
+
+// Report whether err says that a node doesn't support extended attributes
+// at all.
+func xattrsUnsupported(err error) bool {
+	return capnp.IsUnimplemented(err) || errors.Is(err, syscall.ENOTSUP)
+}
+
+func listXattrs(ctx context.Context, node filesystem.Node) ([]string, error) {
+	res, release := node.ListXattrs(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return nil, err
+	}
+	list, err := results.Names()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, list.Len())
+	for i := range names {
+		if names[i], err = list.At(i); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+func getXattr(ctx context.Context, node filesystem.Node, name string) ([]byte, error) {
+	res, release := node.GetXattr(ctx, func(p filesystem.Node_getXattr_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return nil, err
+	}
+	value, err := results.Value()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func setXattr(ctx context.Context, file filesystem.RwFile, name string, value []byte) error {
+	res, release := file.SetXattr(ctx, func(p filesystem.RwFile_setXattr_Params) error {
+		if err := p.SetName(name); err != nil {
+			return err
+		}
+		return p.SetValue(value)
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+// Rename moves the node at oldPath to newPath, both under root.
+//
+// The schema has no rename operation, so this is not a rename at all, but
+// a Copy of the whole tree at oldPath to newPath followed by a RemoveAll of
+// oldPath. That means it is not atomic: other clients can see the copy
+// while it is partly made, and both copies while the original is being
+// removed. A failure part way through may leave a partial copy at newPath
+// or, if the copy succeeded, part or all of the original in place. It
+// also takes time, and space, in proportion to the size of the tree.
+func Rename(ctx context.Context, root filesystem.RwDirectory, oldPath, newPath string) error {
+	oldParts, err := splitPath(oldPath)
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: oldPath, Err: err}
+	}
+	newParts, err := splitPath(newPath)
+	if err != nil {
+		return &fs.PathError{Op: "rename", Path: newPath, Err: err}
+	}
+	if len(oldParts) == 0 || len(newParts) == 0 {
+		return &fs.PathError{Op: "rename", Path: oldPath, Err: fs.ErrInvalid}
+	}
+	if isPrefix(oldParts, newParts) {
+		// Either a no-op, or moving a directory inside itself.
+		if len(oldParts) == len(newParts) {
+			return nil
+		}
+		return &fs.PathError{Op: "rename", Path: oldPath, Err: fs.ErrInvalid}
+	}
+	if err = Copy(ctx, filesystem.Directory{Client: root.Client}, oldPath, root, newPath); err != nil {
+		return err
+	}
+	return RemoveAll(ctx, root, oldPath)
+}
+
+// Report whether prefix is a prefix of parts.
+func isPrefix(prefix, parts []string) bool {
+	if len(prefix) > len(parts) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != parts[i] {
+			return false
+		}
+	}
+	return true
+}
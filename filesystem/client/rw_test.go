@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"testing"
+	gofstest "testing/fstest"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/iofs"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+func mustSetXattr(ctx context.Context, t *testing.T, root filesystem.RwDirectory, p, name, value string) {
+	t.Helper()
+	node, err := Walk(ctx, filesystem.Directory{Client: root.Client}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Client.Release()
+	if err = setXattr(ctx, filesystem.RwFile{Client: node.Client}, name, []byte(value)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func xattrsOf(ctx context.Context, t *testing.T, root filesystem.RwDirectory, p string) map[string]string {
+	t.Helper()
+	node, err := Walk(ctx, filesystem.Directory{Client: root.Client}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Client.Release()
+	names, err := listXattrs(ctx, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := getXattr(ctx, node, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret[name] = string(value)
+	}
+	return ret
+}
+
+func checkXattrs(t *testing.T, p string, got, want map[string]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s has attributes %v, want %v", p, got, want)
+		return
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s has attributes %v, want %v", p, got, want)
+			return
+		}
+	}
+}
+
+func TestCopyXattrs(t *testing.T) {
+	ctx := context.Background()
+	src := memfs.New(0).Root()
+	defer src.Client.Release()
+	dst := memfs.New(0).Root()
+	defer dst.Client.Release()
+
+	if err := WriteFile(ctx, src, "dir/a.txt", []byte("a"), false); err != nil {
+		t.Fatal(err)
+	}
+	mustSetXattr(ctx, t, src, "dir/a.txt", MimeTypeXattr, "text/x-test")
+	mustSetXattr(ctx, t, src, "dir/a.txt", "tag", "blue")
+	// Attributes of a file being overwritten don't survive.
+	if err := WriteFile(ctx, dst, "copy/a.txt", []byte("old"), false); err != nil {
+		t.Fatal(err)
+	}
+	mustSetXattr(ctx, t, dst, "copy/a.txt", "stale", "yes")
+
+	if err := Copy(ctx, filesystem.Directory{Client: src.Client}, "dir", dst, "copy"); err != nil {
+		t.Fatal(err)
+	}
+	checkXattrs(t, "copy/a.txt", xattrsOf(ctx, t, dst, "copy/a.txt"), map[string]string{
+		MimeTypeXattr: "text/x-test",
+		"tag":         "blue",
+	})
+}
+
+// A MIME type that the source reports but doesn't store as an attribute
+// is recorded in the copy.
+func TestCopyReportedMimeType(t *testing.T) {
+	ctx := context.Background()
+	src := iofs.New(gofstest.MapFS{
+		"page.html": &gofstest.MapFile{Data: []byte("<p>hi</p>")},
+	})
+	defer src.Client.Release()
+	dst := memfs.New(0).Root()
+	defer dst.Client.Release()
+
+	if err := Copy(ctx, src, "page.html", dst, "page.html"); err != nil {
+		t.Fatal(err)
+	}
+	checkXattrs(t, "page.html", xattrsOf(ctx, t, dst, "page.html"), map[string]string{
+		MimeTypeXattr: "text/html; charset=utf-8",
+	})
+}
+
+// A file which supports no extended attributes.
+type noXattrs struct{}
+
+func (noXattrs) Stat(ctx context.Context, p filesystem.Node_stat) error {
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	info, err := res.NewInfo()
+	if err != nil {
+		return err
+	}
+	info.SetFile()
+	return nil
+}
+
+func (noXattrs) GetXattr(ctx context.Context, p filesystem.Node_getXattr) error {
+	return capnp.Unimplemented("Extended attributes not supported")
+}
+
+func (noXattrs) ListXattrs(ctx context.Context, p filesystem.Node_listXattrs) error {
+	return capnp.Unimplemented("Extended attributes not supported")
+}
+
+// Nodes which don't support attributes are copied as if they had none.
+func TestCopyXattrsUnsupported(t *testing.T) {
+	ctx := context.Background()
+	dst := memfs.New(0).Root()
+	defer dst.Client.Release()
+	if err := WriteFile(ctx, dst, "a.txt", []byte("a"), false); err != nil {
+		t.Fatal(err)
+	}
+	mustSetXattr(ctx, t, dst, "a.txt", "stale", "yes")
+	node, err := Walk(ctx, filesystem.Directory{Client: dst.Client}, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Client.Release()
+
+	src := filesystem.Node_ServerToClient(noXattrs{}, nil)
+	defer src.Client.Release()
+	if err = copyXattrs(ctx, src, "src", "text/plain", filesystem.RwFile{Client: node.Client}, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	checkXattrs(t, "a.txt", xattrsOf(ctx, t, dst, "a.txt"), map[string]string{
+		MimeTypeXattr: "text/plain",
+	})
+}
@@ -33,10 +33,46 @@ func (c Node) Stat(ctx context.Context, params func(Node_stat_Params) error) (No
 	ans, release := c.Client.SendCall(ctx, s)
 	return Node_stat_Results_Future{Future: ans.Future()}, release
 }
+func (c Node) GetXattr(ctx context.Context, params func(Node_getXattr_Params) error) (Node_getXattr_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_getXattr_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_getXattr_Results_Future{Future: ans.Future()}, release
+}
+func (c Node) ListXattrs(ctx context.Context, params func(Node_listXattrs_Params) error) (Node_listXattrs_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_listXattrs_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_listXattrs_Results_Future{Future: ans.Future()}, release
+}
 
 // A Node_Server is a Node with a local implementation.
 type Node_Server interface {
 	Stat(context.Context, Node_stat) error
+
+	GetXattr(context.Context, Node_getXattr) error
+
+	ListXattrs(context.Context, Node_listXattrs) error
 }
 
 // Node_NewServer creates a new Server from an implementation of Node_Server.
@@ -55,7 +91,7 @@ func Node_ServerToClient(s Node_Server, policy *server.Policy) Node {
 // This can be used to create a more complicated Server.
 func Node_Methods(methods []server.Method, s Node_Server) []server.Method {
 	if cap(methods) == 0 {
-		methods = make([]server.Method, 0, 1)
+		methods = make([]server.Method, 0, 3)
 	}
 
 	methods = append(methods, server.Method{
@@ -70,6 +106,30 @@ func Node_Methods(methods []server.Method, s Node_Server) []server.Method {
 		},
 	})
 
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.GetXattr(ctx, Node_getXattr{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.ListXattrs(ctx, Node_listXattrs{call})
+		},
+	})
+
 	return methods
 }
 
@@ -90,6 +150,40 @@ func (c Node_stat) AllocResults() (Node_stat_Results, error) {
 	return Node_stat_Results{Struct: r}, err
 }
 
+// Node_getXattr holds the state for a server call to Node.getXattr.
+// See server.Call for documentation.
+type Node_getXattr struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c Node_getXattr) Args() Node_getXattr_Params {
+	return Node_getXattr_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c Node_getXattr) AllocResults() (Node_getXattr_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Node_getXattr_Results{Struct: r}, err
+}
+
+// Node_listXattrs holds the state for a server call to Node.listXattrs.
+// See server.Call for documentation.
+type Node_listXattrs struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c Node_listXattrs) Args() Node_listXattrs_Params {
+	return Node_listXattrs_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c Node_listXattrs) AllocResults() (Node_listXattrs_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Node_listXattrs_Results{Struct: r}, err
+}
+
 type Node_stat_Params struct{ capnp.Struct }
 
 // Node_stat_Params_TypeID is the unique identifier for the type Node_stat_Params.
@@ -226,6 +320,281 @@ func (p Node_stat_Results_Future) Info() StatInfo_Future {
 	return StatInfo_Future{Future: p.Future.Field(0, nil)}
 }
 
+type Node_getXattr_Params struct{ capnp.Struct }
+
+// Node_getXattr_Params_TypeID is the unique identifier for the type Node_getXattr_Params.
+const Node_getXattr_Params_TypeID = 0xa73d7d0bf57f78f9
+
+func NewNode_getXattr_Params(s *capnp.Segment) (Node_getXattr_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Node_getXattr_Params{st}, err
+}
+
+func NewRootNode_getXattr_Params(s *capnp.Segment) (Node_getXattr_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Node_getXattr_Params{st}, err
+}
+
+func ReadRootNode_getXattr_Params(msg *capnp.Message) (Node_getXattr_Params, error) {
+	root, err := msg.Root()
+	return Node_getXattr_Params{root.Struct()}, err
+}
+
+func (s Node_getXattr_Params) String() string {
+	str, _ := text.Marshal(0xa73d7d0bf57f78f9, s.Struct)
+	return str
+}
+
+func (s Node_getXattr_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s Node_getXattr_Params) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s Node_getXattr_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s Node_getXattr_Params) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+// Node_getXattr_Params_List is a list of Node_getXattr_Params.
+type Node_getXattr_Params_List struct{ capnp.List }
+
+// NewNode_getXattr_Params creates a new list of Node_getXattr_Params.
+func NewNode_getXattr_Params_List(s *capnp.Segment, sz int32) (Node_getXattr_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return Node_getXattr_Params_List{l}, err
+}
+
+func (s Node_getXattr_Params_List) At(i int) Node_getXattr_Params {
+	return Node_getXattr_Params{s.List.Struct(i)}
+}
+
+func (s Node_getXattr_Params_List) Set(i int, v Node_getXattr_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Node_getXattr_Params_List) String() string {
+	str, _ := text.MarshalList(0xa73d7d0bf57f78f9, s.List)
+	return str
+}
+
+// Node_getXattr_Params_Future is a wrapper for a Node_getXattr_Params promised by a client call.
+type Node_getXattr_Params_Future struct{ *capnp.Future }
+
+func (p Node_getXattr_Params_Future) Struct() (Node_getXattr_Params, error) {
+	s, err := p.Future.Struct()
+	return Node_getXattr_Params{s}, err
+}
+
+type Node_getXattr_Results struct{ capnp.Struct }
+
+// Node_getXattr_Results_TypeID is the unique identifier for the type Node_getXattr_Results.
+const Node_getXattr_Results_TypeID = 0xbb45b52681765412
+
+func NewNode_getXattr_Results(s *capnp.Segment) (Node_getXattr_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Node_getXattr_Results{st}, err
+}
+
+func NewRootNode_getXattr_Results(s *capnp.Segment) (Node_getXattr_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Node_getXattr_Results{st}, err
+}
+
+func ReadRootNode_getXattr_Results(msg *capnp.Message) (Node_getXattr_Results, error) {
+	root, err := msg.Root()
+	return Node_getXattr_Results{root.Struct()}, err
+}
+
+func (s Node_getXattr_Results) String() string {
+	str, _ := text.Marshal(0xbb45b52681765412, s.Struct)
+	return str
+}
+
+func (s Node_getXattr_Results) Value() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return []byte(p.Data()), err
+}
+
+func (s Node_getXattr_Results) HasValue() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s Node_getXattr_Results) SetValue(v []byte) error {
+	return s.Struct.SetData(0, v)
+}
+
+// Node_getXattr_Results_List is a list of Node_getXattr_Results.
+type Node_getXattr_Results_List struct{ capnp.List }
+
+// NewNode_getXattr_Results creates a new list of Node_getXattr_Results.
+func NewNode_getXattr_Results_List(s *capnp.Segment, sz int32) (Node_getXattr_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return Node_getXattr_Results_List{l}, err
+}
+
+func (s Node_getXattr_Results_List) At(i int) Node_getXattr_Results {
+	return Node_getXattr_Results{s.List.Struct(i)}
+}
+
+func (s Node_getXattr_Results_List) Set(i int, v Node_getXattr_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Node_getXattr_Results_List) String() string {
+	str, _ := text.MarshalList(0xbb45b52681765412, s.List)
+	return str
+}
+
+// Node_getXattr_Results_Future is a wrapper for a Node_getXattr_Results promised by a client call.
+type Node_getXattr_Results_Future struct{ *capnp.Future }
+
+func (p Node_getXattr_Results_Future) Struct() (Node_getXattr_Results, error) {
+	s, err := p.Future.Struct()
+	return Node_getXattr_Results{s}, err
+}
+
+type Node_listXattrs_Params struct{ capnp.Struct }
+
+// Node_listXattrs_Params_TypeID is the unique identifier for the type Node_listXattrs_Params.
+const Node_listXattrs_Params_TypeID = 0xb2288654bbafcefd
+
+func NewNode_listXattrs_Params(s *capnp.Segment) (Node_listXattrs_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Node_listXattrs_Params{st}, err
+}
+
+func NewRootNode_listXattrs_Params(s *capnp.Segment) (Node_listXattrs_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Node_listXattrs_Params{st}, err
+}
+
+func ReadRootNode_listXattrs_Params(msg *capnp.Message) (Node_listXattrs_Params, error) {
+	root, err := msg.Root()
+	return Node_listXattrs_Params{root.Struct()}, err
+}
+
+func (s Node_listXattrs_Params) String() string {
+	str, _ := text.Marshal(0xb2288654bbafcefd, s.Struct)
+	return str
+}
+
+// Node_listXattrs_Params_List is a list of Node_listXattrs_Params.
+type Node_listXattrs_Params_List struct{ capnp.List }
+
+// NewNode_listXattrs_Params creates a new list of Node_listXattrs_Params.
+func NewNode_listXattrs_Params_List(s *capnp.Segment, sz int32) (Node_listXattrs_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return Node_listXattrs_Params_List{l}, err
+}
+
+func (s Node_listXattrs_Params_List) At(i int) Node_listXattrs_Params {
+	return Node_listXattrs_Params{s.List.Struct(i)}
+}
+
+func (s Node_listXattrs_Params_List) Set(i int, v Node_listXattrs_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Node_listXattrs_Params_List) String() string {
+	str, _ := text.MarshalList(0xb2288654bbafcefd, s.List)
+	return str
+}
+
+// Node_listXattrs_Params_Future is a wrapper for a Node_listXattrs_Params promised by a client call.
+type Node_listXattrs_Params_Future struct{ *capnp.Future }
+
+func (p Node_listXattrs_Params_Future) Struct() (Node_listXattrs_Params, error) {
+	s, err := p.Future.Struct()
+	return Node_listXattrs_Params{s}, err
+}
+
+type Node_listXattrs_Results struct{ capnp.Struct }
+
+// Node_listXattrs_Results_TypeID is the unique identifier for the type Node_listXattrs_Results.
+const Node_listXattrs_Results_TypeID = 0xac6849e8d9020d24
+
+func NewNode_listXattrs_Results(s *capnp.Segment) (Node_listXattrs_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Node_listXattrs_Results{st}, err
+}
+
+func NewRootNode_listXattrs_Results(s *capnp.Segment) (Node_listXattrs_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Node_listXattrs_Results{st}, err
+}
+
+func ReadRootNode_listXattrs_Results(msg *capnp.Message) (Node_listXattrs_Results, error) {
+	root, err := msg.Root()
+	return Node_listXattrs_Results{root.Struct()}, err
+}
+
+func (s Node_listXattrs_Results) String() string {
+	str, _ := text.Marshal(0xac6849e8d9020d24, s.Struct)
+	return str
+}
+
+func (s Node_listXattrs_Results) Names() (capnp.TextList, error) {
+	p, err := s.Struct.Ptr(0)
+	return capnp.TextList{List: p.List()}, err
+}
+
+func (s Node_listXattrs_Results) HasNames() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s Node_listXattrs_Results) SetNames(v capnp.TextList) error {
+	return s.Struct.SetPtr(0, v.List.ToPtr())
+}
+
+// NewNames sets the names field to a newly
+// allocated capnp.TextList, preferring placement in s's segment.
+func (s Node_listXattrs_Results) NewNames(n int32) (capnp.TextList, error) {
+	l, err := capnp.NewTextList(s.Struct.Segment(), n)
+	if err != nil {
+		return capnp.TextList{}, err
+	}
+	err = s.Struct.SetPtr(0, l.List.ToPtr())
+	return l, err
+}
+
+// Node_listXattrs_Results_List is a list of Node_listXattrs_Results.
+type Node_listXattrs_Results_List struct{ capnp.List }
+
+// NewNode_listXattrs_Results creates a new list of Node_listXattrs_Results.
+func NewNode_listXattrs_Results_List(s *capnp.Segment, sz int32) (Node_listXattrs_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return Node_listXattrs_Results_List{l}, err
+}
+
+func (s Node_listXattrs_Results_List) At(i int) Node_listXattrs_Results {
+	return Node_listXattrs_Results{s.List.Struct(i)}
+}
+
+func (s Node_listXattrs_Results_List) Set(i int, v Node_listXattrs_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Node_listXattrs_Results_List) String() string {
+	str, _ := text.MarshalList(0xac6849e8d9020d24, s.List)
+	return str
+}
+
+// Node_listXattrs_Results_Future is a wrapper for a Node_listXattrs_Results promised by a client call.
+type Node_listXattrs_Results_Future struct{ *capnp.Future }
+
+func (p Node_listXattrs_Results_Future) Struct() (Node_listXattrs_Results, error) {
+	s, err := p.Future.Struct()
+	return Node_listXattrs_Results{s}, err
+}
+
 type StatInfo struct{ capnp.Struct }
 type StatInfo_file StatInfo
 type StatInfo_Which uint16
@@ -251,12 +620,12 @@ func (w StatInfo_Which) String() string {
 const StatInfo_TypeID = 0xc749c282e476c082
 
 func NewStatInfo(s *capnp.Segment) (StatInfo, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0})
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
 	return StatInfo{st}, err
 }
 
 func NewRootStatInfo(s *capnp.Segment) (StatInfo, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0})
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
 	return StatInfo{st}, err
 }
 
@@ -308,12 +677,30 @@ func (s StatInfo) SetWritable(v bool) {
 	s.Struct.SetBit(17, v)
 }
 
+func (s StatInfo) MimeType() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s StatInfo) HasMimeType() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s StatInfo) MimeTypeBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s StatInfo) SetMimeType(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
 // StatInfo_List is a list of StatInfo.
 type StatInfo_List struct{ capnp.List }
 
 // NewStatInfo creates a new list of StatInfo.
 func NewStatInfo_List(s *capnp.Segment, sz int32) (StatInfo_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0}, sz)
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 16, PointerCount: 1}, sz)
 	return StatInfo_List{l}, err
 }
 
@@ -381,22 +768,86 @@ func (c Directory) Walk(ctx context.Context, params func(Directory_walk_Params)
 	ans, release := c.Client.SendCall(ctx, s)
 	return Directory_walk_Results_Future{Future: ans.Future()}, release
 }
-func (c Directory) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
+func (c Directory) Snapshots(ctx context.Context, params func(Directory_snapshots_Params) error) (Directory_snapshots_Results_Future, capnp.ReleaseFunc) {
 	s := capnp.Send{
 		Method: capnp.Method{
-			InterfaceID:   0x955400781a01b061,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:Node",
-			MethodName:    "stat",
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "snapshots",
 		},
 	}
 	if params != nil {
 		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_stat_Params{Struct: s}) }
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_snapshots_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_snapshots_Results_Future{Future: ans.Future()}, release
+}
+func (c Directory) Watch(ctx context.Context, params func(Directory_watch_Params) error) (Directory_watch_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "watch",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_watch_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_watch_Results_Future{Future: ans.Future()}, release
+}
+func (c Directory) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_stat_Params{Struct: s}) }
 	}
 	ans, release := c.Client.SendCall(ctx, s)
 	return Node_stat_Results_Future{Future: ans.Future()}, release
 }
+func (c Directory) GetXattr(ctx context.Context, params func(Node_getXattr_Params) error) (Node_getXattr_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_getXattr_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_getXattr_Results_Future{Future: ans.Future()}, release
+}
+func (c Directory) ListXattrs(ctx context.Context, params func(Node_listXattrs_Params) error) (Node_listXattrs_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_listXattrs_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_listXattrs_Results_Future{Future: ans.Future()}, release
+}
 
 // A Directory_Server is a Directory with a local implementation.
 type Directory_Server interface {
@@ -404,7 +855,15 @@ type Directory_Server interface {
 
 	Walk(context.Context, Directory_walk) error
 
+	Snapshots(context.Context, Directory_snapshots) error
+
+	Watch(context.Context, Directory_watch) error
+
 	Stat(context.Context, Node_stat) error
+
+	GetXattr(context.Context, Node_getXattr) error
+
+	ListXattrs(context.Context, Node_listXattrs) error
 }
 
 // Directory_NewServer creates a new Server from an implementation of Directory_Server.
@@ -423,7 +882,7 @@ func Directory_ServerToClient(s Directory_Server, policy *server.Policy) Directo
 // This can be used to create a more complicated Server.
 func Directory_Methods(methods []server.Method, s Directory_Server) []server.Method {
 	if cap(methods) == 0 {
-		methods = make([]server.Method, 0, 3)
+		methods = make([]server.Method, 0, 7)
 	}
 
 	methods = append(methods, server.Method{
@@ -450,6 +909,30 @@ func Directory_Methods(methods []server.Method, s Directory_Server) []server.Met
 		},
 	})
 
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "snapshots",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Snapshots(ctx, Directory_snapshots{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "watch",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Watch(ctx, Directory_watch{call})
+		},
+	})
+
 	methods = append(methods, server.Method{
 		Method: capnp.Method{
 			InterfaceID:   0x955400781a01b061,
@@ -462,6 +945,30 @@ func Directory_Methods(methods []server.Method, s Directory_Server) []server.Met
 		},
 	})
 
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.GetXattr(ctx, Node_getXattr{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.ListXattrs(ctx, Node_listXattrs{call})
+		},
+	})
+
 	return methods
 }
 
@@ -499,6 +1006,40 @@ func (c Directory_walk) AllocResults() (Directory_walk_Results, error) {
 	return Directory_walk_Results{Struct: r}, err
 }
 
+// Directory_snapshots holds the state for a server call to Directory.snapshots.
+// See server.Call for documentation.
+type Directory_snapshots struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c Directory_snapshots) Args() Directory_snapshots_Params {
+	return Directory_snapshots_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c Directory_snapshots) AllocResults() (Directory_snapshots_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Directory_snapshots_Results{Struct: r}, err
+}
+
+// Directory_watch holds the state for a server call to Directory.watch.
+// See server.Call for documentation.
+type Directory_watch struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c Directory_watch) Args() Directory_watch_Params {
+	return Directory_watch_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c Directory_watch) AllocResults() (Directory_watch_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Directory_watch_Results{Struct: r}, err
+}
+
 type Directory_Entry struct{ capnp.Struct }
 
 // Directory_Entry_TypeID is the unique identifier for the type Directory_Entry.
@@ -967,6 +1508,91 @@ func (p Directory_Entry_Stream_done_Results_Future) Struct() (Directory_Entry_St
 	return Directory_Entry_Stream_done_Results{s}, err
 }
 
+type Directory_Snapshot struct{ capnp.Struct }
+
+// Directory_Snapshot_TypeID is the unique identifier for the type Directory_Snapshot.
+const Directory_Snapshot_TypeID = 0xb04d0ec909ce6191
+
+func NewDirectory_Snapshot(s *capnp.Segment) (Directory_Snapshot, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return Directory_Snapshot{st}, err
+}
+
+func NewRootDirectory_Snapshot(s *capnp.Segment) (Directory_Snapshot, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return Directory_Snapshot{st}, err
+}
+
+func ReadRootDirectory_Snapshot(msg *capnp.Message) (Directory_Snapshot, error) {
+	root, err := msg.Root()
+	return Directory_Snapshot{root.Struct()}, err
+}
+
+func (s Directory_Snapshot) String() string {
+	str, _ := text.Marshal(0xb04d0ec909ce6191, s.Struct)
+	return str
+}
+
+func (s Directory_Snapshot) Dir() Directory {
+	p, _ := s.Struct.Ptr(0)
+	return Directory{Client: p.Interface().Client()}
+}
+
+func (s Directory_Snapshot) HasDir() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s Directory_Snapshot) SetDir(v Directory) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
+}
+
+func (s Directory_Snapshot) Time() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s Directory_Snapshot) SetTime(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+// Directory_Snapshot_List is a list of Directory_Snapshot.
+type Directory_Snapshot_List struct{ capnp.List }
+
+// NewDirectory_Snapshot creates a new list of Directory_Snapshot.
+func NewDirectory_Snapshot_List(s *capnp.Segment, sz int32) (Directory_Snapshot_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1}, sz)
+	return Directory_Snapshot_List{l}, err
+}
+
+func (s Directory_Snapshot_List) At(i int) Directory_Snapshot {
+	return Directory_Snapshot{s.List.Struct(i)}
+}
+
+func (s Directory_Snapshot_List) Set(i int, v Directory_Snapshot) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Directory_Snapshot_List) String() string {
+	str, _ := text.MarshalList(0xb04d0ec909ce6191, s.List)
+	return str
+}
+
+// Directory_Snapshot_Future is a wrapper for a Directory_Snapshot promised by a client call.
+type Directory_Snapshot_Future struct{ *capnp.Future }
+
+func (p Directory_Snapshot_Future) Struct() (Directory_Snapshot, error) {
+	s, err := p.Future.Struct()
+	return Directory_Snapshot{s}, err
+}
+
+func (p Directory_Snapshot_Future) Dir() Directory {
+	return Directory{Client: p.Future.Field(0, nil).Client()}
+}
+
 type Directory_list_Params struct{ capnp.Struct }
 
 // Directory_list_Params_TypeID is the unique identifier for the type Directory_list_Params.
@@ -1249,384 +1875,252 @@ func (p Directory_walk_Results_Future) Node() Node {
 	return Node{Client: p.Future.Field(0, nil).Client()}
 }
 
-type RwDirectory struct{ Client *capnp.Client }
+type Directory_snapshots_Params struct{ capnp.Struct }
 
-// RwDirectory_TypeID is the unique identifier for the type RwDirectory.
-const RwDirectory_TypeID = 0xdffe2836f5c5dffc
+// Directory_snapshots_Params_TypeID is the unique identifier for the type Directory_snapshots_Params.
+const Directory_snapshots_Params_TypeID = 0xe653983935901f5d
 
-func (c RwDirectory) Create(ctx context.Context, params func(RwDirectory_create_Params) error) (RwDirectory_create_Results_Future, capnp.ReleaseFunc) {
-	s := capnp.Send{
-		Method: capnp.Method{
-			InterfaceID:   0xdffe2836f5c5dffc,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:RwDirectory",
-			MethodName:    "create",
-		},
-	}
-	if params != nil {
-		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 1}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(RwDirectory_create_Params{Struct: s}) }
-	}
-	ans, release := c.Client.SendCall(ctx, s)
-	return RwDirectory_create_Results_Future{Future: ans.Future()}, release
+func NewDirectory_snapshots_Params(s *capnp.Segment) (Directory_snapshots_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Directory_snapshots_Params{st}, err
 }
-func (c RwDirectory) Mkdir(ctx context.Context, params func(RwDirectory_mkdir_Params) error) (RwDirectory_mkdir_Results_Future, capnp.ReleaseFunc) {
-	s := capnp.Send{
-		Method: capnp.Method{
-			InterfaceID:   0xdffe2836f5c5dffc,
-			MethodID:      1,
-			InterfaceName: "filesystem.capnp:RwDirectory",
-			MethodName:    "mkdir",
-		},
-	}
-	if params != nil {
-		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(RwDirectory_mkdir_Params{Struct: s}) }
-	}
-	ans, release := c.Client.SendCall(ctx, s)
-	return RwDirectory_mkdir_Results_Future{Future: ans.Future()}, release
+
+func NewRootDirectory_snapshots_Params(s *capnp.Segment) (Directory_snapshots_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Directory_snapshots_Params{st}, err
 }
-func (c RwDirectory) Delete(ctx context.Context, params func(RwDirectory_delete_Params) error) (RwDirectory_delete_Results_Future, capnp.ReleaseFunc) {
-	s := capnp.Send{
-		Method: capnp.Method{
-			InterfaceID:   0xdffe2836f5c5dffc,
-			MethodID:      2,
-			InterfaceName: "filesystem.capnp:RwDirectory",
-			MethodName:    "delete",
-		},
-	}
-	if params != nil {
-		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(RwDirectory_delete_Params{Struct: s}) }
-	}
-	ans, release := c.Client.SendCall(ctx, s)
-	return RwDirectory_delete_Results_Future{Future: ans.Future()}, release
+
+func ReadRootDirectory_snapshots_Params(msg *capnp.Message) (Directory_snapshots_Params, error) {
+	root, err := msg.Root()
+	return Directory_snapshots_Params{root.Struct()}, err
 }
-func (c RwDirectory) List(ctx context.Context, params func(Directory_list_Params) error) (Directory_list_Results_Future, capnp.ReleaseFunc) {
-	s := capnp.Send{
-		Method: capnp.Method{
-			InterfaceID:   0xce3039544779e0fc,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:Directory",
-			MethodName:    "list",
-		},
-	}
-	if params != nil {
-		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_list_Params{Struct: s}) }
-	}
-	ans, release := c.Client.SendCall(ctx, s)
-	return Directory_list_Results_Future{Future: ans.Future()}, release
+
+func (s Directory_snapshots_Params) String() string {
+	str, _ := text.Marshal(0xe653983935901f5d, s.Struct)
+	return str
 }
-func (c RwDirectory) Walk(ctx context.Context, params func(Directory_walk_Params) error) (Directory_walk_Results_Future, capnp.ReleaseFunc) {
-	s := capnp.Send{
-		Method: capnp.Method{
-			InterfaceID:   0xce3039544779e0fc,
-			MethodID:      1,
-			InterfaceName: "filesystem.capnp:Directory",
-			MethodName:    "walk",
-		},
-	}
-	if params != nil {
-		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_walk_Params{Struct: s}) }
-	}
-	ans, release := c.Client.SendCall(ctx, s)
-	return Directory_walk_Results_Future{Future: ans.Future()}, release
-}
-func (c RwDirectory) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
-	s := capnp.Send{
-		Method: capnp.Method{
-			InterfaceID:   0x955400781a01b061,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:Node",
-			MethodName:    "stat",
-		},
-	}
-	if params != nil {
-		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_stat_Params{Struct: s}) }
-	}
-	ans, release := c.Client.SendCall(ctx, s)
-	return Node_stat_Results_Future{Future: ans.Future()}, release
+
+// Directory_snapshots_Params_List is a list of Directory_snapshots_Params.
+type Directory_snapshots_Params_List struct{ capnp.List }
+
+// NewDirectory_snapshots_Params creates a new list of Directory_snapshots_Params.
+func NewDirectory_snapshots_Params_List(s *capnp.Segment, sz int32) (Directory_snapshots_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return Directory_snapshots_Params_List{l}, err
 }
 
-// A RwDirectory_Server is a RwDirectory with a local implementation.
-type RwDirectory_Server interface {
-	Create(context.Context, RwDirectory_create) error
-
-	Mkdir(context.Context, RwDirectory_mkdir) error
-
-	Delete(context.Context, RwDirectory_delete) error
-
-	List(context.Context, Directory_list) error
-
-	Walk(context.Context, Directory_walk) error
-
-	Stat(context.Context, Node_stat) error
+func (s Directory_snapshots_Params_List) At(i int) Directory_snapshots_Params {
+	return Directory_snapshots_Params{s.List.Struct(i)}
 }
 
-// RwDirectory_NewServer creates a new Server from an implementation of RwDirectory_Server.
-func RwDirectory_NewServer(s RwDirectory_Server, policy *server.Policy) *server.Server {
-	c, _ := s.(server.Shutdowner)
-	return server.New(RwDirectory_Methods(nil, s), s, c, policy)
+func (s Directory_snapshots_Params_List) Set(i int, v Directory_snapshots_Params) error {
+	return s.List.SetStruct(i, v.Struct)
 }
 
-// RwDirectory_ServerToClient creates a new Client from an implementation of RwDirectory_Server.
-// The caller is responsible for calling Release on the returned Client.
-func RwDirectory_ServerToClient(s RwDirectory_Server, policy *server.Policy) RwDirectory {
-	return RwDirectory{Client: capnp.NewClient(RwDirectory_NewServer(s, policy))}
+func (s Directory_snapshots_Params_List) String() string {
+	str, _ := text.MarshalList(0xe653983935901f5d, s.List)
+	return str
 }
 
-// RwDirectory_Methods appends Methods to a slice that invoke the methods on s.
-// This can be used to create a more complicated Server.
-func RwDirectory_Methods(methods []server.Method, s RwDirectory_Server) []server.Method {
-	if cap(methods) == 0 {
-		methods = make([]server.Method, 0, 6)
-	}
+// Directory_snapshots_Params_Future is a wrapper for a Directory_snapshots_Params promised by a client call.
+type Directory_snapshots_Params_Future struct{ *capnp.Future }
 
-	methods = append(methods, server.Method{
-		Method: capnp.Method{
-			InterfaceID:   0xdffe2836f5c5dffc,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:RwDirectory",
-			MethodName:    "create",
-		},
-		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Create(ctx, RwDirectory_create{call})
-		},
-	})
+func (p Directory_snapshots_Params_Future) Struct() (Directory_snapshots_Params, error) {
+	s, err := p.Future.Struct()
+	return Directory_snapshots_Params{s}, err
+}
 
-	methods = append(methods, server.Method{
-		Method: capnp.Method{
-			InterfaceID:   0xdffe2836f5c5dffc,
-			MethodID:      1,
-			InterfaceName: "filesystem.capnp:RwDirectory",
-			MethodName:    "mkdir",
-		},
-		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Mkdir(ctx, RwDirectory_mkdir{call})
-		},
-	})
+type Directory_snapshots_Results struct{ capnp.Struct }
 
-	methods = append(methods, server.Method{
-		Method: capnp.Method{
-			InterfaceID:   0xdffe2836f5c5dffc,
-			MethodID:      2,
-			InterfaceName: "filesystem.capnp:RwDirectory",
-			MethodName:    "delete",
-		},
-		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Delete(ctx, RwDirectory_delete{call})
-		},
-	})
+// Directory_snapshots_Results_TypeID is the unique identifier for the type Directory_snapshots_Results.
+const Directory_snapshots_Results_TypeID = 0xdb9c379c9b1b711f
 
-	methods = append(methods, server.Method{
-		Method: capnp.Method{
-			InterfaceID:   0xce3039544779e0fc,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:Directory",
-			MethodName:    "list",
-		},
-		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.List(ctx, Directory_list{call})
-		},
-	})
+func NewDirectory_snapshots_Results(s *capnp.Segment) (Directory_snapshots_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Directory_snapshots_Results{st}, err
+}
 
-	methods = append(methods, server.Method{
-		Method: capnp.Method{
-			InterfaceID:   0xce3039544779e0fc,
-			MethodID:      1,
-			InterfaceName: "filesystem.capnp:Directory",
-			MethodName:    "walk",
-		},
-		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Walk(ctx, Directory_walk{call})
-		},
-	})
+func NewRootDirectory_snapshots_Results(s *capnp.Segment) (Directory_snapshots_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Directory_snapshots_Results{st}, err
+}
 
-	methods = append(methods, server.Method{
-		Method: capnp.Method{
-			InterfaceID:   0x955400781a01b061,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:Node",
-			MethodName:    "stat",
-		},
-		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Stat(ctx, Node_stat{call})
-		},
-	})
+func ReadRootDirectory_snapshots_Results(msg *capnp.Message) (Directory_snapshots_Results, error) {
+	root, err := msg.Root()
+	return Directory_snapshots_Results{root.Struct()}, err
+}
 
-	return methods
+func (s Directory_snapshots_Results) String() string {
+	str, _ := text.Marshal(0xdb9c379c9b1b711f, s.Struct)
+	return str
 }
 
-// RwDirectory_create holds the state for a server call to RwDirectory.create.
-// See server.Call for documentation.
-type RwDirectory_create struct {
-	*server.Call
+func (s Directory_snapshots_Results) Snapshots() (Directory_Snapshot_List, error) {
+	p, err := s.Struct.Ptr(0)
+	return Directory_Snapshot_List{List: p.List()}, err
 }
 
-// Args returns the call's arguments.
-func (c RwDirectory_create) Args() RwDirectory_create_Params {
-	return RwDirectory_create_Params{Struct: c.Call.Args()}
+func (s Directory_snapshots_Results) HasSnapshots() bool {
+	return s.Struct.HasPtr(0)
 }
 
-// AllocResults allocates the results struct.
-func (c RwDirectory_create) AllocResults() (RwDirectory_create_Results, error) {
-	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwDirectory_create_Results{Struct: r}, err
+func (s Directory_snapshots_Results) SetSnapshots(v Directory_Snapshot_List) error {
+	return s.Struct.SetPtr(0, v.List.ToPtr())
 }
 
-// RwDirectory_mkdir holds the state for a server call to RwDirectory.mkdir.
-// See server.Call for documentation.
-type RwDirectory_mkdir struct {
-	*server.Call
+// NewSnapshots sets the snapshots field to a newly
+// allocated Directory_Snapshot_List, preferring placement in s's segment.
+func (s Directory_snapshots_Results) NewSnapshots(n int32) (Directory_Snapshot_List, error) {
+	l, err := NewDirectory_Snapshot_List(s.Struct.Segment(), n)
+	if err != nil {
+		return Directory_Snapshot_List{}, err
+	}
+	err = s.Struct.SetPtr(0, l.List.ToPtr())
+	return l, err
 }
 
-// Args returns the call's arguments.
-func (c RwDirectory_mkdir) Args() RwDirectory_mkdir_Params {
-	return RwDirectory_mkdir_Params{Struct: c.Call.Args()}
+// Directory_snapshots_Results_List is a list of Directory_snapshots_Results.
+type Directory_snapshots_Results_List struct{ capnp.List }
+
+// NewDirectory_snapshots_Results creates a new list of Directory_snapshots_Results.
+func NewDirectory_snapshots_Results_List(s *capnp.Segment, sz int32) (Directory_snapshots_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return Directory_snapshots_Results_List{l}, err
 }
 
-// AllocResults allocates the results struct.
-func (c RwDirectory_mkdir) AllocResults() (RwDirectory_mkdir_Results, error) {
-	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwDirectory_mkdir_Results{Struct: r}, err
+func (s Directory_snapshots_Results_List) At(i int) Directory_snapshots_Results {
+	return Directory_snapshots_Results{s.List.Struct(i)}
 }
 
-// RwDirectory_delete holds the state for a server call to RwDirectory.delete.
-// See server.Call for documentation.
-type RwDirectory_delete struct {
-	*server.Call
+func (s Directory_snapshots_Results_List) Set(i int, v Directory_snapshots_Results) error {
+	return s.List.SetStruct(i, v.Struct)
 }
 
-// Args returns the call's arguments.
-func (c RwDirectory_delete) Args() RwDirectory_delete_Params {
-	return RwDirectory_delete_Params{Struct: c.Call.Args()}
+func (s Directory_snapshots_Results_List) String() string {
+	str, _ := text.MarshalList(0xdb9c379c9b1b711f, s.List)
+	return str
 }
 
-// AllocResults allocates the results struct.
-func (c RwDirectory_delete) AllocResults() (RwDirectory_delete_Results, error) {
-	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return RwDirectory_delete_Results{Struct: r}, err
+// Directory_snapshots_Results_Future is a wrapper for a Directory_snapshots_Results promised by a client call.
+type Directory_snapshots_Results_Future struct{ *capnp.Future }
+
+func (p Directory_snapshots_Results_Future) Struct() (Directory_snapshots_Results, error) {
+	s, err := p.Future.Struct()
+	return Directory_snapshots_Results{s}, err
 }
 
-type RwDirectory_create_Params struct{ capnp.Struct }
+type Directory_watch_Params struct{ capnp.Struct }
 
-// RwDirectory_create_Params_TypeID is the unique identifier for the type RwDirectory_create_Params.
-const RwDirectory_create_Params_TypeID = 0xedd8b7765a623c77
+// Directory_watch_Params_TypeID is the unique identifier for the type Directory_watch_Params.
+const Directory_watch_Params_TypeID = 0xdfe7c859121346c2
 
-func NewRwDirectory_create_Params(s *capnp.Segment) (RwDirectory_create_Params, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
-	return RwDirectory_create_Params{st}, err
+func NewDirectory_watch_Params(s *capnp.Segment) (Directory_watch_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Directory_watch_Params{st}, err
 }
 
-func NewRootRwDirectory_create_Params(s *capnp.Segment) (RwDirectory_create_Params, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
-	return RwDirectory_create_Params{st}, err
+func NewRootDirectory_watch_Params(s *capnp.Segment) (Directory_watch_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Directory_watch_Params{st}, err
 }
 
-func ReadRootRwDirectory_create_Params(msg *capnp.Message) (RwDirectory_create_Params, error) {
+func ReadRootDirectory_watch_Params(msg *capnp.Message) (Directory_watch_Params, error) {
 	root, err := msg.Root()
-	return RwDirectory_create_Params{root.Struct()}, err
+	return Directory_watch_Params{root.Struct()}, err
 }
 
-func (s RwDirectory_create_Params) String() string {
-	str, _ := text.Marshal(0xedd8b7765a623c77, s.Struct)
+func (s Directory_watch_Params) String() string {
+	str, _ := text.Marshal(0xdfe7c859121346c2, s.Struct)
 	return str
 }
 
-func (s RwDirectory_create_Params) Name() (string, error) {
-	p, err := s.Struct.Ptr(0)
-	return p.Text(), err
+func (s Directory_watch_Params) Watcher() Directory_Watcher {
+	p, _ := s.Struct.Ptr(0)
+	return Directory_Watcher{Client: p.Interface().Client()}
 }
 
-func (s RwDirectory_create_Params) HasName() bool {
+func (s Directory_watch_Params) HasWatcher() bool {
 	return s.Struct.HasPtr(0)
 }
 
-func (s RwDirectory_create_Params) NameBytes() ([]byte, error) {
-	p, err := s.Struct.Ptr(0)
-	return p.TextBytes(), err
-}
-
-func (s RwDirectory_create_Params) SetName(v string) error {
-	return s.Struct.SetText(0, v)
-}
-
-func (s RwDirectory_create_Params) Executable() bool {
-	return s.Struct.Bit(0)
-}
-
-func (s RwDirectory_create_Params) SetExecutable(v bool) {
-	s.Struct.SetBit(0, v)
+func (s Directory_watch_Params) SetWatcher(v Directory_Watcher) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
 }
 
-// RwDirectory_create_Params_List is a list of RwDirectory_create_Params.
-type RwDirectory_create_Params_List struct{ capnp.List }
+// Directory_watch_Params_List is a list of Directory_watch_Params.
+type Directory_watch_Params_List struct{ capnp.List }
 
-// NewRwDirectory_create_Params creates a new list of RwDirectory_create_Params.
-func NewRwDirectory_create_Params_List(s *capnp.Segment, sz int32) (RwDirectory_create_Params_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1}, sz)
-	return RwDirectory_create_Params_List{l}, err
+// NewDirectory_watch_Params creates a new list of Directory_watch_Params.
+func NewDirectory_watch_Params_List(s *capnp.Segment, sz int32) (Directory_watch_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return Directory_watch_Params_List{l}, err
 }
 
-func (s RwDirectory_create_Params_List) At(i int) RwDirectory_create_Params {
-	return RwDirectory_create_Params{s.List.Struct(i)}
+func (s Directory_watch_Params_List) At(i int) Directory_watch_Params {
+	return Directory_watch_Params{s.List.Struct(i)}
 }
 
-func (s RwDirectory_create_Params_List) Set(i int, v RwDirectory_create_Params) error {
+func (s Directory_watch_Params_List) Set(i int, v Directory_watch_Params) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-func (s RwDirectory_create_Params_List) String() string {
-	str, _ := text.MarshalList(0xedd8b7765a623c77, s.List)
+func (s Directory_watch_Params_List) String() string {
+	str, _ := text.MarshalList(0xdfe7c859121346c2, s.List)
 	return str
 }
 
-// RwDirectory_create_Params_Future is a wrapper for a RwDirectory_create_Params promised by a client call.
-type RwDirectory_create_Params_Future struct{ *capnp.Future }
+// Directory_watch_Params_Future is a wrapper for a Directory_watch_Params promised by a client call.
+type Directory_watch_Params_Future struct{ *capnp.Future }
 
-func (p RwDirectory_create_Params_Future) Struct() (RwDirectory_create_Params, error) {
+func (p Directory_watch_Params_Future) Struct() (Directory_watch_Params, error) {
 	s, err := p.Future.Struct()
-	return RwDirectory_create_Params{s}, err
+	return Directory_watch_Params{s}, err
 }
 
-type RwDirectory_create_Results struct{ capnp.Struct }
+func (p Directory_watch_Params_Future) Watcher() Directory_Watcher {
+	return Directory_Watcher{Client: p.Future.Field(0, nil).Client()}
+}
 
-// RwDirectory_create_Results_TypeID is the unique identifier for the type RwDirectory_create_Results.
-const RwDirectory_create_Results_TypeID = 0xccdb75f03a83cd44
+type Directory_watch_Results struct{ capnp.Struct }
 
-func NewRwDirectory_create_Results(s *capnp.Segment) (RwDirectory_create_Results, error) {
+// Directory_watch_Results_TypeID is the unique identifier for the type Directory_watch_Results.
+const Directory_watch_Results_TypeID = 0xc493e9169febf59b
+
+func NewDirectory_watch_Results(s *capnp.Segment) (Directory_watch_Results, error) {
 	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwDirectory_create_Results{st}, err
+	return Directory_watch_Results{st}, err
 }
 
-func NewRootRwDirectory_create_Results(s *capnp.Segment) (RwDirectory_create_Results, error) {
+func NewRootDirectory_watch_Results(s *capnp.Segment) (Directory_watch_Results, error) {
 	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwDirectory_create_Results{st}, err
+	return Directory_watch_Results{st}, err
 }
 
-func ReadRootRwDirectory_create_Results(msg *capnp.Message) (RwDirectory_create_Results, error) {
+func ReadRootDirectory_watch_Results(msg *capnp.Message) (Directory_watch_Results, error) {
 	root, err := msg.Root()
-	return RwDirectory_create_Results{root.Struct()}, err
+	return Directory_watch_Results{root.Struct()}, err
 }
 
-func (s RwDirectory_create_Results) String() string {
-	str, _ := text.Marshal(0xccdb75f03a83cd44, s.Struct)
+func (s Directory_watch_Results) String() string {
+	str, _ := text.Marshal(0xc493e9169febf59b, s.Struct)
 	return str
 }
 
-func (s RwDirectory_create_Results) File() RwFile {
+func (s Directory_watch_Results) Handle() WatchHandle {
 	p, _ := s.Struct.Ptr(0)
-	return RwFile{Client: p.Interface().Client()}
+	return WatchHandle{Client: p.Interface().Client()}
 }
 
-func (s RwDirectory_create_Results) HasFile() bool {
+func (s Directory_watch_Results) HasHandle() bool {
 	return s.Struct.HasPtr(0)
 }
 
-func (s RwDirectory_create_Results) SetFile(v RwFile) error {
+func (s Directory_watch_Results) SetHandle(v WatchHandle) error {
 	if !v.Client.IsValid() {
 		return s.Struct.SetPtr(0, capnp.Ptr{})
 	}
@@ -1635,477 +2129,6031 @@ func (s RwDirectory_create_Results) SetFile(v RwFile) error {
 	return s.Struct.SetPtr(0, in.ToPtr())
 }
 
-// RwDirectory_create_Results_List is a list of RwDirectory_create_Results.
-type RwDirectory_create_Results_List struct{ capnp.List }
+// Directory_watch_Results_List is a list of Directory_watch_Results.
+type Directory_watch_Results_List struct{ capnp.List }
 
-// NewRwDirectory_create_Results creates a new list of RwDirectory_create_Results.
-func NewRwDirectory_create_Results_List(s *capnp.Segment, sz int32) (RwDirectory_create_Results_List, error) {
+// NewDirectory_watch_Results creates a new list of Directory_watch_Results.
+func NewDirectory_watch_Results_List(s *capnp.Segment, sz int32) (Directory_watch_Results_List, error) {
 	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
-	return RwDirectory_create_Results_List{l}, err
+	return Directory_watch_Results_List{l}, err
 }
 
-func (s RwDirectory_create_Results_List) At(i int) RwDirectory_create_Results {
-	return RwDirectory_create_Results{s.List.Struct(i)}
+func (s Directory_watch_Results_List) At(i int) Directory_watch_Results {
+	return Directory_watch_Results{s.List.Struct(i)}
 }
 
-func (s RwDirectory_create_Results_List) Set(i int, v RwDirectory_create_Results) error {
+func (s Directory_watch_Results_List) Set(i int, v Directory_watch_Results) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-func (s RwDirectory_create_Results_List) String() string {
-	str, _ := text.MarshalList(0xccdb75f03a83cd44, s.List)
+func (s Directory_watch_Results_List) String() string {
+	str, _ := text.MarshalList(0xc493e9169febf59b, s.List)
 	return str
 }
 
-// RwDirectory_create_Results_Future is a wrapper for a RwDirectory_create_Results promised by a client call.
-type RwDirectory_create_Results_Future struct{ *capnp.Future }
+// Directory_watch_Results_Future is a wrapper for a Directory_watch_Results promised by a client call.
+type Directory_watch_Results_Future struct{ *capnp.Future }
 
-func (p RwDirectory_create_Results_Future) Struct() (RwDirectory_create_Results, error) {
+func (p Directory_watch_Results_Future) Struct() (Directory_watch_Results, error) {
 	s, err := p.Future.Struct()
-	return RwDirectory_create_Results{s}, err
+	return Directory_watch_Results{s}, err
 }
 
-func (p RwDirectory_create_Results_Future) File() RwFile {
-	return RwFile{Client: p.Future.Field(0, nil).Client()}
+func (p Directory_watch_Results_Future) Handle() WatchHandle {
+	return WatchHandle{Client: p.Future.Field(0, nil).Client()}
 }
 
-type RwDirectory_mkdir_Params struct{ capnp.Struct }
-
-// RwDirectory_mkdir_Params_TypeID is the unique identifier for the type RwDirectory_mkdir_Params.
-const RwDirectory_mkdir_Params_TypeID = 0xc264d071767f0ab6
-
-func NewRwDirectory_mkdir_Params(s *capnp.Segment) (RwDirectory_mkdir_Params, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwDirectory_mkdir_Params{st}, err
-}
-
-func NewRootRwDirectory_mkdir_Params(s *capnp.Segment) (RwDirectory_mkdir_Params, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwDirectory_mkdir_Params{st}, err
-}
-
-func ReadRootRwDirectory_mkdir_Params(msg *capnp.Message) (RwDirectory_mkdir_Params, error) {
-	root, err := msg.Root()
-	return RwDirectory_mkdir_Params{root.Struct()}, err
-}
-
-func (s RwDirectory_mkdir_Params) String() string {
-	str, _ := text.Marshal(0xc264d071767f0ab6, s.Struct)
-	return str
-}
-
-func (s RwDirectory_mkdir_Params) Name() (string, error) {
-	p, err := s.Struct.Ptr(0)
-	return p.Text(), err
-}
-
-func (s RwDirectory_mkdir_Params) HasName() bool {
-	return s.Struct.HasPtr(0)
-}
-
-func (s RwDirectory_mkdir_Params) NameBytes() ([]byte, error) {
-	p, err := s.Struct.Ptr(0)
-	return p.TextBytes(), err
-}
-
-func (s RwDirectory_mkdir_Params) SetName(v string) error {
-	return s.Struct.SetText(0, v)
-}
-
-// RwDirectory_mkdir_Params_List is a list of RwDirectory_mkdir_Params.
-type RwDirectory_mkdir_Params_List struct{ capnp.List }
-
-// NewRwDirectory_mkdir_Params creates a new list of RwDirectory_mkdir_Params.
-func NewRwDirectory_mkdir_Params_List(s *capnp.Segment, sz int32) (RwDirectory_mkdir_Params_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
-	return RwDirectory_mkdir_Params_List{l}, err
-}
-
-func (s RwDirectory_mkdir_Params_List) At(i int) RwDirectory_mkdir_Params {
-	return RwDirectory_mkdir_Params{s.List.Struct(i)}
-}
-
-func (s RwDirectory_mkdir_Params_List) Set(i int, v RwDirectory_mkdir_Params) error {
-	return s.List.SetStruct(i, v.Struct)
-}
-
-func (s RwDirectory_mkdir_Params_List) String() string {
-	str, _ := text.MarshalList(0xc264d071767f0ab6, s.List)
-	return str
-}
+type Directory_Watcher struct{ Client *capnp.Client }
 
-// RwDirectory_mkdir_Params_Future is a wrapper for a RwDirectory_mkdir_Params promised by a client call.
-type RwDirectory_mkdir_Params_Future struct{ *capnp.Future }
-
-func (p RwDirectory_mkdir_Params_Future) Struct() (RwDirectory_mkdir_Params, error) {
-	s, err := p.Future.Struct()
-	return RwDirectory_mkdir_Params{s}, err
-}
-
-type RwDirectory_mkdir_Results struct{ capnp.Struct }
-
-// RwDirectory_mkdir_Results_TypeID is the unique identifier for the type RwDirectory_mkdir_Results.
-const RwDirectory_mkdir_Results_TypeID = 0xb7774b1c65f804fa
-
-func NewRwDirectory_mkdir_Results(s *capnp.Segment) (RwDirectory_mkdir_Results, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwDirectory_mkdir_Results{st}, err
-}
-
-func NewRootRwDirectory_mkdir_Results(s *capnp.Segment) (RwDirectory_mkdir_Results, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwDirectory_mkdir_Results{st}, err
-}
+// Directory_Watcher_TypeID is the unique identifier for the type Directory_Watcher.
+const Directory_Watcher_TypeID = 0xbc1aabd11f7671c6
 
-func ReadRootRwDirectory_mkdir_Results(msg *capnp.Message) (RwDirectory_mkdir_Results, error) {
-	root, err := msg.Root()
-	return RwDirectory_mkdir_Results{root.Struct()}, err
+func (c Directory_Watcher) Changed(ctx context.Context, params func(Directory_Watcher_changed_Params) error) (Directory_Watcher_changed_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xbc1aabd11f7671c6,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Directory.Watcher",
+			MethodName:    "changed",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_Watcher_changed_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_Watcher_changed_Results_Future{Future: ans.Future()}, release
 }
 
-func (s RwDirectory_mkdir_Results) String() string {
-	str, _ := text.Marshal(0xb7774b1c65f804fa, s.Struct)
-	return str
+// A Directory_Watcher_Server is a Directory_Watcher with a local implementation.
+type Directory_Watcher_Server interface {
+	Changed(context.Context, Directory_Watcher_changed) error
 }
 
-func (s RwDirectory_mkdir_Results) Dir() RwDirectory {
-	p, _ := s.Struct.Ptr(0)
-	return RwDirectory{Client: p.Interface().Client()}
+// Directory_Watcher_NewServer creates a new Server from an implementation of Directory_Watcher_Server.
+func Directory_Watcher_NewServer(s Directory_Watcher_Server, policy *server.Policy) *server.Server {
+	c, _ := s.(server.Shutdowner)
+	return server.New(Directory_Watcher_Methods(nil, s), s, c, policy)
 }
 
-func (s RwDirectory_mkdir_Results) HasDir() bool {
-	return s.Struct.HasPtr(0)
+// Directory_Watcher_ServerToClient creates a new Client from an implementation of Directory_Watcher_Server.
+// The caller is responsible for calling Release on the returned Client.
+func Directory_Watcher_ServerToClient(s Directory_Watcher_Server, policy *server.Policy) Directory_Watcher {
+	return Directory_Watcher{Client: capnp.NewClient(Directory_Watcher_NewServer(s, policy))}
 }
 
-func (s RwDirectory_mkdir_Results) SetDir(v RwDirectory) error {
-	if !v.Client.IsValid() {
-		return s.Struct.SetPtr(0, capnp.Ptr{})
+// Directory_Watcher_Methods appends Methods to a slice that invoke the methods on s.
+// This can be used to create a more complicated Server.
+func Directory_Watcher_Methods(methods []server.Method, s Directory_Watcher_Server) []server.Method {
+	if cap(methods) == 0 {
+		methods = make([]server.Method, 0, 1)
 	}
-	seg := s.Segment()
-	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
-	return s.Struct.SetPtr(0, in.ToPtr())
-}
-
-// RwDirectory_mkdir_Results_List is a list of RwDirectory_mkdir_Results.
-type RwDirectory_mkdir_Results_List struct{ capnp.List }
-
-// NewRwDirectory_mkdir_Results creates a new list of RwDirectory_mkdir_Results.
-func NewRwDirectory_mkdir_Results_List(s *capnp.Segment, sz int32) (RwDirectory_mkdir_Results_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
-	return RwDirectory_mkdir_Results_List{l}, err
-}
 
-func (s RwDirectory_mkdir_Results_List) At(i int) RwDirectory_mkdir_Results {
-	return RwDirectory_mkdir_Results{s.List.Struct(i)}
-}
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xbc1aabd11f7671c6,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Directory.Watcher",
+			MethodName:    "changed",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Changed(ctx, Directory_Watcher_changed{call})
+		},
+	})
 
-func (s RwDirectory_mkdir_Results_List) Set(i int, v RwDirectory_mkdir_Results) error {
-	return s.List.SetStruct(i, v.Struct)
+	return methods
 }
 
-func (s RwDirectory_mkdir_Results_List) String() string {
-	str, _ := text.MarshalList(0xb7774b1c65f804fa, s.List)
-	return str
+// Directory_Watcher_changed holds the state for a server call to Directory_Watcher.changed.
+// See server.Call for documentation.
+type Directory_Watcher_changed struct {
+	*server.Call
 }
 
-// RwDirectory_mkdir_Results_Future is a wrapper for a RwDirectory_mkdir_Results promised by a client call.
-type RwDirectory_mkdir_Results_Future struct{ *capnp.Future }
-
-func (p RwDirectory_mkdir_Results_Future) Struct() (RwDirectory_mkdir_Results, error) {
-	s, err := p.Future.Struct()
-	return RwDirectory_mkdir_Results{s}, err
+// Args returns the call's arguments.
+func (c Directory_Watcher_changed) Args() Directory_Watcher_changed_Params {
+	return Directory_Watcher_changed_Params{Struct: c.Call.Args()}
 }
 
-func (p RwDirectory_mkdir_Results_Future) Dir() RwDirectory {
-	return RwDirectory{Client: p.Future.Field(0, nil).Client()}
+// AllocResults allocates the results struct.
+func (c Directory_Watcher_changed) AllocResults() (Directory_Watcher_changed_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Directory_Watcher_changed_Results{Struct: r}, err
 }
 
-type RwDirectory_delete_Params struct{ capnp.Struct }
+type Directory_Watcher_changed_Params struct{ capnp.Struct }
 
-// RwDirectory_delete_Params_TypeID is the unique identifier for the type RwDirectory_delete_Params.
-const RwDirectory_delete_Params_TypeID = 0xcf03bca4fb87f453
+// Directory_Watcher_changed_Params_TypeID is the unique identifier for the type Directory_Watcher_changed_Params.
+const Directory_Watcher_changed_Params_TypeID = 0xc5829cd82ce1fcff
 
-func NewRwDirectory_delete_Params(s *capnp.Segment) (RwDirectory_delete_Params, error) {
+func NewDirectory_Watcher_changed_Params(s *capnp.Segment) (Directory_Watcher_changed_Params, error) {
 	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwDirectory_delete_Params{st}, err
+	return Directory_Watcher_changed_Params{st}, err
 }
 
-func NewRootRwDirectory_delete_Params(s *capnp.Segment) (RwDirectory_delete_Params, error) {
+func NewRootDirectory_Watcher_changed_Params(s *capnp.Segment) (Directory_Watcher_changed_Params, error) {
 	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwDirectory_delete_Params{st}, err
+	return Directory_Watcher_changed_Params{st}, err
 }
 
-func ReadRootRwDirectory_delete_Params(msg *capnp.Message) (RwDirectory_delete_Params, error) {
+func ReadRootDirectory_Watcher_changed_Params(msg *capnp.Message) (Directory_Watcher_changed_Params, error) {
 	root, err := msg.Root()
-	return RwDirectory_delete_Params{root.Struct()}, err
+	return Directory_Watcher_changed_Params{root.Struct()}, err
 }
 
-func (s RwDirectory_delete_Params) String() string {
-	str, _ := text.Marshal(0xcf03bca4fb87f453, s.Struct)
+func (s Directory_Watcher_changed_Params) String() string {
+	str, _ := text.Marshal(0xc5829cd82ce1fcff, s.Struct)
 	return str
 }
 
-func (s RwDirectory_delete_Params) Name() (string, error) {
+func (s Directory_Watcher_changed_Params) Paths() (capnp.TextList, error) {
 	p, err := s.Struct.Ptr(0)
-	return p.Text(), err
+	return capnp.TextList{List: p.List()}, err
 }
 
-func (s RwDirectory_delete_Params) HasName() bool {
+func (s Directory_Watcher_changed_Params) HasPaths() bool {
 	return s.Struct.HasPtr(0)
 }
 
-func (s RwDirectory_delete_Params) NameBytes() ([]byte, error) {
-	p, err := s.Struct.Ptr(0)
-	return p.TextBytes(), err
+func (s Directory_Watcher_changed_Params) SetPaths(v capnp.TextList) error {
+	return s.Struct.SetPtr(0, v.List.ToPtr())
 }
 
-func (s RwDirectory_delete_Params) SetName(v string) error {
-	return s.Struct.SetText(0, v)
+// NewPaths sets the paths field to a newly
+// allocated capnp.TextList, preferring placement in s's segment.
+func (s Directory_Watcher_changed_Params) NewPaths(n int32) (capnp.TextList, error) {
+	l, err := capnp.NewTextList(s.Struct.Segment(), n)
+	if err != nil {
+		return capnp.TextList{}, err
+	}
+	err = s.Struct.SetPtr(0, l.List.ToPtr())
+	return l, err
 }
 
-// RwDirectory_delete_Params_List is a list of RwDirectory_delete_Params.
-type RwDirectory_delete_Params_List struct{ capnp.List }
+// Directory_Watcher_changed_Params_List is a list of Directory_Watcher_changed_Params.
+type Directory_Watcher_changed_Params_List struct{ capnp.List }
 
-// NewRwDirectory_delete_Params creates a new list of RwDirectory_delete_Params.
-func NewRwDirectory_delete_Params_List(s *capnp.Segment, sz int32) (RwDirectory_delete_Params_List, error) {
+// NewDirectory_Watcher_changed_Params creates a new list of Directory_Watcher_changed_Params.
+func NewDirectory_Watcher_changed_Params_List(s *capnp.Segment, sz int32) (Directory_Watcher_changed_Params_List, error) {
 	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
-	return RwDirectory_delete_Params_List{l}, err
+	return Directory_Watcher_changed_Params_List{l}, err
 }
 
-func (s RwDirectory_delete_Params_List) At(i int) RwDirectory_delete_Params {
-	return RwDirectory_delete_Params{s.List.Struct(i)}
+func (s Directory_Watcher_changed_Params_List) At(i int) Directory_Watcher_changed_Params {
+	return Directory_Watcher_changed_Params{s.List.Struct(i)}
 }
 
-func (s RwDirectory_delete_Params_List) Set(i int, v RwDirectory_delete_Params) error {
+func (s Directory_Watcher_changed_Params_List) Set(i int, v Directory_Watcher_changed_Params) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-func (s RwDirectory_delete_Params_List) String() string {
-	str, _ := text.MarshalList(0xcf03bca4fb87f453, s.List)
+func (s Directory_Watcher_changed_Params_List) String() string {
+	str, _ := text.MarshalList(0xc5829cd82ce1fcff, s.List)
 	return str
 }
 
-// RwDirectory_delete_Params_Future is a wrapper for a RwDirectory_delete_Params promised by a client call.
-type RwDirectory_delete_Params_Future struct{ *capnp.Future }
+// Directory_Watcher_changed_Params_Future is a wrapper for a Directory_Watcher_changed_Params promised by a client call.
+type Directory_Watcher_changed_Params_Future struct{ *capnp.Future }
 
-func (p RwDirectory_delete_Params_Future) Struct() (RwDirectory_delete_Params, error) {
+func (p Directory_Watcher_changed_Params_Future) Struct() (Directory_Watcher_changed_Params, error) {
 	s, err := p.Future.Struct()
-	return RwDirectory_delete_Params{s}, err
+	return Directory_Watcher_changed_Params{s}, err
 }
 
-type RwDirectory_delete_Results struct{ capnp.Struct }
+type Directory_Watcher_changed_Results struct{ capnp.Struct }
 
-// RwDirectory_delete_Results_TypeID is the unique identifier for the type RwDirectory_delete_Results.
-const RwDirectory_delete_Results_TypeID = 0xff0de3e62887d2f0
+// Directory_Watcher_changed_Results_TypeID is the unique identifier for the type Directory_Watcher_changed_Results.
+const Directory_Watcher_changed_Results_TypeID = 0xcc66b917fda6ae49
 
-func NewRwDirectory_delete_Results(s *capnp.Segment) (RwDirectory_delete_Results, error) {
+func NewDirectory_Watcher_changed_Results(s *capnp.Segment) (Directory_Watcher_changed_Results, error) {
 	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return RwDirectory_delete_Results{st}, err
+	return Directory_Watcher_changed_Results{st}, err
 }
 
-func NewRootRwDirectory_delete_Results(s *capnp.Segment) (RwDirectory_delete_Results, error) {
+func NewRootDirectory_Watcher_changed_Results(s *capnp.Segment) (Directory_Watcher_changed_Results, error) {
 	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return RwDirectory_delete_Results{st}, err
+	return Directory_Watcher_changed_Results{st}, err
 }
 
-func ReadRootRwDirectory_delete_Results(msg *capnp.Message) (RwDirectory_delete_Results, error) {
+func ReadRootDirectory_Watcher_changed_Results(msg *capnp.Message) (Directory_Watcher_changed_Results, error) {
 	root, err := msg.Root()
-	return RwDirectory_delete_Results{root.Struct()}, err
+	return Directory_Watcher_changed_Results{root.Struct()}, err
 }
 
-func (s RwDirectory_delete_Results) String() string {
-	str, _ := text.Marshal(0xff0de3e62887d2f0, s.Struct)
+func (s Directory_Watcher_changed_Results) String() string {
+	str, _ := text.Marshal(0xcc66b917fda6ae49, s.Struct)
 	return str
 }
 
-// RwDirectory_delete_Results_List is a list of RwDirectory_delete_Results.
-type RwDirectory_delete_Results_List struct{ capnp.List }
+// Directory_Watcher_changed_Results_List is a list of Directory_Watcher_changed_Results.
+type Directory_Watcher_changed_Results_List struct{ capnp.List }
 
-// NewRwDirectory_delete_Results creates a new list of RwDirectory_delete_Results.
-func NewRwDirectory_delete_Results_List(s *capnp.Segment, sz int32) (RwDirectory_delete_Results_List, error) {
+// NewDirectory_Watcher_changed_Results creates a new list of Directory_Watcher_changed_Results.
+func NewDirectory_Watcher_changed_Results_List(s *capnp.Segment, sz int32) (Directory_Watcher_changed_Results_List, error) {
 	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
-	return RwDirectory_delete_Results_List{l}, err
+	return Directory_Watcher_changed_Results_List{l}, err
 }
 
-func (s RwDirectory_delete_Results_List) At(i int) RwDirectory_delete_Results {
-	return RwDirectory_delete_Results{s.List.Struct(i)}
+func (s Directory_Watcher_changed_Results_List) At(i int) Directory_Watcher_changed_Results {
+	return Directory_Watcher_changed_Results{s.List.Struct(i)}
 }
 
-func (s RwDirectory_delete_Results_List) Set(i int, v RwDirectory_delete_Results) error {
+func (s Directory_Watcher_changed_Results_List) Set(i int, v Directory_Watcher_changed_Results) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-func (s RwDirectory_delete_Results_List) String() string {
-	str, _ := text.MarshalList(0xff0de3e62887d2f0, s.List)
+func (s Directory_Watcher_changed_Results_List) String() string {
+	str, _ := text.MarshalList(0xcc66b917fda6ae49, s.List)
 	return str
 }
 
-// RwDirectory_delete_Results_Future is a wrapper for a RwDirectory_delete_Results promised by a client call.
-type RwDirectory_delete_Results_Future struct{ *capnp.Future }
+// Directory_Watcher_changed_Results_Future is a wrapper for a Directory_Watcher_changed_Results promised by a client call.
+type Directory_Watcher_changed_Results_Future struct{ *capnp.Future }
 
-func (p RwDirectory_delete_Results_Future) Struct() (RwDirectory_delete_Results, error) {
+func (p Directory_Watcher_changed_Results_Future) Struct() (Directory_Watcher_changed_Results, error) {
 	s, err := p.Future.Struct()
-	return RwDirectory_delete_Results{s}, err
+	return Directory_Watcher_changed_Results{s}, err
 }
 
-type File struct{ Client *capnp.Client }
+type RwDirectory struct{ Client *capnp.Client }
 
-// File_TypeID is the unique identifier for the type File.
-const File_TypeID = 0xaa5b133d60884bbd
+// RwDirectory_TypeID is the unique identifier for the type RwDirectory.
+const RwDirectory_TypeID = 0xdffe2836f5c5dffc
 
-func (c File) Read(ctx context.Context, params func(File_read_Params) error) (File_read_Results_Future, capnp.ReleaseFunc) {
+func (c RwDirectory) Create(ctx context.Context, params func(RwDirectory_create_Params) error) (RwDirectory_create_Results_Future, capnp.ReleaseFunc) {
 	s := capnp.Send{
 		Method: capnp.Method{
-			InterfaceID:   0xaa5b133d60884bbd,
+			InterfaceID:   0xdffe2836f5c5dffc,
 			MethodID:      0,
-			InterfaceName: "filesystem.capnp:File",
-			MethodName:    "read",
+			InterfaceName: "filesystem.capnp:RwDirectory",
+			MethodName:    "create",
 		},
 	}
 	if params != nil {
-		s.ArgsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 1}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(File_read_Params{Struct: s}) }
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwDirectory_create_Params{Struct: s}) }
 	}
 	ans, release := c.Client.SendCall(ctx, s)
-	return File_read_Results_Future{Future: ans.Future()}, release
+	return RwDirectory_create_Results_Future{Future: ans.Future()}, release
 }
-func (c File) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
+func (c RwDirectory) Mkdir(ctx context.Context, params func(RwDirectory_mkdir_Params) error) (RwDirectory_mkdir_Results_Future, capnp.ReleaseFunc) {
 	s := capnp.Send{
 		Method: capnp.Method{
-			InterfaceID:   0x955400781a01b061,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:Node",
-			MethodName:    "stat",
+			InterfaceID:   0xdffe2836f5c5dffc,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:RwDirectory",
+			MethodName:    "mkdir",
 		},
 	}
 	if params != nil {
-		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_stat_Params{Struct: s}) }
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwDirectory_mkdir_Params{Struct: s}) }
 	}
 	ans, release := c.Client.SendCall(ctx, s)
-	return Node_stat_Results_Future{Future: ans.Future()}, release
+	return RwDirectory_mkdir_Results_Future{Future: ans.Future()}, release
 }
-
+func (c RwDirectory) Delete(ctx context.Context, params func(RwDirectory_delete_Params) error) (RwDirectory_delete_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xdffe2836f5c5dffc,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:RwDirectory",
+			MethodName:    "delete",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwDirectory_delete_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwDirectory_delete_Results_Future{Future: ans.Future()}, release
+}
+func (c RwDirectory) Snapshot(ctx context.Context, params func(RwDirectory_snapshot_Params) error) (RwDirectory_snapshot_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xdffe2836f5c5dffc,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:RwDirectory",
+			MethodName:    "snapshot",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwDirectory_snapshot_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwDirectory_snapshot_Results_Future{Future: ans.Future()}, release
+}
+func (c RwDirectory) Trash(ctx context.Context, params func(RwDirectory_trash_Params) error) (RwDirectory_trash_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xdffe2836f5c5dffc,
+			MethodID:      4,
+			InterfaceName: "filesystem.capnp:RwDirectory",
+			MethodName:    "trash",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwDirectory_trash_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwDirectory_trash_Results_Future{Future: ans.Future()}, release
+}
+func (c RwDirectory) List(ctx context.Context, params func(Directory_list_Params) error) (Directory_list_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "list",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_list_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_list_Results_Future{Future: ans.Future()}, release
+}
+func (c RwDirectory) Walk(ctx context.Context, params func(Directory_walk_Params) error) (Directory_walk_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "walk",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_walk_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_walk_Results_Future{Future: ans.Future()}, release
+}
+func (c RwDirectory) Snapshots(ctx context.Context, params func(Directory_snapshots_Params) error) (Directory_snapshots_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "snapshots",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_snapshots_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_snapshots_Results_Future{Future: ans.Future()}, release
+}
+func (c RwDirectory) Watch(ctx context.Context, params func(Directory_watch_Params) error) (Directory_watch_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "watch",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_watch_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_watch_Results_Future{Future: ans.Future()}, release
+}
+func (c RwDirectory) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_stat_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_stat_Results_Future{Future: ans.Future()}, release
+}
+func (c RwDirectory) GetXattr(ctx context.Context, params func(Node_getXattr_Params) error) (Node_getXattr_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_getXattr_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_getXattr_Results_Future{Future: ans.Future()}, release
+}
+func (c RwDirectory) ListXattrs(ctx context.Context, params func(Node_listXattrs_Params) error) (Node_listXattrs_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_listXattrs_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_listXattrs_Results_Future{Future: ans.Future()}, release
+}
+
+// A RwDirectory_Server is a RwDirectory with a local implementation.
+type RwDirectory_Server interface {
+	Create(context.Context, RwDirectory_create) error
+
+	Mkdir(context.Context, RwDirectory_mkdir) error
+
+	Delete(context.Context, RwDirectory_delete) error
+
+	Snapshot(context.Context, RwDirectory_snapshot) error
+
+	Trash(context.Context, RwDirectory_trash) error
+
+	List(context.Context, Directory_list) error
+
+	Walk(context.Context, Directory_walk) error
+
+	Snapshots(context.Context, Directory_snapshots) error
+
+	Watch(context.Context, Directory_watch) error
+
+	Stat(context.Context, Node_stat) error
+
+	GetXattr(context.Context, Node_getXattr) error
+
+	ListXattrs(context.Context, Node_listXattrs) error
+}
+
+// RwDirectory_NewServer creates a new Server from an implementation of RwDirectory_Server.
+func RwDirectory_NewServer(s RwDirectory_Server, policy *server.Policy) *server.Server {
+	c, _ := s.(server.Shutdowner)
+	return server.New(RwDirectory_Methods(nil, s), s, c, policy)
+}
+
+// RwDirectory_ServerToClient creates a new Client from an implementation of RwDirectory_Server.
+// The caller is responsible for calling Release on the returned Client.
+func RwDirectory_ServerToClient(s RwDirectory_Server, policy *server.Policy) RwDirectory {
+	return RwDirectory{Client: capnp.NewClient(RwDirectory_NewServer(s, policy))}
+}
+
+// RwDirectory_Methods appends Methods to a slice that invoke the methods on s.
+// This can be used to create a more complicated Server.
+func RwDirectory_Methods(methods []server.Method, s RwDirectory_Server) []server.Method {
+	if cap(methods) == 0 {
+		methods = make([]server.Method, 0, 12)
+	}
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xdffe2836f5c5dffc,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:RwDirectory",
+			MethodName:    "create",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Create(ctx, RwDirectory_create{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xdffe2836f5c5dffc,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:RwDirectory",
+			MethodName:    "mkdir",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Mkdir(ctx, RwDirectory_mkdir{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xdffe2836f5c5dffc,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:RwDirectory",
+			MethodName:    "delete",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Delete(ctx, RwDirectory_delete{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xdffe2836f5c5dffc,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:RwDirectory",
+			MethodName:    "snapshot",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Snapshot(ctx, RwDirectory_snapshot{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xdffe2836f5c5dffc,
+			MethodID:      4,
+			InterfaceName: "filesystem.capnp:RwDirectory",
+			MethodName:    "trash",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Trash(ctx, RwDirectory_trash{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "list",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.List(ctx, Directory_list{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "walk",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Walk(ctx, Directory_walk{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "snapshots",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Snapshots(ctx, Directory_snapshots{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "watch",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Watch(ctx, Directory_watch{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Stat(ctx, Node_stat{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.GetXattr(ctx, Node_getXattr{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.ListXattrs(ctx, Node_listXattrs{call})
+		},
+	})
+
+	return methods
+}
+
+// RwDirectory_create holds the state for a server call to RwDirectory.create.
+// See server.Call for documentation.
+type RwDirectory_create struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwDirectory_create) Args() RwDirectory_create_Params {
+	return RwDirectory_create_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwDirectory_create) AllocResults() (RwDirectory_create_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_create_Results{Struct: r}, err
+}
+
+// RwDirectory_mkdir holds the state for a server call to RwDirectory.mkdir.
+// See server.Call for documentation.
+type RwDirectory_mkdir struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwDirectory_mkdir) Args() RwDirectory_mkdir_Params {
+	return RwDirectory_mkdir_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwDirectory_mkdir) AllocResults() (RwDirectory_mkdir_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_mkdir_Results{Struct: r}, err
+}
+
+// RwDirectory_delete holds the state for a server call to RwDirectory.delete.
+// See server.Call for documentation.
+type RwDirectory_delete struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwDirectory_delete) Args() RwDirectory_delete_Params {
+	return RwDirectory_delete_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwDirectory_delete) AllocResults() (RwDirectory_delete_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwDirectory_delete_Results{Struct: r}, err
+}
+
+// RwDirectory_snapshot holds the state for a server call to RwDirectory.snapshot.
+// See server.Call for documentation.
+type RwDirectory_snapshot struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwDirectory_snapshot) Args() RwDirectory_snapshot_Params {
+	return RwDirectory_snapshot_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwDirectory_snapshot) AllocResults() (RwDirectory_snapshot_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_snapshot_Results{Struct: r}, err
+}
+
+// RwDirectory_trash holds the state for a server call to RwDirectory.trash.
+// See server.Call for documentation.
+type RwDirectory_trash struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwDirectory_trash) Args() RwDirectory_trash_Params {
+	return RwDirectory_trash_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwDirectory_trash) AllocResults() (RwDirectory_trash_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_trash_Results{Struct: r}, err
+}
+
+type RwDirectory_create_Params struct{ capnp.Struct }
+
+// RwDirectory_create_Params_TypeID is the unique identifier for the type RwDirectory_create_Params.
+const RwDirectory_create_Params_TypeID = 0xedd8b7765a623c77
+
+func NewRwDirectory_create_Params(s *capnp.Segment) (RwDirectory_create_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return RwDirectory_create_Params{st}, err
+}
+
+func NewRootRwDirectory_create_Params(s *capnp.Segment) (RwDirectory_create_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return RwDirectory_create_Params{st}, err
+}
+
+func ReadRootRwDirectory_create_Params(msg *capnp.Message) (RwDirectory_create_Params, error) {
+	root, err := msg.Root()
+	return RwDirectory_create_Params{root.Struct()}, err
+}
+
+func (s RwDirectory_create_Params) String() string {
+	str, _ := text.Marshal(0xedd8b7765a623c77, s.Struct)
+	return str
+}
+
+func (s RwDirectory_create_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s RwDirectory_create_Params) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwDirectory_create_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s RwDirectory_create_Params) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+func (s RwDirectory_create_Params) Executable() bool {
+	return s.Struct.Bit(0)
+}
+
+func (s RwDirectory_create_Params) SetExecutable(v bool) {
+	s.Struct.SetBit(0, v)
+}
+
+// RwDirectory_create_Params_List is a list of RwDirectory_create_Params.
+type RwDirectory_create_Params_List struct{ capnp.List }
+
+// NewRwDirectory_create_Params creates a new list of RwDirectory_create_Params.
+func NewRwDirectory_create_Params_List(s *capnp.Segment, sz int32) (RwDirectory_create_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1}, sz)
+	return RwDirectory_create_Params_List{l}, err
+}
+
+func (s RwDirectory_create_Params_List) At(i int) RwDirectory_create_Params {
+	return RwDirectory_create_Params{s.List.Struct(i)}
+}
+
+func (s RwDirectory_create_Params_List) Set(i int, v RwDirectory_create_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwDirectory_create_Params_List) String() string {
+	str, _ := text.MarshalList(0xedd8b7765a623c77, s.List)
+	return str
+}
+
+// RwDirectory_create_Params_Future is a wrapper for a RwDirectory_create_Params promised by a client call.
+type RwDirectory_create_Params_Future struct{ *capnp.Future }
+
+func (p RwDirectory_create_Params_Future) Struct() (RwDirectory_create_Params, error) {
+	s, err := p.Future.Struct()
+	return RwDirectory_create_Params{s}, err
+}
+
+type RwDirectory_create_Results struct{ capnp.Struct }
+
+// RwDirectory_create_Results_TypeID is the unique identifier for the type RwDirectory_create_Results.
+const RwDirectory_create_Results_TypeID = 0xccdb75f03a83cd44
+
+func NewRwDirectory_create_Results(s *capnp.Segment) (RwDirectory_create_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_create_Results{st}, err
+}
+
+func NewRootRwDirectory_create_Results(s *capnp.Segment) (RwDirectory_create_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_create_Results{st}, err
+}
+
+func ReadRootRwDirectory_create_Results(msg *capnp.Message) (RwDirectory_create_Results, error) {
+	root, err := msg.Root()
+	return RwDirectory_create_Results{root.Struct()}, err
+}
+
+func (s RwDirectory_create_Results) String() string {
+	str, _ := text.Marshal(0xccdb75f03a83cd44, s.Struct)
+	return str
+}
+
+func (s RwDirectory_create_Results) File() RwFile {
+	p, _ := s.Struct.Ptr(0)
+	return RwFile{Client: p.Interface().Client()}
+}
+
+func (s RwDirectory_create_Results) HasFile() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwDirectory_create_Results) SetFile(v RwFile) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
+}
+
+// RwDirectory_create_Results_List is a list of RwDirectory_create_Results.
+type RwDirectory_create_Results_List struct{ capnp.List }
+
+// NewRwDirectory_create_Results creates a new list of RwDirectory_create_Results.
+func NewRwDirectory_create_Results_List(s *capnp.Segment, sz int32) (RwDirectory_create_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return RwDirectory_create_Results_List{l}, err
+}
+
+func (s RwDirectory_create_Results_List) At(i int) RwDirectory_create_Results {
+	return RwDirectory_create_Results{s.List.Struct(i)}
+}
+
+func (s RwDirectory_create_Results_List) Set(i int, v RwDirectory_create_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwDirectory_create_Results_List) String() string {
+	str, _ := text.MarshalList(0xccdb75f03a83cd44, s.List)
+	return str
+}
+
+// RwDirectory_create_Results_Future is a wrapper for a RwDirectory_create_Results promised by a client call.
+type RwDirectory_create_Results_Future struct{ *capnp.Future }
+
+func (p RwDirectory_create_Results_Future) Struct() (RwDirectory_create_Results, error) {
+	s, err := p.Future.Struct()
+	return RwDirectory_create_Results{s}, err
+}
+
+func (p RwDirectory_create_Results_Future) File() RwFile {
+	return RwFile{Client: p.Future.Field(0, nil).Client()}
+}
+
+type RwDirectory_mkdir_Params struct{ capnp.Struct }
+
+// RwDirectory_mkdir_Params_TypeID is the unique identifier for the type RwDirectory_mkdir_Params.
+const RwDirectory_mkdir_Params_TypeID = 0xc264d071767f0ab6
+
+func NewRwDirectory_mkdir_Params(s *capnp.Segment) (RwDirectory_mkdir_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_mkdir_Params{st}, err
+}
+
+func NewRootRwDirectory_mkdir_Params(s *capnp.Segment) (RwDirectory_mkdir_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_mkdir_Params{st}, err
+}
+
+func ReadRootRwDirectory_mkdir_Params(msg *capnp.Message) (RwDirectory_mkdir_Params, error) {
+	root, err := msg.Root()
+	return RwDirectory_mkdir_Params{root.Struct()}, err
+}
+
+func (s RwDirectory_mkdir_Params) String() string {
+	str, _ := text.Marshal(0xc264d071767f0ab6, s.Struct)
+	return str
+}
+
+func (s RwDirectory_mkdir_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s RwDirectory_mkdir_Params) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwDirectory_mkdir_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s RwDirectory_mkdir_Params) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+// RwDirectory_mkdir_Params_List is a list of RwDirectory_mkdir_Params.
+type RwDirectory_mkdir_Params_List struct{ capnp.List }
+
+// NewRwDirectory_mkdir_Params creates a new list of RwDirectory_mkdir_Params.
+func NewRwDirectory_mkdir_Params_List(s *capnp.Segment, sz int32) (RwDirectory_mkdir_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return RwDirectory_mkdir_Params_List{l}, err
+}
+
+func (s RwDirectory_mkdir_Params_List) At(i int) RwDirectory_mkdir_Params {
+	return RwDirectory_mkdir_Params{s.List.Struct(i)}
+}
+
+func (s RwDirectory_mkdir_Params_List) Set(i int, v RwDirectory_mkdir_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwDirectory_mkdir_Params_List) String() string {
+	str, _ := text.MarshalList(0xc264d071767f0ab6, s.List)
+	return str
+}
+
+// RwDirectory_mkdir_Params_Future is a wrapper for a RwDirectory_mkdir_Params promised by a client call.
+type RwDirectory_mkdir_Params_Future struct{ *capnp.Future }
+
+func (p RwDirectory_mkdir_Params_Future) Struct() (RwDirectory_mkdir_Params, error) {
+	s, err := p.Future.Struct()
+	return RwDirectory_mkdir_Params{s}, err
+}
+
+type RwDirectory_mkdir_Results struct{ capnp.Struct }
+
+// RwDirectory_mkdir_Results_TypeID is the unique identifier for the type RwDirectory_mkdir_Results.
+const RwDirectory_mkdir_Results_TypeID = 0xb7774b1c65f804fa
+
+func NewRwDirectory_mkdir_Results(s *capnp.Segment) (RwDirectory_mkdir_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_mkdir_Results{st}, err
+}
+
+func NewRootRwDirectory_mkdir_Results(s *capnp.Segment) (RwDirectory_mkdir_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_mkdir_Results{st}, err
+}
+
+func ReadRootRwDirectory_mkdir_Results(msg *capnp.Message) (RwDirectory_mkdir_Results, error) {
+	root, err := msg.Root()
+	return RwDirectory_mkdir_Results{root.Struct()}, err
+}
+
+func (s RwDirectory_mkdir_Results) String() string {
+	str, _ := text.Marshal(0xb7774b1c65f804fa, s.Struct)
+	return str
+}
+
+func (s RwDirectory_mkdir_Results) Dir() RwDirectory {
+	p, _ := s.Struct.Ptr(0)
+	return RwDirectory{Client: p.Interface().Client()}
+}
+
+func (s RwDirectory_mkdir_Results) HasDir() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwDirectory_mkdir_Results) SetDir(v RwDirectory) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
+}
+
+// RwDirectory_mkdir_Results_List is a list of RwDirectory_mkdir_Results.
+type RwDirectory_mkdir_Results_List struct{ capnp.List }
+
+// NewRwDirectory_mkdir_Results creates a new list of RwDirectory_mkdir_Results.
+func NewRwDirectory_mkdir_Results_List(s *capnp.Segment, sz int32) (RwDirectory_mkdir_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return RwDirectory_mkdir_Results_List{l}, err
+}
+
+func (s RwDirectory_mkdir_Results_List) At(i int) RwDirectory_mkdir_Results {
+	return RwDirectory_mkdir_Results{s.List.Struct(i)}
+}
+
+func (s RwDirectory_mkdir_Results_List) Set(i int, v RwDirectory_mkdir_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwDirectory_mkdir_Results_List) String() string {
+	str, _ := text.MarshalList(0xb7774b1c65f804fa, s.List)
+	return str
+}
+
+// RwDirectory_mkdir_Results_Future is a wrapper for a RwDirectory_mkdir_Results promised by a client call.
+type RwDirectory_mkdir_Results_Future struct{ *capnp.Future }
+
+func (p RwDirectory_mkdir_Results_Future) Struct() (RwDirectory_mkdir_Results, error) {
+	s, err := p.Future.Struct()
+	return RwDirectory_mkdir_Results{s}, err
+}
+
+func (p RwDirectory_mkdir_Results_Future) Dir() RwDirectory {
+	return RwDirectory{Client: p.Future.Field(0, nil).Client()}
+}
+
+type RwDirectory_delete_Params struct{ capnp.Struct }
+
+// RwDirectory_delete_Params_TypeID is the unique identifier for the type RwDirectory_delete_Params.
+const RwDirectory_delete_Params_TypeID = 0xcf03bca4fb87f453
+
+func NewRwDirectory_delete_Params(s *capnp.Segment) (RwDirectory_delete_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_delete_Params{st}, err
+}
+
+func NewRootRwDirectory_delete_Params(s *capnp.Segment) (RwDirectory_delete_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_delete_Params{st}, err
+}
+
+func ReadRootRwDirectory_delete_Params(msg *capnp.Message) (RwDirectory_delete_Params, error) {
+	root, err := msg.Root()
+	return RwDirectory_delete_Params{root.Struct()}, err
+}
+
+func (s RwDirectory_delete_Params) String() string {
+	str, _ := text.Marshal(0xcf03bca4fb87f453, s.Struct)
+	return str
+}
+
+func (s RwDirectory_delete_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s RwDirectory_delete_Params) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwDirectory_delete_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s RwDirectory_delete_Params) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+// RwDirectory_delete_Params_List is a list of RwDirectory_delete_Params.
+type RwDirectory_delete_Params_List struct{ capnp.List }
+
+// NewRwDirectory_delete_Params creates a new list of RwDirectory_delete_Params.
+func NewRwDirectory_delete_Params_List(s *capnp.Segment, sz int32) (RwDirectory_delete_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return RwDirectory_delete_Params_List{l}, err
+}
+
+func (s RwDirectory_delete_Params_List) At(i int) RwDirectory_delete_Params {
+	return RwDirectory_delete_Params{s.List.Struct(i)}
+}
+
+func (s RwDirectory_delete_Params_List) Set(i int, v RwDirectory_delete_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwDirectory_delete_Params_List) String() string {
+	str, _ := text.MarshalList(0xcf03bca4fb87f453, s.List)
+	return str
+}
+
+// RwDirectory_delete_Params_Future is a wrapper for a RwDirectory_delete_Params promised by a client call.
+type RwDirectory_delete_Params_Future struct{ *capnp.Future }
+
+func (p RwDirectory_delete_Params_Future) Struct() (RwDirectory_delete_Params, error) {
+	s, err := p.Future.Struct()
+	return RwDirectory_delete_Params{s}, err
+}
+
+type RwDirectory_delete_Results struct{ capnp.Struct }
+
+// RwDirectory_delete_Results_TypeID is the unique identifier for the type RwDirectory_delete_Results.
+const RwDirectory_delete_Results_TypeID = 0xff0de3e62887d2f0
+
+func NewRwDirectory_delete_Results(s *capnp.Segment) (RwDirectory_delete_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwDirectory_delete_Results{st}, err
+}
+
+func NewRootRwDirectory_delete_Results(s *capnp.Segment) (RwDirectory_delete_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwDirectory_delete_Results{st}, err
+}
+
+func ReadRootRwDirectory_delete_Results(msg *capnp.Message) (RwDirectory_delete_Results, error) {
+	root, err := msg.Root()
+	return RwDirectory_delete_Results{root.Struct()}, err
+}
+
+func (s RwDirectory_delete_Results) String() string {
+	str, _ := text.Marshal(0xff0de3e62887d2f0, s.Struct)
+	return str
+}
+
+// RwDirectory_delete_Results_List is a list of RwDirectory_delete_Results.
+type RwDirectory_delete_Results_List struct{ capnp.List }
+
+// NewRwDirectory_delete_Results creates a new list of RwDirectory_delete_Results.
+func NewRwDirectory_delete_Results_List(s *capnp.Segment, sz int32) (RwDirectory_delete_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return RwDirectory_delete_Results_List{l}, err
+}
+
+func (s RwDirectory_delete_Results_List) At(i int) RwDirectory_delete_Results {
+	return RwDirectory_delete_Results{s.List.Struct(i)}
+}
+
+func (s RwDirectory_delete_Results_List) Set(i int, v RwDirectory_delete_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwDirectory_delete_Results_List) String() string {
+	str, _ := text.MarshalList(0xff0de3e62887d2f0, s.List)
+	return str
+}
+
+// RwDirectory_delete_Results_Future is a wrapper for a RwDirectory_delete_Results promised by a client call.
+type RwDirectory_delete_Results_Future struct{ *capnp.Future }
+
+func (p RwDirectory_delete_Results_Future) Struct() (RwDirectory_delete_Results, error) {
+	s, err := p.Future.Struct()
+	return RwDirectory_delete_Results{s}, err
+}
+
+type RwDirectory_snapshot_Params struct{ capnp.Struct }
+
+// RwDirectory_snapshot_Params_TypeID is the unique identifier for the type RwDirectory_snapshot_Params.
+const RwDirectory_snapshot_Params_TypeID = 0x8e319179feb2732a
+
+func NewRwDirectory_snapshot_Params(s *capnp.Segment) (RwDirectory_snapshot_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwDirectory_snapshot_Params{st}, err
+}
+
+func NewRootRwDirectory_snapshot_Params(s *capnp.Segment) (RwDirectory_snapshot_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwDirectory_snapshot_Params{st}, err
+}
+
+func ReadRootRwDirectory_snapshot_Params(msg *capnp.Message) (RwDirectory_snapshot_Params, error) {
+	root, err := msg.Root()
+	return RwDirectory_snapshot_Params{root.Struct()}, err
+}
+
+func (s RwDirectory_snapshot_Params) String() string {
+	str, _ := text.Marshal(0x8e319179feb2732a, s.Struct)
+	return str
+}
+
+// RwDirectory_snapshot_Params_List is a list of RwDirectory_snapshot_Params.
+type RwDirectory_snapshot_Params_List struct{ capnp.List }
+
+// NewRwDirectory_snapshot_Params creates a new list of RwDirectory_snapshot_Params.
+func NewRwDirectory_snapshot_Params_List(s *capnp.Segment, sz int32) (RwDirectory_snapshot_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return RwDirectory_snapshot_Params_List{l}, err
+}
+
+func (s RwDirectory_snapshot_Params_List) At(i int) RwDirectory_snapshot_Params {
+	return RwDirectory_snapshot_Params{s.List.Struct(i)}
+}
+
+func (s RwDirectory_snapshot_Params_List) Set(i int, v RwDirectory_snapshot_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwDirectory_snapshot_Params_List) String() string {
+	str, _ := text.MarshalList(0x8e319179feb2732a, s.List)
+	return str
+}
+
+// RwDirectory_snapshot_Params_Future is a wrapper for a RwDirectory_snapshot_Params promised by a client call.
+type RwDirectory_snapshot_Params_Future struct{ *capnp.Future }
+
+func (p RwDirectory_snapshot_Params_Future) Struct() (RwDirectory_snapshot_Params, error) {
+	s, err := p.Future.Struct()
+	return RwDirectory_snapshot_Params{s}, err
+}
+
+type RwDirectory_snapshot_Results struct{ capnp.Struct }
+
+// RwDirectory_snapshot_Results_TypeID is the unique identifier for the type RwDirectory_snapshot_Results.
+const RwDirectory_snapshot_Results_TypeID = 0xb895ed6dff9340d4
+
+func NewRwDirectory_snapshot_Results(s *capnp.Segment) (RwDirectory_snapshot_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_snapshot_Results{st}, err
+}
+
+func NewRootRwDirectory_snapshot_Results(s *capnp.Segment) (RwDirectory_snapshot_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_snapshot_Results{st}, err
+}
+
+func ReadRootRwDirectory_snapshot_Results(msg *capnp.Message) (RwDirectory_snapshot_Results, error) {
+	root, err := msg.Root()
+	return RwDirectory_snapshot_Results{root.Struct()}, err
+}
+
+func (s RwDirectory_snapshot_Results) String() string {
+	str, _ := text.Marshal(0xb895ed6dff9340d4, s.Struct)
+	return str
+}
+
+func (s RwDirectory_snapshot_Results) Snapshot() (Directory_Snapshot, error) {
+	p, err := s.Struct.Ptr(0)
+	return Directory_Snapshot{Struct: p.Struct()}, err
+}
+
+func (s RwDirectory_snapshot_Results) HasSnapshot() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwDirectory_snapshot_Results) SetSnapshot(v Directory_Snapshot) error {
+	return s.Struct.SetPtr(0, v.Struct.ToPtr())
+}
+
+// NewSnapshot sets the snapshot field to a newly
+// allocated Directory_Snapshot struct, preferring placement in s's segment.
+func (s RwDirectory_snapshot_Results) NewSnapshot() (Directory_Snapshot, error) {
+	ss, err := NewDirectory_Snapshot(s.Struct.Segment())
+	if err != nil {
+		return Directory_Snapshot{}, err
+	}
+	err = s.Struct.SetPtr(0, ss.Struct.ToPtr())
+	return ss, err
+}
+
+// RwDirectory_snapshot_Results_List is a list of RwDirectory_snapshot_Results.
+type RwDirectory_snapshot_Results_List struct{ capnp.List }
+
+// NewRwDirectory_snapshot_Results creates a new list of RwDirectory_snapshot_Results.
+func NewRwDirectory_snapshot_Results_List(s *capnp.Segment, sz int32) (RwDirectory_snapshot_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return RwDirectory_snapshot_Results_List{l}, err
+}
+
+func (s RwDirectory_snapshot_Results_List) At(i int) RwDirectory_snapshot_Results {
+	return RwDirectory_snapshot_Results{s.List.Struct(i)}
+}
+
+func (s RwDirectory_snapshot_Results_List) Set(i int, v RwDirectory_snapshot_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwDirectory_snapshot_Results_List) String() string {
+	str, _ := text.MarshalList(0xb895ed6dff9340d4, s.List)
+	return str
+}
+
+// RwDirectory_snapshot_Results_Future is a wrapper for a RwDirectory_snapshot_Results promised by a client call.
+type RwDirectory_snapshot_Results_Future struct{ *capnp.Future }
+
+func (p RwDirectory_snapshot_Results_Future) Struct() (RwDirectory_snapshot_Results, error) {
+	s, err := p.Future.Struct()
+	return RwDirectory_snapshot_Results{s}, err
+}
+
+func (p RwDirectory_snapshot_Results_Future) Snapshot() Directory_Snapshot_Future {
+	return Directory_Snapshot_Future{Future: p.Future.Field(0, nil)}
+}
+
+type RwDirectory_trash_Params struct{ capnp.Struct }
+
+// RwDirectory_trash_Params_TypeID is the unique identifier for the type RwDirectory_trash_Params.
+const RwDirectory_trash_Params_TypeID = 0xb1d26305e90c7b3c
+
+func NewRwDirectory_trash_Params(s *capnp.Segment) (RwDirectory_trash_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwDirectory_trash_Params{st}, err
+}
+
+func NewRootRwDirectory_trash_Params(s *capnp.Segment) (RwDirectory_trash_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwDirectory_trash_Params{st}, err
+}
+
+func ReadRootRwDirectory_trash_Params(msg *capnp.Message) (RwDirectory_trash_Params, error) {
+	root, err := msg.Root()
+	return RwDirectory_trash_Params{root.Struct()}, err
+}
+
+func (s RwDirectory_trash_Params) String() string {
+	str, _ := text.Marshal(0xb1d26305e90c7b3c, s.Struct)
+	return str
+}
+
+// RwDirectory_trash_Params_List is a list of RwDirectory_trash_Params.
+type RwDirectory_trash_Params_List struct{ capnp.List }
+
+// NewRwDirectory_trash_Params creates a new list of RwDirectory_trash_Params.
+func NewRwDirectory_trash_Params_List(s *capnp.Segment, sz int32) (RwDirectory_trash_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return RwDirectory_trash_Params_List{l}, err
+}
+
+func (s RwDirectory_trash_Params_List) At(i int) RwDirectory_trash_Params {
+	return RwDirectory_trash_Params{s.List.Struct(i)}
+}
+
+func (s RwDirectory_trash_Params_List) Set(i int, v RwDirectory_trash_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwDirectory_trash_Params_List) String() string {
+	str, _ := text.MarshalList(0xb1d26305e90c7b3c, s.List)
+	return str
+}
+
+// RwDirectory_trash_Params_Future is a wrapper for a RwDirectory_trash_Params promised by a client call.
+type RwDirectory_trash_Params_Future struct{ *capnp.Future }
+
+func (p RwDirectory_trash_Params_Future) Struct() (RwDirectory_trash_Params, error) {
+	s, err := p.Future.Struct()
+	return RwDirectory_trash_Params{s}, err
+}
+
+type RwDirectory_trash_Results struct{ capnp.Struct }
+
+// RwDirectory_trash_Results_TypeID is the unique identifier for the type RwDirectory_trash_Results.
+const RwDirectory_trash_Results_TypeID = 0x9c7e26b2a8ba8db8
+
+func NewRwDirectory_trash_Results(s *capnp.Segment) (RwDirectory_trash_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_trash_Results{st}, err
+}
+
+func NewRootRwDirectory_trash_Results(s *capnp.Segment) (RwDirectory_trash_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwDirectory_trash_Results{st}, err
+}
+
+func ReadRootRwDirectory_trash_Results(msg *capnp.Message) (RwDirectory_trash_Results, error) {
+	root, err := msg.Root()
+	return RwDirectory_trash_Results{root.Struct()}, err
+}
+
+func (s RwDirectory_trash_Results) String() string {
+	str, _ := text.Marshal(0x9c7e26b2a8ba8db8, s.Struct)
+	return str
+}
+
+func (s RwDirectory_trash_Results) Trash() Trash {
+	p, _ := s.Struct.Ptr(0)
+	return Trash{Client: p.Interface().Client()}
+}
+
+func (s RwDirectory_trash_Results) HasTrash() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwDirectory_trash_Results) SetTrash(v Trash) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
+}
+
+// RwDirectory_trash_Results_List is a list of RwDirectory_trash_Results.
+type RwDirectory_trash_Results_List struct{ capnp.List }
+
+// NewRwDirectory_trash_Results creates a new list of RwDirectory_trash_Results.
+func NewRwDirectory_trash_Results_List(s *capnp.Segment, sz int32) (RwDirectory_trash_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return RwDirectory_trash_Results_List{l}, err
+}
+
+func (s RwDirectory_trash_Results_List) At(i int) RwDirectory_trash_Results {
+	return RwDirectory_trash_Results{s.List.Struct(i)}
+}
+
+func (s RwDirectory_trash_Results_List) Set(i int, v RwDirectory_trash_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwDirectory_trash_Results_List) String() string {
+	str, _ := text.MarshalList(0x9c7e26b2a8ba8db8, s.List)
+	return str
+}
+
+// RwDirectory_trash_Results_Future is a wrapper for a RwDirectory_trash_Results promised by a client call.
+type RwDirectory_trash_Results_Future struct{ *capnp.Future }
+
+func (p RwDirectory_trash_Results_Future) Struct() (RwDirectory_trash_Results, error) {
+	s, err := p.Future.Struct()
+	return RwDirectory_trash_Results{s}, err
+}
+
+func (p RwDirectory_trash_Results_Future) Trash() Trash {
+	return Trash{Client: p.Future.Field(0, nil).Client()}
+}
+
+type AppendOnlyDirectory struct{ Client *capnp.Client }
+
+// AppendOnlyDirectory_TypeID is the unique identifier for the type AppendOnlyDirectory.
+const AppendOnlyDirectory_TypeID = 0xfbc0e16373431743
+
+func (c AppendOnlyDirectory) Create(ctx context.Context, params func(AppendOnlyDirectory_create_Params) error) (AppendOnlyDirectory_create_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xfbc0e16373431743,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:AppendOnlyDirectory",
+			MethodName:    "create",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(AppendOnlyDirectory_create_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return AppendOnlyDirectory_create_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyDirectory) Mkdir(ctx context.Context, params func(AppendOnlyDirectory_mkdir_Params) error) (AppendOnlyDirectory_mkdir_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xfbc0e16373431743,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:AppendOnlyDirectory",
+			MethodName:    "mkdir",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(AppendOnlyDirectory_mkdir_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return AppendOnlyDirectory_mkdir_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyDirectory) List(ctx context.Context, params func(Directory_list_Params) error) (Directory_list_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "list",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_list_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_list_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyDirectory) Walk(ctx context.Context, params func(Directory_walk_Params) error) (Directory_walk_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "walk",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_walk_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_walk_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyDirectory) Snapshots(ctx context.Context, params func(Directory_snapshots_Params) error) (Directory_snapshots_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "snapshots",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_snapshots_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_snapshots_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyDirectory) Watch(ctx context.Context, params func(Directory_watch_Params) error) (Directory_watch_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "watch",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_watch_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_watch_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyDirectory) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_stat_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_stat_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyDirectory) GetXattr(ctx context.Context, params func(Node_getXattr_Params) error) (Node_getXattr_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_getXattr_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_getXattr_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyDirectory) ListXattrs(ctx context.Context, params func(Node_listXattrs_Params) error) (Node_listXattrs_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_listXattrs_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_listXattrs_Results_Future{Future: ans.Future()}, release
+}
+
+// A AppendOnlyDirectory_Server is a AppendOnlyDirectory with a local implementation.
+type AppendOnlyDirectory_Server interface {
+	Create(context.Context, AppendOnlyDirectory_create) error
+
+	Mkdir(context.Context, AppendOnlyDirectory_mkdir) error
+
+	List(context.Context, Directory_list) error
+
+	Walk(context.Context, Directory_walk) error
+
+	Snapshots(context.Context, Directory_snapshots) error
+
+	Watch(context.Context, Directory_watch) error
+
+	Stat(context.Context, Node_stat) error
+
+	GetXattr(context.Context, Node_getXattr) error
+
+	ListXattrs(context.Context, Node_listXattrs) error
+}
+
+// AppendOnlyDirectory_NewServer creates a new Server from an implementation of AppendOnlyDirectory_Server.
+func AppendOnlyDirectory_NewServer(s AppendOnlyDirectory_Server, policy *server.Policy) *server.Server {
+	c, _ := s.(server.Shutdowner)
+	return server.New(AppendOnlyDirectory_Methods(nil, s), s, c, policy)
+}
+
+// AppendOnlyDirectory_ServerToClient creates a new Client from an implementation of AppendOnlyDirectory_Server.
+// The caller is responsible for calling Release on the returned Client.
+func AppendOnlyDirectory_ServerToClient(s AppendOnlyDirectory_Server, policy *server.Policy) AppendOnlyDirectory {
+	return AppendOnlyDirectory{Client: capnp.NewClient(AppendOnlyDirectory_NewServer(s, policy))}
+}
+
+// AppendOnlyDirectory_Methods appends Methods to a slice that invoke the methods on s.
+// This can be used to create a more complicated Server.
+func AppendOnlyDirectory_Methods(methods []server.Method, s AppendOnlyDirectory_Server) []server.Method {
+	if cap(methods) == 0 {
+		methods = make([]server.Method, 0, 9)
+	}
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xfbc0e16373431743,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:AppendOnlyDirectory",
+			MethodName:    "create",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Create(ctx, AppendOnlyDirectory_create{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xfbc0e16373431743,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:AppendOnlyDirectory",
+			MethodName:    "mkdir",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Mkdir(ctx, AppendOnlyDirectory_mkdir{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "list",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.List(ctx, Directory_list{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "walk",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Walk(ctx, Directory_walk{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "snapshots",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Snapshots(ctx, Directory_snapshots{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "watch",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Watch(ctx, Directory_watch{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Stat(ctx, Node_stat{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.GetXattr(ctx, Node_getXattr{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.ListXattrs(ctx, Node_listXattrs{call})
+		},
+	})
+
+	return methods
+}
+
+// AppendOnlyDirectory_create holds the state for a server call to AppendOnlyDirectory.create.
+// See server.Call for documentation.
+type AppendOnlyDirectory_create struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c AppendOnlyDirectory_create) Args() AppendOnlyDirectory_create_Params {
+	return AppendOnlyDirectory_create_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c AppendOnlyDirectory_create) AllocResults() (AppendOnlyDirectory_create_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyDirectory_create_Results{Struct: r}, err
+}
+
+// AppendOnlyDirectory_mkdir holds the state for a server call to AppendOnlyDirectory.mkdir.
+// See server.Call for documentation.
+type AppendOnlyDirectory_mkdir struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c AppendOnlyDirectory_mkdir) Args() AppendOnlyDirectory_mkdir_Params {
+	return AppendOnlyDirectory_mkdir_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c AppendOnlyDirectory_mkdir) AllocResults() (AppendOnlyDirectory_mkdir_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyDirectory_mkdir_Results{Struct: r}, err
+}
+
+type AppendOnlyDirectory_create_Params struct{ capnp.Struct }
+
+// AppendOnlyDirectory_create_Params_TypeID is the unique identifier for the type AppendOnlyDirectory_create_Params.
+const AppendOnlyDirectory_create_Params_TypeID = 0x953e497f9e8d1c96
+
+func NewAppendOnlyDirectory_create_Params(s *capnp.Segment) (AppendOnlyDirectory_create_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return AppendOnlyDirectory_create_Params{st}, err
+}
+
+func NewRootAppendOnlyDirectory_create_Params(s *capnp.Segment) (AppendOnlyDirectory_create_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return AppendOnlyDirectory_create_Params{st}, err
+}
+
+func ReadRootAppendOnlyDirectory_create_Params(msg *capnp.Message) (AppendOnlyDirectory_create_Params, error) {
+	root, err := msg.Root()
+	return AppendOnlyDirectory_create_Params{root.Struct()}, err
+}
+
+func (s AppendOnlyDirectory_create_Params) String() string {
+	str, _ := text.Marshal(0x953e497f9e8d1c96, s.Struct)
+	return str
+}
+
+func (s AppendOnlyDirectory_create_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s AppendOnlyDirectory_create_Params) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s AppendOnlyDirectory_create_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s AppendOnlyDirectory_create_Params) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+func (s AppendOnlyDirectory_create_Params) Executable() bool {
+	return s.Struct.Bit(0)
+}
+
+func (s AppendOnlyDirectory_create_Params) SetExecutable(v bool) {
+	s.Struct.SetBit(0, v)
+}
+
+// AppendOnlyDirectory_create_Params_List is a list of AppendOnlyDirectory_create_Params.
+type AppendOnlyDirectory_create_Params_List struct{ capnp.List }
+
+// NewAppendOnlyDirectory_create_Params creates a new list of AppendOnlyDirectory_create_Params.
+func NewAppendOnlyDirectory_create_Params_List(s *capnp.Segment, sz int32) (AppendOnlyDirectory_create_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1}, sz)
+	return AppendOnlyDirectory_create_Params_List{l}, err
+}
+
+func (s AppendOnlyDirectory_create_Params_List) At(i int) AppendOnlyDirectory_create_Params {
+	return AppendOnlyDirectory_create_Params{s.List.Struct(i)}
+}
+
+func (s AppendOnlyDirectory_create_Params_List) Set(i int, v AppendOnlyDirectory_create_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s AppendOnlyDirectory_create_Params_List) String() string {
+	str, _ := text.MarshalList(0x953e497f9e8d1c96, s.List)
+	return str
+}
+
+// AppendOnlyDirectory_create_Params_Future is a wrapper for a AppendOnlyDirectory_create_Params promised by a client call.
+type AppendOnlyDirectory_create_Params_Future struct{ *capnp.Future }
+
+func (p AppendOnlyDirectory_create_Params_Future) Struct() (AppendOnlyDirectory_create_Params, error) {
+	s, err := p.Future.Struct()
+	return AppendOnlyDirectory_create_Params{s}, err
+}
+
+type AppendOnlyDirectory_create_Results struct{ capnp.Struct }
+
+// AppendOnlyDirectory_create_Results_TypeID is the unique identifier for the type AppendOnlyDirectory_create_Results.
+const AppendOnlyDirectory_create_Results_TypeID = 0xcd67c9a8e026dcaf
+
+func NewAppendOnlyDirectory_create_Results(s *capnp.Segment) (AppendOnlyDirectory_create_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyDirectory_create_Results{st}, err
+}
+
+func NewRootAppendOnlyDirectory_create_Results(s *capnp.Segment) (AppendOnlyDirectory_create_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyDirectory_create_Results{st}, err
+}
+
+func ReadRootAppendOnlyDirectory_create_Results(msg *capnp.Message) (AppendOnlyDirectory_create_Results, error) {
+	root, err := msg.Root()
+	return AppendOnlyDirectory_create_Results{root.Struct()}, err
+}
+
+func (s AppendOnlyDirectory_create_Results) String() string {
+	str, _ := text.Marshal(0xcd67c9a8e026dcaf, s.Struct)
+	return str
+}
+
+func (s AppendOnlyDirectory_create_Results) File() AppendOnlyFile {
+	p, _ := s.Struct.Ptr(0)
+	return AppendOnlyFile{Client: p.Interface().Client()}
+}
+
+func (s AppendOnlyDirectory_create_Results) HasFile() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s AppendOnlyDirectory_create_Results) SetFile(v AppendOnlyFile) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
+}
+
+// AppendOnlyDirectory_create_Results_List is a list of AppendOnlyDirectory_create_Results.
+type AppendOnlyDirectory_create_Results_List struct{ capnp.List }
+
+// NewAppendOnlyDirectory_create_Results creates a new list of AppendOnlyDirectory_create_Results.
+func NewAppendOnlyDirectory_create_Results_List(s *capnp.Segment, sz int32) (AppendOnlyDirectory_create_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return AppendOnlyDirectory_create_Results_List{l}, err
+}
+
+func (s AppendOnlyDirectory_create_Results_List) At(i int) AppendOnlyDirectory_create_Results {
+	return AppendOnlyDirectory_create_Results{s.List.Struct(i)}
+}
+
+func (s AppendOnlyDirectory_create_Results_List) Set(i int, v AppendOnlyDirectory_create_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s AppendOnlyDirectory_create_Results_List) String() string {
+	str, _ := text.MarshalList(0xcd67c9a8e026dcaf, s.List)
+	return str
+}
+
+// AppendOnlyDirectory_create_Results_Future is a wrapper for a AppendOnlyDirectory_create_Results promised by a client call.
+type AppendOnlyDirectory_create_Results_Future struct{ *capnp.Future }
+
+func (p AppendOnlyDirectory_create_Results_Future) Struct() (AppendOnlyDirectory_create_Results, error) {
+	s, err := p.Future.Struct()
+	return AppendOnlyDirectory_create_Results{s}, err
+}
+
+func (p AppendOnlyDirectory_create_Results_Future) File() AppendOnlyFile {
+	return AppendOnlyFile{Client: p.Future.Field(0, nil).Client()}
+}
+
+type AppendOnlyDirectory_mkdir_Params struct{ capnp.Struct }
+
+// AppendOnlyDirectory_mkdir_Params_TypeID is the unique identifier for the type AppendOnlyDirectory_mkdir_Params.
+const AppendOnlyDirectory_mkdir_Params_TypeID = 0xf8e7bf83253be0c3
+
+func NewAppendOnlyDirectory_mkdir_Params(s *capnp.Segment) (AppendOnlyDirectory_mkdir_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyDirectory_mkdir_Params{st}, err
+}
+
+func NewRootAppendOnlyDirectory_mkdir_Params(s *capnp.Segment) (AppendOnlyDirectory_mkdir_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyDirectory_mkdir_Params{st}, err
+}
+
+func ReadRootAppendOnlyDirectory_mkdir_Params(msg *capnp.Message) (AppendOnlyDirectory_mkdir_Params, error) {
+	root, err := msg.Root()
+	return AppendOnlyDirectory_mkdir_Params{root.Struct()}, err
+}
+
+func (s AppendOnlyDirectory_mkdir_Params) String() string {
+	str, _ := text.Marshal(0xf8e7bf83253be0c3, s.Struct)
+	return str
+}
+
+func (s AppendOnlyDirectory_mkdir_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s AppendOnlyDirectory_mkdir_Params) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s AppendOnlyDirectory_mkdir_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s AppendOnlyDirectory_mkdir_Params) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+// AppendOnlyDirectory_mkdir_Params_List is a list of AppendOnlyDirectory_mkdir_Params.
+type AppendOnlyDirectory_mkdir_Params_List struct{ capnp.List }
+
+// NewAppendOnlyDirectory_mkdir_Params creates a new list of AppendOnlyDirectory_mkdir_Params.
+func NewAppendOnlyDirectory_mkdir_Params_List(s *capnp.Segment, sz int32) (AppendOnlyDirectory_mkdir_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return AppendOnlyDirectory_mkdir_Params_List{l}, err
+}
+
+func (s AppendOnlyDirectory_mkdir_Params_List) At(i int) AppendOnlyDirectory_mkdir_Params {
+	return AppendOnlyDirectory_mkdir_Params{s.List.Struct(i)}
+}
+
+func (s AppendOnlyDirectory_mkdir_Params_List) Set(i int, v AppendOnlyDirectory_mkdir_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s AppendOnlyDirectory_mkdir_Params_List) String() string {
+	str, _ := text.MarshalList(0xf8e7bf83253be0c3, s.List)
+	return str
+}
+
+// AppendOnlyDirectory_mkdir_Params_Future is a wrapper for a AppendOnlyDirectory_mkdir_Params promised by a client call.
+type AppendOnlyDirectory_mkdir_Params_Future struct{ *capnp.Future }
+
+func (p AppendOnlyDirectory_mkdir_Params_Future) Struct() (AppendOnlyDirectory_mkdir_Params, error) {
+	s, err := p.Future.Struct()
+	return AppendOnlyDirectory_mkdir_Params{s}, err
+}
+
+type AppendOnlyDirectory_mkdir_Results struct{ capnp.Struct }
+
+// AppendOnlyDirectory_mkdir_Results_TypeID is the unique identifier for the type AppendOnlyDirectory_mkdir_Results.
+const AppendOnlyDirectory_mkdir_Results_TypeID = 0x96a729f2425a033c
+
+func NewAppendOnlyDirectory_mkdir_Results(s *capnp.Segment) (AppendOnlyDirectory_mkdir_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyDirectory_mkdir_Results{st}, err
+}
+
+func NewRootAppendOnlyDirectory_mkdir_Results(s *capnp.Segment) (AppendOnlyDirectory_mkdir_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyDirectory_mkdir_Results{st}, err
+}
+
+func ReadRootAppendOnlyDirectory_mkdir_Results(msg *capnp.Message) (AppendOnlyDirectory_mkdir_Results, error) {
+	root, err := msg.Root()
+	return AppendOnlyDirectory_mkdir_Results{root.Struct()}, err
+}
+
+func (s AppendOnlyDirectory_mkdir_Results) String() string {
+	str, _ := text.Marshal(0x96a729f2425a033c, s.Struct)
+	return str
+}
+
+func (s AppendOnlyDirectory_mkdir_Results) Dir() AppendOnlyDirectory {
+	p, _ := s.Struct.Ptr(0)
+	return AppendOnlyDirectory{Client: p.Interface().Client()}
+}
+
+func (s AppendOnlyDirectory_mkdir_Results) HasDir() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s AppendOnlyDirectory_mkdir_Results) SetDir(v AppendOnlyDirectory) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
+}
+
+// AppendOnlyDirectory_mkdir_Results_List is a list of AppendOnlyDirectory_mkdir_Results.
+type AppendOnlyDirectory_mkdir_Results_List struct{ capnp.List }
+
+// NewAppendOnlyDirectory_mkdir_Results creates a new list of AppendOnlyDirectory_mkdir_Results.
+func NewAppendOnlyDirectory_mkdir_Results_List(s *capnp.Segment, sz int32) (AppendOnlyDirectory_mkdir_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return AppendOnlyDirectory_mkdir_Results_List{l}, err
+}
+
+func (s AppendOnlyDirectory_mkdir_Results_List) At(i int) AppendOnlyDirectory_mkdir_Results {
+	return AppendOnlyDirectory_mkdir_Results{s.List.Struct(i)}
+}
+
+func (s AppendOnlyDirectory_mkdir_Results_List) Set(i int, v AppendOnlyDirectory_mkdir_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s AppendOnlyDirectory_mkdir_Results_List) String() string {
+	str, _ := text.MarshalList(0x96a729f2425a033c, s.List)
+	return str
+}
+
+// AppendOnlyDirectory_mkdir_Results_Future is a wrapper for a AppendOnlyDirectory_mkdir_Results promised by a client call.
+type AppendOnlyDirectory_mkdir_Results_Future struct{ *capnp.Future }
+
+func (p AppendOnlyDirectory_mkdir_Results_Future) Struct() (AppendOnlyDirectory_mkdir_Results, error) {
+	s, err := p.Future.Struct()
+	return AppendOnlyDirectory_mkdir_Results{s}, err
+}
+
+func (p AppendOnlyDirectory_mkdir_Results_Future) Dir() AppendOnlyDirectory {
+	return AppendOnlyDirectory{Client: p.Future.Field(0, nil).Client()}
+}
+
+type Trash struct{ Client *capnp.Client }
+
+// Trash_TypeID is the unique identifier for the type Trash.
+const Trash_TypeID = 0xa071adb8bc0e1e2f
+
+func (c Trash) Items(ctx context.Context, params func(Trash_items_Params) error) (Trash_items_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xa071adb8bc0e1e2f,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Trash",
+			MethodName:    "items",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Trash_items_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Trash_items_Results_Future{Future: ans.Future()}, release
+}
+func (c Trash) Restore(ctx context.Context, params func(Trash_restore_Params) error) (Trash_restore_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xa071adb8bc0e1e2f,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Trash",
+			MethodName:    "restore",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Trash_restore_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Trash_restore_Results_Future{Future: ans.Future()}, release
+}
+func (c Trash) Purge(ctx context.Context, params func(Trash_purge_Params) error) (Trash_purge_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xa071adb8bc0e1e2f,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Trash",
+			MethodName:    "purge",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Trash_purge_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Trash_purge_Results_Future{Future: ans.Future()}, release
+}
+func (c Trash) List(ctx context.Context, params func(Directory_list_Params) error) (Directory_list_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "list",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_list_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_list_Results_Future{Future: ans.Future()}, release
+}
+func (c Trash) Walk(ctx context.Context, params func(Directory_walk_Params) error) (Directory_walk_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "walk",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_walk_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_walk_Results_Future{Future: ans.Future()}, release
+}
+func (c Trash) Snapshots(ctx context.Context, params func(Directory_snapshots_Params) error) (Directory_snapshots_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "snapshots",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_snapshots_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_snapshots_Results_Future{Future: ans.Future()}, release
+}
+func (c Trash) Watch(ctx context.Context, params func(Directory_watch_Params) error) (Directory_watch_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "watch",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Directory_watch_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Directory_watch_Results_Future{Future: ans.Future()}, release
+}
+func (c Trash) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_stat_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_stat_Results_Future{Future: ans.Future()}, release
+}
+func (c Trash) GetXattr(ctx context.Context, params func(Node_getXattr_Params) error) (Node_getXattr_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_getXattr_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_getXattr_Results_Future{Future: ans.Future()}, release
+}
+func (c Trash) ListXattrs(ctx context.Context, params func(Node_listXattrs_Params) error) (Node_listXattrs_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_listXattrs_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_listXattrs_Results_Future{Future: ans.Future()}, release
+}
+
+// A Trash_Server is a Trash with a local implementation.
+type Trash_Server interface {
+	Items(context.Context, Trash_items) error
+
+	Restore(context.Context, Trash_restore) error
+
+	Purge(context.Context, Trash_purge) error
+
+	List(context.Context, Directory_list) error
+
+	Walk(context.Context, Directory_walk) error
+
+	Snapshots(context.Context, Directory_snapshots) error
+
+	Watch(context.Context, Directory_watch) error
+
+	Stat(context.Context, Node_stat) error
+
+	GetXattr(context.Context, Node_getXattr) error
+
+	ListXattrs(context.Context, Node_listXattrs) error
+}
+
+// Trash_NewServer creates a new Server from an implementation of Trash_Server.
+func Trash_NewServer(s Trash_Server, policy *server.Policy) *server.Server {
+	c, _ := s.(server.Shutdowner)
+	return server.New(Trash_Methods(nil, s), s, c, policy)
+}
+
+// Trash_ServerToClient creates a new Client from an implementation of Trash_Server.
+// The caller is responsible for calling Release on the returned Client.
+func Trash_ServerToClient(s Trash_Server, policy *server.Policy) Trash {
+	return Trash{Client: capnp.NewClient(Trash_NewServer(s, policy))}
+}
+
+// Trash_Methods appends Methods to a slice that invoke the methods on s.
+// This can be used to create a more complicated Server.
+func Trash_Methods(methods []server.Method, s Trash_Server) []server.Method {
+	if cap(methods) == 0 {
+		methods = make([]server.Method, 0, 10)
+	}
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xa071adb8bc0e1e2f,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Trash",
+			MethodName:    "items",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Items(ctx, Trash_items{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xa071adb8bc0e1e2f,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Trash",
+			MethodName:    "restore",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Restore(ctx, Trash_restore{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xa071adb8bc0e1e2f,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Trash",
+			MethodName:    "purge",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Purge(ctx, Trash_purge{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "list",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.List(ctx, Directory_list{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "walk",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Walk(ctx, Directory_walk{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "snapshots",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Snapshots(ctx, Directory_snapshots{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xce3039544779e0fc,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:Directory",
+			MethodName:    "watch",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Watch(ctx, Directory_watch{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Stat(ctx, Node_stat{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.GetXattr(ctx, Node_getXattr{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.ListXattrs(ctx, Node_listXattrs{call})
+		},
+	})
+
+	return methods
+}
+
+// Trash_items holds the state for a server call to Trash.items.
+// See server.Call for documentation.
+type Trash_items struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c Trash_items) Args() Trash_items_Params {
+	return Trash_items_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c Trash_items) AllocResults() (Trash_items_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Trash_items_Results{Struct: r}, err
+}
+
+// Trash_restore holds the state for a server call to Trash.restore.
+// See server.Call for documentation.
+type Trash_restore struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c Trash_restore) Args() Trash_restore_Params {
+	return Trash_restore_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c Trash_restore) AllocResults() (Trash_restore_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Trash_restore_Results{Struct: r}, err
+}
+
+// Trash_purge holds the state for a server call to Trash.purge.
+// See server.Call for documentation.
+type Trash_purge struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c Trash_purge) Args() Trash_purge_Params {
+	return Trash_purge_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c Trash_purge) AllocResults() (Trash_purge_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Trash_purge_Results{Struct: r}, err
+}
+
+type Trash_Item struct{ capnp.Struct }
+
+// Trash_Item_TypeID is the unique identifier for the type Trash_Item.
+const Trash_Item_TypeID = 0xa89ab0b09cb12102
+
+func NewTrash_Item(s *capnp.Segment) (Trash_Item, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 2})
+	return Trash_Item{st}, err
+}
+
+func NewRootTrash_Item(s *capnp.Segment) (Trash_Item, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 2})
+	return Trash_Item{st}, err
+}
+
+func ReadRootTrash_Item(msg *capnp.Message) (Trash_Item, error) {
+	root, err := msg.Root()
+	return Trash_Item{root.Struct()}, err
+}
+
+func (s Trash_Item) String() string {
+	str, _ := text.Marshal(0xa89ab0b09cb12102, s.Struct)
+	return str
+}
+
+func (s Trash_Item) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s Trash_Item) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s Trash_Item) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s Trash_Item) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+func (s Trash_Item) OriginalPath() (string, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.Text(), err
+}
+
+func (s Trash_Item) HasOriginalPath() bool {
+	return s.Struct.HasPtr(1)
+}
+
+func (s Trash_Item) OriginalPathBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.TextBytes(), err
+}
+
+func (s Trash_Item) SetOriginalPath(v string) error {
+	return s.Struct.SetText(1, v)
+}
+
+func (s Trash_Item) DeletedAt() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s Trash_Item) SetDeletedAt(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+// Trash_Item_List is a list of Trash_Item.
+type Trash_Item_List struct{ capnp.List }
+
+// NewTrash_Item creates a new list of Trash_Item.
+func NewTrash_Item_List(s *capnp.Segment, sz int32) (Trash_Item_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 2}, sz)
+	return Trash_Item_List{l}, err
+}
+
+func (s Trash_Item_List) At(i int) Trash_Item { return Trash_Item{s.List.Struct(i)} }
+
+func (s Trash_Item_List) Set(i int, v Trash_Item) error { return s.List.SetStruct(i, v.Struct) }
+
+func (s Trash_Item_List) String() string {
+	str, _ := text.MarshalList(0xa89ab0b09cb12102, s.List)
+	return str
+}
+
+// Trash_Item_Future is a wrapper for a Trash_Item promised by a client call.
+type Trash_Item_Future struct{ *capnp.Future }
+
+func (p Trash_Item_Future) Struct() (Trash_Item, error) {
+	s, err := p.Future.Struct()
+	return Trash_Item{s}, err
+}
+
+type Trash_items_Params struct{ capnp.Struct }
+
+// Trash_items_Params_TypeID is the unique identifier for the type Trash_items_Params.
+const Trash_items_Params_TypeID = 0xa0b0e0e9bb47502e
+
+func NewTrash_items_Params(s *capnp.Segment) (Trash_items_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Trash_items_Params{st}, err
+}
+
+func NewRootTrash_items_Params(s *capnp.Segment) (Trash_items_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Trash_items_Params{st}, err
+}
+
+func ReadRootTrash_items_Params(msg *capnp.Message) (Trash_items_Params, error) {
+	root, err := msg.Root()
+	return Trash_items_Params{root.Struct()}, err
+}
+
+func (s Trash_items_Params) String() string {
+	str, _ := text.Marshal(0xa0b0e0e9bb47502e, s.Struct)
+	return str
+}
+
+// Trash_items_Params_List is a list of Trash_items_Params.
+type Trash_items_Params_List struct{ capnp.List }
+
+// NewTrash_items_Params creates a new list of Trash_items_Params.
+func NewTrash_items_Params_List(s *capnp.Segment, sz int32) (Trash_items_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return Trash_items_Params_List{l}, err
+}
+
+func (s Trash_items_Params_List) At(i int) Trash_items_Params {
+	return Trash_items_Params{s.List.Struct(i)}
+}
+
+func (s Trash_items_Params_List) Set(i int, v Trash_items_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Trash_items_Params_List) String() string {
+	str, _ := text.MarshalList(0xa0b0e0e9bb47502e, s.List)
+	return str
+}
+
+// Trash_items_Params_Future is a wrapper for a Trash_items_Params promised by a client call.
+type Trash_items_Params_Future struct{ *capnp.Future }
+
+func (p Trash_items_Params_Future) Struct() (Trash_items_Params, error) {
+	s, err := p.Future.Struct()
+	return Trash_items_Params{s}, err
+}
+
+type Trash_items_Results struct{ capnp.Struct }
+
+// Trash_items_Results_TypeID is the unique identifier for the type Trash_items_Results.
+const Trash_items_Results_TypeID = 0xf6ce10b7451552a1
+
+func NewTrash_items_Results(s *capnp.Segment) (Trash_items_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Trash_items_Results{st}, err
+}
+
+func NewRootTrash_items_Results(s *capnp.Segment) (Trash_items_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Trash_items_Results{st}, err
+}
+
+func ReadRootTrash_items_Results(msg *capnp.Message) (Trash_items_Results, error) {
+	root, err := msg.Root()
+	return Trash_items_Results{root.Struct()}, err
+}
+
+func (s Trash_items_Results) String() string {
+	str, _ := text.Marshal(0xf6ce10b7451552a1, s.Struct)
+	return str
+}
+
+func (s Trash_items_Results) Items() (Trash_Item_List, error) {
+	p, err := s.Struct.Ptr(0)
+	return Trash_Item_List{List: p.List()}, err
+}
+
+func (s Trash_items_Results) HasItems() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s Trash_items_Results) SetItems(v Trash_Item_List) error {
+	return s.Struct.SetPtr(0, v.List.ToPtr())
+}
+
+// NewItems sets the items field to a newly
+// allocated Trash_Item_List, preferring placement in s's segment.
+func (s Trash_items_Results) NewItems(n int32) (Trash_Item_List, error) {
+	l, err := NewTrash_Item_List(s.Struct.Segment(), n)
+	if err != nil {
+		return Trash_Item_List{}, err
+	}
+	err = s.Struct.SetPtr(0, l.List.ToPtr())
+	return l, err
+}
+
+// Trash_items_Results_List is a list of Trash_items_Results.
+type Trash_items_Results_List struct{ capnp.List }
+
+// NewTrash_items_Results creates a new list of Trash_items_Results.
+func NewTrash_items_Results_List(s *capnp.Segment, sz int32) (Trash_items_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return Trash_items_Results_List{l}, err
+}
+
+func (s Trash_items_Results_List) At(i int) Trash_items_Results {
+	return Trash_items_Results{s.List.Struct(i)}
+}
+
+func (s Trash_items_Results_List) Set(i int, v Trash_items_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Trash_items_Results_List) String() string {
+	str, _ := text.MarshalList(0xf6ce10b7451552a1, s.List)
+	return str
+}
+
+// Trash_items_Results_Future is a wrapper for a Trash_items_Results promised by a client call.
+type Trash_items_Results_Future struct{ *capnp.Future }
+
+func (p Trash_items_Results_Future) Struct() (Trash_items_Results, error) {
+	s, err := p.Future.Struct()
+	return Trash_items_Results{s}, err
+}
+
+type Trash_restore_Params struct{ capnp.Struct }
+
+// Trash_restore_Params_TypeID is the unique identifier for the type Trash_restore_Params.
+const Trash_restore_Params_TypeID = 0x8ff23ce5a79e125c
+
+func NewTrash_restore_Params(s *capnp.Segment) (Trash_restore_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Trash_restore_Params{st}, err
+}
+
+func NewRootTrash_restore_Params(s *capnp.Segment) (Trash_restore_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Trash_restore_Params{st}, err
+}
+
+func ReadRootTrash_restore_Params(msg *capnp.Message) (Trash_restore_Params, error) {
+	root, err := msg.Root()
+	return Trash_restore_Params{root.Struct()}, err
+}
+
+func (s Trash_restore_Params) String() string {
+	str, _ := text.Marshal(0x8ff23ce5a79e125c, s.Struct)
+	return str
+}
+
+func (s Trash_restore_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s Trash_restore_Params) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s Trash_restore_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s Trash_restore_Params) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+// Trash_restore_Params_List is a list of Trash_restore_Params.
+type Trash_restore_Params_List struct{ capnp.List }
+
+// NewTrash_restore_Params creates a new list of Trash_restore_Params.
+func NewTrash_restore_Params_List(s *capnp.Segment, sz int32) (Trash_restore_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return Trash_restore_Params_List{l}, err
+}
+
+func (s Trash_restore_Params_List) At(i int) Trash_restore_Params {
+	return Trash_restore_Params{s.List.Struct(i)}
+}
+
+func (s Trash_restore_Params_List) Set(i int, v Trash_restore_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Trash_restore_Params_List) String() string {
+	str, _ := text.MarshalList(0x8ff23ce5a79e125c, s.List)
+	return str
+}
+
+// Trash_restore_Params_Future is a wrapper for a Trash_restore_Params promised by a client call.
+type Trash_restore_Params_Future struct{ *capnp.Future }
+
+func (p Trash_restore_Params_Future) Struct() (Trash_restore_Params, error) {
+	s, err := p.Future.Struct()
+	return Trash_restore_Params{s}, err
+}
+
+type Trash_restore_Results struct{ capnp.Struct }
+
+// Trash_restore_Results_TypeID is the unique identifier for the type Trash_restore_Results.
+const Trash_restore_Results_TypeID = 0xb97e72d8dc60ecfe
+
+func NewTrash_restore_Results(s *capnp.Segment) (Trash_restore_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Trash_restore_Results{st}, err
+}
+
+func NewRootTrash_restore_Results(s *capnp.Segment) (Trash_restore_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Trash_restore_Results{st}, err
+}
+
+func ReadRootTrash_restore_Results(msg *capnp.Message) (Trash_restore_Results, error) {
+	root, err := msg.Root()
+	return Trash_restore_Results{root.Struct()}, err
+}
+
+func (s Trash_restore_Results) String() string {
+	str, _ := text.Marshal(0xb97e72d8dc60ecfe, s.Struct)
+	return str
+}
+
+// Trash_restore_Results_List is a list of Trash_restore_Results.
+type Trash_restore_Results_List struct{ capnp.List }
+
+// NewTrash_restore_Results creates a new list of Trash_restore_Results.
+func NewTrash_restore_Results_List(s *capnp.Segment, sz int32) (Trash_restore_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return Trash_restore_Results_List{l}, err
+}
+
+func (s Trash_restore_Results_List) At(i int) Trash_restore_Results {
+	return Trash_restore_Results{s.List.Struct(i)}
+}
+
+func (s Trash_restore_Results_List) Set(i int, v Trash_restore_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Trash_restore_Results_List) String() string {
+	str, _ := text.MarshalList(0xb97e72d8dc60ecfe, s.List)
+	return str
+}
+
+// Trash_restore_Results_Future is a wrapper for a Trash_restore_Results promised by a client call.
+type Trash_restore_Results_Future struct{ *capnp.Future }
+
+func (p Trash_restore_Results_Future) Struct() (Trash_restore_Results, error) {
+	s, err := p.Future.Struct()
+	return Trash_restore_Results{s}, err
+}
+
+type Trash_purge_Params struct{ capnp.Struct }
+
+// Trash_purge_Params_TypeID is the unique identifier for the type Trash_purge_Params.
+const Trash_purge_Params_TypeID = 0xb3b29e47263d052c
+
+func NewTrash_purge_Params(s *capnp.Segment) (Trash_purge_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Trash_purge_Params{st}, err
+}
+
+func NewRootTrash_purge_Params(s *capnp.Segment) (Trash_purge_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return Trash_purge_Params{st}, err
+}
+
+func ReadRootTrash_purge_Params(msg *capnp.Message) (Trash_purge_Params, error) {
+	root, err := msg.Root()
+	return Trash_purge_Params{root.Struct()}, err
+}
+
+func (s Trash_purge_Params) String() string {
+	str, _ := text.Marshal(0xb3b29e47263d052c, s.Struct)
+	return str
+}
+
+func (s Trash_purge_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s Trash_purge_Params) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s Trash_purge_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s Trash_purge_Params) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+// Trash_purge_Params_List is a list of Trash_purge_Params.
+type Trash_purge_Params_List struct{ capnp.List }
+
+// NewTrash_purge_Params creates a new list of Trash_purge_Params.
+func NewTrash_purge_Params_List(s *capnp.Segment, sz int32) (Trash_purge_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return Trash_purge_Params_List{l}, err
+}
+
+func (s Trash_purge_Params_List) At(i int) Trash_purge_Params {
+	return Trash_purge_Params{s.List.Struct(i)}
+}
+
+func (s Trash_purge_Params_List) Set(i int, v Trash_purge_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Trash_purge_Params_List) String() string {
+	str, _ := text.MarshalList(0xb3b29e47263d052c, s.List)
+	return str
+}
+
+// Trash_purge_Params_Future is a wrapper for a Trash_purge_Params promised by a client call.
+type Trash_purge_Params_Future struct{ *capnp.Future }
+
+func (p Trash_purge_Params_Future) Struct() (Trash_purge_Params, error) {
+	s, err := p.Future.Struct()
+	return Trash_purge_Params{s}, err
+}
+
+type Trash_purge_Results struct{ capnp.Struct }
+
+// Trash_purge_Results_TypeID is the unique identifier for the type Trash_purge_Results.
+const Trash_purge_Results_TypeID = 0xce6bacf9a59c2e53
+
+func NewTrash_purge_Results(s *capnp.Segment) (Trash_purge_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Trash_purge_Results{st}, err
+}
+
+func NewRootTrash_purge_Results(s *capnp.Segment) (Trash_purge_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return Trash_purge_Results{st}, err
+}
+
+func ReadRootTrash_purge_Results(msg *capnp.Message) (Trash_purge_Results, error) {
+	root, err := msg.Root()
+	return Trash_purge_Results{root.Struct()}, err
+}
+
+func (s Trash_purge_Results) String() string {
+	str, _ := text.Marshal(0xce6bacf9a59c2e53, s.Struct)
+	return str
+}
+
+// Trash_purge_Results_List is a list of Trash_purge_Results.
+type Trash_purge_Results_List struct{ capnp.List }
+
+// NewTrash_purge_Results creates a new list of Trash_purge_Results.
+func NewTrash_purge_Results_List(s *capnp.Segment, sz int32) (Trash_purge_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return Trash_purge_Results_List{l}, err
+}
+
+func (s Trash_purge_Results_List) At(i int) Trash_purge_Results {
+	return Trash_purge_Results{s.List.Struct(i)}
+}
+
+func (s Trash_purge_Results_List) Set(i int, v Trash_purge_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s Trash_purge_Results_List) String() string {
+	str, _ := text.MarshalList(0xce6bacf9a59c2e53, s.List)
+	return str
+}
+
+// Trash_purge_Results_Future is a wrapper for a Trash_purge_Results promised by a client call.
+type Trash_purge_Results_Future struct{ *capnp.Future }
+
+func (p Trash_purge_Results_Future) Struct() (Trash_purge_Results, error) {
+	s, err := p.Future.Struct()
+	return Trash_purge_Results{s}, err
+}
+
+type File struct{ Client *capnp.Client }
+
+// File_TypeID is the unique identifier for the type File.
+const File_TypeID = 0xaa5b133d60884bbd
+
+func (c File) Read(ctx context.Context, params func(File_read_Params) error) (File_read_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "read",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_read_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_read_Results_Future{Future: ans.Future()}, release
+}
+func (c File) ReadBytes(ctx context.Context, params func(File_readBytes_Params) error) (File_readBytes_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "readBytes",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_readBytes_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_readBytes_Results_Future{Future: ans.Future()}, release
+}
+func (c File) Hash(ctx context.Context, params func(File_hash_Params) error) (File_hash_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "hash",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 24, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_hash_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_hash_Results_Future{Future: ans.Future()}, release
+}
+func (c File) SeekData(ctx context.Context, params func(File_seekData_Params) error) (File_seekData_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekData",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_seekData_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_seekData_Results_Future{Future: ans.Future()}, release
+}
+func (c File) SeekHole(ctx context.Context, params func(File_seekHole_Params) error) (File_seekHole_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      4,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekHole",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_seekHole_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_seekHole_Results_Future{Future: ans.Future()}, release
+}
+func (c File) Versions(ctx context.Context, params func(File_versions_Params) error) (File_versions_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      5,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "versions",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_versions_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_versions_Results_Future{Future: ans.Future()}, release
+}
+func (c File) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_stat_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_stat_Results_Future{Future: ans.Future()}, release
+}
+func (c File) GetXattr(ctx context.Context, params func(Node_getXattr_Params) error) (Node_getXattr_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_getXattr_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_getXattr_Results_Future{Future: ans.Future()}, release
+}
+func (c File) ListXattrs(ctx context.Context, params func(Node_listXattrs_Params) error) (Node_listXattrs_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_listXattrs_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_listXattrs_Results_Future{Future: ans.Future()}, release
+}
+
 // A File_Server is a File with a local implementation.
 type File_Server interface {
 	Read(context.Context, File_read) error
 
-	Stat(context.Context, Node_stat) error
+	ReadBytes(context.Context, File_readBytes) error
+
+	Hash(context.Context, File_hash) error
+
+	SeekData(context.Context, File_seekData) error
+
+	SeekHole(context.Context, File_seekHole) error
+
+	Versions(context.Context, File_versions) error
+
+	Stat(context.Context, Node_stat) error
+
+	GetXattr(context.Context, Node_getXattr) error
+
+	ListXattrs(context.Context, Node_listXattrs) error
+}
+
+// File_NewServer creates a new Server from an implementation of File_Server.
+func File_NewServer(s File_Server, policy *server.Policy) *server.Server {
+	c, _ := s.(server.Shutdowner)
+	return server.New(File_Methods(nil, s), s, c, policy)
+}
+
+// File_ServerToClient creates a new Client from an implementation of File_Server.
+// The caller is responsible for calling Release on the returned Client.
+func File_ServerToClient(s File_Server, policy *server.Policy) File {
+	return File{Client: capnp.NewClient(File_NewServer(s, policy))}
+}
+
+// File_Methods appends Methods to a slice that invoke the methods on s.
+// This can be used to create a more complicated Server.
+func File_Methods(methods []server.Method, s File_Server) []server.Method {
+	if cap(methods) == 0 {
+		methods = make([]server.Method, 0, 9)
+	}
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "read",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Read(ctx, File_read{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "readBytes",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.ReadBytes(ctx, File_readBytes{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "hash",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Hash(ctx, File_hash{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekData",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.SeekData(ctx, File_seekData{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      4,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekHole",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.SeekHole(ctx, File_seekHole{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      5,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "versions",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Versions(ctx, File_versions{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Stat(ctx, Node_stat{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.GetXattr(ctx, Node_getXattr{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.ListXattrs(ctx, Node_listXattrs{call})
+		},
+	})
+
+	return methods
+}
+
+// File_read holds the state for a server call to File.read.
+// See server.Call for documentation.
+type File_read struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c File_read) Args() File_read_Params {
+	return File_read_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c File_read) AllocResults() (File_read_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return File_read_Results{Struct: r}, err
+}
+
+// File_readBytes holds the state for a server call to File.readBytes.
+// See server.Call for documentation.
+type File_readBytes struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c File_readBytes) Args() File_readBytes_Params {
+	return File_readBytes_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c File_readBytes) AllocResults() (File_readBytes_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return File_readBytes_Results{Struct: r}, err
+}
+
+// File_hash holds the state for a server call to File.hash.
+// See server.Call for documentation.
+type File_hash struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c File_hash) Args() File_hash_Params {
+	return File_hash_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c File_hash) AllocResults() (File_hash_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return File_hash_Results{Struct: r}, err
+}
+
+// File_seekData holds the state for a server call to File.seekData.
+// See server.Call for documentation.
+type File_seekData struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c File_seekData) Args() File_seekData_Params {
+	return File_seekData_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c File_seekData) AllocResults() (File_seekData_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return File_seekData_Results{Struct: r}, err
+}
+
+// File_seekHole holds the state for a server call to File.seekHole.
+// See server.Call for documentation.
+type File_seekHole struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c File_seekHole) Args() File_seekHole_Params {
+	return File_seekHole_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c File_seekHole) AllocResults() (File_seekHole_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return File_seekHole_Results{Struct: r}, err
+}
+
+// File_versions holds the state for a server call to File.versions.
+// See server.Call for documentation.
+type File_versions struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c File_versions) Args() File_versions_Params {
+	return File_versions_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c File_versions) AllocResults() (File_versions_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return File_versions_Results{Struct: r}, err
+}
+
+type File_HashAlgorithm uint16
+
+// File_HashAlgorithm_TypeID is the unique identifier for the type File_HashAlgorithm.
+const File_HashAlgorithm_TypeID = 0xd41e7872fd164a58
+
+// Values of File_HashAlgorithm.
+const (
+	File_HashAlgorithm_sha256     File_HashAlgorithm = 0
+	File_HashAlgorithm_blake2b256 File_HashAlgorithm = 1
+	File_HashAlgorithm_crc32      File_HashAlgorithm = 2
+)
+
+// String returns the enum's constant name.
+func (c File_HashAlgorithm) String() string {
+	switch c {
+	case File_HashAlgorithm_sha256:
+		return "sha256"
+	case File_HashAlgorithm_blake2b256:
+		return "blake2b256"
+	case File_HashAlgorithm_crc32:
+		return "crc32"
+
+	default:
+		return ""
+	}
+}
+
+// File_HashAlgorithmFromString returns the enum value with a name,
+// or the zero value if there's no such value.
+func File_HashAlgorithmFromString(c string) File_HashAlgorithm {
+	switch c {
+	case "sha256":
+		return File_HashAlgorithm_sha256
+	case "blake2b256":
+		return File_HashAlgorithm_blake2b256
+	case "crc32":
+		return File_HashAlgorithm_crc32
+
+	default:
+		return 0
+	}
+}
+
+type File_HashAlgorithm_List struct{ capnp.List }
+
+func NewFile_HashAlgorithm_List(s *capnp.Segment, sz int32) (File_HashAlgorithm_List, error) {
+	l, err := capnp.NewUInt16List(s, sz)
+	return File_HashAlgorithm_List{l.List}, err
+}
+
+func (l File_HashAlgorithm_List) At(i int) File_HashAlgorithm {
+	ul := capnp.UInt16List{List: l.List}
+	return File_HashAlgorithm(ul.At(i))
+}
+
+func (l File_HashAlgorithm_List) Set(i int, v File_HashAlgorithm) {
+	ul := capnp.UInt16List{List: l.List}
+	ul.Set(i, uint16(v))
+}
+
+type File_Version struct{ capnp.Struct }
+
+// File_Version_TypeID is the unique identifier for the type File_Version.
+const File_Version_TypeID = 0x89428d2339805f35
+
+func NewFile_Version(s *capnp.Segment) (File_Version, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return File_Version{st}, err
+}
+
+func NewRootFile_Version(s *capnp.Segment) (File_Version, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return File_Version{st}, err
+}
+
+func ReadRootFile_Version(msg *capnp.Message) (File_Version, error) {
+	root, err := msg.Root()
+	return File_Version{root.Struct()}, err
+}
+
+func (s File_Version) String() string {
+	str, _ := text.Marshal(0x89428d2339805f35, s.Struct)
+	return str
+}
+
+func (s File_Version) File() File {
+	p, _ := s.Struct.Ptr(0)
+	return File{Client: p.Interface().Client()}
+}
+
+func (s File_Version) HasFile() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s File_Version) SetFile(v File) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
+}
+
+func (s File_Version) Time() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s File_Version) SetTime(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+// File_Version_List is a list of File_Version.
+type File_Version_List struct{ capnp.List }
+
+// NewFile_Version creates a new list of File_Version.
+func NewFile_Version_List(s *capnp.Segment, sz int32) (File_Version_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1}, sz)
+	return File_Version_List{l}, err
+}
+
+func (s File_Version_List) At(i int) File_Version { return File_Version{s.List.Struct(i)} }
+
+func (s File_Version_List) Set(i int, v File_Version) error { return s.List.SetStruct(i, v.Struct) }
+
+func (s File_Version_List) String() string {
+	str, _ := text.MarshalList(0x89428d2339805f35, s.List)
+	return str
+}
+
+// File_Version_Future is a wrapper for a File_Version promised by a client call.
+type File_Version_Future struct{ *capnp.Future }
+
+func (p File_Version_Future) Struct() (File_Version, error) {
+	s, err := p.Future.Struct()
+	return File_Version{s}, err
+}
+
+func (p File_Version_Future) File() File {
+	return File{Client: p.Future.Field(0, nil).Client()}
+}
+
+type File_read_Params struct{ capnp.Struct }
+
+// File_read_Params_TypeID is the unique identifier for the type File_read_Params.
+const File_read_Params_TypeID = 0xfb1101f5d0d1edeb
+
+func NewFile_read_Params(s *capnp.Segment) (File_read_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
+	return File_read_Params{st}, err
+}
+
+func NewRootFile_read_Params(s *capnp.Segment) (File_read_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
+	return File_read_Params{st}, err
+}
+
+func ReadRootFile_read_Params(msg *capnp.Message) (File_read_Params, error) {
+	root, err := msg.Root()
+	return File_read_Params{root.Struct()}, err
+}
+
+func (s File_read_Params) String() string {
+	str, _ := text.Marshal(0xfb1101f5d0d1edeb, s.Struct)
+	return str
+}
+
+func (s File_read_Params) StartAt() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s File_read_Params) SetStartAt(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+func (s File_read_Params) Amount() uint64 {
+	return s.Struct.Uint64(8)
+}
+
+func (s File_read_Params) SetAmount(v uint64) {
+	s.Struct.SetUint64(8, v)
+}
+
+func (s File_read_Params) Sink() util.ByteStream {
+	p, _ := s.Struct.Ptr(0)
+	return util.ByteStream{Client: p.Interface().Client()}
+}
+
+func (s File_read_Params) HasSink() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s File_read_Params) SetSink(v util.ByteStream) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
+}
+
+// File_read_Params_List is a list of File_read_Params.
+type File_read_Params_List struct{ capnp.List }
+
+// NewFile_read_Params creates a new list of File_read_Params.
+func NewFile_read_Params_List(s *capnp.Segment, sz int32) (File_read_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 16, PointerCount: 1}, sz)
+	return File_read_Params_List{l}, err
+}
+
+func (s File_read_Params_List) At(i int) File_read_Params { return File_read_Params{s.List.Struct(i)} }
+
+func (s File_read_Params_List) Set(i int, v File_read_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_read_Params_List) String() string {
+	str, _ := text.MarshalList(0xfb1101f5d0d1edeb, s.List)
+	return str
+}
+
+// File_read_Params_Future is a wrapper for a File_read_Params promised by a client call.
+type File_read_Params_Future struct{ *capnp.Future }
+
+func (p File_read_Params_Future) Struct() (File_read_Params, error) {
+	s, err := p.Future.Struct()
+	return File_read_Params{s}, err
+}
+
+func (p File_read_Params_Future) Sink() util.ByteStream {
+	return util.ByteStream{Client: p.Future.Field(0, nil).Client()}
+}
+
+type File_read_Results struct{ capnp.Struct }
+
+// File_read_Results_TypeID is the unique identifier for the type File_read_Results.
+const File_read_Results_TypeID = 0xd6e8aca7864c2c0a
+
+func NewFile_read_Results(s *capnp.Segment) (File_read_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return File_read_Results{st}, err
+}
+
+func NewRootFile_read_Results(s *capnp.Segment) (File_read_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return File_read_Results{st}, err
+}
+
+func ReadRootFile_read_Results(msg *capnp.Message) (File_read_Results, error) {
+	root, err := msg.Root()
+	return File_read_Results{root.Struct()}, err
+}
+
+func (s File_read_Results) String() string {
+	str, _ := text.Marshal(0xd6e8aca7864c2c0a, s.Struct)
+	return str
+}
+
+// File_read_Results_List is a list of File_read_Results.
+type File_read_Results_List struct{ capnp.List }
+
+// NewFile_read_Results creates a new list of File_read_Results.
+func NewFile_read_Results_List(s *capnp.Segment, sz int32) (File_read_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return File_read_Results_List{l}, err
+}
+
+func (s File_read_Results_List) At(i int) File_read_Results {
+	return File_read_Results{s.List.Struct(i)}
+}
+
+func (s File_read_Results_List) Set(i int, v File_read_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_read_Results_List) String() string {
+	str, _ := text.MarshalList(0xd6e8aca7864c2c0a, s.List)
+	return str
+}
+
+// File_read_Results_Future is a wrapper for a File_read_Results promised by a client call.
+type File_read_Results_Future struct{ *capnp.Future }
+
+func (p File_read_Results_Future) Struct() (File_read_Results, error) {
+	s, err := p.Future.Struct()
+	return File_read_Results{s}, err
+}
+
+type File_readBytes_Params struct{ capnp.Struct }
+
+// File_readBytes_Params_TypeID is the unique identifier for the type File_readBytes_Params.
+const File_readBytes_Params_TypeID = 0xc81e848505cc2050
+
+func NewFile_readBytes_Params(s *capnp.Segment) (File_readBytes_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0})
+	return File_readBytes_Params{st}, err
+}
+
+func NewRootFile_readBytes_Params(s *capnp.Segment) (File_readBytes_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0})
+	return File_readBytes_Params{st}, err
+}
+
+func ReadRootFile_readBytes_Params(msg *capnp.Message) (File_readBytes_Params, error) {
+	root, err := msg.Root()
+	return File_readBytes_Params{root.Struct()}, err
+}
+
+func (s File_readBytes_Params) String() string {
+	str, _ := text.Marshal(0xc81e848505cc2050, s.Struct)
+	return str
+}
+
+func (s File_readBytes_Params) StartAt() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s File_readBytes_Params) SetStartAt(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+func (s File_readBytes_Params) Amount() uint64 {
+	return s.Struct.Uint64(8)
+}
+
+func (s File_readBytes_Params) SetAmount(v uint64) {
+	s.Struct.SetUint64(8, v)
+}
+
+// File_readBytes_Params_List is a list of File_readBytes_Params.
+type File_readBytes_Params_List struct{ capnp.List }
+
+// NewFile_readBytes_Params creates a new list of File_readBytes_Params.
+func NewFile_readBytes_Params_List(s *capnp.Segment, sz int32) (File_readBytes_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0}, sz)
+	return File_readBytes_Params_List{l}, err
+}
+
+func (s File_readBytes_Params_List) At(i int) File_readBytes_Params {
+	return File_readBytes_Params{s.List.Struct(i)}
+}
+
+func (s File_readBytes_Params_List) Set(i int, v File_readBytes_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_readBytes_Params_List) String() string {
+	str, _ := text.MarshalList(0xc81e848505cc2050, s.List)
+	return str
+}
+
+// File_readBytes_Params_Future is a wrapper for a File_readBytes_Params promised by a client call.
+type File_readBytes_Params_Future struct{ *capnp.Future }
+
+func (p File_readBytes_Params_Future) Struct() (File_readBytes_Params, error) {
+	s, err := p.Future.Struct()
+	return File_readBytes_Params{s}, err
+}
+
+type File_readBytes_Results struct{ capnp.Struct }
+
+// File_readBytes_Results_TypeID is the unique identifier for the type File_readBytes_Results.
+const File_readBytes_Results_TypeID = 0xe5b4eb4f6cb15e2a
+
+func NewFile_readBytes_Results(s *capnp.Segment) (File_readBytes_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return File_readBytes_Results{st}, err
+}
+
+func NewRootFile_readBytes_Results(s *capnp.Segment) (File_readBytes_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+	return File_readBytes_Results{st}, err
+}
+
+func ReadRootFile_readBytes_Results(msg *capnp.Message) (File_readBytes_Results, error) {
+	root, err := msg.Root()
+	return File_readBytes_Results{root.Struct()}, err
+}
+
+func (s File_readBytes_Results) String() string {
+	str, _ := text.Marshal(0xe5b4eb4f6cb15e2a, s.Struct)
+	return str
+}
+
+func (s File_readBytes_Results) Data() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return []byte(p.Data()), err
+}
+
+func (s File_readBytes_Results) HasData() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s File_readBytes_Results) SetData(v []byte) error {
+	return s.Struct.SetData(0, v)
+}
+
+func (s File_readBytes_Results) Eof() bool {
+	return s.Struct.Bit(0)
+}
+
+func (s File_readBytes_Results) SetEof(v bool) {
+	s.Struct.SetBit(0, v)
+}
+
+// File_readBytes_Results_List is a list of File_readBytes_Results.
+type File_readBytes_Results_List struct{ capnp.List }
+
+// NewFile_readBytes_Results creates a new list of File_readBytes_Results.
+func NewFile_readBytes_Results_List(s *capnp.Segment, sz int32) (File_readBytes_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 1}, sz)
+	return File_readBytes_Results_List{l}, err
+}
+
+func (s File_readBytes_Results_List) At(i int) File_readBytes_Results {
+	return File_readBytes_Results{s.List.Struct(i)}
+}
+
+func (s File_readBytes_Results_List) Set(i int, v File_readBytes_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_readBytes_Results_List) String() string {
+	str, _ := text.MarshalList(0xe5b4eb4f6cb15e2a, s.List)
+	return str
+}
+
+// File_readBytes_Results_Future is a wrapper for a File_readBytes_Results promised by a client call.
+type File_readBytes_Results_Future struct{ *capnp.Future }
+
+func (p File_readBytes_Results_Future) Struct() (File_readBytes_Results, error) {
+	s, err := p.Future.Struct()
+	return File_readBytes_Results{s}, err
+}
+
+type File_hash_Params struct{ capnp.Struct }
+
+// File_hash_Params_TypeID is the unique identifier for the type File_hash_Params.
+const File_hash_Params_TypeID = 0x81b1b612374cb988
+
+func NewFile_hash_Params(s *capnp.Segment) (File_hash_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 24, PointerCount: 0})
+	return File_hash_Params{st}, err
+}
+
+func NewRootFile_hash_Params(s *capnp.Segment) (File_hash_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 24, PointerCount: 0})
+	return File_hash_Params{st}, err
+}
+
+func ReadRootFile_hash_Params(msg *capnp.Message) (File_hash_Params, error) {
+	root, err := msg.Root()
+	return File_hash_Params{root.Struct()}, err
+}
+
+func (s File_hash_Params) String() string {
+	str, _ := text.Marshal(0x81b1b612374cb988, s.Struct)
+	return str
+}
+
+func (s File_hash_Params) Algorithm() File_HashAlgorithm {
+	return File_HashAlgorithm(s.Struct.Uint16(0))
+}
+
+func (s File_hash_Params) SetAlgorithm(v File_HashAlgorithm) {
+	s.Struct.SetUint16(0, uint16(v))
+}
+
+func (s File_hash_Params) StartAt() int64 {
+	return int64(s.Struct.Uint64(8))
+}
+
+func (s File_hash_Params) SetStartAt(v int64) {
+	s.Struct.SetUint64(8, uint64(v))
+}
+
+func (s File_hash_Params) Amount() uint64 {
+	return s.Struct.Uint64(16)
+}
+
+func (s File_hash_Params) SetAmount(v uint64) {
+	s.Struct.SetUint64(16, v)
+}
+
+// File_hash_Params_List is a list of File_hash_Params.
+type File_hash_Params_List struct{ capnp.List }
+
+// NewFile_hash_Params creates a new list of File_hash_Params.
+func NewFile_hash_Params_List(s *capnp.Segment, sz int32) (File_hash_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 24, PointerCount: 0}, sz)
+	return File_hash_Params_List{l}, err
+}
+
+func (s File_hash_Params_List) At(i int) File_hash_Params { return File_hash_Params{s.List.Struct(i)} }
+
+func (s File_hash_Params_List) Set(i int, v File_hash_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_hash_Params_List) String() string {
+	str, _ := text.MarshalList(0x81b1b612374cb988, s.List)
+	return str
+}
+
+// File_hash_Params_Future is a wrapper for a File_hash_Params promised by a client call.
+type File_hash_Params_Future struct{ *capnp.Future }
+
+func (p File_hash_Params_Future) Struct() (File_hash_Params, error) {
+	s, err := p.Future.Struct()
+	return File_hash_Params{s}, err
+}
+
+type File_hash_Results struct{ capnp.Struct }
+
+// File_hash_Results_TypeID is the unique identifier for the type File_hash_Results.
+const File_hash_Results_TypeID = 0xd0e49866c27afa2d
+
+func NewFile_hash_Results(s *capnp.Segment) (File_hash_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return File_hash_Results{st}, err
+}
+
+func NewRootFile_hash_Results(s *capnp.Segment) (File_hash_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return File_hash_Results{st}, err
+}
+
+func ReadRootFile_hash_Results(msg *capnp.Message) (File_hash_Results, error) {
+	root, err := msg.Root()
+	return File_hash_Results{root.Struct()}, err
+}
+
+func (s File_hash_Results) String() string {
+	str, _ := text.Marshal(0xd0e49866c27afa2d, s.Struct)
+	return str
+}
+
+func (s File_hash_Results) Digest() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return []byte(p.Data()), err
+}
+
+func (s File_hash_Results) HasDigest() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s File_hash_Results) SetDigest(v []byte) error {
+	return s.Struct.SetData(0, v)
+}
+
+// File_hash_Results_List is a list of File_hash_Results.
+type File_hash_Results_List struct{ capnp.List }
+
+// NewFile_hash_Results creates a new list of File_hash_Results.
+func NewFile_hash_Results_List(s *capnp.Segment, sz int32) (File_hash_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return File_hash_Results_List{l}, err
+}
+
+func (s File_hash_Results_List) At(i int) File_hash_Results {
+	return File_hash_Results{s.List.Struct(i)}
+}
+
+func (s File_hash_Results_List) Set(i int, v File_hash_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_hash_Results_List) String() string {
+	str, _ := text.MarshalList(0xd0e49866c27afa2d, s.List)
+	return str
+}
+
+// File_hash_Results_Future is a wrapper for a File_hash_Results promised by a client call.
+type File_hash_Results_Future struct{ *capnp.Future }
+
+func (p File_hash_Results_Future) Struct() (File_hash_Results, error) {
+	s, err := p.Future.Struct()
+	return File_hash_Results{s}, err
+}
+
+type File_seekData_Params struct{ capnp.Struct }
+
+// File_seekData_Params_TypeID is the unique identifier for the type File_seekData_Params.
+const File_seekData_Params_TypeID = 0xa534018eeff51000
+
+func NewFile_seekData_Params(s *capnp.Segment) (File_seekData_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return File_seekData_Params{st}, err
+}
+
+func NewRootFile_seekData_Params(s *capnp.Segment) (File_seekData_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return File_seekData_Params{st}, err
+}
+
+func ReadRootFile_seekData_Params(msg *capnp.Message) (File_seekData_Params, error) {
+	root, err := msg.Root()
+	return File_seekData_Params{root.Struct()}, err
+}
+
+func (s File_seekData_Params) String() string {
+	str, _ := text.Marshal(0xa534018eeff51000, s.Struct)
+	return str
+}
+
+func (s File_seekData_Params) Offset() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s File_seekData_Params) SetOffset(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+// File_seekData_Params_List is a list of File_seekData_Params.
+type File_seekData_Params_List struct{ capnp.List }
+
+// NewFile_seekData_Params creates a new list of File_seekData_Params.
+func NewFile_seekData_Params_List(s *capnp.Segment, sz int32) (File_seekData_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
+	return File_seekData_Params_List{l}, err
+}
+
+func (s File_seekData_Params_List) At(i int) File_seekData_Params {
+	return File_seekData_Params{s.List.Struct(i)}
+}
+
+func (s File_seekData_Params_List) Set(i int, v File_seekData_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_seekData_Params_List) String() string {
+	str, _ := text.MarshalList(0xa534018eeff51000, s.List)
+	return str
+}
+
+// File_seekData_Params_Future is a wrapper for a File_seekData_Params promised by a client call.
+type File_seekData_Params_Future struct{ *capnp.Future }
+
+func (p File_seekData_Params_Future) Struct() (File_seekData_Params, error) {
+	s, err := p.Future.Struct()
+	return File_seekData_Params{s}, err
+}
+
+type File_seekData_Results struct{ capnp.Struct }
+
+// File_seekData_Results_TypeID is the unique identifier for the type File_seekData_Results.
+const File_seekData_Results_TypeID = 0xa56438f3f0a440a2
+
+func NewFile_seekData_Results(s *capnp.Segment) (File_seekData_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return File_seekData_Results{st}, err
+}
+
+func NewRootFile_seekData_Results(s *capnp.Segment) (File_seekData_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return File_seekData_Results{st}, err
+}
+
+func ReadRootFile_seekData_Results(msg *capnp.Message) (File_seekData_Results, error) {
+	root, err := msg.Root()
+	return File_seekData_Results{root.Struct()}, err
+}
+
+func (s File_seekData_Results) String() string {
+	str, _ := text.Marshal(0xa56438f3f0a440a2, s.Struct)
+	return str
+}
+
+func (s File_seekData_Results) Offset() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s File_seekData_Results) SetOffset(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+// File_seekData_Results_List is a list of File_seekData_Results.
+type File_seekData_Results_List struct{ capnp.List }
+
+// NewFile_seekData_Results creates a new list of File_seekData_Results.
+func NewFile_seekData_Results_List(s *capnp.Segment, sz int32) (File_seekData_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
+	return File_seekData_Results_List{l}, err
+}
+
+func (s File_seekData_Results_List) At(i int) File_seekData_Results {
+	return File_seekData_Results{s.List.Struct(i)}
+}
+
+func (s File_seekData_Results_List) Set(i int, v File_seekData_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_seekData_Results_List) String() string {
+	str, _ := text.MarshalList(0xa56438f3f0a440a2, s.List)
+	return str
+}
+
+// File_seekData_Results_Future is a wrapper for a File_seekData_Results promised by a client call.
+type File_seekData_Results_Future struct{ *capnp.Future }
+
+func (p File_seekData_Results_Future) Struct() (File_seekData_Results, error) {
+	s, err := p.Future.Struct()
+	return File_seekData_Results{s}, err
+}
+
+type File_seekHole_Params struct{ capnp.Struct }
+
+// File_seekHole_Params_TypeID is the unique identifier for the type File_seekHole_Params.
+const File_seekHole_Params_TypeID = 0xd24c7c48e57a035e
+
+func NewFile_seekHole_Params(s *capnp.Segment) (File_seekHole_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return File_seekHole_Params{st}, err
+}
+
+func NewRootFile_seekHole_Params(s *capnp.Segment) (File_seekHole_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return File_seekHole_Params{st}, err
+}
+
+func ReadRootFile_seekHole_Params(msg *capnp.Message) (File_seekHole_Params, error) {
+	root, err := msg.Root()
+	return File_seekHole_Params{root.Struct()}, err
+}
+
+func (s File_seekHole_Params) String() string {
+	str, _ := text.Marshal(0xd24c7c48e57a035e, s.Struct)
+	return str
+}
+
+func (s File_seekHole_Params) Offset() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s File_seekHole_Params) SetOffset(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+// File_seekHole_Params_List is a list of File_seekHole_Params.
+type File_seekHole_Params_List struct{ capnp.List }
+
+// NewFile_seekHole_Params creates a new list of File_seekHole_Params.
+func NewFile_seekHole_Params_List(s *capnp.Segment, sz int32) (File_seekHole_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
+	return File_seekHole_Params_List{l}, err
+}
+
+func (s File_seekHole_Params_List) At(i int) File_seekHole_Params {
+	return File_seekHole_Params{s.List.Struct(i)}
+}
+
+func (s File_seekHole_Params_List) Set(i int, v File_seekHole_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_seekHole_Params_List) String() string {
+	str, _ := text.MarshalList(0xd24c7c48e57a035e, s.List)
+	return str
+}
+
+// File_seekHole_Params_Future is a wrapper for a File_seekHole_Params promised by a client call.
+type File_seekHole_Params_Future struct{ *capnp.Future }
+
+func (p File_seekHole_Params_Future) Struct() (File_seekHole_Params, error) {
+	s, err := p.Future.Struct()
+	return File_seekHole_Params{s}, err
+}
+
+type File_seekHole_Results struct{ capnp.Struct }
+
+// File_seekHole_Results_TypeID is the unique identifier for the type File_seekHole_Results.
+const File_seekHole_Results_TypeID = 0xd88110d2d1e93bb2
+
+func NewFile_seekHole_Results(s *capnp.Segment) (File_seekHole_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return File_seekHole_Results{st}, err
+}
+
+func NewRootFile_seekHole_Results(s *capnp.Segment) (File_seekHole_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return File_seekHole_Results{st}, err
+}
+
+func ReadRootFile_seekHole_Results(msg *capnp.Message) (File_seekHole_Results, error) {
+	root, err := msg.Root()
+	return File_seekHole_Results{root.Struct()}, err
+}
+
+func (s File_seekHole_Results) String() string {
+	str, _ := text.Marshal(0xd88110d2d1e93bb2, s.Struct)
+	return str
+}
+
+func (s File_seekHole_Results) Offset() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s File_seekHole_Results) SetOffset(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+// File_seekHole_Results_List is a list of File_seekHole_Results.
+type File_seekHole_Results_List struct{ capnp.List }
+
+// NewFile_seekHole_Results creates a new list of File_seekHole_Results.
+func NewFile_seekHole_Results_List(s *capnp.Segment, sz int32) (File_seekHole_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
+	return File_seekHole_Results_List{l}, err
+}
+
+func (s File_seekHole_Results_List) At(i int) File_seekHole_Results {
+	return File_seekHole_Results{s.List.Struct(i)}
+}
+
+func (s File_seekHole_Results_List) Set(i int, v File_seekHole_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_seekHole_Results_List) String() string {
+	str, _ := text.MarshalList(0xd88110d2d1e93bb2, s.List)
+	return str
+}
+
+// File_seekHole_Results_Future is a wrapper for a File_seekHole_Results promised by a client call.
+type File_seekHole_Results_Future struct{ *capnp.Future }
+
+func (p File_seekHole_Results_Future) Struct() (File_seekHole_Results, error) {
+	s, err := p.Future.Struct()
+	return File_seekHole_Results{s}, err
+}
+
+type File_versions_Params struct{ capnp.Struct }
+
+// File_versions_Params_TypeID is the unique identifier for the type File_versions_Params.
+const File_versions_Params_TypeID = 0x847fd6b40727ff2b
+
+func NewFile_versions_Params(s *capnp.Segment) (File_versions_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return File_versions_Params{st}, err
+}
+
+func NewRootFile_versions_Params(s *capnp.Segment) (File_versions_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return File_versions_Params{st}, err
+}
+
+func ReadRootFile_versions_Params(msg *capnp.Message) (File_versions_Params, error) {
+	root, err := msg.Root()
+	return File_versions_Params{root.Struct()}, err
+}
+
+func (s File_versions_Params) String() string {
+	str, _ := text.Marshal(0x847fd6b40727ff2b, s.Struct)
+	return str
+}
+
+// File_versions_Params_List is a list of File_versions_Params.
+type File_versions_Params_List struct{ capnp.List }
+
+// NewFile_versions_Params creates a new list of File_versions_Params.
+func NewFile_versions_Params_List(s *capnp.Segment, sz int32) (File_versions_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return File_versions_Params_List{l}, err
+}
+
+func (s File_versions_Params_List) At(i int) File_versions_Params {
+	return File_versions_Params{s.List.Struct(i)}
+}
+
+func (s File_versions_Params_List) Set(i int, v File_versions_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_versions_Params_List) String() string {
+	str, _ := text.MarshalList(0x847fd6b40727ff2b, s.List)
+	return str
+}
+
+// File_versions_Params_Future is a wrapper for a File_versions_Params promised by a client call.
+type File_versions_Params_Future struct{ *capnp.Future }
+
+func (p File_versions_Params_Future) Struct() (File_versions_Params, error) {
+	s, err := p.Future.Struct()
+	return File_versions_Params{s}, err
+}
+
+type File_versions_Results struct{ capnp.Struct }
+
+// File_versions_Results_TypeID is the unique identifier for the type File_versions_Results.
+const File_versions_Results_TypeID = 0xc88df402246abb8c
+
+func NewFile_versions_Results(s *capnp.Segment) (File_versions_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return File_versions_Results{st}, err
+}
+
+func NewRootFile_versions_Results(s *capnp.Segment) (File_versions_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return File_versions_Results{st}, err
+}
+
+func ReadRootFile_versions_Results(msg *capnp.Message) (File_versions_Results, error) {
+	root, err := msg.Root()
+	return File_versions_Results{root.Struct()}, err
+}
+
+func (s File_versions_Results) String() string {
+	str, _ := text.Marshal(0xc88df402246abb8c, s.Struct)
+	return str
+}
+
+func (s File_versions_Results) Versions() (File_Version_List, error) {
+	p, err := s.Struct.Ptr(0)
+	return File_Version_List{List: p.List()}, err
+}
+
+func (s File_versions_Results) HasVersions() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s File_versions_Results) SetVersions(v File_Version_List) error {
+	return s.Struct.SetPtr(0, v.List.ToPtr())
+}
+
+// NewVersions sets the versions field to a newly
+// allocated File_Version_List, preferring placement in s's segment.
+func (s File_versions_Results) NewVersions(n int32) (File_Version_List, error) {
+	l, err := NewFile_Version_List(s.Struct.Segment(), n)
+	if err != nil {
+		return File_Version_List{}, err
+	}
+	err = s.Struct.SetPtr(0, l.List.ToPtr())
+	return l, err
+}
+
+// File_versions_Results_List is a list of File_versions_Results.
+type File_versions_Results_List struct{ capnp.List }
+
+// NewFile_versions_Results creates a new list of File_versions_Results.
+func NewFile_versions_Results_List(s *capnp.Segment, sz int32) (File_versions_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return File_versions_Results_List{l}, err
+}
+
+func (s File_versions_Results_List) At(i int) File_versions_Results {
+	return File_versions_Results{s.List.Struct(i)}
+}
+
+func (s File_versions_Results_List) Set(i int, v File_versions_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s File_versions_Results_List) String() string {
+	str, _ := text.MarshalList(0xc88df402246abb8c, s.List)
+	return str
+}
+
+// File_versions_Results_Future is a wrapper for a File_versions_Results promised by a client call.
+type File_versions_Results_Future struct{ *capnp.Future }
+
+func (p File_versions_Results_Future) Struct() (File_versions_Results, error) {
+	s, err := p.Future.Struct()
+	return File_versions_Results{s}, err
+}
+
+type RwFile struct{ Client *capnp.Client }
+
+// RwFile_TypeID is the unique identifier for the type RwFile.
+const RwFile_TypeID = 0xb4810121539f6e53
+
+func (c RwFile) Write(ctx context.Context, params func(RwFile_write_Params) error) (RwFile_write_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "write",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_write_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwFile_write_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) Truncate(ctx context.Context, params func(RwFile_truncate_Params) error) (RwFile_truncate_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "truncate",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_truncate_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwFile_truncate_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) SetExec(ctx context.Context, params func(RwFile_setExec_Params) error) (RwFile_setExec_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "setExec",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_setExec_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwFile_setExec_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) SetXattr(ctx context.Context, params func(RwFile_setXattr_Params) error) (RwFile_setXattr_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "setXattr",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 2}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_setXattr_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwFile_setXattr_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) RemoveXattr(ctx context.Context, params func(RwFile_removeXattr_Params) error) (RwFile_removeXattr_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      4,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "removeXattr",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_removeXattr_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwFile_removeXattr_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) Allocate(ctx context.Context, params func(RwFile_allocate_Params) error) (RwFile_allocate_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      5,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "allocate",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_allocate_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwFile_allocate_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) PunchHole(ctx context.Context, params func(RwFile_punchHole_Params) error) (RwFile_punchHole_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      6,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "punchHole",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_punchHole_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwFile_punchHole_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) Lock(ctx context.Context, params func(RwFile_lock_Params) error) (RwFile_lock_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      7,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "lock",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_lock_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwFile_lock_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) TryLock(ctx context.Context, params func(RwFile_tryLock_Params) error) (RwFile_tryLock_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      8,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "tryLock",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_tryLock_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return RwFile_tryLock_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) Read(ctx context.Context, params func(File_read_Params) error) (File_read_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "read",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_read_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_read_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) ReadBytes(ctx context.Context, params func(File_readBytes_Params) error) (File_readBytes_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "readBytes",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_readBytes_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_readBytes_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) Hash(ctx context.Context, params func(File_hash_Params) error) (File_hash_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "hash",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 24, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_hash_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_hash_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) SeekData(ctx context.Context, params func(File_seekData_Params) error) (File_seekData_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekData",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_seekData_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_seekData_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) SeekHole(ctx context.Context, params func(File_seekHole_Params) error) (File_seekHole_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      4,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekHole",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_seekHole_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_seekHole_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) Versions(ctx context.Context, params func(File_versions_Params) error) (File_versions_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      5,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "versions",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_versions_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_versions_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_stat_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_stat_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) GetXattr(ctx context.Context, params func(Node_getXattr_Params) error) (Node_getXattr_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_getXattr_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_getXattr_Results_Future{Future: ans.Future()}, release
+}
+func (c RwFile) ListXattrs(ctx context.Context, params func(Node_listXattrs_Params) error) (Node_listXattrs_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_listXattrs_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_listXattrs_Results_Future{Future: ans.Future()}, release
+}
+
+// A RwFile_Server is a RwFile with a local implementation.
+type RwFile_Server interface {
+	Write(context.Context, RwFile_write) error
+
+	Truncate(context.Context, RwFile_truncate) error
+
+	SetExec(context.Context, RwFile_setExec) error
+
+	SetXattr(context.Context, RwFile_setXattr) error
+
+	RemoveXattr(context.Context, RwFile_removeXattr) error
+
+	Allocate(context.Context, RwFile_allocate) error
+
+	PunchHole(context.Context, RwFile_punchHole) error
+
+	Lock(context.Context, RwFile_lock) error
+
+	TryLock(context.Context, RwFile_tryLock) error
+
+	Read(context.Context, File_read) error
+
+	ReadBytes(context.Context, File_readBytes) error
+
+	Hash(context.Context, File_hash) error
+
+	SeekData(context.Context, File_seekData) error
+
+	SeekHole(context.Context, File_seekHole) error
+
+	Versions(context.Context, File_versions) error
+
+	Stat(context.Context, Node_stat) error
+
+	GetXattr(context.Context, Node_getXattr) error
+
+	ListXattrs(context.Context, Node_listXattrs) error
+}
+
+// RwFile_NewServer creates a new Server from an implementation of RwFile_Server.
+func RwFile_NewServer(s RwFile_Server, policy *server.Policy) *server.Server {
+	c, _ := s.(server.Shutdowner)
+	return server.New(RwFile_Methods(nil, s), s, c, policy)
+}
+
+// RwFile_ServerToClient creates a new Client from an implementation of RwFile_Server.
+// The caller is responsible for calling Release on the returned Client.
+func RwFile_ServerToClient(s RwFile_Server, policy *server.Policy) RwFile {
+	return RwFile{Client: capnp.NewClient(RwFile_NewServer(s, policy))}
+}
+
+// RwFile_Methods appends Methods to a slice that invoke the methods on s.
+// This can be used to create a more complicated Server.
+func RwFile_Methods(methods []server.Method, s RwFile_Server) []server.Method {
+	if cap(methods) == 0 {
+		methods = make([]server.Method, 0, 18)
+	}
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "write",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Write(ctx, RwFile_write{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "truncate",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Truncate(ctx, RwFile_truncate{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "setExec",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.SetExec(ctx, RwFile_setExec{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "setXattr",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.SetXattr(ctx, RwFile_setXattr{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      4,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "removeXattr",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.RemoveXattr(ctx, RwFile_removeXattr{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      5,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "allocate",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Allocate(ctx, RwFile_allocate{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      6,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "punchHole",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.PunchHole(ctx, RwFile_punchHole{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      7,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "lock",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Lock(ctx, RwFile_lock{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xb4810121539f6e53,
+			MethodID:      8,
+			InterfaceName: "filesystem.capnp:RwFile",
+			MethodName:    "tryLock",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.TryLock(ctx, RwFile_tryLock{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "read",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Read(ctx, File_read{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "readBytes",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.ReadBytes(ctx, File_readBytes{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "hash",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Hash(ctx, File_hash{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekData",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.SeekData(ctx, File_seekData{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      4,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekHole",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.SeekHole(ctx, File_seekHole{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      5,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "versions",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Versions(ctx, File_versions{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Stat(ctx, Node_stat{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.GetXattr(ctx, Node_getXattr{call})
+		},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.ListXattrs(ctx, Node_listXattrs{call})
+		},
+	})
+
+	return methods
+}
+
+// RwFile_write holds the state for a server call to RwFile.write.
+// See server.Call for documentation.
+type RwFile_write struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwFile_write) Args() RwFile_write_Params {
+	return RwFile_write_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwFile_write) AllocResults() (RwFile_write_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_write_Results{Struct: r}, err
+}
+
+// RwFile_truncate holds the state for a server call to RwFile.truncate.
+// See server.Call for documentation.
+type RwFile_truncate struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwFile_truncate) Args() RwFile_truncate_Params {
+	return RwFile_truncate_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwFile_truncate) AllocResults() (RwFile_truncate_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_truncate_Results{Struct: r}, err
+}
+
+// RwFile_setExec holds the state for a server call to RwFile.setExec.
+// See server.Call for documentation.
+type RwFile_setExec struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwFile_setExec) Args() RwFile_setExec_Params {
+	return RwFile_setExec_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwFile_setExec) AllocResults() (RwFile_setExec_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_setExec_Results{Struct: r}, err
+}
+
+// RwFile_setXattr holds the state for a server call to RwFile.setXattr.
+// See server.Call for documentation.
+type RwFile_setXattr struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwFile_setXattr) Args() RwFile_setXattr_Params {
+	return RwFile_setXattr_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwFile_setXattr) AllocResults() (RwFile_setXattr_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_setXattr_Results{Struct: r}, err
+}
+
+// RwFile_removeXattr holds the state for a server call to RwFile.removeXattr.
+// See server.Call for documentation.
+type RwFile_removeXattr struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwFile_removeXattr) Args() RwFile_removeXattr_Params {
+	return RwFile_removeXattr_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwFile_removeXattr) AllocResults() (RwFile_removeXattr_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_removeXattr_Results{Struct: r}, err
+}
+
+// RwFile_allocate holds the state for a server call to RwFile.allocate.
+// See server.Call for documentation.
+type RwFile_allocate struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwFile_allocate) Args() RwFile_allocate_Params {
+	return RwFile_allocate_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwFile_allocate) AllocResults() (RwFile_allocate_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_allocate_Results{Struct: r}, err
+}
+
+// RwFile_punchHole holds the state for a server call to RwFile.punchHole.
+// See server.Call for documentation.
+type RwFile_punchHole struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwFile_punchHole) Args() RwFile_punchHole_Params {
+	return RwFile_punchHole_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwFile_punchHole) AllocResults() (RwFile_punchHole_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_punchHole_Results{Struct: r}, err
+}
+
+// RwFile_lock holds the state for a server call to RwFile.lock.
+// See server.Call for documentation.
+type RwFile_lock struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwFile_lock) Args() RwFile_lock_Params {
+	return RwFile_lock_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwFile_lock) AllocResults() (RwFile_lock_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_lock_Results{Struct: r}, err
+}
+
+// RwFile_tryLock holds the state for a server call to RwFile.tryLock.
+// See server.Call for documentation.
+type RwFile_tryLock struct {
+	*server.Call
+}
+
+// Args returns the call's arguments.
+func (c RwFile_tryLock) Args() RwFile_tryLock_Params {
+	return RwFile_tryLock_Params{Struct: c.Call.Args()}
+}
+
+// AllocResults allocates the results struct.
+func (c RwFile_tryLock) AllocResults() (RwFile_tryLock_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_tryLock_Results{Struct: r}, err
+}
+
+type RwFile_write_Params struct{ capnp.Struct }
+
+// RwFile_write_Params_TypeID is the unique identifier for the type RwFile_write_Params.
+const RwFile_write_Params_TypeID = 0xec401fdf2c149f1b
+
+func NewRwFile_write_Params(s *capnp.Segment) (RwFile_write_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return RwFile_write_Params{st}, err
+}
+
+func NewRootRwFile_write_Params(s *capnp.Segment) (RwFile_write_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return RwFile_write_Params{st}, err
+}
+
+func ReadRootRwFile_write_Params(msg *capnp.Message) (RwFile_write_Params, error) {
+	root, err := msg.Root()
+	return RwFile_write_Params{root.Struct()}, err
+}
+
+func (s RwFile_write_Params) String() string {
+	str, _ := text.Marshal(0xec401fdf2c149f1b, s.Struct)
+	return str
+}
+
+func (s RwFile_write_Params) StartAt() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s RwFile_write_Params) SetStartAt(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+// RwFile_write_Params_List is a list of RwFile_write_Params.
+type RwFile_write_Params_List struct{ capnp.List }
+
+// NewRwFile_write_Params creates a new list of RwFile_write_Params.
+func NewRwFile_write_Params_List(s *capnp.Segment, sz int32) (RwFile_write_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
+	return RwFile_write_Params_List{l}, err
+}
+
+func (s RwFile_write_Params_List) At(i int) RwFile_write_Params {
+	return RwFile_write_Params{s.List.Struct(i)}
+}
+
+func (s RwFile_write_Params_List) Set(i int, v RwFile_write_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_write_Params_List) String() string {
+	str, _ := text.MarshalList(0xec401fdf2c149f1b, s.List)
+	return str
+}
+
+// RwFile_write_Params_Future is a wrapper for a RwFile_write_Params promised by a client call.
+type RwFile_write_Params_Future struct{ *capnp.Future }
+
+func (p RwFile_write_Params_Future) Struct() (RwFile_write_Params, error) {
+	s, err := p.Future.Struct()
+	return RwFile_write_Params{s}, err
+}
+
+type RwFile_write_Results struct{ capnp.Struct }
+
+// RwFile_write_Results_TypeID is the unique identifier for the type RwFile_write_Results.
+const RwFile_write_Results_TypeID = 0x83db8ff5946e5b09
+
+func NewRwFile_write_Results(s *capnp.Segment) (RwFile_write_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_write_Results{st}, err
+}
+
+func NewRootRwFile_write_Results(s *capnp.Segment) (RwFile_write_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_write_Results{st}, err
+}
+
+func ReadRootRwFile_write_Results(msg *capnp.Message) (RwFile_write_Results, error) {
+	root, err := msg.Root()
+	return RwFile_write_Results{root.Struct()}, err
+}
+
+func (s RwFile_write_Results) String() string {
+	str, _ := text.Marshal(0x83db8ff5946e5b09, s.Struct)
+	return str
+}
+
+func (s RwFile_write_Results) Sink() util.ByteStream {
+	p, _ := s.Struct.Ptr(0)
+	return util.ByteStream{Client: p.Interface().Client()}
+}
+
+func (s RwFile_write_Results) HasSink() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwFile_write_Results) SetSink(v util.ByteStream) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
+}
+
+// RwFile_write_Results_List is a list of RwFile_write_Results.
+type RwFile_write_Results_List struct{ capnp.List }
+
+// NewRwFile_write_Results creates a new list of RwFile_write_Results.
+func NewRwFile_write_Results_List(s *capnp.Segment, sz int32) (RwFile_write_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return RwFile_write_Results_List{l}, err
+}
+
+func (s RwFile_write_Results_List) At(i int) RwFile_write_Results {
+	return RwFile_write_Results{s.List.Struct(i)}
+}
+
+func (s RwFile_write_Results_List) Set(i int, v RwFile_write_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_write_Results_List) String() string {
+	str, _ := text.MarshalList(0x83db8ff5946e5b09, s.List)
+	return str
+}
+
+// RwFile_write_Results_Future is a wrapper for a RwFile_write_Results promised by a client call.
+type RwFile_write_Results_Future struct{ *capnp.Future }
+
+func (p RwFile_write_Results_Future) Struct() (RwFile_write_Results, error) {
+	s, err := p.Future.Struct()
+	return RwFile_write_Results{s}, err
+}
+
+func (p RwFile_write_Results_Future) Sink() util.ByteStream {
+	return util.ByteStream{Client: p.Future.Field(0, nil).Client()}
+}
+
+type RwFile_truncate_Params struct{ capnp.Struct }
+
+// RwFile_truncate_Params_TypeID is the unique identifier for the type RwFile_truncate_Params.
+const RwFile_truncate_Params_TypeID = 0xbf2ae4dc7cac598c
+
+func NewRwFile_truncate_Params(s *capnp.Segment) (RwFile_truncate_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return RwFile_truncate_Params{st}, err
+}
+
+func NewRootRwFile_truncate_Params(s *capnp.Segment) (RwFile_truncate_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return RwFile_truncate_Params{st}, err
+}
+
+func ReadRootRwFile_truncate_Params(msg *capnp.Message) (RwFile_truncate_Params, error) {
+	root, err := msg.Root()
+	return RwFile_truncate_Params{root.Struct()}, err
+}
+
+func (s RwFile_truncate_Params) String() string {
+	str, _ := text.Marshal(0xbf2ae4dc7cac598c, s.Struct)
+	return str
+}
+
+func (s RwFile_truncate_Params) Size() uint64 {
+	return s.Struct.Uint64(0)
+}
+
+func (s RwFile_truncate_Params) SetSize(v uint64) {
+	s.Struct.SetUint64(0, v)
+}
+
+// RwFile_truncate_Params_List is a list of RwFile_truncate_Params.
+type RwFile_truncate_Params_List struct{ capnp.List }
+
+// NewRwFile_truncate_Params creates a new list of RwFile_truncate_Params.
+func NewRwFile_truncate_Params_List(s *capnp.Segment, sz int32) (RwFile_truncate_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
+	return RwFile_truncate_Params_List{l}, err
+}
+
+func (s RwFile_truncate_Params_List) At(i int) RwFile_truncate_Params {
+	return RwFile_truncate_Params{s.List.Struct(i)}
+}
+
+func (s RwFile_truncate_Params_List) Set(i int, v RwFile_truncate_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_truncate_Params_List) String() string {
+	str, _ := text.MarshalList(0xbf2ae4dc7cac598c, s.List)
+	return str
+}
+
+// RwFile_truncate_Params_Future is a wrapper for a RwFile_truncate_Params promised by a client call.
+type RwFile_truncate_Params_Future struct{ *capnp.Future }
+
+func (p RwFile_truncate_Params_Future) Struct() (RwFile_truncate_Params, error) {
+	s, err := p.Future.Struct()
+	return RwFile_truncate_Params{s}, err
+}
+
+type RwFile_truncate_Results struct{ capnp.Struct }
+
+// RwFile_truncate_Results_TypeID is the unique identifier for the type RwFile_truncate_Results.
+const RwFile_truncate_Results_TypeID = 0xf101f68e2a8fff80
+
+func NewRwFile_truncate_Results(s *capnp.Segment) (RwFile_truncate_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_truncate_Results{st}, err
+}
+
+func NewRootRwFile_truncate_Results(s *capnp.Segment) (RwFile_truncate_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_truncate_Results{st}, err
+}
+
+func ReadRootRwFile_truncate_Results(msg *capnp.Message) (RwFile_truncate_Results, error) {
+	root, err := msg.Root()
+	return RwFile_truncate_Results{root.Struct()}, err
+}
+
+func (s RwFile_truncate_Results) String() string {
+	str, _ := text.Marshal(0xf101f68e2a8fff80, s.Struct)
+	return str
+}
+
+// RwFile_truncate_Results_List is a list of RwFile_truncate_Results.
+type RwFile_truncate_Results_List struct{ capnp.List }
+
+// NewRwFile_truncate_Results creates a new list of RwFile_truncate_Results.
+func NewRwFile_truncate_Results_List(s *capnp.Segment, sz int32) (RwFile_truncate_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return RwFile_truncate_Results_List{l}, err
+}
+
+func (s RwFile_truncate_Results_List) At(i int) RwFile_truncate_Results {
+	return RwFile_truncate_Results{s.List.Struct(i)}
+}
+
+func (s RwFile_truncate_Results_List) Set(i int, v RwFile_truncate_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_truncate_Results_List) String() string {
+	str, _ := text.MarshalList(0xf101f68e2a8fff80, s.List)
+	return str
+}
+
+// RwFile_truncate_Results_Future is a wrapper for a RwFile_truncate_Results promised by a client call.
+type RwFile_truncate_Results_Future struct{ *capnp.Future }
+
+func (p RwFile_truncate_Results_Future) Struct() (RwFile_truncate_Results, error) {
+	s, err := p.Future.Struct()
+	return RwFile_truncate_Results{s}, err
+}
+
+type RwFile_setExec_Params struct{ capnp.Struct }
+
+// RwFile_setExec_Params_TypeID is the unique identifier for the type RwFile_setExec_Params.
+const RwFile_setExec_Params_TypeID = 0xc799a0caf614d135
+
+func NewRwFile_setExec_Params(s *capnp.Segment) (RwFile_setExec_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return RwFile_setExec_Params{st}, err
+}
+
+func NewRootRwFile_setExec_Params(s *capnp.Segment) (RwFile_setExec_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return RwFile_setExec_Params{st}, err
+}
+
+func ReadRootRwFile_setExec_Params(msg *capnp.Message) (RwFile_setExec_Params, error) {
+	root, err := msg.Root()
+	return RwFile_setExec_Params{root.Struct()}, err
+}
+
+func (s RwFile_setExec_Params) String() string {
+	str, _ := text.Marshal(0xc799a0caf614d135, s.Struct)
+	return str
+}
+
+func (s RwFile_setExec_Params) Exec() bool {
+	return s.Struct.Bit(0)
+}
+
+func (s RwFile_setExec_Params) SetExec(v bool) {
+	s.Struct.SetBit(0, v)
+}
+
+// RwFile_setExec_Params_List is a list of RwFile_setExec_Params.
+type RwFile_setExec_Params_List struct{ capnp.List }
+
+// NewRwFile_setExec_Params creates a new list of RwFile_setExec_Params.
+func NewRwFile_setExec_Params_List(s *capnp.Segment, sz int32) (RwFile_setExec_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
+	return RwFile_setExec_Params_List{l}, err
+}
+
+func (s RwFile_setExec_Params_List) At(i int) RwFile_setExec_Params {
+	return RwFile_setExec_Params{s.List.Struct(i)}
+}
+
+func (s RwFile_setExec_Params_List) Set(i int, v RwFile_setExec_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_setExec_Params_List) String() string {
+	str, _ := text.MarshalList(0xc799a0caf614d135, s.List)
+	return str
+}
+
+// RwFile_setExec_Params_Future is a wrapper for a RwFile_setExec_Params promised by a client call.
+type RwFile_setExec_Params_Future struct{ *capnp.Future }
+
+func (p RwFile_setExec_Params_Future) Struct() (RwFile_setExec_Params, error) {
+	s, err := p.Future.Struct()
+	return RwFile_setExec_Params{s}, err
+}
+
+type RwFile_setExec_Results struct{ capnp.Struct }
+
+// RwFile_setExec_Results_TypeID is the unique identifier for the type RwFile_setExec_Results.
+const RwFile_setExec_Results_TypeID = 0xddad3e0282b03294
+
+func NewRwFile_setExec_Results(s *capnp.Segment) (RwFile_setExec_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_setExec_Results{st}, err
+}
+
+func NewRootRwFile_setExec_Results(s *capnp.Segment) (RwFile_setExec_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_setExec_Results{st}, err
+}
+
+func ReadRootRwFile_setExec_Results(msg *capnp.Message) (RwFile_setExec_Results, error) {
+	root, err := msg.Root()
+	return RwFile_setExec_Results{root.Struct()}, err
+}
+
+func (s RwFile_setExec_Results) String() string {
+	str, _ := text.Marshal(0xddad3e0282b03294, s.Struct)
+	return str
+}
+
+// RwFile_setExec_Results_List is a list of RwFile_setExec_Results.
+type RwFile_setExec_Results_List struct{ capnp.List }
+
+// NewRwFile_setExec_Results creates a new list of RwFile_setExec_Results.
+func NewRwFile_setExec_Results_List(s *capnp.Segment, sz int32) (RwFile_setExec_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return RwFile_setExec_Results_List{l}, err
+}
+
+func (s RwFile_setExec_Results_List) At(i int) RwFile_setExec_Results {
+	return RwFile_setExec_Results{s.List.Struct(i)}
+}
+
+func (s RwFile_setExec_Results_List) Set(i int, v RwFile_setExec_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_setExec_Results_List) String() string {
+	str, _ := text.MarshalList(0xddad3e0282b03294, s.List)
+	return str
+}
+
+// RwFile_setExec_Results_Future is a wrapper for a RwFile_setExec_Results promised by a client call.
+type RwFile_setExec_Results_Future struct{ *capnp.Future }
+
+func (p RwFile_setExec_Results_Future) Struct() (RwFile_setExec_Results, error) {
+	s, err := p.Future.Struct()
+	return RwFile_setExec_Results{s}, err
+}
+
+type RwFile_setXattr_Params struct{ capnp.Struct }
+
+// RwFile_setXattr_Params_TypeID is the unique identifier for the type RwFile_setXattr_Params.
+const RwFile_setXattr_Params_TypeID = 0xe4de233468ba1a29
+
+func NewRwFile_setXattr_Params(s *capnp.Segment) (RwFile_setXattr_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return RwFile_setXattr_Params{st}, err
+}
+
+func NewRootRwFile_setXattr_Params(s *capnp.Segment) (RwFile_setXattr_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return RwFile_setXattr_Params{st}, err
+}
+
+func ReadRootRwFile_setXattr_Params(msg *capnp.Message) (RwFile_setXattr_Params, error) {
+	root, err := msg.Root()
+	return RwFile_setXattr_Params{root.Struct()}, err
+}
+
+func (s RwFile_setXattr_Params) String() string {
+	str, _ := text.Marshal(0xe4de233468ba1a29, s.Struct)
+	return str
+}
+
+func (s RwFile_setXattr_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s RwFile_setXattr_Params) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwFile_setXattr_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s RwFile_setXattr_Params) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+func (s RwFile_setXattr_Params) Value() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return []byte(p.Data()), err
+}
+
+func (s RwFile_setXattr_Params) HasValue() bool {
+	return s.Struct.HasPtr(1)
+}
+
+func (s RwFile_setXattr_Params) SetValue(v []byte) error {
+	return s.Struct.SetData(1, v)
+}
+
+// RwFile_setXattr_Params_List is a list of RwFile_setXattr_Params.
+type RwFile_setXattr_Params_List struct{ capnp.List }
+
+// NewRwFile_setXattr_Params creates a new list of RwFile_setXattr_Params.
+func NewRwFile_setXattr_Params_List(s *capnp.Segment, sz int32) (RwFile_setXattr_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2}, sz)
+	return RwFile_setXattr_Params_List{l}, err
+}
+
+func (s RwFile_setXattr_Params_List) At(i int) RwFile_setXattr_Params {
+	return RwFile_setXattr_Params{s.List.Struct(i)}
+}
+
+func (s RwFile_setXattr_Params_List) Set(i int, v RwFile_setXattr_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_setXattr_Params_List) String() string {
+	str, _ := text.MarshalList(0xe4de233468ba1a29, s.List)
+	return str
+}
+
+// RwFile_setXattr_Params_Future is a wrapper for a RwFile_setXattr_Params promised by a client call.
+type RwFile_setXattr_Params_Future struct{ *capnp.Future }
+
+func (p RwFile_setXattr_Params_Future) Struct() (RwFile_setXattr_Params, error) {
+	s, err := p.Future.Struct()
+	return RwFile_setXattr_Params{s}, err
+}
+
+type RwFile_setXattr_Results struct{ capnp.Struct }
+
+// RwFile_setXattr_Results_TypeID is the unique identifier for the type RwFile_setXattr_Results.
+const RwFile_setXattr_Results_TypeID = 0xd78ceed755f2c228
+
+func NewRwFile_setXattr_Results(s *capnp.Segment) (RwFile_setXattr_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_setXattr_Results{st}, err
+}
+
+func NewRootRwFile_setXattr_Results(s *capnp.Segment) (RwFile_setXattr_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_setXattr_Results{st}, err
+}
+
+func ReadRootRwFile_setXattr_Results(msg *capnp.Message) (RwFile_setXattr_Results, error) {
+	root, err := msg.Root()
+	return RwFile_setXattr_Results{root.Struct()}, err
+}
+
+func (s RwFile_setXattr_Results) String() string {
+	str, _ := text.Marshal(0xd78ceed755f2c228, s.Struct)
+	return str
+}
+
+// RwFile_setXattr_Results_List is a list of RwFile_setXattr_Results.
+type RwFile_setXattr_Results_List struct{ capnp.List }
+
+// NewRwFile_setXattr_Results creates a new list of RwFile_setXattr_Results.
+func NewRwFile_setXattr_Results_List(s *capnp.Segment, sz int32) (RwFile_setXattr_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return RwFile_setXattr_Results_List{l}, err
+}
+
+func (s RwFile_setXattr_Results_List) At(i int) RwFile_setXattr_Results {
+	return RwFile_setXattr_Results{s.List.Struct(i)}
+}
+
+func (s RwFile_setXattr_Results_List) Set(i int, v RwFile_setXattr_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_setXattr_Results_List) String() string {
+	str, _ := text.MarshalList(0xd78ceed755f2c228, s.List)
+	return str
+}
+
+// RwFile_setXattr_Results_Future is a wrapper for a RwFile_setXattr_Results promised by a client call.
+type RwFile_setXattr_Results_Future struct{ *capnp.Future }
+
+func (p RwFile_setXattr_Results_Future) Struct() (RwFile_setXattr_Results, error) {
+	s, err := p.Future.Struct()
+	return RwFile_setXattr_Results{s}, err
+}
+
+type RwFile_removeXattr_Params struct{ capnp.Struct }
+
+// RwFile_removeXattr_Params_TypeID is the unique identifier for the type RwFile_removeXattr_Params.
+const RwFile_removeXattr_Params_TypeID = 0x8ff2dca56ab721fe
+
+func NewRwFile_removeXattr_Params(s *capnp.Segment) (RwFile_removeXattr_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_removeXattr_Params{st}, err
+}
+
+func NewRootRwFile_removeXattr_Params(s *capnp.Segment) (RwFile_removeXattr_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_removeXattr_Params{st}, err
+}
+
+func ReadRootRwFile_removeXattr_Params(msg *capnp.Message) (RwFile_removeXattr_Params, error) {
+	root, err := msg.Root()
+	return RwFile_removeXattr_Params{root.Struct()}, err
+}
+
+func (s RwFile_removeXattr_Params) String() string {
+	str, _ := text.Marshal(0x8ff2dca56ab721fe, s.Struct)
+	return str
+}
+
+func (s RwFile_removeXattr_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s RwFile_removeXattr_Params) HasName() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwFile_removeXattr_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s RwFile_removeXattr_Params) SetName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+// RwFile_removeXattr_Params_List is a list of RwFile_removeXattr_Params.
+type RwFile_removeXattr_Params_List struct{ capnp.List }
+
+// NewRwFile_removeXattr_Params creates a new list of RwFile_removeXattr_Params.
+func NewRwFile_removeXattr_Params_List(s *capnp.Segment, sz int32) (RwFile_removeXattr_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return RwFile_removeXattr_Params_List{l}, err
+}
+
+func (s RwFile_removeXattr_Params_List) At(i int) RwFile_removeXattr_Params {
+	return RwFile_removeXattr_Params{s.List.Struct(i)}
+}
+
+func (s RwFile_removeXattr_Params_List) Set(i int, v RwFile_removeXattr_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_removeXattr_Params_List) String() string {
+	str, _ := text.MarshalList(0x8ff2dca56ab721fe, s.List)
+	return str
+}
+
+// RwFile_removeXattr_Params_Future is a wrapper for a RwFile_removeXattr_Params promised by a client call.
+type RwFile_removeXattr_Params_Future struct{ *capnp.Future }
+
+func (p RwFile_removeXattr_Params_Future) Struct() (RwFile_removeXattr_Params, error) {
+	s, err := p.Future.Struct()
+	return RwFile_removeXattr_Params{s}, err
+}
+
+type RwFile_removeXattr_Results struct{ capnp.Struct }
+
+// RwFile_removeXattr_Results_TypeID is the unique identifier for the type RwFile_removeXattr_Results.
+const RwFile_removeXattr_Results_TypeID = 0x9ee362728c632ff4
+
+func NewRwFile_removeXattr_Results(s *capnp.Segment) (RwFile_removeXattr_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_removeXattr_Results{st}, err
+}
+
+func NewRootRwFile_removeXattr_Results(s *capnp.Segment) (RwFile_removeXattr_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_removeXattr_Results{st}, err
+}
+
+func ReadRootRwFile_removeXattr_Results(msg *capnp.Message) (RwFile_removeXattr_Results, error) {
+	root, err := msg.Root()
+	return RwFile_removeXattr_Results{root.Struct()}, err
+}
+
+func (s RwFile_removeXattr_Results) String() string {
+	str, _ := text.Marshal(0x9ee362728c632ff4, s.Struct)
+	return str
+}
+
+// RwFile_removeXattr_Results_List is a list of RwFile_removeXattr_Results.
+type RwFile_removeXattr_Results_List struct{ capnp.List }
+
+// NewRwFile_removeXattr_Results creates a new list of RwFile_removeXattr_Results.
+func NewRwFile_removeXattr_Results_List(s *capnp.Segment, sz int32) (RwFile_removeXattr_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return RwFile_removeXattr_Results_List{l}, err
+}
+
+func (s RwFile_removeXattr_Results_List) At(i int) RwFile_removeXattr_Results {
+	return RwFile_removeXattr_Results{s.List.Struct(i)}
+}
+
+func (s RwFile_removeXattr_Results_List) Set(i int, v RwFile_removeXattr_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_removeXattr_Results_List) String() string {
+	str, _ := text.MarshalList(0x9ee362728c632ff4, s.List)
+	return str
+}
+
+// RwFile_removeXattr_Results_Future is a wrapper for a RwFile_removeXattr_Results promised by a client call.
+type RwFile_removeXattr_Results_Future struct{ *capnp.Future }
+
+func (p RwFile_removeXattr_Results_Future) Struct() (RwFile_removeXattr_Results, error) {
+	s, err := p.Future.Struct()
+	return RwFile_removeXattr_Results{s}, err
+}
+
+type RwFile_allocate_Params struct{ capnp.Struct }
+
+// RwFile_allocate_Params_TypeID is the unique identifier for the type RwFile_allocate_Params.
+const RwFile_allocate_Params_TypeID = 0x83bae5e9cc30e8cf
+
+func NewRwFile_allocate_Params(s *capnp.Segment) (RwFile_allocate_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0})
+	return RwFile_allocate_Params{st}, err
+}
+
+func NewRootRwFile_allocate_Params(s *capnp.Segment) (RwFile_allocate_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0})
+	return RwFile_allocate_Params{st}, err
+}
+
+func ReadRootRwFile_allocate_Params(msg *capnp.Message) (RwFile_allocate_Params, error) {
+	root, err := msg.Root()
+	return RwFile_allocate_Params{root.Struct()}, err
+}
+
+func (s RwFile_allocate_Params) String() string {
+	str, _ := text.Marshal(0x83bae5e9cc30e8cf, s.Struct)
+	return str
+}
+
+func (s RwFile_allocate_Params) Offset() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s RwFile_allocate_Params) SetOffset(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+func (s RwFile_allocate_Params) Length() int64 {
+	return int64(s.Struct.Uint64(8))
+}
+
+func (s RwFile_allocate_Params) SetLength(v int64) {
+	s.Struct.SetUint64(8, uint64(v))
+}
+
+// RwFile_allocate_Params_List is a list of RwFile_allocate_Params.
+type RwFile_allocate_Params_List struct{ capnp.List }
+
+// NewRwFile_allocate_Params creates a new list of RwFile_allocate_Params.
+func NewRwFile_allocate_Params_List(s *capnp.Segment, sz int32) (RwFile_allocate_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0}, sz)
+	return RwFile_allocate_Params_List{l}, err
+}
+
+func (s RwFile_allocate_Params_List) At(i int) RwFile_allocate_Params {
+	return RwFile_allocate_Params{s.List.Struct(i)}
+}
+
+func (s RwFile_allocate_Params_List) Set(i int, v RwFile_allocate_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_allocate_Params_List) String() string {
+	str, _ := text.MarshalList(0x83bae5e9cc30e8cf, s.List)
+	return str
+}
+
+// RwFile_allocate_Params_Future is a wrapper for a RwFile_allocate_Params promised by a client call.
+type RwFile_allocate_Params_Future struct{ *capnp.Future }
+
+func (p RwFile_allocate_Params_Future) Struct() (RwFile_allocate_Params, error) {
+	s, err := p.Future.Struct()
+	return RwFile_allocate_Params{s}, err
+}
+
+type RwFile_allocate_Results struct{ capnp.Struct }
+
+// RwFile_allocate_Results_TypeID is the unique identifier for the type RwFile_allocate_Results.
+const RwFile_allocate_Results_TypeID = 0xbdc211e2f867d77e
+
+func NewRwFile_allocate_Results(s *capnp.Segment) (RwFile_allocate_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_allocate_Results{st}, err
+}
+
+func NewRootRwFile_allocate_Results(s *capnp.Segment) (RwFile_allocate_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_allocate_Results{st}, err
+}
+
+func ReadRootRwFile_allocate_Results(msg *capnp.Message) (RwFile_allocate_Results, error) {
+	root, err := msg.Root()
+	return RwFile_allocate_Results{root.Struct()}, err
+}
+
+func (s RwFile_allocate_Results) String() string {
+	str, _ := text.Marshal(0xbdc211e2f867d77e, s.Struct)
+	return str
+}
+
+// RwFile_allocate_Results_List is a list of RwFile_allocate_Results.
+type RwFile_allocate_Results_List struct{ capnp.List }
+
+// NewRwFile_allocate_Results creates a new list of RwFile_allocate_Results.
+func NewRwFile_allocate_Results_List(s *capnp.Segment, sz int32) (RwFile_allocate_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return RwFile_allocate_Results_List{l}, err
+}
+
+func (s RwFile_allocate_Results_List) At(i int) RwFile_allocate_Results {
+	return RwFile_allocate_Results{s.List.Struct(i)}
+}
+
+func (s RwFile_allocate_Results_List) Set(i int, v RwFile_allocate_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_allocate_Results_List) String() string {
+	str, _ := text.MarshalList(0xbdc211e2f867d77e, s.List)
+	return str
+}
+
+// RwFile_allocate_Results_Future is a wrapper for a RwFile_allocate_Results promised by a client call.
+type RwFile_allocate_Results_Future struct{ *capnp.Future }
+
+func (p RwFile_allocate_Results_Future) Struct() (RwFile_allocate_Results, error) {
+	s, err := p.Future.Struct()
+	return RwFile_allocate_Results{s}, err
+}
+
+type RwFile_punchHole_Params struct{ capnp.Struct }
+
+// RwFile_punchHole_Params_TypeID is the unique identifier for the type RwFile_punchHole_Params.
+const RwFile_punchHole_Params_TypeID = 0xc93107a92a2d9be4
+
+func NewRwFile_punchHole_Params(s *capnp.Segment) (RwFile_punchHole_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0})
+	return RwFile_punchHole_Params{st}, err
+}
+
+func NewRootRwFile_punchHole_Params(s *capnp.Segment) (RwFile_punchHole_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0})
+	return RwFile_punchHole_Params{st}, err
+}
+
+func ReadRootRwFile_punchHole_Params(msg *capnp.Message) (RwFile_punchHole_Params, error) {
+	root, err := msg.Root()
+	return RwFile_punchHole_Params{root.Struct()}, err
+}
+
+func (s RwFile_punchHole_Params) String() string {
+	str, _ := text.Marshal(0xc93107a92a2d9be4, s.Struct)
+	return str
+}
+
+func (s RwFile_punchHole_Params) Offset() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s RwFile_punchHole_Params) SetOffset(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+func (s RwFile_punchHole_Params) Length() int64 {
+	return int64(s.Struct.Uint64(8))
+}
+
+func (s RwFile_punchHole_Params) SetLength(v int64) {
+	s.Struct.SetUint64(8, uint64(v))
+}
+
+// RwFile_punchHole_Params_List is a list of RwFile_punchHole_Params.
+type RwFile_punchHole_Params_List struct{ capnp.List }
+
+// NewRwFile_punchHole_Params creates a new list of RwFile_punchHole_Params.
+func NewRwFile_punchHole_Params_List(s *capnp.Segment, sz int32) (RwFile_punchHole_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 16, PointerCount: 0}, sz)
+	return RwFile_punchHole_Params_List{l}, err
+}
+
+func (s RwFile_punchHole_Params_List) At(i int) RwFile_punchHole_Params {
+	return RwFile_punchHole_Params{s.List.Struct(i)}
+}
+
+func (s RwFile_punchHole_Params_List) Set(i int, v RwFile_punchHole_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_punchHole_Params_List) String() string {
+	str, _ := text.MarshalList(0xc93107a92a2d9be4, s.List)
+	return str
+}
+
+// RwFile_punchHole_Params_Future is a wrapper for a RwFile_punchHole_Params promised by a client call.
+type RwFile_punchHole_Params_Future struct{ *capnp.Future }
+
+func (p RwFile_punchHole_Params_Future) Struct() (RwFile_punchHole_Params, error) {
+	s, err := p.Future.Struct()
+	return RwFile_punchHole_Params{s}, err
+}
+
+type RwFile_punchHole_Results struct{ capnp.Struct }
+
+// RwFile_punchHole_Results_TypeID is the unique identifier for the type RwFile_punchHole_Results.
+const RwFile_punchHole_Results_TypeID = 0xad44f9bbf73178d1
+
+func NewRwFile_punchHole_Results(s *capnp.Segment) (RwFile_punchHole_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_punchHole_Results{st}, err
+}
+
+func NewRootRwFile_punchHole_Results(s *capnp.Segment) (RwFile_punchHole_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return RwFile_punchHole_Results{st}, err
+}
+
+func ReadRootRwFile_punchHole_Results(msg *capnp.Message) (RwFile_punchHole_Results, error) {
+	root, err := msg.Root()
+	return RwFile_punchHole_Results{root.Struct()}, err
+}
+
+func (s RwFile_punchHole_Results) String() string {
+	str, _ := text.Marshal(0xad44f9bbf73178d1, s.Struct)
+	return str
+}
+
+// RwFile_punchHole_Results_List is a list of RwFile_punchHole_Results.
+type RwFile_punchHole_Results_List struct{ capnp.List }
+
+// NewRwFile_punchHole_Results creates a new list of RwFile_punchHole_Results.
+func NewRwFile_punchHole_Results_List(s *capnp.Segment, sz int32) (RwFile_punchHole_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return RwFile_punchHole_Results_List{l}, err
+}
+
+func (s RwFile_punchHole_Results_List) At(i int) RwFile_punchHole_Results {
+	return RwFile_punchHole_Results{s.List.Struct(i)}
+}
+
+func (s RwFile_punchHole_Results_List) Set(i int, v RwFile_punchHole_Results) error {
+	return s.List.SetStruct(i, v.Struct)
 }
 
-// File_NewServer creates a new Server from an implementation of File_Server.
-func File_NewServer(s File_Server, policy *server.Policy) *server.Server {
-	c, _ := s.(server.Shutdowner)
-	return server.New(File_Methods(nil, s), s, c, policy)
+func (s RwFile_punchHole_Results_List) String() string {
+	str, _ := text.MarshalList(0xad44f9bbf73178d1, s.List)
+	return str
 }
 
-// File_ServerToClient creates a new Client from an implementation of File_Server.
-// The caller is responsible for calling Release on the returned Client.
-func File_ServerToClient(s File_Server, policy *server.Policy) File {
-	return File{Client: capnp.NewClient(File_NewServer(s, policy))}
+// RwFile_punchHole_Results_Future is a wrapper for a RwFile_punchHole_Results promised by a client call.
+type RwFile_punchHole_Results_Future struct{ *capnp.Future }
+
+func (p RwFile_punchHole_Results_Future) Struct() (RwFile_punchHole_Results, error) {
+	s, err := p.Future.Struct()
+	return RwFile_punchHole_Results{s}, err
 }
 
-// File_Methods appends Methods to a slice that invoke the methods on s.
-// This can be used to create a more complicated Server.
-func File_Methods(methods []server.Method, s File_Server) []server.Method {
-	if cap(methods) == 0 {
-		methods = make([]server.Method, 0, 2)
-	}
+type RwFile_lock_Params struct{ capnp.Struct }
 
-	methods = append(methods, server.Method{
-		Method: capnp.Method{
-			InterfaceID:   0xaa5b133d60884bbd,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:File",
-			MethodName:    "read",
-		},
-		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Read(ctx, File_read{call})
-		},
-	})
+// RwFile_lock_Params_TypeID is the unique identifier for the type RwFile_lock_Params.
+const RwFile_lock_Params_TypeID = 0xef08d6153b1b1f3f
 
-	methods = append(methods, server.Method{
-		Method: capnp.Method{
-			InterfaceID:   0x955400781a01b061,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:Node",
-			MethodName:    "stat",
-		},
-		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Stat(ctx, Node_stat{call})
-		},
-	})
+func NewRwFile_lock_Params(s *capnp.Segment) (RwFile_lock_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return RwFile_lock_Params{st}, err
+}
 
-	return methods
+func NewRootRwFile_lock_Params(s *capnp.Segment) (RwFile_lock_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return RwFile_lock_Params{st}, err
 }
 
-// File_read holds the state for a server call to File.read.
-// See server.Call for documentation.
-type File_read struct {
-	*server.Call
+func ReadRootRwFile_lock_Params(msg *capnp.Message) (RwFile_lock_Params, error) {
+	root, err := msg.Root()
+	return RwFile_lock_Params{root.Struct()}, err
 }
 
-// Args returns the call's arguments.
-func (c File_read) Args() File_read_Params {
-	return File_read_Params{Struct: c.Call.Args()}
+func (s RwFile_lock_Params) String() string {
+	str, _ := text.Marshal(0xef08d6153b1b1f3f, s.Struct)
+	return str
 }
 
-// AllocResults allocates the results struct.
-func (c File_read) AllocResults() (File_read_Results, error) {
-	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return File_read_Results{Struct: r}, err
+func (s RwFile_lock_Params) Exclusive() bool {
+	return s.Struct.Bit(0)
 }
 
-type File_read_Params struct{ capnp.Struct }
+func (s RwFile_lock_Params) SetExclusive(v bool) {
+	s.Struct.SetBit(0, v)
+}
 
-// File_read_Params_TypeID is the unique identifier for the type File_read_Params.
-const File_read_Params_TypeID = 0xfb1101f5d0d1edeb
+// RwFile_lock_Params_List is a list of RwFile_lock_Params.
+type RwFile_lock_Params_List struct{ capnp.List }
 
-func NewFile_read_Params(s *capnp.Segment) (File_read_Params, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
-	return File_read_Params{st}, err
+// NewRwFile_lock_Params creates a new list of RwFile_lock_Params.
+func NewRwFile_lock_Params_List(s *capnp.Segment, sz int32) (RwFile_lock_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
+	return RwFile_lock_Params_List{l}, err
 }
 
-func NewRootFile_read_Params(s *capnp.Segment) (File_read_Params, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
-	return File_read_Params{st}, err
+func (s RwFile_lock_Params_List) At(i int) RwFile_lock_Params {
+	return RwFile_lock_Params{s.List.Struct(i)}
 }
 
-func ReadRootFile_read_Params(msg *capnp.Message) (File_read_Params, error) {
-	root, err := msg.Root()
-	return File_read_Params{root.Struct()}, err
+func (s RwFile_lock_Params_List) Set(i int, v RwFile_lock_Params) error {
+	return s.List.SetStruct(i, v.Struct)
 }
 
-func (s File_read_Params) String() string {
-	str, _ := text.Marshal(0xfb1101f5d0d1edeb, s.Struct)
+func (s RwFile_lock_Params_List) String() string {
+	str, _ := text.MarshalList(0xef08d6153b1b1f3f, s.List)
 	return str
 }
 
-func (s File_read_Params) StartAt() int64 {
-	return int64(s.Struct.Uint64(0))
+// RwFile_lock_Params_Future is a wrapper for a RwFile_lock_Params promised by a client call.
+type RwFile_lock_Params_Future struct{ *capnp.Future }
+
+func (p RwFile_lock_Params_Future) Struct() (RwFile_lock_Params, error) {
+	s, err := p.Future.Struct()
+	return RwFile_lock_Params{s}, err
 }
 
-func (s File_read_Params) SetStartAt(v int64) {
-	s.Struct.SetUint64(0, uint64(v))
+type RwFile_lock_Results struct{ capnp.Struct }
+
+// RwFile_lock_Results_TypeID is the unique identifier for the type RwFile_lock_Results.
+const RwFile_lock_Results_TypeID = 0xd84b93b4f266b64f
+
+func NewRwFile_lock_Results(s *capnp.Segment) (RwFile_lock_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_lock_Results{st}, err
 }
 
-func (s File_read_Params) Amount() uint64 {
-	return s.Struct.Uint64(8)
+func NewRootRwFile_lock_Results(s *capnp.Segment) (RwFile_lock_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_lock_Results{st}, err
 }
 
-func (s File_read_Params) SetAmount(v uint64) {
-	s.Struct.SetUint64(8, v)
+func ReadRootRwFile_lock_Results(msg *capnp.Message) (RwFile_lock_Results, error) {
+	root, err := msg.Root()
+	return RwFile_lock_Results{root.Struct()}, err
 }
 
-func (s File_read_Params) Sink() util.ByteStream {
+func (s RwFile_lock_Results) String() string {
+	str, _ := text.Marshal(0xd84b93b4f266b64f, s.Struct)
+	return str
+}
+
+func (s RwFile_lock_Results) Handle() LockHandle {
 	p, _ := s.Struct.Ptr(0)
-	return util.ByteStream{Client: p.Interface().Client()}
+	return LockHandle{Client: p.Interface().Client()}
 }
 
-func (s File_read_Params) HasSink() bool {
+func (s RwFile_lock_Results) HasHandle() bool {
 	return s.Struct.HasPtr(0)
 }
 
-func (s File_read_Params) SetSink(v util.ByteStream) error {
+func (s RwFile_lock_Results) SetHandle(v LockHandle) error {
 	if !v.Client.IsValid() {
 		return s.Struct.SetPtr(0, capnp.Ptr{})
 	}
@@ -2114,163 +8162,298 @@ func (s File_read_Params) SetSink(v util.ByteStream) error {
 	return s.Struct.SetPtr(0, in.ToPtr())
 }
 
-// File_read_Params_List is a list of File_read_Params.
-type File_read_Params_List struct{ capnp.List }
+// RwFile_lock_Results_List is a list of RwFile_lock_Results.
+type RwFile_lock_Results_List struct{ capnp.List }
 
-// NewFile_read_Params creates a new list of File_read_Params.
-func NewFile_read_Params_List(s *capnp.Segment, sz int32) (File_read_Params_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 16, PointerCount: 1}, sz)
-	return File_read_Params_List{l}, err
+// NewRwFile_lock_Results creates a new list of RwFile_lock_Results.
+func NewRwFile_lock_Results_List(s *capnp.Segment, sz int32) (RwFile_lock_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return RwFile_lock_Results_List{l}, err
 }
 
-func (s File_read_Params_List) At(i int) File_read_Params { return File_read_Params{s.List.Struct(i)} }
+func (s RwFile_lock_Results_List) At(i int) RwFile_lock_Results {
+	return RwFile_lock_Results{s.List.Struct(i)}
+}
 
-func (s File_read_Params_List) Set(i int, v File_read_Params) error {
+func (s RwFile_lock_Results_List) Set(i int, v RwFile_lock_Results) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-func (s File_read_Params_List) String() string {
-	str, _ := text.MarshalList(0xfb1101f5d0d1edeb, s.List)
+func (s RwFile_lock_Results_List) String() string {
+	str, _ := text.MarshalList(0xd84b93b4f266b64f, s.List)
 	return str
 }
 
-// File_read_Params_Future is a wrapper for a File_read_Params promised by a client call.
-type File_read_Params_Future struct{ *capnp.Future }
+// RwFile_lock_Results_Future is a wrapper for a RwFile_lock_Results promised by a client call.
+type RwFile_lock_Results_Future struct{ *capnp.Future }
 
-func (p File_read_Params_Future) Struct() (File_read_Params, error) {
+func (p RwFile_lock_Results_Future) Struct() (RwFile_lock_Results, error) {
 	s, err := p.Future.Struct()
-	return File_read_Params{s}, err
+	return RwFile_lock_Results{s}, err
 }
 
-func (p File_read_Params_Future) Sink() util.ByteStream {
-	return util.ByteStream{Client: p.Future.Field(0, nil).Client()}
+func (p RwFile_lock_Results_Future) Handle() LockHandle {
+	return LockHandle{Client: p.Future.Field(0, nil).Client()}
 }
 
-type File_read_Results struct{ capnp.Struct }
+type RwFile_tryLock_Params struct{ capnp.Struct }
 
-// File_read_Results_TypeID is the unique identifier for the type File_read_Results.
-const File_read_Results_TypeID = 0xd6e8aca7864c2c0a
+// RwFile_tryLock_Params_TypeID is the unique identifier for the type RwFile_tryLock_Params.
+const RwFile_tryLock_Params_TypeID = 0xb17baf2a44c47306
 
-func NewFile_read_Results(s *capnp.Segment) (File_read_Results, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return File_read_Results{st}, err
+func NewRwFile_tryLock_Params(s *capnp.Segment) (RwFile_tryLock_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return RwFile_tryLock_Params{st}, err
 }
 
-func NewRootFile_read_Results(s *capnp.Segment) (File_read_Results, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return File_read_Results{st}, err
+func NewRootRwFile_tryLock_Params(s *capnp.Segment) (RwFile_tryLock_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
+	return RwFile_tryLock_Params{st}, err
 }
 
-func ReadRootFile_read_Results(msg *capnp.Message) (File_read_Results, error) {
+func ReadRootRwFile_tryLock_Params(msg *capnp.Message) (RwFile_tryLock_Params, error) {
 	root, err := msg.Root()
-	return File_read_Results{root.Struct()}, err
+	return RwFile_tryLock_Params{root.Struct()}, err
 }
 
-func (s File_read_Results) String() string {
-	str, _ := text.Marshal(0xd6e8aca7864c2c0a, s.Struct)
+func (s RwFile_tryLock_Params) String() string {
+	str, _ := text.Marshal(0xb17baf2a44c47306, s.Struct)
 	return str
 }
 
-// File_read_Results_List is a list of File_read_Results.
-type File_read_Results_List struct{ capnp.List }
+func (s RwFile_tryLock_Params) Exclusive() bool {
+	return s.Struct.Bit(0)
+}
 
-// NewFile_read_Results creates a new list of File_read_Results.
-func NewFile_read_Results_List(s *capnp.Segment, sz int32) (File_read_Results_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
-	return File_read_Results_List{l}, err
+func (s RwFile_tryLock_Params) SetExclusive(v bool) {
+	s.Struct.SetBit(0, v)
 }
 
-func (s File_read_Results_List) At(i int) File_read_Results {
-	return File_read_Results{s.List.Struct(i)}
+// RwFile_tryLock_Params_List is a list of RwFile_tryLock_Params.
+type RwFile_tryLock_Params_List struct{ capnp.List }
+
+// NewRwFile_tryLock_Params creates a new list of RwFile_tryLock_Params.
+func NewRwFile_tryLock_Params_List(s *capnp.Segment, sz int32) (RwFile_tryLock_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
+	return RwFile_tryLock_Params_List{l}, err
 }
 
-func (s File_read_Results_List) Set(i int, v File_read_Results) error {
+func (s RwFile_tryLock_Params_List) At(i int) RwFile_tryLock_Params {
+	return RwFile_tryLock_Params{s.List.Struct(i)}
+}
+
+func (s RwFile_tryLock_Params_List) Set(i int, v RwFile_tryLock_Params) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-func (s File_read_Results_List) String() string {
-	str, _ := text.MarshalList(0xd6e8aca7864c2c0a, s.List)
+func (s RwFile_tryLock_Params_List) String() string {
+	str, _ := text.MarshalList(0xb17baf2a44c47306, s.List)
 	return str
 }
 
-// File_read_Results_Future is a wrapper for a File_read_Results promised by a client call.
-type File_read_Results_Future struct{ *capnp.Future }
+// RwFile_tryLock_Params_Future is a wrapper for a RwFile_tryLock_Params promised by a client call.
+type RwFile_tryLock_Params_Future struct{ *capnp.Future }
 
-func (p File_read_Results_Future) Struct() (File_read_Results, error) {
+func (p RwFile_tryLock_Params_Future) Struct() (RwFile_tryLock_Params, error) {
 	s, err := p.Future.Struct()
-	return File_read_Results{s}, err
+	return RwFile_tryLock_Params{s}, err
 }
 
-type RwFile struct{ Client *capnp.Client }
+type RwFile_tryLock_Results struct{ capnp.Struct }
 
-// RwFile_TypeID is the unique identifier for the type RwFile.
-const RwFile_TypeID = 0xb4810121539f6e53
+// RwFile_tryLock_Results_TypeID is the unique identifier for the type RwFile_tryLock_Results.
+const RwFile_tryLock_Results_TypeID = 0xac70df5a8141653c
 
-func (c RwFile) Write(ctx context.Context, params func(RwFile_write_Params) error) (RwFile_write_Results_Future, capnp.ReleaseFunc) {
+func NewRwFile_tryLock_Results(s *capnp.Segment) (RwFile_tryLock_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_tryLock_Results{st}, err
+}
+
+func NewRootRwFile_tryLock_Results(s *capnp.Segment) (RwFile_tryLock_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return RwFile_tryLock_Results{st}, err
+}
+
+func ReadRootRwFile_tryLock_Results(msg *capnp.Message) (RwFile_tryLock_Results, error) {
+	root, err := msg.Root()
+	return RwFile_tryLock_Results{root.Struct()}, err
+}
+
+func (s RwFile_tryLock_Results) String() string {
+	str, _ := text.Marshal(0xac70df5a8141653c, s.Struct)
+	return str
+}
+
+func (s RwFile_tryLock_Results) Handle() LockHandle {
+	p, _ := s.Struct.Ptr(0)
+	return LockHandle{Client: p.Interface().Client()}
+}
+
+func (s RwFile_tryLock_Results) HasHandle() bool {
+	return s.Struct.HasPtr(0)
+}
+
+func (s RwFile_tryLock_Results) SetHandle(v LockHandle) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
+}
+
+// RwFile_tryLock_Results_List is a list of RwFile_tryLock_Results.
+type RwFile_tryLock_Results_List struct{ capnp.List }
+
+// NewRwFile_tryLock_Results creates a new list of RwFile_tryLock_Results.
+func NewRwFile_tryLock_Results_List(s *capnp.Segment, sz int32) (RwFile_tryLock_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return RwFile_tryLock_Results_List{l}, err
+}
+
+func (s RwFile_tryLock_Results_List) At(i int) RwFile_tryLock_Results {
+	return RwFile_tryLock_Results{s.List.Struct(i)}
+}
+
+func (s RwFile_tryLock_Results_List) Set(i int, v RwFile_tryLock_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+func (s RwFile_tryLock_Results_List) String() string {
+	str, _ := text.MarshalList(0xac70df5a8141653c, s.List)
+	return str
+}
+
+// RwFile_tryLock_Results_Future is a wrapper for a RwFile_tryLock_Results promised by a client call.
+type RwFile_tryLock_Results_Future struct{ *capnp.Future }
+
+func (p RwFile_tryLock_Results_Future) Struct() (RwFile_tryLock_Results, error) {
+	s, err := p.Future.Struct()
+	return RwFile_tryLock_Results{s}, err
+}
+
+func (p RwFile_tryLock_Results_Future) Handle() LockHandle {
+	return LockHandle{Client: p.Future.Field(0, nil).Client()}
+}
+
+type AppendOnlyFile struct{ Client *capnp.Client }
+
+// AppendOnlyFile_TypeID is the unique identifier for the type AppendOnlyFile.
+const AppendOnlyFile_TypeID = 0xebb70318b85ece31
+
+func (c AppendOnlyFile) Append(ctx context.Context, params func(AppendOnlyFile_append_Params) error) (AppendOnlyFile_append_Results_Future, capnp.ReleaseFunc) {
 	s := capnp.Send{
 		Method: capnp.Method{
-			InterfaceID:   0xb4810121539f6e53,
+			InterfaceID:   0xebb70318b85ece31,
 			MethodID:      0,
-			InterfaceName: "filesystem.capnp:RwFile",
-			MethodName:    "write",
+			InterfaceName: "filesystem.capnp:AppendOnlyFile",
+			MethodName:    "append",
 		},
 	}
 	if params != nil {
-		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_write_Params{Struct: s}) }
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(AppendOnlyFile_append_Params{Struct: s}) }
 	}
 	ans, release := c.Client.SendCall(ctx, s)
-	return RwFile_write_Results_Future{Future: ans.Future()}, release
+	return AppendOnlyFile_append_Results_Future{Future: ans.Future()}, release
 }
-func (c RwFile) Truncate(ctx context.Context, params func(RwFile_truncate_Params) error) (RwFile_truncate_Results_Future, capnp.ReleaseFunc) {
+func (c AppendOnlyFile) Read(ctx context.Context, params func(File_read_Params) error) (File_read_Results_Future, capnp.ReleaseFunc) {
 	s := capnp.Send{
 		Method: capnp.Method{
-			InterfaceID:   0xb4810121539f6e53,
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "read",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_read_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_read_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyFile) ReadBytes(ctx context.Context, params func(File_readBytes_Params) error) (File_readBytes_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
 			MethodID:      1,
-			InterfaceName: "filesystem.capnp:RwFile",
-			MethodName:    "truncate",
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "readBytes",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_readBytes_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_readBytes_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyFile) Hash(ctx context.Context, params func(File_hash_Params) error) (File_hash_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "hash",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 24, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_hash_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return File_hash_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyFile) SeekData(ctx context.Context, params func(File_seekData_Params) error) (File_seekData_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekData",
 		},
 	}
 	if params != nil {
 		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_truncate_Params{Struct: s}) }
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_seekData_Params{Struct: s}) }
 	}
 	ans, release := c.Client.SendCall(ctx, s)
-	return RwFile_truncate_Results_Future{Future: ans.Future()}, release
+	return File_seekData_Results_Future{Future: ans.Future()}, release
 }
-func (c RwFile) SetExec(ctx context.Context, params func(RwFile_setExec_Params) error) (RwFile_setExec_Results_Future, capnp.ReleaseFunc) {
+func (c AppendOnlyFile) SeekHole(ctx context.Context, params func(File_seekHole_Params) error) (File_seekHole_Results_Future, capnp.ReleaseFunc) {
 	s := capnp.Send{
 		Method: capnp.Method{
-			InterfaceID:   0xb4810121539f6e53,
-			MethodID:      2,
-			InterfaceName: "filesystem.capnp:RwFile",
-			MethodName:    "setExec",
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      4,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekHole",
 		},
 	}
 	if params != nil {
 		s.ArgsSize = capnp.ObjectSize{DataSize: 8, PointerCount: 0}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(RwFile_setExec_Params{Struct: s}) }
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_seekHole_Params{Struct: s}) }
 	}
 	ans, release := c.Client.SendCall(ctx, s)
-	return RwFile_setExec_Results_Future{Future: ans.Future()}, release
+	return File_seekHole_Results_Future{Future: ans.Future()}, release
 }
-func (c RwFile) Read(ctx context.Context, params func(File_read_Params) error) (File_read_Results_Future, capnp.ReleaseFunc) {
+func (c AppendOnlyFile) Versions(ctx context.Context, params func(File_versions_Params) error) (File_versions_Results_Future, capnp.ReleaseFunc) {
 	s := capnp.Send{
 		Method: capnp.Method{
 			InterfaceID:   0xaa5b133d60884bbd,
-			MethodID:      0,
+			MethodID:      5,
 			InterfaceName: "filesystem.capnp:File",
-			MethodName:    "read",
+			MethodName:    "versions",
 		},
 	}
 	if params != nil {
-		s.ArgsSize = capnp.ObjectSize{DataSize: 16, PointerCount: 1}
-		s.PlaceArgs = func(s capnp.Struct) error { return params(File_read_Params{Struct: s}) }
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(File_versions_Params{Struct: s}) }
 	}
 	ans, release := c.Client.SendCall(ctx, s)
-	return File_read_Results_Future{Future: ans.Future()}, release
+	return File_versions_Results_Future{Future: ans.Future()}, release
 }
-func (c RwFile) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
+func (c AppendOnlyFile) Stat(ctx context.Context, params func(Node_stat_Params) error) (Node_stat_Results_Future, capnp.ReleaseFunc) {
 	s := capnp.Send{
 		Method: capnp.Method{
 			InterfaceID:   0x955400781a01b061,
@@ -2286,527 +8469,508 @@ func (c RwFile) Stat(ctx context.Context, params func(Node_stat_Params) error) (
 	ans, release := c.Client.SendCall(ctx, s)
 	return Node_stat_Results_Future{Future: ans.Future()}, release
 }
+func (c AppendOnlyFile) GetXattr(ctx context.Context, params func(Node_getXattr_Params) error) (Node_getXattr_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_getXattr_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_getXattr_Results_Future{Future: ans.Future()}, release
+}
+func (c AppendOnlyFile) ListXattrs(ctx context.Context, params func(Node_listXattrs_Params) error) (Node_listXattrs_Results_Future, capnp.ReleaseFunc) {
+	s := capnp.Send{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+	}
+	if params != nil {
+		s.ArgsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		s.PlaceArgs = func(s capnp.Struct) error { return params(Node_listXattrs_Params{Struct: s}) }
+	}
+	ans, release := c.Client.SendCall(ctx, s)
+	return Node_listXattrs_Results_Future{Future: ans.Future()}, release
+}
 
-// A RwFile_Server is a RwFile with a local implementation.
-type RwFile_Server interface {
-	Write(context.Context, RwFile_write) error
+// A AppendOnlyFile_Server is a AppendOnlyFile with a local implementation.
+type AppendOnlyFile_Server interface {
+	Append(context.Context, AppendOnlyFile_append) error
 
-	Truncate(context.Context, RwFile_truncate) error
+	Read(context.Context, File_read) error
 
-	SetExec(context.Context, RwFile_setExec) error
+	ReadBytes(context.Context, File_readBytes) error
 
-	Read(context.Context, File_read) error
+	Hash(context.Context, File_hash) error
+
+	SeekData(context.Context, File_seekData) error
+
+	SeekHole(context.Context, File_seekHole) error
+
+	Versions(context.Context, File_versions) error
 
 	Stat(context.Context, Node_stat) error
+
+	GetXattr(context.Context, Node_getXattr) error
+
+	ListXattrs(context.Context, Node_listXattrs) error
 }
 
-// RwFile_NewServer creates a new Server from an implementation of RwFile_Server.
-func RwFile_NewServer(s RwFile_Server, policy *server.Policy) *server.Server {
+// AppendOnlyFile_NewServer creates a new Server from an implementation of AppendOnlyFile_Server.
+func AppendOnlyFile_NewServer(s AppendOnlyFile_Server, policy *server.Policy) *server.Server {
 	c, _ := s.(server.Shutdowner)
-	return server.New(RwFile_Methods(nil, s), s, c, policy)
+	return server.New(AppendOnlyFile_Methods(nil, s), s, c, policy)
 }
 
-// RwFile_ServerToClient creates a new Client from an implementation of RwFile_Server.
+// AppendOnlyFile_ServerToClient creates a new Client from an implementation of AppendOnlyFile_Server.
 // The caller is responsible for calling Release on the returned Client.
-func RwFile_ServerToClient(s RwFile_Server, policy *server.Policy) RwFile {
-	return RwFile{Client: capnp.NewClient(RwFile_NewServer(s, policy))}
+func AppendOnlyFile_ServerToClient(s AppendOnlyFile_Server, policy *server.Policy) AppendOnlyFile {
+	return AppendOnlyFile{Client: capnp.NewClient(AppendOnlyFile_NewServer(s, policy))}
 }
 
-// RwFile_Methods appends Methods to a slice that invoke the methods on s.
+// AppendOnlyFile_Methods appends Methods to a slice that invoke the methods on s.
 // This can be used to create a more complicated Server.
-func RwFile_Methods(methods []server.Method, s RwFile_Server) []server.Method {
+func AppendOnlyFile_Methods(methods []server.Method, s AppendOnlyFile_Server) []server.Method {
 	if cap(methods) == 0 {
-		methods = make([]server.Method, 0, 5)
+		methods = make([]server.Method, 0, 10)
 	}
 
 	methods = append(methods, server.Method{
 		Method: capnp.Method{
-			InterfaceID:   0xb4810121539f6e53,
+			InterfaceID:   0xebb70318b85ece31,
 			MethodID:      0,
-			InterfaceName: "filesystem.capnp:RwFile",
-			MethodName:    "write",
+			InterfaceName: "filesystem.capnp:AppendOnlyFile",
+			MethodName:    "append",
 		},
 		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Write(ctx, RwFile_write{call})
+			return s.Append(ctx, AppendOnlyFile_append{call})
 		},
 	})
 
 	methods = append(methods, server.Method{
 		Method: capnp.Method{
-			InterfaceID:   0xb4810121539f6e53,
-			MethodID:      1,
-			InterfaceName: "filesystem.capnp:RwFile",
-			MethodName:    "truncate",
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "read",
 		},
 		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Truncate(ctx, RwFile_truncate{call})
+			return s.Read(ctx, File_read{call})
 		},
 	})
 
 	methods = append(methods, server.Method{
 		Method: capnp.Method{
-			InterfaceID:   0xb4810121539f6e53,
-			MethodID:      2,
-			InterfaceName: "filesystem.capnp:RwFile",
-			MethodName:    "setExec",
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "readBytes",
 		},
 		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.SetExec(ctx, RwFile_setExec{call})
+			return s.ReadBytes(ctx, File_readBytes{call})
 		},
 	})
 
 	methods = append(methods, server.Method{
 		Method: capnp.Method{
 			InterfaceID:   0xaa5b133d60884bbd,
-			MethodID:      0,
+			MethodID:      2,
 			InterfaceName: "filesystem.capnp:File",
-			MethodName:    "read",
+			MethodName:    "hash",
 		},
 		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Read(ctx, File_read{call})
+			return s.Hash(ctx, File_hash{call})
 		},
 	})
 
 	methods = append(methods, server.Method{
 		Method: capnp.Method{
-			InterfaceID:   0x955400781a01b061,
-			MethodID:      0,
-			InterfaceName: "filesystem.capnp:Node",
-			MethodName:    "stat",
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      3,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekData",
 		},
 		Impl: func(ctx context.Context, call *server.Call) error {
-			return s.Stat(ctx, Node_stat{call})
+			return s.SeekData(ctx, File_seekData{call})
 		},
 	})
 
-	return methods
-}
-
-// RwFile_write holds the state for a server call to RwFile.write.
-// See server.Call for documentation.
-type RwFile_write struct {
-	*server.Call
-}
-
-// Args returns the call's arguments.
-func (c RwFile_write) Args() RwFile_write_Params {
-	return RwFile_write_Params{Struct: c.Call.Args()}
-}
-
-// AllocResults allocates the results struct.
-func (c RwFile_write) AllocResults() (RwFile_write_Results, error) {
-	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwFile_write_Results{Struct: r}, err
-}
-
-// RwFile_truncate holds the state for a server call to RwFile.truncate.
-// See server.Call for documentation.
-type RwFile_truncate struct {
-	*server.Call
-}
-
-// Args returns the call's arguments.
-func (c RwFile_truncate) Args() RwFile_truncate_Params {
-	return RwFile_truncate_Params{Struct: c.Call.Args()}
-}
-
-// AllocResults allocates the results struct.
-func (c RwFile_truncate) AllocResults() (RwFile_truncate_Results, error) {
-	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return RwFile_truncate_Results{Struct: r}, err
-}
-
-// RwFile_setExec holds the state for a server call to RwFile.setExec.
-// See server.Call for documentation.
-type RwFile_setExec struct {
-	*server.Call
-}
-
-// Args returns the call's arguments.
-func (c RwFile_setExec) Args() RwFile_setExec_Params {
-	return RwFile_setExec_Params{Struct: c.Call.Args()}
-}
-
-// AllocResults allocates the results struct.
-func (c RwFile_setExec) AllocResults() (RwFile_setExec_Results, error) {
-	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return RwFile_setExec_Results{Struct: r}, err
-}
-
-type RwFile_write_Params struct{ capnp.Struct }
-
-// RwFile_write_Params_TypeID is the unique identifier for the type RwFile_write_Params.
-const RwFile_write_Params_TypeID = 0xec401fdf2c149f1b
-
-func NewRwFile_write_Params(s *capnp.Segment) (RwFile_write_Params, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
-	return RwFile_write_Params{st}, err
-}
-
-func NewRootRwFile_write_Params(s *capnp.Segment) (RwFile_write_Params, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
-	return RwFile_write_Params{st}, err
-}
-
-func ReadRootRwFile_write_Params(msg *capnp.Message) (RwFile_write_Params, error) {
-	root, err := msg.Root()
-	return RwFile_write_Params{root.Struct()}, err
-}
-
-func (s RwFile_write_Params) String() string {
-	str, _ := text.Marshal(0xec401fdf2c149f1b, s.Struct)
-	return str
-}
-
-func (s RwFile_write_Params) StartAt() int64 {
-	return int64(s.Struct.Uint64(0))
-}
-
-func (s RwFile_write_Params) SetStartAt(v int64) {
-	s.Struct.SetUint64(0, uint64(v))
-}
-
-// RwFile_write_Params_List is a list of RwFile_write_Params.
-type RwFile_write_Params_List struct{ capnp.List }
-
-// NewRwFile_write_Params creates a new list of RwFile_write_Params.
-func NewRwFile_write_Params_List(s *capnp.Segment, sz int32) (RwFile_write_Params_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
-	return RwFile_write_Params_List{l}, err
-}
-
-func (s RwFile_write_Params_List) At(i int) RwFile_write_Params {
-	return RwFile_write_Params{s.List.Struct(i)}
-}
-
-func (s RwFile_write_Params_List) Set(i int, v RwFile_write_Params) error {
-	return s.List.SetStruct(i, v.Struct)
-}
-
-func (s RwFile_write_Params_List) String() string {
-	str, _ := text.MarshalList(0xec401fdf2c149f1b, s.List)
-	return str
-}
-
-// RwFile_write_Params_Future is a wrapper for a RwFile_write_Params promised by a client call.
-type RwFile_write_Params_Future struct{ *capnp.Future }
-
-func (p RwFile_write_Params_Future) Struct() (RwFile_write_Params, error) {
-	s, err := p.Future.Struct()
-	return RwFile_write_Params{s}, err
-}
-
-type RwFile_write_Results struct{ capnp.Struct }
-
-// RwFile_write_Results_TypeID is the unique identifier for the type RwFile_write_Results.
-const RwFile_write_Results_TypeID = 0x83db8ff5946e5b09
-
-func NewRwFile_write_Results(s *capnp.Segment) (RwFile_write_Results, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwFile_write_Results{st}, err
-}
-
-func NewRootRwFile_write_Results(s *capnp.Segment) (RwFile_write_Results, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return RwFile_write_Results{st}, err
-}
-
-func ReadRootRwFile_write_Results(msg *capnp.Message) (RwFile_write_Results, error) {
-	root, err := msg.Root()
-	return RwFile_write_Results{root.Struct()}, err
-}
-
-func (s RwFile_write_Results) String() string {
-	str, _ := text.Marshal(0x83db8ff5946e5b09, s.Struct)
-	return str
-}
-
-func (s RwFile_write_Results) Sink() util.ByteStream {
-	p, _ := s.Struct.Ptr(0)
-	return util.ByteStream{Client: p.Interface().Client()}
-}
-
-func (s RwFile_write_Results) HasSink() bool {
-	return s.Struct.HasPtr(0)
-}
-
-func (s RwFile_write_Results) SetSink(v util.ByteStream) error {
-	if !v.Client.IsValid() {
-		return s.Struct.SetPtr(0, capnp.Ptr{})
-	}
-	seg := s.Segment()
-	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
-	return s.Struct.SetPtr(0, in.ToPtr())
-}
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      4,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "seekHole",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.SeekHole(ctx, File_seekHole{call})
+		},
+	})
 
-// RwFile_write_Results_List is a list of RwFile_write_Results.
-type RwFile_write_Results_List struct{ capnp.List }
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xaa5b133d60884bbd,
+			MethodID:      5,
+			InterfaceName: "filesystem.capnp:File",
+			MethodName:    "versions",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Versions(ctx, File_versions{call})
+		},
+	})
 
-// NewRwFile_write_Results creates a new list of RwFile_write_Results.
-func NewRwFile_write_Results_List(s *capnp.Segment, sz int32) (RwFile_write_Results_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
-	return RwFile_write_Results_List{l}, err
-}
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      0,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "stat",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.Stat(ctx, Node_stat{call})
+		},
+	})
 
-func (s RwFile_write_Results_List) At(i int) RwFile_write_Results {
-	return RwFile_write_Results{s.List.Struct(i)}
-}
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      1,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "getXattr",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.GetXattr(ctx, Node_getXattr{call})
+		},
+	})
 
-func (s RwFile_write_Results_List) Set(i int, v RwFile_write_Results) error {
-	return s.List.SetStruct(i, v.Struct)
-}
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x955400781a01b061,
+			MethodID:      2,
+			InterfaceName: "filesystem.capnp:Node",
+			MethodName:    "listXattrs",
+		},
+		Impl: func(ctx context.Context, call *server.Call) error {
+			return s.ListXattrs(ctx, Node_listXattrs{call})
+		},
+	})
 
-func (s RwFile_write_Results_List) String() string {
-	str, _ := text.MarshalList(0x83db8ff5946e5b09, s.List)
-	return str
+	return methods
 }
 
-// RwFile_write_Results_Future is a wrapper for a RwFile_write_Results promised by a client call.
-type RwFile_write_Results_Future struct{ *capnp.Future }
+// AppendOnlyFile_append holds the state for a server call to AppendOnlyFile.append.
+// See server.Call for documentation.
+type AppendOnlyFile_append struct {
+	*server.Call
+}
 
-func (p RwFile_write_Results_Future) Struct() (RwFile_write_Results, error) {
-	s, err := p.Future.Struct()
-	return RwFile_write_Results{s}, err
+// Args returns the call's arguments.
+func (c AppendOnlyFile_append) Args() AppendOnlyFile_append_Params {
+	return AppendOnlyFile_append_Params{Struct: c.Call.Args()}
 }
 
-func (p RwFile_write_Results_Future) Sink() util.ByteStream {
-	return util.ByteStream{Client: p.Future.Field(0, nil).Client()}
+// AllocResults allocates the results struct.
+func (c AppendOnlyFile_append) AllocResults() (AppendOnlyFile_append_Results, error) {
+	r, err := c.Call.AllocResults(capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyFile_append_Results{Struct: r}, err
 }
 
-type RwFile_truncate_Params struct{ capnp.Struct }
+type AppendOnlyFile_append_Params struct{ capnp.Struct }
 
-// RwFile_truncate_Params_TypeID is the unique identifier for the type RwFile_truncate_Params.
-const RwFile_truncate_Params_TypeID = 0xbf2ae4dc7cac598c
+// AppendOnlyFile_append_Params_TypeID is the unique identifier for the type AppendOnlyFile_append_Params.
+const AppendOnlyFile_append_Params_TypeID = 0xaa5cf6dd73f5aee9
 
-func NewRwFile_truncate_Params(s *capnp.Segment) (RwFile_truncate_Params, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
-	return RwFile_truncate_Params{st}, err
+func NewAppendOnlyFile_append_Params(s *capnp.Segment) (AppendOnlyFile_append_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return AppendOnlyFile_append_Params{st}, err
 }
 
-func NewRootRwFile_truncate_Params(s *capnp.Segment) (RwFile_truncate_Params, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
-	return RwFile_truncate_Params{st}, err
+func NewRootAppendOnlyFile_append_Params(s *capnp.Segment) (AppendOnlyFile_append_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return AppendOnlyFile_append_Params{st}, err
 }
 
-func ReadRootRwFile_truncate_Params(msg *capnp.Message) (RwFile_truncate_Params, error) {
+func ReadRootAppendOnlyFile_append_Params(msg *capnp.Message) (AppendOnlyFile_append_Params, error) {
 	root, err := msg.Root()
-	return RwFile_truncate_Params{root.Struct()}, err
+	return AppendOnlyFile_append_Params{root.Struct()}, err
 }
 
-func (s RwFile_truncate_Params) String() string {
-	str, _ := text.Marshal(0xbf2ae4dc7cac598c, s.Struct)
+func (s AppendOnlyFile_append_Params) String() string {
+	str, _ := text.Marshal(0xaa5cf6dd73f5aee9, s.Struct)
 	return str
 }
 
-func (s RwFile_truncate_Params) Size() uint64 {
-	return s.Struct.Uint64(0)
-}
-
-func (s RwFile_truncate_Params) SetSize(v uint64) {
-	s.Struct.SetUint64(0, v)
-}
-
-// RwFile_truncate_Params_List is a list of RwFile_truncate_Params.
-type RwFile_truncate_Params_List struct{ capnp.List }
+// AppendOnlyFile_append_Params_List is a list of AppendOnlyFile_append_Params.
+type AppendOnlyFile_append_Params_List struct{ capnp.List }
 
-// NewRwFile_truncate_Params creates a new list of RwFile_truncate_Params.
-func NewRwFile_truncate_Params_List(s *capnp.Segment, sz int32) (RwFile_truncate_Params_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
-	return RwFile_truncate_Params_List{l}, err
+// NewAppendOnlyFile_append_Params creates a new list of AppendOnlyFile_append_Params.
+func NewAppendOnlyFile_append_Params_List(s *capnp.Segment, sz int32) (AppendOnlyFile_append_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return AppendOnlyFile_append_Params_List{l}, err
 }
 
-func (s RwFile_truncate_Params_List) At(i int) RwFile_truncate_Params {
-	return RwFile_truncate_Params{s.List.Struct(i)}
+func (s AppendOnlyFile_append_Params_List) At(i int) AppendOnlyFile_append_Params {
+	return AppendOnlyFile_append_Params{s.List.Struct(i)}
 }
 
-func (s RwFile_truncate_Params_List) Set(i int, v RwFile_truncate_Params) error {
+func (s AppendOnlyFile_append_Params_List) Set(i int, v AppendOnlyFile_append_Params) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-func (s RwFile_truncate_Params_List) String() string {
-	str, _ := text.MarshalList(0xbf2ae4dc7cac598c, s.List)
+func (s AppendOnlyFile_append_Params_List) String() string {
+	str, _ := text.MarshalList(0xaa5cf6dd73f5aee9, s.List)
 	return str
 }
 
-// RwFile_truncate_Params_Future is a wrapper for a RwFile_truncate_Params promised by a client call.
-type RwFile_truncate_Params_Future struct{ *capnp.Future }
+// AppendOnlyFile_append_Params_Future is a wrapper for a AppendOnlyFile_append_Params promised by a client call.
+type AppendOnlyFile_append_Params_Future struct{ *capnp.Future }
 
-func (p RwFile_truncate_Params_Future) Struct() (RwFile_truncate_Params, error) {
+func (p AppendOnlyFile_append_Params_Future) Struct() (AppendOnlyFile_append_Params, error) {
 	s, err := p.Future.Struct()
-	return RwFile_truncate_Params{s}, err
+	return AppendOnlyFile_append_Params{s}, err
 }
 
-type RwFile_truncate_Results struct{ capnp.Struct }
+type AppendOnlyFile_append_Results struct{ capnp.Struct }
 
-// RwFile_truncate_Results_TypeID is the unique identifier for the type RwFile_truncate_Results.
-const RwFile_truncate_Results_TypeID = 0xf101f68e2a8fff80
+// AppendOnlyFile_append_Results_TypeID is the unique identifier for the type AppendOnlyFile_append_Results.
+const AppendOnlyFile_append_Results_TypeID = 0xfb75ccd4dc926d3b
 
-func NewRwFile_truncate_Results(s *capnp.Segment) (RwFile_truncate_Results, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return RwFile_truncate_Results{st}, err
+func NewAppendOnlyFile_append_Results(s *capnp.Segment) (AppendOnlyFile_append_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyFile_append_Results{st}, err
 }
 
-func NewRootRwFile_truncate_Results(s *capnp.Segment) (RwFile_truncate_Results, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return RwFile_truncate_Results{st}, err
+func NewRootAppendOnlyFile_append_Results(s *capnp.Segment) (AppendOnlyFile_append_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return AppendOnlyFile_append_Results{st}, err
 }
 
-func ReadRootRwFile_truncate_Results(msg *capnp.Message) (RwFile_truncate_Results, error) {
+func ReadRootAppendOnlyFile_append_Results(msg *capnp.Message) (AppendOnlyFile_append_Results, error) {
 	root, err := msg.Root()
-	return RwFile_truncate_Results{root.Struct()}, err
+	return AppendOnlyFile_append_Results{root.Struct()}, err
 }
 
-func (s RwFile_truncate_Results) String() string {
-	str, _ := text.Marshal(0xf101f68e2a8fff80, s.Struct)
+func (s AppendOnlyFile_append_Results) String() string {
+	str, _ := text.Marshal(0xfb75ccd4dc926d3b, s.Struct)
 	return str
 }
 
-// RwFile_truncate_Results_List is a list of RwFile_truncate_Results.
-type RwFile_truncate_Results_List struct{ capnp.List }
-
-// NewRwFile_truncate_Results creates a new list of RwFile_truncate_Results.
-func NewRwFile_truncate_Results_List(s *capnp.Segment, sz int32) (RwFile_truncate_Results_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
-	return RwFile_truncate_Results_List{l}, err
-}
-
-func (s RwFile_truncate_Results_List) At(i int) RwFile_truncate_Results {
-	return RwFile_truncate_Results{s.List.Struct(i)}
-}
-
-func (s RwFile_truncate_Results_List) Set(i int, v RwFile_truncate_Results) error {
-	return s.List.SetStruct(i, v.Struct)
+func (s AppendOnlyFile_append_Results) Sink() util.ByteStream {
+	p, _ := s.Struct.Ptr(0)
+	return util.ByteStream{Client: p.Interface().Client()}
 }
 
-func (s RwFile_truncate_Results_List) String() string {
-	str, _ := text.MarshalList(0xf101f68e2a8fff80, s.List)
-	return str
+func (s AppendOnlyFile_append_Results) HasSink() bool {
+	return s.Struct.HasPtr(0)
 }
 
-// RwFile_truncate_Results_Future is a wrapper for a RwFile_truncate_Results promised by a client call.
-type RwFile_truncate_Results_Future struct{ *capnp.Future }
-
-func (p RwFile_truncate_Results_Future) Struct() (RwFile_truncate_Results, error) {
-	s, err := p.Future.Struct()
-	return RwFile_truncate_Results{s}, err
+func (s AppendOnlyFile_append_Results) SetSink(v util.ByteStream) error {
+	if !v.Client.IsValid() {
+		return s.Struct.SetPtr(0, capnp.Ptr{})
+	}
+	seg := s.Segment()
+	in := capnp.NewInterface(seg, seg.Message().AddCap(v.Client))
+	return s.Struct.SetPtr(0, in.ToPtr())
 }
 
-type RwFile_setExec_Params struct{ capnp.Struct }
-
-// RwFile_setExec_Params_TypeID is the unique identifier for the type RwFile_setExec_Params.
-const RwFile_setExec_Params_TypeID = 0xc799a0caf614d135
+// AppendOnlyFile_append_Results_List is a list of AppendOnlyFile_append_Results.
+type AppendOnlyFile_append_Results_List struct{ capnp.List }
 
-func NewRwFile_setExec_Params(s *capnp.Segment) (RwFile_setExec_Params, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
-	return RwFile_setExec_Params{st}, err
+// NewAppendOnlyFile_append_Results creates a new list of AppendOnlyFile_append_Results.
+func NewAppendOnlyFile_append_Results_List(s *capnp.Segment, sz int32) (AppendOnlyFile_append_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return AppendOnlyFile_append_Results_List{l}, err
 }
 
-func NewRootRwFile_setExec_Params(s *capnp.Segment) (RwFile_setExec_Params, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0})
-	return RwFile_setExec_Params{st}, err
+func (s AppendOnlyFile_append_Results_List) At(i int) AppendOnlyFile_append_Results {
+	return AppendOnlyFile_append_Results{s.List.Struct(i)}
 }
 
-func ReadRootRwFile_setExec_Params(msg *capnp.Message) (RwFile_setExec_Params, error) {
-	root, err := msg.Root()
-	return RwFile_setExec_Params{root.Struct()}, err
+func (s AppendOnlyFile_append_Results_List) Set(i int, v AppendOnlyFile_append_Results) error {
+	return s.List.SetStruct(i, v.Struct)
 }
 
-func (s RwFile_setExec_Params) String() string {
-	str, _ := text.Marshal(0xc799a0caf614d135, s.Struct)
+func (s AppendOnlyFile_append_Results_List) String() string {
+	str, _ := text.MarshalList(0xfb75ccd4dc926d3b, s.List)
 	return str
 }
 
-func (s RwFile_setExec_Params) Exec() bool {
-	return s.Struct.Bit(0)
+// AppendOnlyFile_append_Results_Future is a wrapper for a AppendOnlyFile_append_Results promised by a client call.
+type AppendOnlyFile_append_Results_Future struct{ *capnp.Future }
+
+func (p AppendOnlyFile_append_Results_Future) Struct() (AppendOnlyFile_append_Results, error) {
+	s, err := p.Future.Struct()
+	return AppendOnlyFile_append_Results{s}, err
 }
 
-func (s RwFile_setExec_Params) SetExec(v bool) {
-	s.Struct.SetBit(0, v)
+func (p AppendOnlyFile_append_Results_Future) Sink() util.ByteStream {
+	return util.ByteStream{Client: p.Future.Field(0, nil).Client()}
 }
 
-// RwFile_setExec_Params_List is a list of RwFile_setExec_Params.
-type RwFile_setExec_Params_List struct{ capnp.List }
+type LockHandle struct{ Client *capnp.Client }
 
-// NewRwFile_setExec_Params creates a new list of RwFile_setExec_Params.
-func NewRwFile_setExec_Params_List(s *capnp.Segment, sz int32) (RwFile_setExec_Params_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 0}, sz)
-	return RwFile_setExec_Params_List{l}, err
-}
+// LockHandle_TypeID is the unique identifier for the type LockHandle.
+const LockHandle_TypeID = 0x980e5f14396ec3c5
 
-func (s RwFile_setExec_Params_List) At(i int) RwFile_setExec_Params {
-	return RwFile_setExec_Params{s.List.Struct(i)}
+// A LockHandle_Server is a LockHandle with a local implementation.
+type LockHandle_Server interface {
 }
 
-func (s RwFile_setExec_Params_List) Set(i int, v RwFile_setExec_Params) error {
-	return s.List.SetStruct(i, v.Struct)
+// LockHandle_NewServer creates a new Server from an implementation of LockHandle_Server.
+func LockHandle_NewServer(s LockHandle_Server, policy *server.Policy) *server.Server {
+	c, _ := s.(server.Shutdowner)
+	return server.New(LockHandle_Methods(nil, s), s, c, policy)
 }
 
-func (s RwFile_setExec_Params_List) String() string {
-	str, _ := text.MarshalList(0xc799a0caf614d135, s.List)
-	return str
+// LockHandle_ServerToClient creates a new Client from an implementation of LockHandle_Server.
+// The caller is responsible for calling Release on the returned Client.
+func LockHandle_ServerToClient(s LockHandle_Server, policy *server.Policy) LockHandle {
+	return LockHandle{Client: capnp.NewClient(LockHandle_NewServer(s, policy))}
 }
 
-// RwFile_setExec_Params_Future is a wrapper for a RwFile_setExec_Params promised by a client call.
-type RwFile_setExec_Params_Future struct{ *capnp.Future }
+// LockHandle_Methods appends Methods to a slice that invoke the methods on s.
+// This can be used to create a more complicated Server.
+func LockHandle_Methods(methods []server.Method, s LockHandle_Server) []server.Method {
+	if cap(methods) == 0 {
+		methods = make([]server.Method, 0, 0)
+	}
 
-func (p RwFile_setExec_Params_Future) Struct() (RwFile_setExec_Params, error) {
-	s, err := p.Future.Struct()
-	return RwFile_setExec_Params{s}, err
+	return methods
 }
 
-type RwFile_setExec_Results struct{ capnp.Struct }
+type WatchHandle struct{ Client *capnp.Client }
 
-// RwFile_setExec_Results_TypeID is the unique identifier for the type RwFile_setExec_Results.
-const RwFile_setExec_Results_TypeID = 0xddad3e0282b03294
+// WatchHandle_TypeID is the unique identifier for the type WatchHandle.
+const WatchHandle_TypeID = 0xe188ff561f522d4d
 
-func NewRwFile_setExec_Results(s *capnp.Segment) (RwFile_setExec_Results, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return RwFile_setExec_Results{st}, err
+// A WatchHandle_Server is a WatchHandle with a local implementation.
+type WatchHandle_Server interface {
 }
 
-func NewRootRwFile_setExec_Results(s *capnp.Segment) (RwFile_setExec_Results, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return RwFile_setExec_Results{st}, err
+// WatchHandle_NewServer creates a new Server from an implementation of WatchHandle_Server.
+func WatchHandle_NewServer(s WatchHandle_Server, policy *server.Policy) *server.Server {
+	c, _ := s.(server.Shutdowner)
+	return server.New(WatchHandle_Methods(nil, s), s, c, policy)
 }
 
-func ReadRootRwFile_setExec_Results(msg *capnp.Message) (RwFile_setExec_Results, error) {
-	root, err := msg.Root()
-	return RwFile_setExec_Results{root.Struct()}, err
+// WatchHandle_ServerToClient creates a new Client from an implementation of WatchHandle_Server.
+// The caller is responsible for calling Release on the returned Client.
+func WatchHandle_ServerToClient(s WatchHandle_Server, policy *server.Policy) WatchHandle {
+	return WatchHandle{Client: capnp.NewClient(WatchHandle_NewServer(s, policy))}
 }
 
-func (s RwFile_setExec_Results) String() string {
-	str, _ := text.Marshal(0xddad3e0282b03294, s.Struct)
-	return str
+// WatchHandle_Methods appends Methods to a slice that invoke the methods on s.
+// This can be used to create a more complicated Server.
+func WatchHandle_Methods(methods []server.Method, s WatchHandle_Server) []server.Method {
+	if cap(methods) == 0 {
+		methods = make([]server.Method, 0, 0)
+	}
+
+	return methods
 }
 
-// RwFile_setExec_Results_List is a list of RwFile_setExec_Results.
-type RwFile_setExec_Results_List struct{ capnp.List }
+type ErrorCode uint16
 
-// NewRwFile_setExec_Results creates a new list of RwFile_setExec_Results.
-func NewRwFile_setExec_Results_List(s *capnp.Segment, sz int32) (RwFile_setExec_Results_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
-	return RwFile_setExec_Results_List{l}, err
-}
+// ErrorCode_TypeID is the unique identifier for the type ErrorCode.
+const ErrorCode_TypeID = 0xb98085274c98afb5
 
-func (s RwFile_setExec_Results_List) At(i int) RwFile_setExec_Results {
-	return RwFile_setExec_Results{s.List.Struct(i)}
+// Values of ErrorCode.
+const (
+	ErrorCode_unknown          ErrorCode = 0
+	ErrorCode_notFound         ErrorCode = 1
+	ErrorCode_exists           ErrorCode = 2
+	ErrorCode_notADirectory    ErrorCode = 3
+	ErrorCode_notEmpty         ErrorCode = 4
+	ErrorCode_permissionDenied ErrorCode = 5
+	ErrorCode_quota            ErrorCode = 6
+	ErrorCode_invalidName      ErrorCode = 7
+	ErrorCode_invalidArgument  ErrorCode = 8
+	ErrorCode_io               ErrorCode = 9
+)
+
+// String returns the enum's constant name.
+func (c ErrorCode) String() string {
+	switch c {
+	case ErrorCode_unknown:
+		return "unknown"
+	case ErrorCode_notFound:
+		return "notFound"
+	case ErrorCode_exists:
+		return "exists"
+	case ErrorCode_notADirectory:
+		return "notADirectory"
+	case ErrorCode_notEmpty:
+		return "notEmpty"
+	case ErrorCode_permissionDenied:
+		return "permissionDenied"
+	case ErrorCode_quota:
+		return "quota"
+	case ErrorCode_invalidName:
+		return "invalidName"
+	case ErrorCode_invalidArgument:
+		return "invalidArgument"
+	case ErrorCode_io:
+		return "io"
+
+	default:
+		return ""
+	}
 }
 
-func (s RwFile_setExec_Results_List) Set(i int, v RwFile_setExec_Results) error {
-	return s.List.SetStruct(i, v.Struct)
+// ErrorCodeFromString returns the enum value with a name,
+// or the zero value if there's no such value.
+func ErrorCodeFromString(c string) ErrorCode {
+	switch c {
+	case "unknown":
+		return ErrorCode_unknown
+	case "notFound":
+		return ErrorCode_notFound
+	case "exists":
+		return ErrorCode_exists
+	case "notADirectory":
+		return ErrorCode_notADirectory
+	case "notEmpty":
+		return ErrorCode_notEmpty
+	case "permissionDenied":
+		return ErrorCode_permissionDenied
+	case "quota":
+		return ErrorCode_quota
+	case "invalidName":
+		return ErrorCode_invalidName
+	case "invalidArgument":
+		return ErrorCode_invalidArgument
+	case "io":
+		return ErrorCode_io
+
+	default:
+		return 0
+	}
 }
 
-func (s RwFile_setExec_Results_List) String() string {
-	str, _ := text.MarshalList(0xddad3e0282b03294, s.List)
-	return str
+type ErrorCode_List struct{ capnp.List }
+
+func NewErrorCode_List(s *capnp.Segment, sz int32) (ErrorCode_List, error) {
+	l, err := capnp.NewUInt16List(s, sz)
+	return ErrorCode_List{l.List}, err
 }
 
-// RwFile_setExec_Results_Future is a wrapper for a RwFile_setExec_Results promised by a client call.
-type RwFile_setExec_Results_Future struct{ *capnp.Future }
+func (l ErrorCode_List) At(i int) ErrorCode {
+	ul := capnp.UInt16List{List: l.List}
+	return ErrorCode(ul.At(i))
+}
 
-func (p RwFile_setExec_Results_Future) Struct() (RwFile_setExec_Results, error) {
-	s, err := p.Future.Struct()
-	return RwFile_setExec_Results{s}, err
+func (l ErrorCode_List) Set(i int, v ErrorCode) {
+	ul := capnp.UInt16List{List: l.List}
+	ul.Set(i, uint16(v))
 }
 
 const schema_e91f231103c0780e = "x\xda\x9cWml\x1c\xd5\x15\xbdwf\x97\xdd\xf1\xce" +
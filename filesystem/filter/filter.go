@@ -0,0 +1,76 @@
+// Package filter attenuates filesystem capabilities, hiding the nodes
+// which don't match a predicate.
+//
+// The filter applies recursively: to the results of list and walk on the
+// wrapped directory and on every directory reached through it, and to the
+// names passed to create and mkdir. Hidden nodes are left out of listings,
+// walk reports that they don't exist, attempts to create them fail, and
+// watchers aren't told about changes to them.
+package filter
+
+import (
+	"path"
+	"strings"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/membrane"
+)
+
+// A Predicate reports whether the child of a directory with the given name
+// and info should be visible. For create and mkdir, info describes the node
+// that would be created.
+type Predicate func(name string, info filesystem.StatInfo) bool
+
+// Wrap returns a view of node, which implements the interface given by
+// kind, in which only children matching pred are visible. Wrap takes
+// ownership of node.Client.
+func Wrap(node filesystem.Node, kind membrane.Kind, pred Predicate) filesystem.Node {
+	return membrane.Wrap(node, kind, membrane.Hooks{Filter: pred})
+}
+
+// Like Wrap, but for a Directory.
+func WrapDirectory(dir filesystem.Directory, pred Predicate) filesystem.Directory {
+	node := Wrap(filesystem.Node{Client: dir.Client}, membrane.KindDirectory, pred)
+	return filesystem.Directory{Client: node.Client}
+}
+
+// Like Wrap, but for an RwDirectory.
+func WrapRwDirectory(dir filesystem.RwDirectory, pred Predicate) filesystem.RwDirectory {
+	node := Wrap(filesystem.Node{Client: dir.Client}, membrane.KindRwDirectory, pred)
+	return filesystem.RwDirectory{Client: node.Client}
+}
+
+// Hides files and directories whose names start with a '.'.
+func NoDotfiles(name string, info filesystem.StatInfo) bool {
+	return !strings.HasPrefix(name, ".")
+}
+
+// Extensions returns a predicate which matches files with any of the given
+// extensions (e.g. ".jpg"), ignoring case. Directories always match, so
+// that matching files in subdirectories can be reached.
+func Extensions(exts ...string) Predicate {
+	return func(name string, info filesystem.StatInfo) bool {
+		if info.Which() == filesystem.StatInfo_Which_dir {
+			return true
+		}
+		ext := path.Ext(name)
+		for _, want := range exts {
+			if strings.EqualFold(ext, want) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// And returns a predicate which matches only what all of preds match.
+func And(preds ...Predicate) Predicate {
+	return func(name string, info filesystem.StatInfo) bool {
+		for _, pred := range preds {
+			if !pred(name, info) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,326 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/local"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+)
+
+var files = []string{".hidden", "a.txt", "d/.secret", "d/b.txt", "d/.dotdir/c.txt"}
+
+// Make a memfs containing files, and return it along with a view of it
+// which hides dotfiles.
+func newView(ctx context.Context, t *testing.T) (filesystem.RwDirectory, filesystem.RwDirectory) {
+	t.Helper()
+	root := memfs.New(0).Root()
+	t.Cleanup(root.Client.Release)
+	for _, p := range files {
+		if err := client.WriteFile(ctx, root, p, []byte(p), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	view := WrapRwDirectory(filesystem.RwDirectory{Client: root.Client.AddRef()}, NoDotfiles)
+	t.Cleanup(view.Client.Release)
+	return root, view
+}
+
+func names(ctx context.Context, t *testing.T, dir filesystem.Directory, p string) []string {
+	t.Helper()
+	entries, err := client.ReadDir(ctx, dir, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret := []string{}
+	for _, ent := range entries {
+		ret = append(ret, ent.Name())
+	}
+	return ret
+}
+
+// Check that dir shows only the files which don't start with a dot, at
+// every level.
+func checkHidden(ctx context.Context, t *testing.T, dir filesystem.Directory) {
+	t.Helper()
+	if got, want := names(ctx, t, dir, ""), []string{"a.txt", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listed %v, want %v", got, want)
+	}
+	if got, want := names(ctx, t, dir, "d"), []string{"b.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listed %v in d, want %v", got, want)
+	}
+	for _, p := range []string{".hidden", "d/.secret", "d/.dotdir", "d/.dotdir/c.txt"} {
+		if _, err := client.Walk(ctx, dir, p); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("walk to %s: got %v, want not found", p, err)
+		}
+	}
+	if _, err := client.Walk(ctx, dir, "d/b.txt"); err != nil {
+		t.Errorf("walk to d/b.txt: %v", err)
+	}
+}
+
+func create(ctx context.Context, dir filesystem.RwDirectory, name string) error {
+	res, release := dir.Create(ctx, func(p filesystem.RwDirectory_create_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func mkdir(ctx context.Context, dir filesystem.RwDirectory, name string) error {
+	res, release := dir.Mkdir(ctx, func(p filesystem.RwDirectory_mkdir_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func TestFilter(t *testing.T) {
+	ctx := context.Background()
+	root, view := newView(ctx, t)
+	checkHidden(ctx, t, filesystem.Directory{Client: view.Client})
+
+	sub, err := client.WalkRwDir(ctx, view, "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Client.Release()
+	for _, dir := range []filesystem.RwDirectory{view, sub} {
+		if err := create(ctx, dir, ".new"); fserrors.CodeOf(err) != filesystem.ErrorCode_permissionDenied {
+			t.Errorf("create: got %v, want permission denied", err)
+		}
+		if err := mkdir(ctx, dir, ".newdir"); fserrors.CodeOf(err) != filesystem.ErrorCode_permissionDenied {
+			t.Errorf("mkdir: got %v, want permission denied", err)
+		}
+		if err := create(ctx, dir, "new.txt"); err != nil {
+			t.Errorf("create: %v", err)
+		}
+	}
+	if got, want := names(ctx, t, filesystem.Directory{Client: root.Client}, "d"), []string{".dotdir", ".secret", "b.txt", "new.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("the underlying d contains %v, want %v", got, want)
+	}
+}
+
+// Snapshots taken through a view, and listed through it, are filtered too.
+func TestFilterSnapshots(t *testing.T) {
+	ctx := context.Background()
+	_, view := newView(ctx, t)
+
+	res, release := view.Snapshot(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := results.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkHidden(ctx, t, snapshot.Dir())
+
+	listRes, release := view.Snapshots(ctx, nil)
+	defer release()
+	listResults, err := listRes.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := listResults.Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	} else if snapshots.Len() != 1 {
+		t.Fatalf("got %d snapshots, want 1", snapshots.Len())
+	}
+	checkHidden(ctx, t, snapshots.At(0).Dir())
+}
+
+func trashItem(ctx context.Context, trash filesystem.Trash, name string, purge bool) error {
+	if purge {
+		res, release := trash.Purge(ctx, func(p filesystem.Trash_purge_Params) error {
+			return p.SetName(name)
+		})
+		defer release()
+		_, err := res.Struct()
+		return err
+	}
+	res, release := trash.Restore(ctx, func(p filesystem.Trash_restore_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+// Items deleted from hidden nodes are hidden in the trash, going by the
+// names they were deleted under.
+func TestFilterTrash(t *testing.T) {
+	ctx := context.Background()
+	n, err := local.NewNode(t.TempDir(), &local.Config{TrashDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filesystem.RwDirectory{Client: n.MakeClient().Client}
+	defer root.Client.Release()
+	for _, p := range []string{".hidden", "a.txt", "d/.secret", "d/b.txt"} {
+		if err := client.WriteFile(ctx, root, p, []byte(p), false); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Remove(ctx, root, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	view := WrapRwDirectory(filesystem.RwDirectory{Client: root.Client.AddRef()}, NoDotfiles)
+	defer view.Client.Release()
+
+	res, release := view.Trash(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trash := results.Trash()
+
+	byPath := trashItems(ctx, t, trash)
+	if len(byPath) != 2 || byPath["a.txt"] == "" || byPath["d/b.txt"] == "" {
+		t.Fatalf("got items %v, want a.txt and d/b.txt", byPath)
+	}
+	if got := names(ctx, t, filesystem.Directory{Client: trash.Client}, ""); len(got) != 2 {
+		t.Errorf("listed %v, want the two visible items", got)
+	}
+
+	// Find the names of the hidden items from the underlying trash.
+	rawRes, release := root.Trash(ctx, nil)
+	defer release()
+	var hidden []string
+	for orig, name := range trashItems(ctx, t, rawRes.Trash()) {
+		if _, ok := byPath[orig]; !ok {
+			hidden = append(hidden, name)
+		}
+	}
+	if len(hidden) != 2 {
+		t.Fatalf("got %d hidden items, want 2", len(hidden))
+	}
+	for _, name := range hidden {
+		if _, err := client.Walk(ctx, filesystem.Directory{Client: trash.Client}, name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("walk to hidden item: got %v, want not found", err)
+		}
+		for _, purge := range []bool{false, true} {
+			if err := trashItem(ctx, trash, name, purge); fserrors.CodeOf(err) != filesystem.ErrorCode_notFound {
+				t.Errorf("restore/purge (%v) of hidden item: got %v, want not found", purge, err)
+			}
+		}
+	}
+
+	if err := trashItem(ctx, trash, byPath["a.txt"], false); err != nil {
+		t.Fatal(err)
+	}
+	if err := trashItem(ctx, trash, byPath["d/b.txt"], true); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(ctx, t, filesystem.Directory{Client: root.Client}, ""), []string{"a.txt", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after restoring, listed %v, want %v", got, want)
+	}
+}
+
+// Return the items in trash, as a map from original paths to names.
+func trashItems(ctx context.Context, t *testing.T, trash filesystem.Trash) map[string]string {
+	t.Helper()
+	res, release := trash.Items(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := results.Items()
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := make(map[string]string, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		name, err := list.At(i).Name()
+		if err != nil {
+			t.Fatal(err)
+		}
+		orig, err := list.At(i).OriginalPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		items[orig] = name
+	}
+	return items
+}
+
+// A Directory.Watcher which sends the paths it's told about down a
+// channel.
+type watcher chan []string
+
+func (w watcher) Changed(ctx context.Context, p filesystem.Directory_Watcher_changed) error {
+	paths, err := p.Args().Paths()
+	if err != nil {
+		return err
+	}
+	var got []string
+	for i := 0; i < paths.Len(); i++ {
+		p, err := paths.At(i)
+		if err != nil {
+			return err
+		}
+		got = append(got, p)
+	}
+	w <- got
+	return nil
+}
+
+// Changes to hidden nodes aren't reported to watchers, but others are.
+func TestFilterWatch(t *testing.T) {
+	ctx := context.Background()
+	root, view := newView(ctx, t)
+
+	w := make(watcher, 10)
+	res, release := view.Watch(ctx, func(p filesystem.Directory_watch_Params) error {
+		return p.SetWatcher(filesystem.Directory_Watcher_ServerToClient(w, nil))
+	})
+	defer release()
+	if _, err := res.Struct(); err != nil {
+		t.Fatal(err)
+	}
+
+	// memfs reports changes in order, so anything about the hidden
+	// files would arrive before or alongside d/b.txt.
+	for _, p := range []string{".hidden", "d/.secret", "d/.dotdir/c.txt", "d/b.txt"} {
+		if err := client.WriteFile(ctx, root, p, []byte("changed"), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case paths := <-w:
+			if len(paths) == 0 {
+				t.Fatal("got an empty list of paths, want d/b.txt")
+			}
+			done := false
+			for _, p := range paths {
+				switch p {
+				case ".hidden", "d/.secret", "d/.dotdir", "d/.dotdir/c.txt":
+					t.Errorf("got hidden path %q", p)
+				case "d/b.txt":
+					done = true
+				}
+			}
+			if done {
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for d/b.txt")
+		}
+	}
+}
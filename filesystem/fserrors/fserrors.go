@@ -0,0 +1,267 @@
+// Package fserrors implements the error conventions described by ErrorCode
+// in filesystem.capnp.
+//
+// Servers should construct their errors with New, and wrap their methods
+// with CensorMethods so that anything else -- in particular errors from
+// package os, which include paths on the host -- is reduced to a code and
+// a generic description before it leaves the grain.
+//
+// Clients can recover the code from an error returned by a call with
+// CodeOf, or Decode it into an error which works with errors.Is and the
+// io/fs sentinel errors, and translate it with Errno or HTTPStatus.
+package fserrors
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+
+	"zombiezen.com/go/capnproto2"
+	"zombiezen.com/go/capnproto2/server"
+)
+
+// An Error is an error with an ErrorCode.
+type Error struct {
+	Code    filesystem.ErrorCode
+	Message string
+}
+
+// New returns an error with the given code and description. The
+// description is sent to clients, so it must not say anything about the
+// host.
+func New(code filesystem.ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Code.String() + ": " + e.Message
+}
+
+func (e *Error) ErrorCode() filesystem.ErrorCode {
+	return e.Code
+}
+
+// Is reports whether the error matches one of the io/fs sentinel errors,
+// so that e.g. errors.Is(err, fs.ErrNotExist) works.
+func (e *Error) Is(target error) bool {
+	return is(e.Code, target)
+}
+
+// An error received from another grain, which carried a code.
+type remoteError struct {
+	code filesystem.ErrorCode
+	err  error
+}
+
+func (e *remoteError) Error() string {
+	return e.err.Error()
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+func (e *remoteError) ErrorCode() filesystem.ErrorCode {
+	return e.code
+}
+
+func (e *remoteError) Is(target error) bool {
+	return is(e.code, target)
+}
+
+func is(code filesystem.ErrorCode, target error) bool {
+	switch target {
+	case fs.ErrNotExist:
+		return code == filesystem.ErrorCode_notFound
+	case fs.ErrExist:
+		return code == filesystem.ErrorCode_exists
+	case fs.ErrPermission:
+		return code == filesystem.ErrorCode_permissionDenied
+	case fs.ErrInvalid:
+		return code == filesystem.ErrorCode_invalidName ||
+			code == filesystem.ErrorCode_invalidArgument
+	default:
+		return false
+	}
+}
+
+// Decode returns an error which reports the code carried by err, if any,
+// to errors.Is and CodeOf. Its message is the same as err's. If err
+// carries no code, it is returned unchanged.
+func Decode(err error) error {
+	if err == nil {
+		return nil
+	}
+	var coded interface{ ErrorCode() filesystem.ErrorCode }
+	if errors.As(err, &coded) {
+		return err
+	}
+	code := codeOfMessage(err.Error())
+	if code == filesystem.ErrorCode_unknown {
+		return err
+	}
+	return &remoteError{code: code, err: err}
+}
+
+// CodeOf returns the code for err. This works with errors constructed by
+// New, errors received over the network, and errors from the host
+// (syscall.Errno and the io/fs sentinel errors).
+func CodeOf(err error) filesystem.ErrorCode {
+	if err == nil {
+		return filesystem.ErrorCode_unknown
+	}
+	var coded interface{ ErrorCode() filesystem.ErrorCode }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		if code, ok := errnoCodes[errno]; ok {
+			return code
+		}
+		return filesystem.ErrorCode_io
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return filesystem.ErrorCode_notFound
+	case errors.Is(err, fs.ErrExist):
+		return filesystem.ErrorCode_exists
+	case errors.Is(err, fs.ErrPermission):
+		return filesystem.ErrorCode_permissionDenied
+	case errors.Is(err, fs.ErrInvalid):
+		return filesystem.ErrorCode_invalidArgument
+	}
+	return codeOfMessage(err.Error())
+}
+
+// Look for a code in one of the ": "-separated segments of msg.
+func codeOfMessage(msg string) filesystem.ErrorCode {
+	for _, seg := range strings.Split(msg, ": ") {
+		if code := filesystem.ErrorCodeFromString(seg); code != filesystem.ErrorCode_unknown {
+			return code
+		}
+	}
+	return filesystem.ErrorCode_unknown
+}
+
+var errnoCodes = map[syscall.Errno]filesystem.ErrorCode{
+	syscall.ENOENT:       filesystem.ErrorCode_notFound,
+	syscall.EEXIST:       filesystem.ErrorCode_exists,
+	syscall.ENOTDIR:      filesystem.ErrorCode_notADirectory,
+	syscall.ENOTEMPTY:    filesystem.ErrorCode_notEmpty,
+	syscall.EACCES:       filesystem.ErrorCode_permissionDenied,
+	syscall.EPERM:        filesystem.ErrorCode_permissionDenied,
+	syscall.EROFS:        filesystem.ErrorCode_permissionDenied,
+	syscall.ENOSPC:       filesystem.ErrorCode_quota,
+	syscall.EDQUOT:       filesystem.ErrorCode_quota,
+	syscall.EFBIG:        filesystem.ErrorCode_quota,
+	syscall.ENAMETOOLONG: filesystem.ErrorCode_invalidName,
+	syscall.EINVAL:       filesystem.ErrorCode_invalidArgument,
+	syscall.EIO:          filesystem.ErrorCode_io,
+}
+
+// Generic descriptions for each code, used by Censor.
+var messages = map[filesystem.ErrorCode]string{
+	filesystem.ErrorCode_unknown:          "Operation failed",
+	filesystem.ErrorCode_notFound:         "No such file or directory",
+	filesystem.ErrorCode_exists:           "File already exists",
+	filesystem.ErrorCode_notADirectory:    "Not a directory",
+	filesystem.ErrorCode_notEmpty:         "Directory not empty",
+	filesystem.ErrorCode_permissionDenied: "Permission denied",
+	filesystem.ErrorCode_quota:            "No space left",
+	filesystem.ErrorCode_invalidName:      "Illegal file name",
+	filesystem.ErrorCode_invalidArgument:  "Invalid argument",
+	filesystem.ErrorCode_io:               "Input/output error",
+}
+
+// Censor returns an error which is safe to send to clients in place of
+// err: errors constructed by New and those from the RPC system are passed
+// through, and anything else is replaced by a generic error with the
+// same code.
+func Censor(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	if capnp.IsUnimplemented(err) || capnp.IsDisconnected(err) ||
+		err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	}
+	code := CodeOf(err)
+	return New(code, messages[code])
+}
+
+// CensorMethods wraps each of methods so that the errors it returns are
+// passed through Censor.
+func CensorMethods(methods []server.Method) []server.Method {
+	ret := make([]server.Method, len(methods))
+	for i, m := range methods {
+		impl := m.Impl
+		ret[i] = server.Method{
+			Method: m.Method,
+			Impl: func(ctx context.Context, call *server.Call) error {
+				return Censor(impl(ctx, call))
+			},
+		}
+	}
+	return ret
+}
+
+// Errno returns the errno corresponding to err, for use by e.g. FUSE
+// servers. Errno(nil) is 0.
+func Errno(err error) syscall.Errno {
+	if err == nil {
+		return 0
+	}
+	switch CodeOf(err) {
+	case filesystem.ErrorCode_notFound:
+		return syscall.ENOENT
+	case filesystem.ErrorCode_exists:
+		return syscall.EEXIST
+	case filesystem.ErrorCode_notADirectory:
+		return syscall.ENOTDIR
+	case filesystem.ErrorCode_notEmpty:
+		return syscall.ENOTEMPTY
+	case filesystem.ErrorCode_permissionDenied:
+		return syscall.EACCES
+	case filesystem.ErrorCode_quota:
+		return syscall.ENOSPC
+	case filesystem.ErrorCode_invalidName, filesystem.ErrorCode_invalidArgument:
+		return syscall.EINVAL
+	}
+	if capnp.IsUnimplemented(err) {
+		return syscall.ENOSYS
+	}
+	return syscall.EIO
+}
+
+// HTTPStatus returns the HTTP status code corresponding to err.
+// HTTPStatus(nil) is 200.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	switch CodeOf(err) {
+	case filesystem.ErrorCode_notFound, filesystem.ErrorCode_notADirectory:
+		return http.StatusNotFound
+	case filesystem.ErrorCode_exists, filesystem.ErrorCode_notEmpty:
+		return http.StatusConflict
+	case filesystem.ErrorCode_permissionDenied:
+		return http.StatusForbidden
+	case filesystem.ErrorCode_quota:
+		return http.StatusInsufficientStorage
+	case filesystem.ErrorCode_invalidName, filesystem.ErrorCode_invalidArgument:
+		return http.StatusBadRequest
+	}
+	if capnp.IsUnimplemented(err) {
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,535 @@
+// Package fstest checks that implementations of the interfaces in
+// filesystem.capnp follow the rules written there.
+//
+// It is meant to be called from the tests of each implementation, e.g.
+//
+//	func TestConformance(t *testing.T) {
+//		fstest.TestRwDirectory(t, func(t *testing.T) filesystem.RwDirectory {
+//			return memfs.New(0).Root()
+//		})
+//	}
+//
+// Every capability under test is served over an in-process RPC
+// connection, so that implementations are exercised the way other grains
+// see them.
+package fstest
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+// How long each test may take before it is considered hung.
+const testTimeout = 30 * time.Second
+
+// Names which every implementation must refuse.
+var illegalNames = []string{"", ".", "..", "a/b", "/", "a/"}
+
+// TestDirectory tests a read-only Directory. newDir should return a
+// fresh capability to a directory tree containing exactly the files in
+// want, which maps slash-separated paths to contents; any parent
+// directories are implied.
+func TestDirectory(t *testing.T, newDir func(t *testing.T) filesystem.Directory, want map[string][]byte) {
+	run := func(name string, f func(ctx context.Context, t *testing.T, dir filesystem.Directory)) {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+			client, shutdown := serve(newDir(t).Client)
+			defer shutdown()
+			f(ctx, t, filesystem.Directory{Client: client})
+		})
+	}
+
+	run("Tree", func(ctx context.Context, t *testing.T, dir filesystem.Directory) {
+		checkTree(ctx, t, dir, "", want)
+	})
+	run("IllegalNames", func(ctx context.Context, t *testing.T, dir filesystem.Directory) {
+		for _, name := range illegalNames {
+			if _, err := walk(ctx, dir, name); err == nil {
+				t.Errorf("walk(%q) succeeded", name)
+			}
+		}
+	})
+	run("WalkMissing", func(ctx context.Context, t *testing.T, dir filesystem.Directory) {
+		if _, err := walk(ctx, dir, "does-not-exist"); err == nil {
+			t.Error("walk to non-existent child succeeded")
+		}
+	})
+	run("ListWaitsForDone", func(ctx context.Context, t *testing.T, dir filesystem.Directory) {
+		checkListDone(ctx, t, dir)
+	})
+
+	for p, content := range want {
+		p, content := p, content
+		run("Read/"+p, func(ctx context.Context, t *testing.T, dir filesystem.Directory) {
+			node, err := walkPath(ctx, dir, p)
+			if err != nil {
+				t.Fatalf("walk(%q): %v", p, err)
+			}
+			defer node.Client.Release()
+			checkRead(ctx, t, filesystem.File{Client: node.Client}, content)
+		})
+	}
+}
+
+// TestRwDirectory tests an RwDirectory, including everything
+// TestDirectory checks. newDir should return a fresh capability to an
+// empty, writable directory.
+func TestRwDirectory(t *testing.T, newDir func(t *testing.T) filesystem.RwDirectory) {
+	run := func(name string, f func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory)) {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+			client, shutdown := serve(newDir(t).Client)
+			defer shutdown()
+			f(ctx, t, filesystem.RwDirectory{Client: client})
+		})
+	}
+
+	run("Empty", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		checkTree(ctx, t, filesystem.Directory{Client: dir.Client}, "", nil)
+	})
+
+	run("IllegalNames", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		for _, name := range illegalNames {
+			if _, err := walk(ctx, filesystem.Directory{Client: dir.Client}, name); err == nil {
+				t.Errorf("walk(%q) succeeded", name)
+			}
+			if _, err := create(ctx, dir, name, false); err == nil {
+				t.Errorf("create(%q) succeeded", name)
+			}
+			if _, err := mkdir(ctx, dir, name); err == nil {
+				t.Errorf("mkdir(%q) succeeded", name)
+			}
+			if err := remove(ctx, dir, name); err == nil {
+				t.Errorf("delete(%q) succeeded", name)
+			}
+		}
+		checkTree(ctx, t, filesystem.Directory{Client: dir.Client}, "", nil)
+	})
+
+	run("CreateAndRead", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		file := mustCreate(ctx, t, dir, "hello.txt", "Hello, World!")
+		defer file.Client.Release()
+		checkRead(ctx, t, filesystem.File{Client: file.Client}, []byte("Hello, World!"))
+		checkTree(ctx, t, filesystem.Directory{Client: dir.Client}, "", map[string][]byte{
+			"hello.txt": []byte("Hello, World!"),
+		})
+	})
+
+	run("WriteAppend", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		file := mustCreate(ctx, t, dir, "log", "one,")
+		defer file.Client.Release()
+		if err := write(ctx, file, -1, []byte("two,")); err != nil {
+			t.Fatal("write(-1):", err)
+		}
+		if err := write(ctx, file, -1, []byte("three")); err != nil {
+			t.Fatal("write(-1):", err)
+		}
+		checkRead(ctx, t, filesystem.File{Client: file.Client}, []byte("one,two,three"))
+	})
+
+	run("WriteAtOffset", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		file := mustCreate(ctx, t, dir, "f", "abcdef")
+		defer file.Client.Release()
+		if err := write(ctx, file, 2, []byte("XY")); err != nil {
+			t.Fatal("write(2):", err)
+		}
+		checkRead(ctx, t, filesystem.File{Client: file.Client}, []byte("abXYef"))
+		if err := write(ctx, file, 5, []byte("123")); err != nil {
+			t.Fatal("write(5):", err)
+		}
+		checkRead(ctx, t, filesystem.File{Client: file.Client}, []byte("abXYe123"))
+	})
+
+	run("WriteInvalidOffset", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		file := mustCreate(ctx, t, dir, "f", "")
+		defer file.Client.Release()
+		if err := write(ctx, file, -2, []byte("x")); err == nil {
+			t.Error("write(-2) succeeded")
+		}
+	})
+
+	run("Truncate", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		file := mustCreate(ctx, t, dir, "f", "abcdef")
+		defer file.Client.Release()
+		truncate := func(size uint64) {
+			res, release := file.Truncate(ctx, func(p filesystem.RwFile_truncate_Params) error {
+				p.SetSize(size)
+				return nil
+			})
+			defer release()
+			if _, err := res.Struct(); err != nil {
+				t.Fatalf("truncate(%d): %v", size, err)
+			}
+		}
+		truncate(3)
+		checkRead(ctx, t, filesystem.File{Client: file.Client}, []byte("abc"))
+		truncate(5)
+		checkRead(ctx, t, filesystem.File{Client: file.Client}, []byte("abc\x00\x00"))
+	})
+
+	run("SetExec", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		file := mustCreate(ctx, t, dir, "f", "")
+		defer file.Client.Release()
+		for _, exec := range []bool{true, false} {
+			res, release := file.SetExec(ctx, func(p filesystem.RwFile_setExec_Params) error {
+				p.SetExec(exec)
+				return nil
+			})
+			_, err := res.Struct()
+			release()
+			if err != nil {
+				t.Fatalf("setExec(%v): %v", exec, err)
+			}
+			info, err := stat(ctx, filesystem.Node{Client: file.Client})
+			if err != nil {
+				t.Fatal("stat:", err)
+			}
+			if info.executable != exec {
+				t.Errorf("after setExec(%v), stat says executable = %v", exec, info.executable)
+			}
+		}
+	})
+
+	run("CreateExecutable", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		file, err := create(ctx, dir, "script", true)
+		if err != nil {
+			t.Fatal("create:", err)
+		}
+		defer file.Client.Release()
+		info, err := stat(ctx, filesystem.Node{Client: file.Client})
+		if err != nil {
+			t.Fatal("stat:", err)
+		}
+		if !info.executable {
+			t.Error("file created with executable = true is not executable")
+		}
+	})
+
+	run("Subdirectories", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		sub, err := mkdir(ctx, dir, "sub")
+		if err != nil {
+			t.Fatal("mkdir:", err)
+		}
+		defer sub.Client.Release()
+		subsub, err := mkdir(ctx, sub, "subsub")
+		if err != nil {
+			t.Fatal("mkdir:", err)
+		}
+		defer subsub.Client.Release()
+		mustCreate(ctx, t, sub, "a", "A").Client.Release()
+		mustCreate(ctx, t, subsub, "b", "BB").Client.Release()
+		if _, err := mkdir(ctx, dir, "sub"); err == nil {
+			t.Error("mkdir of existing directory succeeded")
+		}
+		checkTree(ctx, t, filesystem.Directory{Client: dir.Client}, "", map[string][]byte{
+			"sub/a":        []byte("A"),
+			"sub/subsub/b": []byte("BB"),
+		})
+	})
+
+	run("Delete", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		mustCreate(ctx, t, dir, "f", "x").Client.Release()
+		sub, err := mkdir(ctx, dir, "sub")
+		if err != nil {
+			t.Fatal("mkdir:", err)
+		}
+		defer sub.Client.Release()
+		mustCreate(ctx, t, sub, "g", "y").Client.Release()
+
+		if err := remove(ctx, dir, "sub"); err == nil {
+			t.Error("deleting a non-empty directory succeeded")
+		}
+		if err := remove(ctx, dir, "does-not-exist"); err == nil {
+			t.Error("deleting a non-existent child succeeded")
+		}
+		if err := remove(ctx, dir, "f"); err != nil {
+			t.Error("delete(f):", err)
+		}
+		if err := remove(ctx, sub, "g"); err != nil {
+			t.Error("delete(sub/g):", err)
+		}
+		if err := remove(ctx, dir, "sub"); err != nil {
+			t.Error("delete(sub):", err)
+		}
+		checkTree(ctx, t, filesystem.Directory{Client: dir.Client}, "", nil)
+	})
+
+	run("Xattrs", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		file := mustCreate(ctx, t, dir, "f", "")
+		defer file.Client.Release()
+		checkXattrs(ctx, t, file)
+	})
+
+	run("ListWaitsForDone", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		for i := 0; i < 3; i++ {
+			mustCreate(ctx, t, dir, string('a'+rune(i)), "").Client.Release()
+		}
+		checkListDone(ctx, t, filesystem.Directory{Client: dir.Client})
+	})
+
+	run("ManyEntries", func(ctx context.Context, t *testing.T, dir filesystem.RwDirectory) {
+		// Enough that implementations which push in batches will use
+		// more than one.
+		want := make(map[string][]byte)
+		for i := 0; i < 2500; i++ {
+			name := "file-" + strings.Repeat("x", i%7) + "-" + strconv.Itoa(i)
+			mustCreate(ctx, t, dir, name, "").Client.Release()
+			want[name] = []byte{}
+		}
+		checkTree(ctx, t, filesystem.Directory{Client: dir.Client}, "", want)
+	})
+}
+
+func mustCreate(ctx context.Context, t *testing.T, dir filesystem.RwDirectory, name, content string) filesystem.RwFile {
+	t.Helper()
+	file, err := create(ctx, dir, name, false)
+	if err != nil {
+		t.Fatalf("create(%q): %v", name, err)
+	}
+	if content != "" {
+		if err := write(ctx, file, 0, []byte(content)); err != nil {
+			t.Fatalf("write to %q: %v", name, err)
+		}
+	}
+	return file
+}
+
+// Walk through a slash-separated path.
+func walkPath(ctx context.Context, dir filesystem.Directory, p string) (filesystem.Node, error) {
+	node := filesystem.Node{Client: dir.Client.AddRef()}
+	for _, name := range strings.Split(p, "/") {
+		child, err := walk(ctx, filesystem.Directory{Client: node.Client}, name)
+		node.Client.Release()
+		if err != nil {
+			return filesystem.Node{}, err
+		}
+		node = child
+	}
+	return node, nil
+}
+
+// Check that the tree under dir (which is at prefix within the whole
+// tree) contains exactly the files in want, with the right contents,
+// and that list and stat agree with each other.
+func checkTree(ctx context.Context, t *testing.T, dir filesystem.Directory, prefix string, want map[string][]byte) {
+	t.Helper()
+
+	// The children of dir we expect, and whether each is a directory.
+	wantChildren := make(map[string]bool)
+	for p := range want {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		rest := p[len(prefix):]
+		if i := strings.Index(rest, "/"); i >= 0 {
+			wantChildren[rest[:i]] = true
+		} else {
+			wantChildren[rest] = false
+		}
+	}
+
+	c, err := list(ctx, dir)
+	if err != nil {
+		t.Fatalf("list(%q): %v", prefix, err)
+	}
+	got := c.names()
+	if len(got) != len(wantChildren) {
+		t.Errorf("list(%q) = %q, want %d entries", prefix, got, len(wantChildren))
+	}
+	for name, isDir := range wantChildren {
+		p := prefix + name
+		info, ok := c.entries[name]
+		if !ok {
+			t.Errorf("list(%q) is missing %q", prefix, name)
+			continue
+		}
+		if info.isDir != isDir {
+			t.Errorf("list(%q): %q has dir = %v, want %v", prefix, name, info.isDir, isDir)
+			continue
+		}
+		node, err := walk(ctx, dir, name)
+		if err != nil {
+			t.Errorf("walk(%q): %v", p, err)
+			continue
+		}
+		statInfo, err := stat(ctx, node)
+		if err != nil {
+			t.Errorf("stat(%q): %v", p, err)
+		} else if statInfo != info {
+			t.Errorf("stat(%q) = %+v, but list says %+v", p, statInfo, info)
+		}
+		if isDir {
+			checkTree(ctx, t, filesystem.Directory{Client: node.Client}, p+"/", want)
+		} else {
+			if info.size != int64(len(want[p])) {
+				t.Errorf("%q has size %d, want %d", p, info.size, len(want[p]))
+			}
+			data, err := readAll(ctx, filesystem.File{Client: node.Client})
+			if err != nil {
+				t.Errorf("readBytes(%q): %v", p, err)
+			} else if !bytes.Equal(data, want[p]) {
+				t.Errorf("%q contains %q, want %q", p, data, want[p])
+			}
+		}
+		node.Client.Release()
+	}
+}
+
+// Check the various ways of reading file, which should contain want.
+func checkRead(ctx context.Context, t *testing.T, file filesystem.File, want []byte) {
+	t.Helper()
+
+	// amount = 0 means read to the end.
+	got, done, err := read(ctx, file, 0, 0)
+	if err != nil {
+		t.Fatal("read(0, 0):", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("read(0, 0) = %q, want %q", got, want)
+	}
+	if !done {
+		t.Error("read returned before calling done on the sink")
+	}
+
+	if len(want) >= 2 {
+		start, amount := 1, len(want)/2
+		got, _, err = read(ctx, file, int64(start), uint64(amount))
+		if err != nil {
+			t.Fatalf("read(%d, %d): %v", start, amount, err)
+		}
+		if !bytes.Equal(got, want[start:start+amount]) {
+			t.Errorf("read(%d, %d) = %q, want %q", start, amount, got, want[start:start+amount])
+		}
+	}
+
+	got, _, err = read(ctx, file, int64(len(want)+10), 0)
+	if err != nil {
+		t.Errorf("read past the end: %v", err)
+	} else if len(got) != 0 {
+		t.Errorf("read past the end returned %q", got)
+	}
+
+	if _, _, err = read(ctx, file, -1, 0); err == nil {
+		t.Error("read(-1, 0) succeeded")
+	}
+	if _, _, err = readBytes(ctx, file, -1, 0); err == nil {
+		t.Error("readBytes(-1, 0) succeeded")
+	}
+
+	got, err = readAll(ctx, file)
+	if err != nil {
+		t.Fatal("readBytes:", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("readBytes returned %q, want %q", got, want)
+	}
+
+	info, err := stat(ctx, filesystem.Node{Client: file.Client})
+	if err != nil {
+		t.Fatal("stat:", err)
+	}
+	if info.isDir || info.size != int64(len(want)) {
+		t.Errorf("stat = %+v, want a file of size %d", info, len(want))
+	}
+}
+
+// Check that list calls done on the stream before returning, and doesn't
+// push anything afterwards.
+func checkListDone(ctx context.Context, t *testing.T, dir filesystem.Directory) {
+	t.Helper()
+	c, err := list(ctx, dir)
+	if err != nil {
+		t.Fatal("list:", err)
+	}
+	c.mu.Lock()
+	done := c.done
+	c.mu.Unlock()
+	if !done {
+		t.Error("list returned before calling done on the stream")
+	}
+	// Give any stray pushes a chance to arrive.
+	time.Sleep(10 * time.Millisecond)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pushAfterDone {
+		t.Error("list pushed entries after calling done")
+	}
+}
+
+func checkXattrs(ctx context.Context, t *testing.T, file filesystem.RwFile) {
+	t.Helper()
+	res, release := file.SetXattr(ctx, func(p filesystem.RwFile_setXattr_Params) error {
+		if err := p.SetName("test"); err != nil {
+			return err
+		}
+		return p.SetValue([]byte("value"))
+	})
+	_, err := res.Struct()
+	release()
+	if capnp.IsUnimplemented(err) {
+		t.Skip("extended attributes not implemented")
+	}
+	if err != nil {
+		t.Fatal("setXattr:", err)
+	}
+
+	get := func() ([]byte, error) {
+		res, release := file.GetXattr(ctx, func(p filesystem.Node_getXattr_Params) error {
+			return p.SetName("test")
+		})
+		defer release()
+		results, err := res.Struct()
+		if err != nil {
+			return nil, err
+		}
+		return results.Value()
+	}
+	value, err := get()
+	if err != nil {
+		t.Fatal("getXattr:", err)
+	}
+	if string(value) != "value" {
+		t.Errorf("getXattr = %q, want %q", value, "value")
+	}
+
+	listRes, release := file.ListXattrs(ctx, nil)
+	results, err := listRes.Struct()
+	if err != nil {
+		t.Fatal("listXattrs:", err)
+	}
+	names, err := results.Names()
+	if err != nil {
+		t.Fatal("listXattrs:", err)
+	}
+	found := false
+	for i := 0; i < names.Len(); i++ {
+		name, _ := names.At(i)
+		found = found || name == "test"
+	}
+	release()
+	if !found {
+		t.Error("listXattrs doesn't include an attribute we set")
+	}
+
+	removeRes, release := file.RemoveXattr(ctx, func(p filesystem.RwFile_removeXattr_Params) error {
+		return p.SetName("test")
+	})
+	_, err = removeRes.Struct()
+	release()
+	if err != nil {
+		t.Fatal("removeXattr:", err)
+	}
+	if _, err = get(); err == nil {
+		t.Error("getXattr succeeded after removeXattr")
+	}
+}
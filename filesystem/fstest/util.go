@@ -0,0 +1,287 @@
+package fstest
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+
+	"zenhack.net/go/sandstorm/exp/util/bytestream"
+
+	"zombiezen.com/go/capnproto2"
+	"zombiezen.com/go/capnproto2/rpc"
+)
+
+// Serve client over an in-process RPC connection, and return a client for
+// the other end, along with a function which shuts the connection down.
+// This way implementations are tested as other grains would see them,
+// rather than via direct calls.
+func serve(client *capnp.Client) (*capnp.Client, func()) {
+	p1, p2 := socketPair()
+	server := rpc.NewConn(rpc.NewStreamTransport(p1), &rpc.Options{
+		BootstrapClient: client,
+	})
+	conn := rpc.NewConn(rpc.NewStreamTransport(p2), nil)
+	remote := conn.Bootstrap(context.Background())
+	return remote, func() {
+		remote.Release()
+		conn.Close()
+		server.Close()
+	}
+}
+
+// Return the two ends of a connected pair of unix sockets. We can't use
+// net.Pipe: its writes wait until the other end reads them, and an rpc
+// connection may write from the goroutine that reads its messages, so two
+// of them joined by a pipe can end up each waiting for the other to read.
+func socketPair() (net.Conn, net.Conn) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		panic(err)
+	}
+	var conns [2]net.Conn
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socketpair")
+		conns[i], err = net.FileConn(f)
+		f.Close()
+		if err != nil {
+			panic(err)
+		}
+	}
+	return conns[0], conns[1]
+}
+
+// A Directory.Entry.Stream which collects the entries pushed to it.
+type entryCollector struct {
+	mu      sync.Mutex
+	entries map[string]entryInfo
+	done    bool
+
+	// Set if push is called after done.
+	pushAfterDone bool
+}
+
+type entryInfo struct {
+	isDir      bool
+	size       int64
+	executable bool
+	writable   bool
+}
+
+func newEntryCollector() *entryCollector {
+	return &entryCollector{entries: make(map[string]entryInfo)}
+}
+
+func (c *entryCollector) Push(ctx context.Context, p filesystem.Directory_Entry_Stream_push) error {
+	entries, err := p.Args().Entries()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done {
+		c.pushAfterDone = true
+	}
+	for i := 0; i < entries.Len(); i++ {
+		name, err := entries.At(i).Name()
+		if err != nil {
+			return err
+		}
+		info, err := entries.At(i).Info()
+		if err != nil {
+			return err
+		}
+		c.entries[name] = newEntryInfo(info)
+	}
+	return nil
+}
+
+func (c *entryCollector) Done(ctx context.Context, p filesystem.Directory_Entry_Stream_done) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done = true
+	return nil
+}
+
+func (c *entryCollector) names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.entries))
+	for name := range c.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newEntryInfo(info filesystem.StatInfo) entryInfo {
+	ret := entryInfo{
+		isDir:      info.Which() == filesystem.StatInfo_Which_dir,
+		executable: info.Executable(),
+		writable:   info.Writable(),
+	}
+	if !ret.isDir {
+		ret.size = info.File().Size()
+	}
+	return ret
+}
+
+// List dir, returning the collector the entries were pushed to.
+func list(ctx context.Context, dir filesystem.Directory) (*entryCollector, error) {
+	c := newEntryCollector()
+	res, release := dir.List(ctx, func(p filesystem.Directory_list_Params) error {
+		return p.SetStream(filesystem.Directory_Entry_Stream_ServerToClient(c, nil))
+	})
+	defer release()
+	_, err := res.Struct()
+	return c, err
+}
+
+func walk(ctx context.Context, dir filesystem.Directory, name string) (filesystem.Node, error) {
+	res, release := dir.Walk(ctx, func(p filesystem.Directory_walk_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return filesystem.Node{}, err
+	}
+	return filesystem.Node{Client: results.Node().Client.AddRef()}, nil
+}
+
+func stat(ctx context.Context, node filesystem.Node) (entryInfo, error) {
+	res, release := node.Stat(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return entryInfo{}, err
+	}
+	info, err := results.Info()
+	if err != nil {
+		return entryInfo{}, err
+	}
+	return newEntryInfo(info), nil
+}
+
+func create(ctx context.Context, dir filesystem.RwDirectory, name string, executable bool) (filesystem.RwFile, error) {
+	res, release := dir.Create(ctx, func(p filesystem.RwDirectory_create_Params) error {
+		p.SetExecutable(executable)
+		return p.SetName(name)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return filesystem.RwFile{}, err
+	}
+	return filesystem.RwFile{Client: results.File().Client.AddRef()}, nil
+}
+
+func mkdir(ctx context.Context, dir filesystem.RwDirectory, name string) (filesystem.RwDirectory, error) {
+	res, release := dir.Mkdir(ctx, func(p filesystem.RwDirectory_mkdir_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return filesystem.RwDirectory{}, err
+	}
+	return filesystem.RwDirectory{Client: results.Dir().Client.AddRef()}, nil
+}
+
+func remove(ctx context.Context, dir filesystem.RwDirectory, name string) error {
+	res, release := dir.Delete(ctx, func(p filesystem.RwDirectory_delete_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+// Write data to file, starting at startAt, and wait for it to finish.
+func write(ctx context.Context, file filesystem.RwFile, startAt int64, data []byte) error {
+	res, release := file.Write(ctx, func(p filesystem.RwFile_write_Params) error {
+		p.SetStartAt(startAt)
+		return nil
+	})
+	defer release()
+	wc := bytestream.ToWriteCloser(ctx, res.Sink())
+	if _, err := wc.Write(data); err != nil {
+		wc.Close()
+		return err
+	}
+	return wc.Close()
+}
+
+// A buffer which can be used as a read sink.
+type sinkBuffer struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (b *sinkBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *sinkBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
+
+// Read from file with the read method. Also reports whether the sink was
+// closed (by calling done) before read returned.
+func read(ctx context.Context, file filesystem.File, startAt int64, amount uint64) ([]byte, bool, error) {
+	b := &sinkBuffer{}
+	res, release := file.Read(ctx, func(p filesystem.File_read_Params) error {
+		p.SetStartAt(startAt)
+		p.SetAmount(amount)
+		return p.SetSink(bytestream.FromWriteCloser(b, nil))
+	})
+	defer release()
+	_, err := res.Struct()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes(), b.closed, err
+}
+
+func readBytes(ctx context.Context, file filesystem.File, startAt int64, amount uint64) ([]byte, bool, error) {
+	res, release := file.ReadBytes(ctx, func(p filesystem.File_readBytes_Params) error {
+		p.SetStartAt(startAt)
+		p.SetAmount(amount)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := results.Data()
+	if err != nil {
+		return nil, false, err
+	}
+	return append([]byte(nil), data...), results.Eof(), nil
+}
+
+// Read the whole of file using readBytes.
+func readAll(ctx context.Context, file filesystem.File) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		data, eof, err := readBytes(ctx, file, int64(buf.Len()), 0)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		if eof || len(data) == 0 {
+			return buf.Bytes(), nil
+		}
+	}
+}
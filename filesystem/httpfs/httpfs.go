@@ -4,13 +4,14 @@ import (
 	"context"
 	"errors"
 	"io"
+	"mime"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"zenhack.net/go/sandstorm-filesystem/filesystem"
-	"zenhack.net/go/sandstorm/exp/util/bytestream"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
 
 	"zombiezen.com/go/capnproto2"
 )
@@ -46,6 +47,7 @@ type File struct {
 }
 
 func (f *File) Close() error {
+	f.Node.Client.Release()
 	return nil
 }
 
@@ -53,22 +55,32 @@ func (f *File) Read(buf []byte) (n int, err error) {
 	if f.Info.IsDir() {
 		return 0, InvalidArgument
 	}
-	r, w := io.Pipe()
+	if len(buf) == 0 {
+		return 0, nil
+	}
 	file := filesystem.File{Client: f.Node.Client}
-	file.Read(context.TODO(), func(p filesystem.File_read_Params) error {
+	res, release := file.ReadBytes(context.TODO(), func(p filesystem.File_readBytes_Params) error {
 		p.SetStartAt(f.pos)
 		p.SetAmount(uint64(len(buf)))
-		p.SetSink(bytestream.FromWriteCloser(w, nil))
 		return nil
 	})
-	n, err = io.ReadFull(r, buf)
-	if err == io.ErrUnexpectedEOF {
-		// ReadFull expects to read the full buffer, but
-		// a short read is OK for Read in general.
-		err = nil
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return 0, err
+	}
+	data, err := results.Data()
+	if err != nil {
+		return 0, err
+	}
+	// The server may return less than we asked for, which is fine;
+	// a short read is OK for Read in general.
+	n = copy(buf, data)
+	f.pos += int64(n)
+	if n == 0 && results.Eof() {
+		return 0, io.EOF
 	}
-	r.Close()
-	return n, err
+	return n, nil
 }
 
 func (f *File) Seek(offset int64, whence int) (int64, error) {
@@ -224,57 +236,94 @@ func (fi *FileInfo) Sys() interface{} {
 	return fi.info
 }
 
+// Returns the MIME type recorded for the file, or "" if there is none.
+func (fi *FileInfo) MimeType() string {
+	mimeType, _ := fi.info.MimeType()
+	return mimeType
+}
+
+// FileServer is like http.FileServer, except that it uses the MIME types
+// reported by the filesystem, if any, rather than guessing them from the
+// file's name and contents. MIME types are set by whoever can write the
+// file, so ones which don't parse are ignored, and browsers are told not
+// to second-guess the rest.
+func FileServer(fs *FileSystem) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.FileServer(mimeTypeFS{fs: fs, w: w}).ServeHTTP(w, req)
+	})
+}
+
+// An http.FileSystem for use by FileServer, which sets the Content-Type of
+// the response to the MIME type of each file it opens, so that we don't
+// have to open the file a second time just to find it. http.FileServer
+// leaves Content-Type alone if it is already set.
+type mimeTypeFS struct {
+	fs *FileSystem
+	w  http.ResponseWriter
+}
+
+func (m mimeTypeFS) Open(name string) (http.File, error) {
+	f, err := m.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	fi := f.(*File).Info
+	if contentType := contentType(fi.MimeType()); !fi.IsDir() && contentType != "" {
+		m.w.Header().Set("Content-Type", contentType)
+	}
+	return f, nil
+}
+
+// Return mimeType in a form fit for a Content-Type header, or "" if it
+// isn't a valid MIME type.
+func contentType(mimeType string) string {
+	if mimeType == "" {
+		return ""
+	}
+	mediaType, params, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return ""
+	}
+	return mime.FormatMediaType(mediaType, params)
+}
+
 func (fs *FileSystem) Open(name string) (http.File, error) {
-	parts := strings.Split(name, "/")
-	parts = parts[1:] // remove the empty string at the start
+	parts := strings.Split(strings.Trim(name, "/"), "/")
 	if len(parts) != 0 && parts[0] == "fs" {
 		// TODO(cleanup): This logic ought to go elsewhere.
 
 		// strip off the path prefix
 		parts = parts[1:]
 	}
-	toRelease := make([]capnp.ReleaseFunc, 0, len(parts))
-
-	var node filesystem.Node
-	var dir filesystem.Directory
-	node = filesystem.Node{Client: fs.Dir.Client}
-	dir.Client = node.Client
-
-	defer func() {
-		for _, release := range toRelease {
-			release()
-		}
-	}()
-
-	for _, nodeName := range parts {
-		res, release := dir.Walk(context.TODO(), func(p filesystem.Directory_walk_Params) error {
-			p.SetName(nodeName)
-			return nil
-		})
-		node = res.Node()
-		toRelease = append(toRelease, release)
-		dir = filesystem.Directory{node.Client}
-	}
-	ret, _ := node.Stat(context.TODO(), func(p filesystem.Node_stat_Params) error {
-		return nil
-	})
+	p := strings.Join(parts, "/")
 
-	info, err := ret.Info().Struct()
+	ctx := context.TODO()
+	node, err := client.Walk(ctx, fs.Dir, p)
 	if err != nil {
 		return nil, err
 	}
-
-	var retName string
-	if len(parts) == 0 {
-		retName = ""
-	} else {
-		retName = parts[len(parts)-1]
+	res, release := node.Stat(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err == nil {
+		var info filesystem.StatInfo
+		info, err = results.Info()
+		if err == nil {
+			var retName string
+			if len(parts) != 0 {
+				retName = parts[len(parts)-1]
+			}
+			return &File{
+				Node: node,
+				Name: retName,
+				Info: &FileInfo{
+					name: retName,
+					info: cloneInfo(info),
+				},
+			}, nil
+		}
 	}
-	return &File{
-		Node: node,
-		Info: &FileInfo{
-			name: retName,
-			info: cloneInfo(info),
-		},
-	}, nil
+	node.Client.Release()
+	return nil, err
 }
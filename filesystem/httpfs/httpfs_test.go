@@ -0,0 +1,151 @@
+package httpfs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+)
+
+// Serve a memfs holding a few files, as the reference for what
+// FileServer should send.
+func newTestServer(t *testing.T) *httptest.Server {
+	ctx := context.Background()
+	root := memfs.New(0).Root()
+	t.Cleanup(root.Client.Release)
+	files := map[string]string{
+		"hello.txt":        "Hello, world!\n",
+		"sub/index.html":   "<p>index</p>",
+		"sub/notes/a.md":   "# A",
+		"sub/notes/b.json": "{}",
+	}
+	for name, data := range files {
+		if err := client.WriteFile(ctx, root, name, []byte(data), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// data.bin has a MIME type recorded, which should win over
+	// guessing from its name. bad.txt has one which isn't valid, and
+	// should be ignored.
+	for name, mimeType := range map[string]string{
+		"data.bin": "application/x-test",
+		"bad.txt":  "text/html\r\nX-Injected: yes",
+	} {
+		if err := client.WriteFile(ctx, root, name, []byte("0123456789"), false); err != nil {
+			t.Fatal(err)
+		}
+		setXattr(ctx, t, root, name, memfs.MimeTypeXattr, mimeType)
+	}
+
+	srv := httptest.NewServer(FileServer(&FileSystem{
+		Dir: filesystem.Directory{Client: root.Client},
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func setXattr(ctx context.Context, t *testing.T, root filesystem.RwDirectory, p, name, value string) {
+	t.Helper()
+	node, err := client.Walk(ctx, filesystem.Directory{Client: root.Client}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Client.Release()
+	res, release := filesystem.RwFile{Client: node.Client}.SetXattr(ctx, func(p filesystem.RwFile_setXattr_Params) error {
+		if err := p.SetName(name); err != nil {
+			return err
+		}
+		return p.SetValue([]byte(value))
+	})
+	defer release()
+	if _, err = res.Struct(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(t *testing.T, req *http.Request) (*http.Response, string) {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestFileServer(t *testing.T) {
+	srv := newTestServer(t)
+	tests := []struct {
+		path        string
+		rangeHeader string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"/hello.txt", "", http.StatusOK, "text/plain; charset=utf-8", "Hello, world!\n"},
+		{"/data.bin", "", http.StatusOK, "application/x-test", "0123456789"},
+		{"/data.bin", "bytes=2-4", http.StatusPartialContent, "application/x-test", "234"},
+		{"/bad.txt", "", http.StatusOK, "text/plain; charset=utf-8", "0123456789"},
+		{"/hello.txt", "bytes=-3", http.StatusPartialContent, "text/plain; charset=utf-8", "d!\n"},
+		{"/sub/", "", http.StatusOK, "text/html; charset=utf-8", "<p>index</p>"},
+		{"/missing", "", http.StatusNotFound, "", ""},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest("GET", srv.URL+test.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.rangeHeader != "" {
+			req.Header.Set("Range", test.rangeHeader)
+		}
+		resp, body := get(t, req)
+		if resp.StatusCode != test.status {
+			t.Errorf("GET %s (Range %q): status %d, want %d",
+				test.path, test.rangeHeader, resp.StatusCode, test.status)
+			continue
+		}
+		if test.status == http.StatusNotFound {
+			continue
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != test.contentType {
+			t.Errorf("GET %s: Content-Type %q, want %q", test.path, ct, test.contentType)
+		}
+		if resp.Header.Get("X-Injected") != "" {
+			t.Errorf("GET %s: the MIME type added a header", test.path)
+		}
+		if nosniff := resp.Header.Get("X-Content-Type-Options"); nosniff != "nosniff" {
+			t.Errorf("GET %s: X-Content-Type-Options %q, want nosniff", test.path, nosniff)
+		}
+		if body != test.body {
+			t.Errorf("GET %s (Range %q): got %q, want %q",
+				test.path, test.rangeHeader, body, test.body)
+		}
+	}
+}
+
+func TestFileServerListing(t *testing.T) {
+	srv := newTestServer(t)
+	req, err := http.NewRequest("GET", srv.URL+"/sub/notes/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, body := get(t, req)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	for _, name := range []string{"a.md", "b.json"} {
+		if !strings.Contains(body, `href="`+name+`"`) {
+			t.Errorf("listing doesn't link to %s:\n%s", name, body)
+		}
+	}
+}
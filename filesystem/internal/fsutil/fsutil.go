@@ -0,0 +1,54 @@
+// Package fsutil holds what the packages implementing the filesystem
+// interfaces -- local, memfs and iofs -- have in common, so that they
+// validate arguments, and hash files, the same way.
+package fsutil
+
+import (
+	"crypto/sha256"
+	"hash"
+	"hash/crc32"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+)
+
+// The maximum number of bytes returned by a single call to ReadBytes.
+const MaxReadBytes = 64 * 1024
+
+// The hash algorithms supported by File.hash.
+var HashAlgorithms = map[filesystem.File_HashAlgorithm]func() hash.Hash{
+	filesystem.File_HashAlgorithm_sha256: sha256.New,
+	filesystem.File_HashAlgorithm_blake2b256: func() hash.Hash {
+		h, err := blake2b.New256(nil)
+		if err != nil {
+			// Only happens if the key is too long, and we don't use one.
+			panic(err)
+		}
+		return h
+	},
+	filesystem.File_HashAlgorithm_crc32: func() hash.Hash {
+		return crc32.NewIEEE()
+	},
+}
+
+// ValidFileName reports whether name may be used as the name of a file
+// or directory.
+func ValidFileName(name string) bool {
+	return name != "" &&
+		name != "." &&
+		name != ".." &&
+		!strings.Contains(name, "/")
+}
+
+// ValidXattrName reports whether name may be used as the name of an
+// extended attribute. Package local stores them on the host under the
+// "user." namespace, and Linux limits attribute names to 255 bytes,
+// including the prefix; the others enforce the same limit, so that files
+// can be copied between them.
+func ValidXattrName(name string) bool {
+	return name != "" &&
+		len("user.")+len(name) <= 255 &&
+		!strings.Contains(name, "\x00")
+}
@@ -0,0 +1,519 @@
+package iofs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+)
+
+// An FS presents a Directory capability as an fs.FS, so that it can be
+// used with fs.WalkDir, template.ParseFS, http.FS, and so on. In addition
+// to fs.FS, it implements fs.ReadDirFS, fs.StatFS, fs.ReadFileFS and
+// fs.SubFS.
+//
+// The fs.FS interface has no way to pass a context, so calls are made
+// with the FS's context instead; see WithContext and WithTimeout.
+type FS struct {
+	dir filesystem.Directory
+
+	// The path of the directory under dir which this FS presents, or
+	// "." for dir itself; see Sub.
+	root string
+
+	ctx     context.Context
+	timeout time.Duration
+}
+
+var (
+	_ fs.ReadDirFS  = &FS{}
+	_ fs.StatFS     = &FS{}
+	_ fs.ReadFileFS = &FS{}
+	_ fs.SubFS      = &FS{}
+)
+
+// NewFS returns an FS for dir. It does not take ownership of dir.Client;
+// the caller must keep it alive for as long as the FS is in use.
+func NewFS(dir filesystem.Directory) *FS {
+	return &FS{dir: dir, root: ".", ctx: context.Background()}
+}
+
+// WithContext returns a copy of fsys which makes calls with ctx. Once ctx
+// is cancelled, operations on the copy (and on files opened through it)
+// fail.
+func (fsys *FS) WithContext(ctx context.Context) *FS {
+	ret := *fsys
+	ret.ctx = ctx
+	return &ret
+}
+
+// WithTimeout returns a copy of fsys in which each operation -- each call
+// to one of the FS's methods, or to a method of a file opened through
+// it -- fails if it takes longer than timeout. Zero means no timeout.
+func (fsys *FS) WithTimeout(timeout time.Duration) *FS {
+	ret := *fsys
+	ret.timeout = timeout
+	return &ret
+}
+
+// Return a context for a single operation.
+func (fsys *FS) context() (context.Context, context.CancelFunc) {
+	if fsys.timeout == 0 {
+		return context.WithCancel(fsys.ctx)
+	}
+	return context.WithTimeout(fsys.ctx, fsys.timeout)
+}
+
+// Walk to the node at name, which must be a valid path as per
+// fs.ValidPath. The caller must release the result.
+func (fsys *FS) walk(ctx context.Context, op, name string) (filesystem.Node, error) {
+	if !fs.ValidPath(name) {
+		return filesystem.Node{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	node := filesystem.Node{Client: fsys.dir.Client.AddRef()}
+	full := path.Join(fsys.root, name)
+	if full == "." {
+		return node, nil
+	}
+	for _, part := range strings.Split(full, "/") {
+		res, release := filesystem.Directory{Client: node.Client}.Walk(ctx, func(p filesystem.Directory_walk_Params) error {
+			return p.SetName(part)
+		})
+		results, err := res.Struct()
+		node.Client.Release()
+		if err != nil {
+			release()
+			return filesystem.Node{}, &fs.PathError{Op: op, Path: name, Err: walkError{err}}
+		}
+		node = filesystem.Node{Client: results.Node().Client.AddRef()}
+		release()
+	}
+	return node, nil
+}
+
+// An error from walk. These match the io/fs error for the code the server
+// sent, if any. Servers which don't send codes nearly always mean there's
+// nothing there, so otherwise these match fs.ErrNotExist, as http.FS and
+// others expect.
+type walkError struct {
+	err error
+}
+
+func (e walkError) Error() string {
+	return e.err.Error()
+}
+
+func (e walkError) Unwrap() error {
+	return e.err
+}
+
+func (e walkError) Is(target error) bool {
+	if fserrors.CodeOf(e.err) != filesystem.ErrorCode_unknown {
+		return errors.Is(fserrors.Decode(e.err), target)
+	}
+	return target == fs.ErrNotExist
+}
+
+func statNode(ctx context.Context, node filesystem.Node, name string) (*fileInfo, error) {
+	res, release := node.Stat(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return nil, err
+	}
+	info, err := results.Info()
+	if err != nil {
+		return nil, err
+	}
+	return newFileInfo(name, info)
+}
+
+func (fsys *FS) Open(name string) (fs.File, error) {
+	ctx, cancel := fsys.context()
+	defer cancel()
+	node, err := fsys.walk(ctx, "open", name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := statNode(ctx, node, baseName(name))
+	if err != nil {
+		node.Client.Release()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fserrors.Decode(err)}
+	}
+	f := &file{fsys: fsys, node: node, name: name, info: fi}
+	if fi.IsDir() {
+		return &dirFile{file: f}, nil
+	}
+	return f, nil
+}
+
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	ctx, cancel := fsys.context()
+	defer cancel()
+	node, err := fsys.walk(ctx, "stat", name)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Client.Release()
+	fi, err := statNode(ctx, node, baseName(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fserrors.Decode(err)}
+	}
+	return fi, nil
+}
+
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	ctx, cancel := fsys.context()
+	defer cancel()
+	node, err := fsys.walk(ctx, "readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Client.Release()
+	entries, err := listDir(ctx, filesystem.Directory{Client: node.Client})
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fserrors.Decode(err)}
+	}
+	return entries, nil
+}
+
+func (fsys *FS) ReadFile(name string) ([]byte, error) {
+	ctx, cancel := fsys.context()
+	defer cancel()
+	node, err := fsys.walk(ctx, "read", name)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Client.Release()
+	var data []byte
+	for {
+		chunk, eof, err := readBytes(ctx, filesystem.File{Client: node.Client}, int64(len(data)), MaxReadBytes)
+		if err != nil {
+			return nil, &fs.PathError{Op: "read", Path: name, Err: fserrors.Decode(err)}
+		}
+		data = append(data, chunk...)
+		if eof || len(chunk) == 0 {
+			return data, nil
+		}
+	}
+}
+
+// Sub returns an FS for the subdirectory dir. The new FS walks to dir
+// from fsys's directory on each operation, rather than holding a
+// capability of its own, so like fsys it needs nothing released when it
+// is no longer used.
+func (fsys *FS) Sub(dir string) (fs.FS, error) {
+	ctx, cancel := fsys.context()
+	defer cancel()
+	node, err := fsys.walk(ctx, "sub", dir)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Client.Release()
+	fi, err := statNode(ctx, node, baseName(dir))
+	if err != nil {
+		return nil, &fs.PathError{Op: "sub", Path: dir, Err: fserrors.Decode(err)}
+	}
+	if !fi.IsDir() {
+		return nil, &fs.PathError{Op: "sub", Path: dir, Err: errNotDir}
+	}
+	ret := *fsys
+	ret.root = path.Join(fsys.root, dir)
+	return &ret, nil
+}
+
+func baseName(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// Read up to amount bytes from file, starting at startAt.
+func readBytes(ctx context.Context, file filesystem.File, startAt int64, amount int) ([]byte, bool, error) {
+	res, release := file.ReadBytes(ctx, func(p filesystem.File_readBytes_Params) error {
+		p.SetStartAt(startAt)
+		p.SetAmount(uint64(amount))
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := results.Data()
+	if err != nil {
+		return nil, false, err
+	}
+	return append([]byte(nil), data...), results.Eof(), nil
+}
+
+// Return the entries of dir, sorted by name.
+func listDir(ctx context.Context, dir filesystem.Directory) ([]fs.DirEntry, error) {
+	c := &entryCollector{}
+	res, release := dir.List(ctx, func(p filesystem.Directory_list_Params) error {
+		return p.SetStream(filesystem.Directory_Entry_Stream_ServerToClient(c, nil))
+	})
+	defer release()
+	if _, err := res.Struct(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sort.Slice(c.entries, func(i, j int) bool {
+		return c.entries[i].Name() < c.entries[j].Name()
+	})
+	return c.entries, nil
+}
+
+type entryCollector struct {
+	mu      sync.Mutex
+	entries []fs.DirEntry
+}
+
+func (c *entryCollector) Push(ctx context.Context, p filesystem.Directory_Entry_Stream_push) error {
+	entries, err := p.Args().Entries()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < entries.Len(); i++ {
+		name, err := entries.At(i).Name()
+		if err != nil {
+			return err
+		}
+		info, err := entries.At(i).Info()
+		if err != nil {
+			return err
+		}
+		fi, err := newFileInfo(name, info)
+		if err != nil {
+			return err
+		}
+		c.entries = append(c.entries, fs.FileInfoToDirEntry(fi))
+	}
+	return nil
+}
+
+func (c *entryCollector) Done(ctx context.Context, p filesystem.Directory_Entry_Stream_done) error {
+	return nil
+}
+
+// Implements fs.FileInfo, based on a StatInfo.
+type fileInfo struct {
+	name       string
+	isDir      bool
+	size       int64
+	executable bool
+	writable   bool
+	mimeType   string
+}
+
+func newFileInfo(name string, info filesystem.StatInfo) (*fileInfo, error) {
+	mimeType, err := info.MimeType()
+	if err != nil {
+		return nil, err
+	}
+	fi := &fileInfo{
+		name:       name,
+		isDir:      info.Which() == filesystem.StatInfo_Which_dir,
+		executable: info.Executable(),
+		writable:   info.Writable(),
+		mimeType:   mimeType,
+	}
+	if !fi.isDir {
+		fi.size = info.File().Size()
+	}
+	return fi, nil
+}
+
+func (fi *fileInfo) Name() string {
+	return fi.name
+}
+
+func (fi *fileInfo) Size() int64 {
+	return fi.size
+}
+
+func (fi *fileInfo) Mode() fs.FileMode {
+	mode := fs.FileMode(0444)
+	if fi.isDir {
+		mode |= fs.ModeDir | 0111
+	}
+	if fi.executable {
+		mode |= 0111
+	}
+	if fi.writable {
+		mode |= 0200
+	}
+	return mode
+}
+
+// The schema doesn't include modification times.
+func (fi *fileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (fi *fileInfo) IsDir() bool {
+	return fi.isDir
+}
+
+// Returns the MIME type of the file, as a string; empty if unknown.
+func (fi *fileInfo) Sys() interface{} {
+	return fi.mimeType
+}
+
+// An open file. Implements fs.File, io.ReaderAt and io.Seeker.
+type file struct {
+	fsys *FS
+	node filesystem.Node
+	name string
+	info *fileInfo
+
+	mu     sync.Mutex
+	offset int64
+	closed bool
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *file) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	f.node.Client.Release()
+	return nil
+}
+
+func (f *file) Read(buf []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.readAt(buf, f.offset, false)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *file) ReadAt(buf []byte, offset int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.readAt(buf, offset, true)
+}
+
+// Read into buf from offset. If full is true, keep reading until buf is
+// full, as io.ReaderAt requires. Must be called with f.mu held.
+func (f *file) readAt(buf []byte, offset int64, full bool) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrClosed}
+	}
+	if f.info.isDir {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: errIsDir}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrInvalid}
+	}
+	ctx, cancel := f.fsys.context()
+	defer cancel()
+	n := 0
+	for n < len(buf) {
+		amount := len(buf) - n
+		if amount > MaxReadBytes {
+			amount = MaxReadBytes
+		}
+		data, eof, err := readBytes(ctx, filesystem.File{Client: f.node.Client}, offset+int64(n), amount)
+		if err != nil {
+			return n, &fs.PathError{Op: "read", Path: f.name, Err: fserrors.Decode(err)}
+		}
+		n += copy(buf[n:], data)
+		if eof || len(data) == 0 {
+			if n < len(buf) {
+				return n, io.EOF
+			}
+			break
+		}
+		if !full {
+			// A short read is fine for Read.
+			break
+		}
+	}
+	return n, nil
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.size
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+var (
+	errIsDir  = errors.New("is a directory")
+	errNotDir = errors.New("not a directory")
+)
+
+// An open directory. Implements fs.ReadDirFile.
+type dirFile struct {
+	*file
+
+	// Whether we've listed the directory yet, and the entries not yet
+	// returned by ReadDir.
+	listed  bool
+	entries []fs.DirEntry
+}
+
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil, &fs.PathError{Op: "readdir", Path: d.name, Err: fs.ErrClosed}
+	}
+	if !d.listed {
+		ctx, cancel := d.fsys.context()
+		defer cancel()
+		entries, err := listDir(ctx, filesystem.Directory{Client: d.node.Client})
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: d.name, Err: fserrors.Decode(err)}
+		}
+		d.listed = true
+		d.entries = entries
+	}
+	if n <= 0 {
+		ret := d.entries
+		d.entries = nil
+		if ret == nil {
+			ret = []fs.DirEntry{}
+		}
+		return ret, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	ret := d.entries[:n]
+	d.entries = d.entries[n:]
+	return ret, nil
+}
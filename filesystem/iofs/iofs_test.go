@@ -0,0 +1,73 @@
+package iofs
+
+import (
+	"context"
+	"testing"
+	gofstest "testing/fstest"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fstest"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+)
+
+var testFiles = map[string]string{
+	"hello.txt":          "Hello, world!\n",
+	"empty":              "",
+	"sub/a.md":           "# A",
+	"sub/deeper/b.json":  "{}",
+	"sub/deeper/big.bin": string(make([]byte, 3*MaxReadBytes+5)),
+}
+
+func testMapFS() gofstest.MapFS {
+	fsys := gofstest.MapFS{}
+	for name, data := range testFiles {
+		fsys[name] = &gofstest.MapFile{Data: []byte(data), Mode: 0644}
+	}
+	return fsys
+}
+
+func testFileNames() []string {
+	names := make([]string, 0, len(testFiles))
+	for name := range testFiles {
+		names = append(names, name)
+	}
+	return names
+}
+
+// The Directory served by New follows the rules in filesystem.capnp.
+func TestConformance(t *testing.T) {
+	want := make(map[string][]byte, len(testFiles))
+	for name, data := range testFiles {
+		want[name] = []byte(data)
+	}
+	fstest.TestDirectory(t, func(t *testing.T) filesystem.Directory {
+		return New(testMapFS())
+	}, want)
+}
+
+// An FS made from some other implementation of Directory behaves as
+// io/fs requires.
+func TestFS(t *testing.T) {
+	ctx := context.Background()
+	root := memfs.New(0).Root()
+	defer root.Client.Release()
+	for name, data := range testFiles {
+		if err := client.WriteFile(ctx, root, name, []byte(data), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsys := NewFS(filesystem.Directory{Client: root.Client})
+	if err := gofstest.TestFS(fsys, testFileNames()...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Going from an fs.FS to a Directory and back again gets the same files.
+func TestRoundTrip(t *testing.T) {
+	dir := New(testMapFS())
+	defer dir.Client.Release()
+	if err := gofstest.TestFS(NewFS(dir), testFileNames()...); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,390 @@
+// Package iofs adapts between the filesystem interfaces and Go's io/fs.
+//
+// New exports any fs.FS -- embedded assets, a zip archive, an os.DirFS --
+// as a read-only Directory capability, so it can be shared with other
+// grains without copying it to disk. It goes through fs.Stat and
+// fs.ReadDir, so file systems implementing fs.StatFS and fs.ReadDirFS get
+// their fast paths, and serves ranged reads with io.ReaderAt or io.Seeker
+// where files support them.
+package iofs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"mime"
+	"path"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/internal/fsutil"
+
+	"zenhack.net/go/sandstorm/exp/util/bytestream"
+
+	"zombiezen.com/go/capnproto2"
+	"zombiezen.com/go/capnproto2/server"
+)
+
+// The maximum number of bytes returned by a single call to ReadBytes.
+const MaxReadBytes = fsutil.MaxReadBytes
+
+// The maximum number of entries sent in one push by List.
+const maxListBatch = 1024
+
+var (
+	InvalidArgument = fserrors.New(filesystem.ErrorCode_invalidArgument, "Invalid argument")
+	IllegalFileName = fserrors.New(filesystem.ErrorCode_invalidName, "Illegal file name")
+	NotFound        = fserrors.New(filesystem.ErrorCode_notFound, "No such file or directory")
+	NoSuchAttribute = fserrors.New(filesystem.ErrorCode_notFound, "No such attribute")
+	OpenFailed      = fserrors.New(filesystem.ErrorCode_io, "Open failed")
+	NotImplemented  = capnp.Unimplemented("Not implemented")
+)
+
+// New returns a read-only Directory capability for the root of fsys.
+func New(fsys fs.FS) filesystem.Directory {
+	n := &node{fsys: fsys, path: ".", isDir: true}
+	return filesystem.Directory{Client: n.makeClient()}
+}
+
+// A file or directory in an fs.FS. Implements filesystem.Directory_Server
+// and filesystem.File_Server.
+type node struct {
+	fsys  fs.FS
+	path  string
+	isDir bool
+}
+
+func (n *node) makeClient() *capnp.Client {
+	var methods []server.Method
+	if n.isDir {
+		methods = filesystem.Directory_Methods(nil, n)
+	} else {
+		methods = filesystem.File_Methods(nil, n)
+	}
+	return capnp.NewClient(server.New(methods, n, nil, nil))
+}
+
+// Fill in info from fi.
+func setStatInfo(info filesystem.StatInfo, fi fs.FileInfo) error {
+	if fi.IsDir() {
+		info.SetDir()
+	} else {
+		info.SetFile()
+		info.File().SetSize(fi.Size())
+	}
+	info.SetWritable(false)
+	info.SetExecutable(fi.Mode()&0100 != 0)
+	if fi.IsDir() {
+		return nil
+	}
+	return info.SetMimeType(mime.TypeByExtension(path.Ext(fi.Name())))
+}
+
+// Translate an error from fsys into one we can hand to clients; errors
+// from fs.FS implementations may contain paths on the host.
+func censor(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return NotFound
+	}
+	return OpenFailed
+}
+
+func (n *node) Stat(ctx context.Context, p filesystem.Node_stat) error {
+	fi, err := fs.Stat(n.fsys, n.path)
+	if err != nil {
+		return censor(err)
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	info, err := res.NewInfo()
+	if err != nil {
+		return err
+	}
+	return setStatInfo(info, fi)
+}
+
+// fs.FS has no extended attributes.
+
+func (n *node) GetXattr(ctx context.Context, p filesystem.Node_getXattr) error {
+	return NoSuchAttribute
+}
+
+func (n *node) ListXattrs(ctx context.Context, p filesystem.Node_listXattrs) error {
+	_, err := p.AllocResults()
+	return err
+}
+
+func (n *node) List(ctx context.Context, p filesystem.Directory_list) error {
+	// Don't block other calls while we wait on the stream.
+	p.Ack()
+	stream := p.Args().Stream()
+	entries, err := fs.ReadDir(n.fsys, n.path)
+	if err != nil {
+		return censor(err)
+	}
+
+	for len(entries) > 0 && ctx.Err() == nil {
+		batch := entries
+		if len(batch) > maxListBatch {
+			batch = batch[:maxListBatch]
+		}
+		entries = entries[len(batch):]
+
+		infos := make([]fs.FileInfo, 0, len(batch))
+		for _, ent := range batch {
+			fi, err := ent.Info()
+			if err != nil {
+				// Removed since we read the directory.
+				continue
+			}
+			infos = append(infos, fi)
+		}
+		res, release := stream.Push(ctx, func(p filesystem.Directory_Entry_Stream_push_Params) error {
+			list, err := p.NewEntries(int32(len(infos)))
+			if err != nil {
+				return err
+			}
+			for i, fi := range infos {
+				ent := list.At(i)
+				if err = ent.SetName(fi.Name()); err != nil {
+					return err
+				}
+				info, err := ent.NewInfo()
+				if err != nil {
+					return err
+				}
+				if err = setStatInfo(info, fi); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		_, err := res.Struct()
+		release()
+		if err != nil {
+			return err
+		}
+	}
+
+	res, release := stream.Done(ctx, nil)
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (n *node) Walk(ctx context.Context, p filesystem.Directory_walk) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidFileName(name) {
+		return IllegalFileName
+	}
+	childPath := path.Join(n.path, name)
+	fi, err := fs.Stat(n.fsys, childPath)
+	if err != nil {
+		return censor(err)
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	child := &node{fsys: n.fsys, path: childPath, isDir: fi.IsDir()}
+	return res.SetNode(filesystem.Node{Client: child.makeClient()})
+}
+
+// fs.FS has no snapshots or versions, so these are always empty.
+
+func (n *node) Snapshots(ctx context.Context, p filesystem.Directory_snapshots) error {
+	_, err := p.AllocResults()
+	return err
+}
+
+func (n *node) Versions(ctx context.Context, p filesystem.File_versions) error {
+	_, err := p.AllocResults()
+	return err
+}
+
+// fs.FS has no way to watch for changes.
+func (n *node) Watch(ctx context.Context, p filesystem.Directory_watch) error {
+	return NotImplemented
+}
+
+// Open the file and return a reader for the range described by startAt
+// and amount, where amount == 0 means "to the end of the file". Uses
+// io.ReaderAt or io.Seeker if the file supports them, so that we don't
+// have to read through everything before startAt.
+func (n *node) openRange(startAt int64, amount uint64) (io.ReadCloser, error) {
+	if startAt < 0 {
+		return nil, InvalidArgument
+	}
+	if amount > math.MaxInt64 {
+		// Larger than any file could be, so the same as reading
+		// the whole thing.
+		amount = 0
+	}
+	file, err := n.fsys.Open(n.path)
+	if err != nil {
+		return nil, censor(err)
+	}
+	// Some files' ReadAt fails if asked to start past the end, rather
+	// than returning io.EOF, but reading there should just get nothing.
+	if fi, err := file.Stat(); err == nil && startAt > fi.Size() {
+		startAt = fi.Size()
+	}
+
+	var r io.Reader
+	if ra, ok := file.(io.ReaderAt); ok {
+		limit := int64(math.MaxInt64 - startAt)
+		if amount != 0 && int64(amount) < limit {
+			limit = int64(amount)
+		}
+		r = io.NewSectionReader(ra, startAt, limit)
+	} else {
+		if s, ok := file.(io.Seeker); ok {
+			_, err = s.Seek(startAt, io.SeekStart)
+		} else {
+			_, err = io.CopyN(io.Discard, file, startAt)
+			if err == io.EOF {
+				err = nil
+			}
+		}
+		if err != nil {
+			file.Close()
+			return nil, OpenFailed
+		}
+		r = file
+		if amount != 0 {
+			r = io.LimitReader(r, int64(amount))
+		}
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, file}, nil
+}
+
+func (n *node) Read(ctx context.Context, p filesystem.File_read) error {
+	p.Ack() // As in List.
+	r, err := n.openRange(p.Args().StartAt(), p.Args().Amount())
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	wc := bytestream.ToWriteCloser(ctx, p.Args().Sink())
+	if _, err = io.Copy(wc, r); err != nil {
+		return err
+	}
+	return wc.Close()
+}
+
+func (n *node) ReadBytes(ctx context.Context, p filesystem.File_readBytes) error {
+	amount := p.Args().Amount()
+	if amount == 0 || amount > MaxReadBytes {
+		amount = MaxReadBytes
+	}
+	r, err := n.openRange(p.Args().StartAt(), amount)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	buf := make([]byte, amount)
+	count, err := io.ReadFull(r, buf)
+	eof := err == io.EOF || err == io.ErrUnexpectedEOF
+	if err != nil && !eof {
+		return OpenFailed
+	}
+	if !eof {
+		// ReadFull doesn't report EOF if the data ends exactly where
+		// the buffer does, so check whether we stopped at the end of
+		// the file ourselves.
+		fi, err := fs.Stat(n.fsys, n.path)
+		if err != nil {
+			return censor(err)
+		}
+		eof = p.Args().StartAt()+int64(count) >= fi.Size()
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	res.SetEof(eof)
+	return res.SetData(buf[:count])
+}
+
+func (n *node) Hash(ctx context.Context, p filesystem.File_hash) error {
+	newHash, ok := fsutil.HashAlgorithms[p.Args().Algorithm()]
+	if !ok {
+		return InvalidArgument
+	}
+	r, err := n.openRange(p.Args().StartAt(), p.Args().Amount())
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	h := newHash()
+	if _, err = io.Copy(h, r); err != nil {
+		return OpenFailed
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetDigest(h.Sum(nil))
+}
+
+// fs.FS doesn't report holes, so the whole file counts as data.
+
+func (n *node) size() (int64, error) {
+	fi, err := fs.Stat(n.fsys, n.path)
+	if err != nil {
+		return 0, censor(err)
+	}
+	return fi.Size(), nil
+}
+
+func (n *node) SeekData(ctx context.Context, p filesystem.File_seekData) error {
+	offset := p.Args().Offset()
+	if offset < 0 {
+		return InvalidArgument
+	}
+	size, err := n.size()
+	if err != nil {
+		return err
+	}
+	if offset >= size {
+		offset = -1
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	res.SetOffset(offset)
+	return nil
+}
+
+func (n *node) SeekHole(ctx context.Context, p filesystem.File_seekHole) error {
+	offset := p.Args().Offset()
+	if offset < 0 {
+		return InvalidArgument
+	}
+	size, err := n.size()
+	if err != nil {
+		return err
+	}
+	if offset >= size {
+		offset = -1
+	} else {
+		offset = size
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	res.SetOffset(offset)
+	return nil
+}
@@ -0,0 +1,101 @@
+package local
+
+import (
+	"context"
+	"os"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/internal/fsutil"
+
+	"zenhack.net/go/sandstorm/exp/util/bytestream"
+)
+
+// Wraps a Node to implement AppendOnlyDirectory and AppendOnlyFile, whose
+// create and mkdir methods have different signatures than RwDirectory's.
+type appendOnlyNode struct {
+	*Node
+}
+
+func (d appendOnlyNode) Create(ctx context.Context, p filesystem.AppendOnlyDirectory_create) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidFileName(name) {
+		return IllegalFileName
+	}
+
+	node := Node{
+		Path:       d.Path + "/" + name,
+		Executable: p.Args().Executable(),
+		AppendOnly: true,
+		Config:     d.Config,
+	}
+
+	mode := os.FileMode(0644)
+	if node.Executable {
+		mode |= 0111
+	}
+
+	// O_EXCL, so we can't be used to clobber an existing file.
+	file, err := os.OpenFile(node.Path, os.O_RDWR|os.O_CREATE|os.O_EXCL, mode)
+	if os.IsExist(err) {
+		return AlreadyExists
+	} else if err != nil {
+		return fserrors.Censor(err)
+	}
+	file.Close()
+
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetFile(filesystem.AppendOnlyFile{
+		Client: node.MakeClient().Client,
+	})
+}
+
+func (d appendOnlyNode) Mkdir(ctx context.Context, p filesystem.AppendOnlyDirectory_mkdir) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidFileName(name) {
+		return IllegalFileName
+	}
+
+	node := Node{
+		Path:       d.Path + "/" + name,
+		IsDir:      true,
+		AppendOnly: true,
+		Config:     d.Config,
+	}
+	err = os.Mkdir(node.Path, 0700)
+	if os.IsExist(err) {
+		return AlreadyExists
+	} else if err != nil {
+		return fserrors.Censor(err)
+	}
+
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetDir(filesystem.AppendOnlyDirectory{
+		Client: node.MakeClient().Client,
+	})
+}
+
+func (f appendOnlyNode) Append(ctx context.Context, p filesystem.AppendOnlyFile_append) error {
+	file, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return res.SetSink(bytestream.FromWriteCloser(file, nil))
+}
@@ -0,0 +1,66 @@
+package local
+
+import (
+	"sync"
+	"time"
+)
+
+// A Config says where a Node keeps the data for its optional features:
+// the trash, old versions & snapshots, and extended attributes on
+// filesystems which can't store them. Nodes reached from a node (by
+// walking, creating files, etc.) share its Config.
+//
+// A nil *Config, like the zero value, disables all of these features.
+// The fields must not be changed once a Config is in use, except via
+// SetVersionRetention.
+type Config struct {
+	// If non-empty, deleted files are moved here instead of being
+	// removed outright; see trash.go. TrashDir should be on the same
+	// filesystem as the directories being served, since files are moved
+	// in and out of it by renaming them.
+	TrashDir string
+
+	// How long deleted files are kept in the trash. Zero means forever.
+	TrashExpiry time.Duration
+
+	// If non-empty, old versions of files, and snapshots of
+	// directories, are kept here; see versions.go.
+	VersionDir string
+
+	// The policy used to discard old versions & snapshots.
+	VersionRetention RetentionPolicy
+
+	// If the underlying filesystem doesn't support extended attributes,
+	// we store them in "sidecar" files in this directory instead. If
+	// it is empty, attributes just aren't available on such filesystems.
+	XattrSidecarDir string
+
+	// Held while modifying anything under TrashDir.
+	trashLock sync.Mutex
+
+	// Held while modifying anything under VersionDir, and while reading
+	// VersionRetention.
+	versionLock sync.Mutex
+
+	// Guards read-modify-write cycles on the sidecar files.
+	sidecarLock sync.Mutex
+}
+
+// Used by nodes with a nil Config.
+var noConfig = &Config{}
+
+// SetVersionRetention changes c.VersionRetention, which may be done while
+// c is in use. The new policy is applied the next time versions are
+// saved or listed.
+func (c *Config) SetVersionRetention(policy RetentionPolicy) {
+	c.versionLock.Lock()
+	defer c.versionLock.Unlock()
+	c.VersionRetention = policy
+}
+
+func (n *Node) config() *Config {
+	if n.Config == nil {
+		return noConfig
+	}
+	return n.Config
+}
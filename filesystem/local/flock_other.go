@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package local
+
+import (
+	"os"
+)
+
+// We don't have flock here, so locks only coordinate between clients of
+// this process.
+func tryFlock(file *os.File, exclusive bool) error {
+	return nil
+}
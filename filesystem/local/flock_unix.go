@@ -0,0 +1,23 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package local
+
+import (
+	"os"
+	"syscall"
+)
+
+// Try to flock the file, without blocking. Returns errWouldBlock if
+// someone else holds a conflicting lock.
+func tryFlock(file *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	err := syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return errWouldBlock
+	}
+	return err
+}
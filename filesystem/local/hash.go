@@ -0,0 +1,116 @@
+package local
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/internal/fsutil"
+)
+
+// The maximum number of digests we keep in hashCache.
+const maxCachedHashes = 4096
+
+// Computing a hash means reading the whole file, so we remember the
+// results. Entries are keyed on the file's size and modification time
+// (as well as what was asked for), so that a file which has been changed
+// since won't get a stale digest.
+var hashCache = struct {
+	sync.Mutex
+	digests map[hashCacheKey][]byte
+}{
+	digests: make(map[hashCacheKey][]byte),
+}
+
+type hashCacheKey struct {
+	path      string
+	algorithm filesystem.File_HashAlgorithm
+	startAt   int64
+	amount    int64
+	size      int64
+	mtime     time.Time
+}
+
+func getCachedHash(key hashCacheKey) ([]byte, bool) {
+	hashCache.Lock()
+	defer hashCache.Unlock()
+	digest, ok := hashCache.digests[key]
+	return digest, ok
+}
+
+func putCachedHash(key hashCacheKey, digest []byte) {
+	hashCache.Lock()
+	defer hashCache.Unlock()
+	if len(hashCache.digests) >= maxCachedHashes {
+		// Crude, but it keeps memory use bounded, and the cache is only
+		// an optimization.
+		hashCache.digests = make(map[hashCacheKey][]byte)
+	}
+	hashCache.digests[key] = digest
+}
+
+func (f *Node) Hash(ctx context.Context, p filesystem.File_hash) error {
+	algorithm := p.Args().Algorithm()
+	newHash, ok := fsutil.HashAlgorithms[algorithm]
+	if !ok {
+		return InvalidArgument
+	}
+
+	startAt := p.Args().StartAt()
+	if startAt < 0 {
+		return InvalidArgument
+	}
+
+	amount := int64(p.Args().Amount())
+	if amount < 0 {
+		// See the comment in Read.
+		amount = 0
+	}
+
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	key := hashCacheKey{
+		path:      f.Path,
+		algorithm: algorithm,
+		startAt:   startAt,
+		amount:    amount,
+		size:      fi.Size(),
+		mtime:     fi.ModTime(),
+	}
+
+	digest, ok := getCachedHash(key)
+	if !ok {
+		_, err = file.Seek(startAt, 0)
+		if err != nil {
+			return err
+		}
+		r := io.Reader(file)
+		if amount != 0 {
+			r = io.LimitReader(r, amount)
+		}
+		h := newHash()
+		if _, err = io.Copy(h, r); err != nil {
+			return err
+		}
+		digest = h.Sum(nil)
+		putCachedHash(key, digest)
+	}
+
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetDigest(digest)
+}
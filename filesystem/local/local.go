@@ -5,12 +5,13 @@ package local
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"io"
+	"math"
 	"os"
-	"strings"
 
 	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/internal/fsutil"
 
 	grain_capnp "zenhack.net/go/sandstorm/capnp/grain"
 	bridge_capnp "zenhack.net/go/sandstorm/capnp/sandstormhttpbridge"
@@ -20,19 +21,25 @@ import (
 	"zombiezen.com/go/capnproto2/server"
 )
 
+// The maximum number of bytes returned by a single call to ReadBytes.
+const MaxReadBytes = fsutil.MaxReadBytes
+
 var (
-	InvalidArgument = errors.New("Invalid argument")
-	IllegalFileName = errors.New("Illegal file name")
-	OpenFailed      = errors.New("Open failed")
+	InvalidArgument = fserrors.New(filesystem.ErrorCode_invalidArgument, "Invalid argument")
+	IllegalFileName = fserrors.New(filesystem.ErrorCode_invalidName, "Illegal file name")
+	OpenFailed      = fserrors.New(filesystem.ErrorCode_io, "Open failed")
 	NotImplemented  = capnp.Unimplemented("Not implemented")
 )
 
-func NewNode(path string) (*Node, error) {
+// NewNode returns a Node for the file or directory at path, which uses
+// config for its optional features; config may be nil.
+func NewNode(path string, config *Config) (*Node, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
 	return &Node{
+		Config:     config,
 		Path:       path,
 		IsDir:      fi.IsDir(),
 		Writable:   fi.Mode()&0200 != 0,
@@ -45,6 +52,13 @@ type Node struct {
 	Writable   bool
 	Executable bool
 	Path       string
+
+	// If true, this node is exposed as an AppendOnlyDirectory or
+	// AppendOnlyFile, regardless of Writable.
+	AppendOnly bool `json:",omitempty"`
+
+	// Not saved; whoever restores a node must supply it again.
+	Config *Config `json:"-"`
 }
 
 func (n *Node) Save(ctx context.Context, p grain_capnp.AppPersistent_save) error {
@@ -103,18 +117,21 @@ func (n *Node) Stat(ctx context.Context, p filesystem.Node_stat) error {
 		info.File().SetSize(fi.Size())
 	}
 	info.SetWritable(n.Writable)
-	info.SetExecutable(n.Executable)
-	return nil
+	info.SetExecutable(fi.Mode()&0100 != 0)
+	return info.SetMimeType(n.mimeType())
 }
 
 func (d *Node) List(ctx context.Context, p filesystem.Directory_list) error {
+	// We wait on calls to the stream below; until we acknowledge the
+	// call, nothing else can be delivered to this capability, which can
+	// deadlock the connection.
+	p.Ack()
 	stream := p.Args().Stream()
 	file, err := os.Open(d.Path)
 	if err != nil {
 		// err might contain private info, e.g. where the directory
-		// is rooted. So we return a generic error. It might be nice
-		// to find some way to allow more information for debugging.
-		return OpenFailed
+		// is rooted. So we return a generic error with the same code.
+		return fserrors.Censor(err)
 	}
 	defer file.Close()
 	maxBufSize := 1024
@@ -146,6 +163,10 @@ func (d *Node) List(ctx context.Context, p filesystem.Directory_list) error {
 					info.SetFile()
 					info.File().SetSize(fi.Size())
 				}
+				child := &Node{Path: d.Path + "/" + fi.Name(), Config: d.Config}
+				if err = info.SetMimeType(child.mimeType()); err != nil {
+					return err
+				}
 			}
 			return nil
 		})
@@ -168,14 +189,14 @@ func (d *Node) Walk(ctx context.Context, p filesystem.Directory_walk) error {
 		return err
 	}
 
-	if !validFileName(name) {
+	if !fsutil.ValidFileName(name) {
 		return IllegalFileName
 	}
 
 	path := d.Path + "/" + name
 	fi, err := os.Stat(path)
 	if err != nil {
-		return err
+		return fserrors.Censor(err)
 	}
 
 	node := &Node{
@@ -183,6 +204,8 @@ func (d *Node) Walk(ctx context.Context, p filesystem.Directory_walk) error {
 		IsDir:      fi.IsDir(),
 		Writable:   d.Writable && fi.Mode()&0200 != 0,
 		Executable: fi.Mode()&0100 != 0,
+		AppendOnly: d.AppendOnly && fi.IsDir(),
+		Config:     d.Config,
 	}
 
 	res, err := p.AllocResults()
@@ -194,12 +217,18 @@ func (d *Node) Walk(ctx context.Context, p filesystem.Directory_walk) error {
 	return nil
 }
 
+// Other processes can change the directory behind our back, and we don't
+// watch the host filesystem for changes, so clients have to poll.
+func (d *Node) Watch(ctx context.Context, p filesystem.Directory_watch) error {
+	return NotImplemented
+}
+
 func (d *Node) Create(ctx context.Context, p filesystem.RwDirectory_create) error {
 	name, err := p.Args().Name()
 	if err != nil {
 		return err
 	}
-	if !validFileName(name) {
+	if !fsutil.ValidFileName(name) {
 		return IllegalFileName
 	}
 
@@ -207,6 +236,7 @@ func (d *Node) Create(ctx context.Context, p filesystem.RwDirectory_create) erro
 		Path:       d.Path + "/" + name,
 		Executable: p.Args().Executable(),
 		Writable:   true,
+		Config:     d.Config,
 	}
 
 	mode := os.FileMode(0644)
@@ -216,7 +246,7 @@ func (d *Node) Create(ctx context.Context, p filesystem.RwDirectory_create) erro
 
 	file, err := os.OpenFile(node.Path, os.O_RDWR|os.O_CREATE, mode)
 	if err != nil {
-		return OpenFailed
+		return fserrors.Censor(err)
 	}
 	file.Close()
 
@@ -235,26 +265,75 @@ func (d *Node) Mkdir(ctx context.Context, p filesystem.RwDirectory_mkdir) error
 	if err != nil {
 		return err
 	}
-	if !validFileName(name) {
+	if !fsutil.ValidFileName(name) {
 		return IllegalFileName
 	}
-	return os.Mkdir(d.Path+"/"+name, 0700)
+
+	node := Node{
+		Path:     d.Path + "/" + name,
+		IsDir:    true,
+		Writable: true,
+		Config:   d.Config,
+	}
+	if err = os.Mkdir(node.Path, 0700); err != nil {
+		return fserrors.Censor(err)
+	}
+
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetDir(filesystem.RwDirectory{
+		Client: node.MakeClient().Client,
+	})
 }
 
 func (d *Node) Delete(ctx context.Context, p filesystem.RwDirectory_delete) error {
-	return NotImplemented
-}
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidFileName(name) {
+		return IllegalFileName
+	}
 
-func validFileName(name string) bool {
-	return name != "" &&
-		name != "." &&
-		name != ".." &&
-		!strings.Contains(name, "/")
+	path := d.Path + "/" + name
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	config := d.config()
+	if fi.IsDir() || config.TrashDir == "" {
+		// Directories are never moved to the trash, since only empty
+		// ones can be deleted, and there's nothing in those worth
+		// restoring. rmdir checks that, so we don't have to: checking
+		// first and then removing would race with anything created
+		// in the directory in between.
+		err = os.Remove(path)
+		if err == nil {
+			err = config.moveSidecar(path, "")
+		}
+	} else {
+		err = config.moveToTrash(path)
+		if err == nil {
+			err = config.ExpireTrash()
+		}
+	}
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	return nil
 }
 
 func (n *Node) MakeClient() filesystem.Node {
 	var methods []server.Method
-	if n.IsDir {
+	if n.AppendOnly {
+		if n.IsDir {
+			methods = filesystem.AppendOnlyDirectory_Methods(nil, appendOnlyNode{n})
+		} else {
+			methods = filesystem.AppendOnlyFile_Methods(nil, appendOnlyNode{n})
+		}
+	} else if n.IsDir {
 		if n.Writable {
 			methods = filesystem.RwDirectory_Methods(nil, n)
 		} else {
@@ -269,10 +348,10 @@ func (n *Node) MakeClient() filesystem.Node {
 	}
 	return filesystem.Node{
 		Client: capnp.NewClient(server.New(
-			append(
+			fserrors.CensorMethods(append(
 				methods,
 				grain_capnp.AppPersistent_Methods(nil, n)...,
-			),
+			)),
 			n,
 			nil,
 			nil,
@@ -287,18 +366,24 @@ func (f *Node) Write(ctx context.Context, p filesystem.RwFile_write) error {
 		return InvalidArgument
 	}
 
-	file, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_APPEND, 0)
-	if err != nil {
-		return err
+	if err := f.config().saveVersion(f.Path); err != nil {
+		return VersionFailed
 	}
+	// Only append if asked to; O_APPEND makes every write go to the end
+	// of the file, regardless of where we seek to.
+	flags := os.O_WRONLY
 	if startAt == -1 {
-		_, err = file.Seek(0, 2)
-	} else {
-		_, err = file.Seek(startAt, 0)
+		flags |= os.O_APPEND
 	}
+	file, err := os.OpenFile(f.Path, flags, 0)
 	if err != nil {
-		file.Close()
-		return err
+		return fserrors.Censor(err)
+	}
+	if startAt != -1 {
+		if _, err = file.Seek(startAt, 0); err != nil {
+			file.Close()
+			return err
+		}
 	}
 	bs := bytestream.FromWriteCloser(file, nil)
 	res, err := p.AllocResults()
@@ -312,28 +397,37 @@ func (f *Node) Write(ctx context.Context, p filesystem.RwFile_write) error {
 func (f *Node) SetExec(ctx context.Context, p filesystem.RwFile_setExec) error {
 	exec := p.Args().Exec()
 	fi, err := os.Stat(f.Path)
-	// FIXME: censor error like with OpenFailed.
 	if err != nil {
-		return err
+		return fserrors.Censor(err)
 	}
 	if exec {
-		// FIXME: censor error like with OpenFailed.
-		return os.Chmod(f.Path, fi.Mode()|0111)
+		err = os.Chmod(f.Path, fi.Mode()|0111)
 	} else {
-		// FIXME: censor error like with OpenFailed.
-		return os.Chmod(f.Path, fi.Mode()&^0111)
+		err = os.Chmod(f.Path, fi.Mode()&^0111)
 	}
+	return fserrors.Censor(err)
 }
 
 func (f *Node) Truncate(ctx context.Context, p filesystem.RwFile_truncate) error {
-	// FIXME: cast/overflow issues.
-	if err := os.Truncate(f.Path, int64(p.Args().Size())); err != nil {
-		return OpenFailed
+	size := p.Args().Size()
+	if size > math.MaxInt64 {
+		// Can't be represented as an off_t, and no filesystem would
+		// let us make a file that big anyway.
+		return InvalidArgument
+	}
+	if err := f.config().saveVersion(f.Path); err != nil {
+		return VersionFailed
+	}
+	if err := os.Truncate(f.Path, int64(size)); err != nil {
+		return fserrors.Censor(err)
 	}
 	return nil
 }
 
 func (f *Node) Read(ctx context.Context, p filesystem.File_read) error {
+	// Writing to the sink waits on the client, so don't hold up other
+	// calls while we do; see List.
+	p.Ack()
 	startAt := p.Args().StartAt()
 	if startAt < 0 {
 		return InvalidArgument
@@ -351,7 +445,7 @@ func (f *Node) Read(ctx context.Context, p filesystem.File_read) error {
 
 	file, err := os.Open(f.Path)
 	if err != nil {
-		return OpenFailed
+		return fserrors.Censor(err)
 	}
 	defer file.Close()
 
@@ -371,3 +465,44 @@ func (f *Node) Read(ctx context.Context, p filesystem.File_read) error {
 	wc.Close()
 	return nil
 }
+
+func (f *Node) ReadBytes(ctx context.Context, p filesystem.File_readBytes) error {
+	startAt := p.Args().StartAt()
+	if startAt < 0 {
+		return InvalidArgument
+	}
+
+	amount := p.Args().Amount()
+	if amount == 0 || amount > MaxReadBytes {
+		amount = MaxReadBytes
+	}
+
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	defer file.Close()
+
+	buf := make([]byte, amount)
+	n, err := file.ReadAt(buf, startAt)
+	eof := err == io.EOF
+	if err != nil && !eof {
+		return err
+	}
+	if !eof {
+		// ReadAt only reports EOF on a short read, so we have to check
+		// whether we stopped right at the end of the file ourselves.
+		fi, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		eof = startAt+int64(n) >= fi.Size()
+	}
+
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	res.SetEof(eof)
+	return res.SetData(buf[:n])
+}
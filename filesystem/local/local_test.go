@@ -0,0 +1,745 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fstest"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+func TestConformance(t *testing.T) {
+	fstest.TestRwDirectory(t, func(t *testing.T) filesystem.RwDirectory {
+		n, err := NewNode(t.TempDir(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return filesystem.RwDirectory{Client: n.MakeClient().Client}
+	})
+}
+
+// Serve a fresh temporary directory with config, returning it and its
+// path.
+func newTestDir(t *testing.T, config *Config) (filesystem.RwDirectory, string) {
+	t.Helper()
+	dir := t.TempDir()
+	n, err := NewNode(dir, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filesystem.RwDirectory{Client: n.MakeClient().Client}
+	t.Cleanup(root.Client.Release)
+	return root, dir
+}
+
+func checkHash(ctx context.Context, t *testing.T, root filesystem.RwDirectory, p string, want []byte) {
+	t.Helper()
+	got, err := client.Hash(ctx, filesystem.Directory{Client: root.Client}, p, filesystem.File_HashAlgorithm_sha256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum := sha256.Sum256(want); string(got) != string(sum[:]) {
+		t.Errorf("%s: got the hash of something other than %q", p, want)
+	}
+}
+
+// Cached digests aren't used once the file's path, size or modification
+// time differ.
+func TestHashCache(t *testing.T) {
+	ctx := context.Background()
+	root, dir := newTestDir(t, nil)
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	write := func(name, data string, mtime time.Time) {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("a", "aaa", mtime)
+	checkHash(ctx, t, root, "a", []byte("aaa"))
+
+	// Another file, the same in every other way.
+	write("b", "bbb", mtime)
+	checkHash(ctx, t, root, "b", []byte("bbb"))
+
+	// A different size.
+	write("a", "aaaa", mtime)
+	checkHash(ctx, t, root, "a", []byte("aaaa"))
+
+	// The same size, but modified since.
+	write("a", "xxxx", mtime.Add(time.Second))
+	checkHash(ctx, t, root, "a", []byte("xxxx"))
+
+	// Through the protocol, which updates the modification time.
+	if err := client.WriteFile(ctx, root, "a", []byte("yyyy"), false); err != nil {
+		t.Fatal(err)
+	}
+	checkHash(ctx, t, root, "a", []byte("yyyy"))
+}
+
+// Open the file at p for writing.
+func openRwFile(ctx context.Context, t *testing.T, root filesystem.RwDirectory, p string) filesystem.RwFile {
+	t.Helper()
+	node, err := client.Walk(ctx, filesystem.Directory{Client: root.Client}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(node.Client.Release)
+	return filesystem.RwFile{Client: node.Client}
+}
+
+func allocateRange(ctx context.Context, f filesystem.RwFile, offset, length int64) error {
+	res, release := f.Allocate(ctx, func(p filesystem.RwFile_allocate_Params) error {
+		p.SetOffset(offset)
+		p.SetLength(length)
+		return nil
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func punchHoleRange(ctx context.Context, f filesystem.RwFile, offset, length int64) error {
+	res, release := f.PunchHole(ctx, func(p filesystem.RwFile_punchHole_Params) error {
+		p.SetOffset(offset)
+		p.SetLength(length)
+		return nil
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func seekTo(ctx context.Context, t *testing.T, f filesystem.RwFile, offset int64, hole bool) int64 {
+	t.Helper()
+	var (
+		results interface{ Offset() int64 }
+		err     error
+	)
+	if hole {
+		res, release := f.SeekHole(ctx, func(p filesystem.File_seekHole_Params) error {
+			p.SetOffset(offset)
+			return nil
+		})
+		defer release()
+		results, err = res.Struct()
+	} else {
+		res, release := f.SeekData(ctx, func(p filesystem.File_seekData_Params) error {
+			p.SetOffset(offset)
+			return nil
+		})
+		defer release()
+		results, err = res.Struct()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return results.Offset()
+}
+
+func checkContents(ctx context.Context, t *testing.T, root filesystem.RwDirectory, p string, want []byte) {
+	t.Helper()
+	got, err := client.ReadFile(ctx, filesystem.Directory{Client: root.Client}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: got %d bytes, not the %d expected", p, len(got), len(want))
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	ctx := context.Background()
+	root, _ := newTestDir(t, nil)
+	if err := client.WriteFile(ctx, root, "f", []byte("abc"), false); err != nil {
+		t.Fatal(err)
+	}
+	f := openRwFile(ctx, t, root, "f")
+
+	if err := allocateRange(ctx, f, 1, 0); err != nil {
+		t.Errorf("allocating nothing: %v", err)
+	}
+	if err := allocateRange(ctx, f, -1, 10); fserrors.CodeOf(err) != filesystem.ErrorCode_invalidArgument {
+		t.Errorf("allocating at a negative offset: got %v, want invalidArgument", err)
+	}
+	// Allocating past the end extends the file with zeros; allocating
+	// within it changes nothing.
+	if err := allocateRange(ctx, f, 2, 8); err != nil {
+		t.Fatal(err)
+	}
+	if err := allocateRange(ctx, f, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+	checkContents(ctx, t, root, "f", []byte("abc\x00\x00\x00\x00\x00\x00\x00"))
+}
+
+func TestPunchHole(t *testing.T) {
+	ctx := context.Background()
+	root, _ := newTestDir(t, nil)
+	if err := client.WriteFile(ctx, root, "f", []byte("abcdefgh"), false); err != nil {
+		t.Fatal(err)
+	}
+	f := openRwFile(ctx, t, root, "f")
+
+	if err := punchHoleRange(ctx, f, 1, 0); err != nil {
+		t.Errorf("punching an empty hole: %v", err)
+	}
+	if err := punchHoleRange(ctx, f, 0, -1); fserrors.CodeOf(err) != filesystem.ErrorCode_invalidArgument {
+		t.Errorf("punching a negative length: got %v, want invalidArgument", err)
+	}
+	// The size is unchanged, even for a hole past the end.
+	err := punchHoleRange(ctx, f, 2, 3)
+	if capnp.IsUnimplemented(err) {
+		t.Skip("the filesystem doesn't support holes")
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if err := punchHoleRange(ctx, f, 7, 10); err != nil {
+		t.Fatal(err)
+	}
+	checkContents(ctx, t, root, "f", []byte("ab\x00\x00\x00fg\x00"))
+}
+
+// Make a sparse file at p, with a block of data at the start and in
+// the middle, and a hole at the end; returns its contents.
+func makeSparse(t *testing.T, p string) []byte {
+	t.Helper()
+	const block = 1 << 16
+	want := make([]byte, 3*block)
+	copy(want, "start")
+	copy(want[block:], "middle")
+	file, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err = file.Write(want[:len("start")]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = file.WriteAt(want[block:block+len("middle")], block); err != nil {
+		t.Fatal(err)
+	}
+	if err = file.Truncate(int64(len(want))); err != nil {
+		t.Fatal(err)
+	}
+	return want
+}
+
+func TestSeek(t *testing.T) {
+	ctx := context.Background()
+	root, dir := newTestDir(t, nil)
+	data := makeSparse(t, filepath.Join(dir, "f"))
+	size := int64(len(data))
+	f := openRwFile(ctx, t, root, "f")
+
+	if got := seekTo(ctx, t, f, 0, false); got != 0 {
+		t.Errorf("seekData(0) = %d, want 0", got)
+	}
+	hole := seekTo(ctx, t, f, 0, true)
+	if hole <= 0 || hole > size {
+		t.Fatalf("seekHole(0) = %d, want a hole after the start", hole)
+	}
+	for _, hole := range []bool{false, true} {
+		if got := seekTo(ctx, t, f, size, hole); got != -1 {
+			t.Errorf("seeking (hole = %v) from the end gave %d, want -1", hole, got)
+		}
+	}
+	if hole == size {
+		t.Skip("the filesystem doesn't report holes")
+	}
+	// The data in the middle is found, and is followed by the hole at
+	// the end, after which there's no data.
+	middle := seekTo(ctx, t, f, hole, false)
+	if middle <= hole || middle > size/3 {
+		t.Errorf("seekData(%d) = %d, want the middle block at %d", hole, middle, size/3)
+	}
+	end := seekTo(ctx, t, f, middle, true)
+	if end <= middle || end >= size {
+		t.Errorf("seekHole(%d) = %d, want the hole at the end", middle, end)
+	}
+	if got := seekTo(ctx, t, f, end, false); got != -1 {
+		t.Errorf("seekData(%d) = %d, want -1", end, got)
+	}
+}
+
+// Copying a sparse file preserves its contents, including the hole at
+// the end.
+func TestCopySparse(t *testing.T) {
+	ctx := context.Background()
+	root, dir := newTestDir(t, nil)
+	want := makeSparse(t, filepath.Join(dir, "f"))
+	if err := client.Copy(ctx, filesystem.Directory{Client: root.Client}, "f", root, "copy"); err != nil {
+		t.Fatal(err)
+	}
+	checkContents(ctx, t, root, "copy", want)
+	if seekTo(ctx, t, openRwFile(ctx, t, root, "f"), 0, true) == int64(len(want)) {
+		return
+	}
+	// The holes were skipped rather than written as zeros.
+	fi, err := os.Stat(filepath.Join(dir, "copy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && st.Blocks*512 >= int64(len(want)) {
+		t.Errorf("the copy takes %d bytes on disk; the holes were filled in", st.Blocks*512)
+	}
+}
+
+// Try to lock f, returning the handle, which is null if the lock wasn't
+// available.
+func tryLock(ctx context.Context, t *testing.T, f filesystem.RwFile, exclusive bool) filesystem.LockHandle {
+	t.Helper()
+	res, release := f.TryLock(ctx, func(p filesystem.RwFile_tryLock_Params) error {
+		p.SetExclusive(exclusive)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filesystem.LockHandle{Client: results.Handle().Client.AddRef()}
+}
+
+// Locks exclude each other, whether they are taken by our clients or by
+// flock elsewhere.
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	root, dir := newTestDir(t, nil)
+	if err := client.WriteFile(ctx, root, "f", []byte("f"), false); err != nil {
+		t.Fatal(err)
+	}
+	f := openRwFile(ctx, t, root, "f")
+
+	// Shared locks only exclude exclusive ones.
+	a := tryLock(ctx, t, f, false)
+	b := tryLock(ctx, t, f, false)
+	if a.Client == nil || b.Client == nil {
+		t.Fatal("couldn't take two shared locks")
+	}
+	if h := tryLock(ctx, t, f, true); h.Client != nil {
+		t.Fatal("took an exclusive lock while shared locks were held")
+	}
+	a.Client.Release()
+	b.Client.Release()
+
+	excl := tryLock(ctx, t, f, true)
+	if excl.Client == nil {
+		t.Fatal("couldn't take an exclusive lock after the shared ones were released")
+	}
+	if h := tryLock(ctx, t, f, false); h.Client != nil {
+		t.Fatal("took a shared lock while an exclusive lock was held")
+	}
+	excl.Client.Release()
+
+	// Another open file stands in for another process.
+	other, err := os.Open(filepath.Join(dir, "f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err = syscall.Flock(int(other.Fd()), syscall.LOCK_EX); err != nil {
+		t.Fatal(err)
+	}
+	if h := tryLock(ctx, t, f, false); h.Client != nil {
+		t.Fatal("took a lock while another process held an exclusive one")
+	}
+
+	// lock waits until the other process lets go.
+	res, release := f.Lock(ctx, func(p filesystem.RwFile_lock_Params) error {
+		p.SetExclusive(true)
+		return nil
+	})
+	defer release()
+	select {
+	case <-res.Done():
+		t.Fatal("lock returned while another process held the lock")
+	case <-time.After(3 * flockPollInterval):
+	}
+	if err = syscall.Flock(int(other.Fd()), syscall.LOCK_UN); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = res.Struct(); err != nil {
+		t.Fatal(err)
+	}
+	if err = syscall.Flock(int(other.Fd()), syscall.LOCK_SH|syscall.LOCK_NB); err != syscall.EWOULDBLOCK {
+		t.Errorf("another process could lock the file while we held it: %v", err)
+	}
+}
+
+// A waiting lock gives up when its caller does.
+func TestLockCancel(t *testing.T) {
+	ctx := context.Background()
+	root, _ := newTestDir(t, nil)
+	if err := client.WriteFile(ctx, root, "f", []byte("f"), false); err != nil {
+		t.Fatal(err)
+	}
+	f := openRwFile(ctx, t, root, "f")
+	h := tryLock(ctx, t, f, true)
+	if h.Client == nil {
+		t.Fatal("couldn't lock the file")
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 3*flockPollInterval)
+	defer cancel()
+	res, release := f.Lock(waitCtx, func(p filesystem.RwFile_lock_Params) error {
+		p.SetExclusive(false)
+		return nil
+	})
+	defer release()
+	if _, err := res.Struct(); err == nil {
+		t.Fatal("took a shared lock while an exclusive lock was held")
+	}
+
+	// Once the lock is released, the abandoned call doesn't take it.
+	h.Client.Release()
+	time.Sleep(flockPollInterval)
+	if h = tryLock(ctx, t, f, true); h.Client == nil {
+		t.Fatal("the cancelled lock call took the lock")
+	}
+	h.Client.Release()
+}
+
+// Without a version directory, snapshot is unimplemented, which clients
+// can tell apart from other failures.
+func TestSnapshotsNotSupported(t *testing.T) {
+	ctx := context.Background()
+	root, _ := newTestDir(t, nil)
+	res, release := root.Snapshot(ctx, nil)
+	defer release()
+	if _, err := res.Struct(); !capnp.IsUnimplemented(err) {
+		t.Errorf("snapshot: got %v, want unimplemented", err)
+	}
+}
+
+// Snapshots keep the contents and attributes of the files in them.
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	root, _ := newTestDir(t, &Config{
+		VersionDir:      t.TempDir(),
+		XattrSidecarDir: t.TempDir(),
+	})
+	if err := client.WriteFile(ctx, root, "d/f", []byte("old"), false); err != nil {
+		t.Fatal(err)
+	}
+	f := openRwFile(ctx, t, root, "d/f")
+	setRes, release := f.SetXattr(ctx, func(p filesystem.RwFile_setXattr_Params) error {
+		if err := p.SetName(MimeTypeXattr); err != nil {
+			return err
+		}
+		return p.SetValue([]byte("text/plain"))
+	})
+	defer release()
+	if _, err := setRes.Struct(); err != nil {
+		t.Fatal(err)
+	}
+
+	res, release := root.Snapshot(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := results.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = client.WriteFile(ctx, root, "d/f", []byte("new"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := client.Stat(ctx, snapshot.Dir(), "d/f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.MimeType() != "text/plain" {
+		t.Errorf("the snapshot has a MIME type of %q, want text/plain", fi.MimeType())
+	}
+	data, err := client.ReadFile(ctx, snapshot.Dir(), "d/f")
+	if err != nil {
+		t.Fatal(err)
+	} else if string(data) != "old" {
+		t.Errorf("the snapshot contains %q, want %q", data, "old")
+	}
+}
+
+// Allocating saves the old version first, as other modifications do.
+func TestAllocateSavesVersion(t *testing.T) {
+	ctx := context.Background()
+	root, dir := newTestDir(t, &Config{VersionDir: t.TempDir()})
+	if err := os.WriteFile(filepath.Join(dir, "f"), []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f := openRwFile(ctx, t, root, "f")
+	if err := allocateRange(ctx, f, 0, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	res, release := f.Versions(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, err := results.Versions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions.Len() != 1 {
+		t.Fatalf("got %d versions, want 1", versions.Len())
+	}
+	w := &bytesWriteCloser{}
+	if err = client.ReadTo(ctx, versions.At(0).File(), w); err != nil {
+		t.Fatal(err)
+	} else if w.String() != "abc" {
+		t.Errorf("the old version contains %q, want %q", w.String(), "abc")
+	}
+}
+
+type bytesWriteCloser struct {
+	bytes.Buffer
+}
+
+func (*bytesWriteCloser) Close() error {
+	return nil
+}
+
+// Return the trash as seen from the directory at p.
+func openTrash(ctx context.Context, t *testing.T, root filesystem.RwDirectory, p string) filesystem.Trash {
+	t.Helper()
+	dir, err := client.WalkRwDir(ctx, root, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dir.Client.Release()
+	res, release := dir.Trash(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trash := filesystem.Trash{Client: results.Trash().Client.AddRef()}
+	t.Cleanup(trash.Client.Release)
+	return trash
+}
+
+// Return the items in trash, as a map from original paths to names.
+func trashItems(ctx context.Context, t *testing.T, trash filesystem.Trash) map[string]string {
+	t.Helper()
+	res, release := trash.Items(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := results.Items()
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := make(map[string]string, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		name, err := list.At(i).Name()
+		if err != nil {
+			t.Fatal(err)
+		}
+		orig, err := list.At(i).OriginalPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		items[orig] = name
+	}
+	return items
+}
+
+func restore(ctx context.Context, trash filesystem.Trash, name string) error {
+	res, release := trash.Restore(ctx, func(p filesystem.Trash_restore_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func purge(ctx context.Context, trash filesystem.Trash, name string) error {
+	res, release := trash.Purge(ctx, func(p filesystem.Trash_purge_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+// A trash only shows what was deleted from beneath the directory it was
+// obtained from, with paths relative to that directory.
+func TestTrashView(t *testing.T) {
+	ctx := context.Background()
+	root, _ := newTestDir(t, &Config{TrashDir: t.TempDir()})
+	for _, p := range []string{"top", "d/a", "d/sub/b", "other/c"} {
+		if err := client.WriteFile(ctx, root, p, []byte(p), false); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Remove(ctx, root, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := trashItems(ctx, t, openTrash(ctx, t, root, "")); len(got) != 4 {
+		t.Errorf("the root's trash has %v, want all 4 items", got)
+	}
+
+	trash := openTrash(ctx, t, root, "d")
+	items := trashItems(ctx, t, trash)
+	if len(items) != 2 || items["a"] == "" || items["sub/b"] == "" {
+		t.Fatalf("d's trash has %v, want a and sub/b", items)
+	}
+	entries, err := client.List(ctx, filesystem.Directory{Client: trash.Client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("listed %d entries in d's trash, want 2", len(entries))
+	}
+	data, err := client.ReadFile(ctx, filesystem.Directory{Client: trash.Client}, items["a"])
+	if err != nil {
+		t.Fatal(err)
+	} else if string(data) != "d/a" {
+		t.Errorf("the trashed d/a contains %q", data)
+	}
+
+	// Items deleted from elsewhere can't be reached, or restored or
+	// purged.
+	hidden := trashItems(ctx, t, openTrash(ctx, t, root, ""))["other/c"]
+	if _, err = client.Walk(ctx, filesystem.Directory{Client: trash.Client}, hidden); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("walking to another directory's item: got %v, want not found", err)
+	}
+	if err = restore(ctx, trash, hidden); fserrors.CodeOf(err) != filesystem.ErrorCode_notFound {
+		t.Errorf("restoring another directory's item: got %v, want notFound", err)
+	}
+	if err = purge(ctx, trash, hidden); fserrors.CodeOf(err) != filesystem.ErrorCode_notFound {
+		t.Errorf("purging another directory's item: got %v, want notFound", err)
+	}
+}
+
+func TestTrashRestore(t *testing.T) {
+	ctx := context.Background()
+	root, _ := newTestDir(t, &Config{TrashDir: t.TempDir()})
+	for _, p := range []string{"d/a", "d/b"} {
+		if err := client.WriteFile(ctx, root, p, []byte(p), false); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Remove(ctx, root, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	trash := openTrash(ctx, t, root, "")
+	items := trashItems(ctx, t, trash)
+
+	if err := restore(ctx, trash, items["d/a"]); err != nil {
+		t.Fatal(err)
+	}
+	checkContents(ctx, t, root, "d/a", []byte("d/a"))
+	if err := restore(ctx, trash, items["d/a"]); fserrors.CodeOf(err) != filesystem.ErrorCode_notFound {
+		t.Errorf("restoring twice: got %v, want notFound", err)
+	}
+
+	// Something new in the way isn't overwritten, and the item stays
+	// in the trash.
+	if err := client.WriteFile(ctx, root, "d/b", []byte("new"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := restore(ctx, trash, items["d/b"]); fserrors.CodeOf(err) != filesystem.ErrorCode_exists {
+		t.Errorf("restoring over a new file: got %v, want exists", err)
+	}
+	checkContents(ctx, t, root, "d/b", []byte("new"))
+	if got := trashItems(ctx, t, trash); got["d/b"] != items["d/b"] || len(got) != 1 {
+		t.Errorf("the trash has %v after the failed restore, want just d/b", got)
+	}
+}
+
+func TestTrashPurge(t *testing.T) {
+	ctx := context.Background()
+	trashDir := t.TempDir()
+	root, _ := newTestDir(t, &Config{TrashDir: trashDir})
+	if err := client.WriteFile(ctx, root, "a", []byte("a"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Remove(ctx, root, "a"); err != nil {
+		t.Fatal(err)
+	}
+	trash := openTrash(ctx, t, root, "")
+	name := trashItems(ctx, t, trash)["a"]
+	if err := purge(ctx, trash, name); err != nil {
+		t.Fatal(err)
+	}
+	if got := trashItems(ctx, t, trash); len(got) != 0 {
+		t.Errorf("the trash has %v after purging, want nothing", got)
+	}
+	for _, sub := range []string{"items", "meta"} {
+		fis, err := os.ReadDir(filepath.Join(trashDir, sub))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(fis) != 0 {
+			t.Errorf("%s is left with %d entries", sub, len(fis))
+		}
+	}
+}
+
+// Items older than the expiry time are purged when something else is
+// deleted.
+func TestTrashExpiry(t *testing.T) {
+	ctx := context.Background()
+	config := &Config{TrashDir: t.TempDir(), TrashExpiry: time.Hour}
+	root, _ := newTestDir(t, config)
+	for _, p := range []string{"old", "new"} {
+		if err := client.WriteFile(ctx, root, p, []byte(p), false); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Remove(ctx, root, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	trash := openTrash(ctx, t, root, "")
+	items := trashItems(ctx, t, trash)
+
+	// Backdate "old".
+	item, err := config.readTrashItem(items["old"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.DeletedAt = time.Now().Add(-2 * time.Hour).UnixNano()
+	data, err := json.Marshal(item.trashMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(config.trashMetaPath(item.id), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = client.WriteFile(ctx, root, "another", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Remove(ctx, root, "another"); err != nil {
+		t.Fatal(err)
+	}
+	got := trashItems(ctx, t, trash)
+	if _, ok := got["old"]; ok || len(got) != 2 {
+		t.Errorf("the trash has %v, want new and another", got)
+	}
+}
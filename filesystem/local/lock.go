@@ -0,0 +1,180 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+)
+
+// How often to retry flock when another process holds a conflicting
+// lock. We poll rather than blocking in flock so that we notice when
+// the caller gives up.
+const flockPollInterval = 50 * time.Millisecond
+
+// Returned by tryFlock if another process holds a conflicting lock.
+var errWouldBlock = errors.New("Lock held by another process")
+
+// Table of the advisory locks held via this process, keyed on path.
+// flock alone isn't enough for this, since we may have several clients
+// locking the same file, and flock locks belong to open files, not
+// clients -- so we coordinate our own clients here, and only use flock
+// to coordinate with other processes.
+var lockTable = struct {
+	sync.Mutex
+	locks map[string]*lockState
+}{
+	locks: make(map[string]*lockState),
+}
+
+type lockState struct {
+	shared    int
+	exclusive bool
+
+	// Closed whenever a lock on the file is released, to wake up
+	// anyone waiting for it.
+	released chan struct{}
+}
+
+// Try to take a lock in lockTable. If the lock is not available,
+// returns false and a channel which will be closed when it is worth
+// trying again.
+func tryLockLocal(path string, exclusive bool) (bool, <-chan struct{}) {
+	lockTable.Lock()
+	defer lockTable.Unlock()
+	st, ok := lockTable.locks[path]
+	if !ok {
+		st = &lockState{released: make(chan struct{})}
+		lockTable.locks[path] = st
+	}
+	if st.exclusive || (exclusive && st.shared > 0) {
+		return false, st.released
+	}
+	if exclusive {
+		st.exclusive = true
+	} else {
+		st.shared++
+	}
+	return true, nil
+}
+
+func unlockLocal(path string, exclusive bool) {
+	lockTable.Lock()
+	defer lockTable.Unlock()
+	st := lockTable.locks[path]
+	if exclusive {
+		st.exclusive = false
+	} else {
+		st.shared--
+	}
+	close(st.released)
+	if !st.exclusive && st.shared == 0 {
+		delete(lockTable.locks, path)
+	} else {
+		st.released = make(chan struct{})
+	}
+}
+
+// A held lock. Implements filesystem.LockHandle_Server; the lock is
+// released when the client is shut down.
+type lockHandle struct {
+	path      string
+	exclusive bool
+	file      *os.File
+}
+
+func (h *lockHandle) Shutdown() {
+	// Closing the file drops the flock.
+	h.file.Close()
+	unlockLocal(h.path, h.exclusive)
+}
+
+// Acquire a lock on the file at path. If wait is false and the lock is
+// not immediately available, returns (nil, nil).
+func acquireLock(ctx context.Context, path string, exclusive, wait bool) (*lockHandle, error) {
+	path = filepath.Clean(path)
+	for {
+		ok, retry := tryLockLocal(path, exclusive)
+		if ok {
+			break
+		}
+		if !wait {
+			return nil, nil
+		}
+		select {
+		case <-retry:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		unlockLocal(path, exclusive)
+		return nil, fserrors.Censor(err)
+	}
+	for {
+		err = tryFlock(file, exclusive)
+		if err == nil {
+			return &lockHandle{
+				path:      path,
+				exclusive: exclusive,
+				file:      file,
+			}, nil
+		}
+		if err == errWouldBlock && wait {
+			select {
+			case <-time.After(flockPollInterval):
+				continue
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+		file.Close()
+		unlockLocal(path, exclusive)
+		if err == errWouldBlock {
+			return nil, nil
+		}
+		return nil, err
+	}
+}
+
+func (f *Node) Lock(ctx context.Context, p filesystem.RwFile_lock) error {
+	// This may wait indefinitely, so let other calls through meanwhile;
+	// not least the release of the lock we're waiting for.
+	p.Ack()
+	h, err := acquireLock(ctx, f.Path, p.Args().Exclusive(), true)
+	if err != nil {
+		return err
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		h.Shutdown()
+		return err
+	}
+	return res.SetHandle(filesystem.LockHandle_ServerToClient(h, nil))
+}
+
+func (f *Node) TryLock(ctx context.Context, p filesystem.RwFile_tryLock) error {
+	h, err := acquireLock(ctx, f.Path, p.Args().Exclusive(), false)
+	if err != nil {
+		return err
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		if h != nil {
+			h.Shutdown()
+		}
+		return err
+	}
+	if h == nil {
+		// Leave the handle null.
+		return nil
+	}
+	return res.SetHandle(filesystem.LockHandle_ServerToClient(h, nil))
+}
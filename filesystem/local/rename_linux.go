@@ -0,0 +1,17 @@
+package local
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// Rename the node at from to to, failing if something already exists
+// at to.
+func renameNoReplace(from, to string) error {
+	err := unix.Renameat2(unix.AT_FDCWD, from, unix.AT_FDCWD, to, unix.RENAME_NOREPLACE)
+	if err == unix.EINVAL || err == unix.ENOSYS {
+		// An old kernel, or a filesystem which doesn't support the
+		// flag.
+		return linkNoReplace(from, to)
+	}
+	return err
+}
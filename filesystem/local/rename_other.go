@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package local
+
+func renameNoReplace(from, to string) error {
+	return linkNoReplace(from, to)
+}
@@ -0,0 +1,140 @@
+package local
+
+import (
+	"context"
+	"math"
+	"os"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+)
+
+// Check that a range passed to allocate or punchHole is sensible, i.e.
+// non-negative, and its end is representable.
+func validRange(offset, length int64) bool {
+	return offset >= 0 && length >= 0 && offset <= math.MaxInt64-length
+}
+
+func (f *Node) Allocate(ctx context.Context, p filesystem.RwFile_allocate) error {
+	offset, length := p.Args().Offset(), p.Args().Length()
+	if !validRange(offset, length) {
+		return InvalidArgument
+	} else if length == 0 {
+		// fallocate rejects an empty range, but there's nothing to do.
+		return nil
+	}
+	if err := f.config().saveVersion(f.Path); err != nil {
+		return VersionFailed
+	}
+	file, err := os.OpenFile(f.Path, os.O_WRONLY, 0)
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	defer file.Close()
+	return allocate(file, offset, length)
+}
+
+func (f *Node) PunchHole(ctx context.Context, p filesystem.RwFile_punchHole) error {
+	offset, length := p.Args().Offset(), p.Args().Length()
+	if !validRange(offset, length) {
+		return InvalidArgument
+	} else if length == 0 {
+		return nil
+	}
+	if err := f.config().saveVersion(f.Path); err != nil {
+		return VersionFailed
+	}
+	file, err := os.OpenFile(f.Path, os.O_WRONLY, 0)
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	defer file.Close()
+	return punchHole(file, offset, length)
+}
+
+func (f *Node) SeekData(ctx context.Context, p filesystem.File_seekData) error {
+	return f.seek(p.Args().Offset(), seekData, func(offset int64) error {
+		res, err := p.AllocResults()
+		if err != nil {
+			return err
+		}
+		res.SetOffset(offset)
+		return nil
+	})
+}
+
+func (f *Node) SeekHole(ctx context.Context, p filesystem.File_seekHole) error {
+	return f.seek(p.Args().Offset(), seekHole, func(offset int64) error {
+		res, err := p.AllocResults()
+		if err != nil {
+			return err
+		}
+		res.SetOffset(offset)
+		return nil
+	})
+}
+
+// Common logic for SeekData and SeekHole. Calls setResult with the
+// offset found by seekFn, or -1 if there is no such offset.
+func (f *Node) seek(
+	offset int64,
+	seekFn func(*os.File, int64) (int64, bool, error),
+	setResult func(int64) error,
+) error {
+	if offset < 0 {
+		return InvalidArgument
+	}
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	defer file.Close()
+	ret, ok, err := seekFn(file, offset)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		ret = -1
+	}
+	return setResult(ret)
+}
+
+// Fallback implementations of seekData and seekHole for platforms or
+// filesystems that can't report holes; these treat the whole file as
+// data.
+
+func seekDataNoHoles(file *os.File, offset int64) (int64, bool, error) {
+	fi, err := file.Stat()
+	if err != nil {
+		return 0, false, err
+	}
+	if offset >= fi.Size() {
+		return 0, false, nil
+	}
+	return offset, true, nil
+}
+
+func seekHoleNoHoles(file *os.File, offset int64) (int64, bool, error) {
+	fi, err := file.Stat()
+	if err != nil {
+		return 0, false, err
+	}
+	if offset >= fi.Size() {
+		return 0, false, nil
+	}
+	return fi.Size(), true, nil
+}
+
+// Extend the file to at least offset + length bytes, without actually
+// reserving any space. This is the best we can do for allocate on
+// platforms without fallocate.
+func extendTo(file *os.File, offset, length int64) error {
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() >= offset+length {
+		return nil
+	}
+	return file.Truncate(offset + length)
+}
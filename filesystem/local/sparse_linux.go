@@ -0,0 +1,57 @@
+package local
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func allocate(file *os.File, offset, length int64) error {
+	err := unix.Fallocate(int(file.Fd()), 0, offset, length)
+	if err == unix.EOPNOTSUPP {
+		return extendTo(file, offset, length)
+	}
+	return err
+}
+
+func punchHole(file *os.File, offset, length int64) error {
+	err := unix.Fallocate(
+		int(file.Fd()),
+		unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE,
+		offset,
+		length,
+	)
+	if err == unix.EOPNOTSUPP {
+		return NotImplemented
+	}
+	return err
+}
+
+func seekData(file *os.File, offset int64) (int64, bool, error) {
+	return lseek(file, offset, unix.SEEK_DATA, seekDataNoHoles)
+}
+
+func seekHole(file *os.File, offset int64) (int64, bool, error) {
+	return lseek(file, offset, unix.SEEK_HOLE, seekHoleNoHoles)
+}
+
+func lseek(
+	file *os.File,
+	offset int64,
+	whence int,
+	fallback func(*os.File, int64) (int64, bool, error),
+) (int64, bool, error) {
+	ret, err := unix.Seek(int(file.Fd()), offset, whence)
+	switch err {
+	case nil:
+		return ret, true, nil
+	case unix.ENXIO:
+		// offset is past the end of the data (or file).
+		return 0, false, nil
+	case unix.EINVAL:
+		// Very old kernels don't know about SEEK_DATA/SEEK_HOLE.
+		return fallback(file, offset)
+	default:
+		return 0, false, err
+	}
+}
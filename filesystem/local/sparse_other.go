@@ -0,0 +1,24 @@
+//go:build !linux
+// +build !linux
+
+package local
+
+import (
+	"os"
+)
+
+func allocate(file *os.File, offset, length int64) error {
+	return extendTo(file, offset, length)
+}
+
+func punchHole(file *os.File, offset, length int64) error {
+	return NotImplemented
+}
+
+func seekData(file *os.File, offset int64) (int64, bool, error) {
+	return seekDataNoHoles(file, offset)
+}
+
+func seekHole(file *os.File, offset int64) (int64, bool, error) {
+	return seekHoleNoHoles(file, offset)
+}
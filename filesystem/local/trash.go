@@ -0,0 +1,404 @@
+package local
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/internal/fsutil"
+
+	"zombiezen.com/go/capnproto2"
+	"zombiezen.com/go/capnproto2/server"
+)
+
+// If Config.TrashDir is non-empty, deleted files are moved there instead
+// of being removed outright, laid out like:
+//
+//	items/<id>
+//	meta/<id>.json
+var (
+	NoSuchItem        = fserrors.New(filesystem.ErrorCode_notFound, "No such item in trash")
+	AlreadyExists     = fserrors.New(filesystem.ErrorCode_exists, "File already exists")
+	DirectoryNotEmpty = fserrors.New(filesystem.ErrorCode_notEmpty, "Directory not empty")
+)
+
+// Information about an item in the trash, stored in its meta file.
+type trashMeta struct {
+	// The absolute path the item was deleted from.
+	OriginalPath string
+
+	// Nanoseconds since the unix epoch.
+	DeletedAt int64
+}
+
+type trashItem struct {
+	id string
+	trashMeta
+}
+
+func (c *Config) trashItemPath(id string) string {
+	return filepath.Join(c.TrashDir, "items", id)
+}
+
+func (c *Config) trashMetaPath(id string) string {
+	return filepath.Join(c.TrashDir, "meta", id+".json")
+}
+
+// Move the node at path into the trash.
+func (c *Config) moveToTrash(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	var buf [16]byte
+	if _, err = rand.Read(buf[:]); err != nil {
+		return err
+	}
+	id := hex.EncodeToString(buf[:])
+	data, err := json.Marshal(trashMeta{
+		OriginalPath: abs,
+		DeletedAt:    time.Now().UnixNano(),
+	})
+	if err != nil {
+		return err
+	}
+
+	c.trashLock.Lock()
+	defer c.trashLock.Unlock()
+
+	for _, dir := range []string{"items", "meta"} {
+		if err = os.MkdirAll(filepath.Join(c.TrashDir, dir), 0700); err != nil {
+			return err
+		}
+	}
+	if err = ioutil.WriteFile(c.trashMetaPath(id), data, 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(abs, c.trashItemPath(id)); err != nil {
+		os.Remove(c.trashMetaPath(id))
+		return err
+	}
+	return c.moveSidecar(abs, c.trashItemPath(id))
+}
+
+// List the items in the trash, most recently deleted first. Must be called
+// with c.trashLock held.
+func (c *Config) listTrash() ([]trashItem, error) {
+	fis, err := ioutil.ReadDir(filepath.Join(c.TrashDir, "meta"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	items := make([]trashItem, 0, len(fis))
+	for _, fi := range fis {
+		id := strings.TrimSuffix(fi.Name(), ".json")
+		item, err := c.readTrashItem(id)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt > items[j].DeletedAt
+	})
+	return items, nil
+}
+
+func (c *Config) readTrashItem(id string) (trashItem, error) {
+	item := trashItem{id: id}
+	data, err := ioutil.ReadFile(c.trashMetaPath(id))
+	if err != nil {
+		return item, err
+	}
+	err = json.Unmarshal(data, &item.trashMeta)
+	return item, err
+}
+
+// Rename from to to, failing if something already exists at to, by
+// making a hard link and then removing the original. This works for
+// anything but directories, which are never moved to the trash.
+func linkNoReplace(from, to string) error {
+	if err := os.Link(from, to); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+// Must be called with c.trashLock held.
+func (c *Config) purgeTrashItem(id string) error {
+	if err := os.RemoveAll(c.trashItemPath(id)); err != nil {
+		return err
+	}
+	if err := c.moveSidecar(c.trashItemPath(id), ""); err != nil {
+		return err
+	}
+	return os.Remove(c.trashMetaPath(id))
+}
+
+// ExpireTrash permanently deletes anything that has been in the trash for
+// longer than c.TrashExpiry. This is done whenever something is deleted,
+// but applications may also want to call it periodically.
+func (c *Config) ExpireTrash() error {
+	if c.TrashDir == "" || c.TrashExpiry == 0 {
+		return nil
+	}
+	c.trashLock.Lock()
+	defer c.trashLock.Unlock()
+	items, err := c.listTrash()
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-c.TrashExpiry).UnixNano()
+	for _, item := range items {
+		if item.DeletedAt < cutoff {
+			if err = c.purgeTrashItem(item.id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// The trash, as seen from a particular directory: only items deleted from
+// beneath root are visible. Implements filesystem.Trash_Server.
+type trashView struct {
+	root   string
+	config *Config
+}
+
+func (d *Node) Trash(ctx context.Context, p filesystem.RwDirectory_trash) error {
+	if d.config().TrashDir == "" {
+		return NotImplemented
+	}
+	root, err := filepath.Abs(d.Path)
+	if err != nil {
+		return err
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	t := &trashView{root: root, config: d.Config}
+	return res.SetTrash(filesystem.Trash{
+		Client: capnp.NewClient(server.New(
+			fserrors.CensorMethods(filesystem.Trash_Methods(nil, t)),
+			t,
+			nil,
+			nil,
+		)),
+	})
+}
+
+// Return the items visible from this view, with their original paths
+// made relative to t.root.
+func (t *trashView) items() ([]trashItem, error) {
+	t.config.trashLock.Lock()
+	defer t.config.trashLock.Unlock()
+	all, err := t.config.listTrash()
+	if err != nil {
+		return nil, err
+	}
+	items := all[:0]
+	for _, item := range all {
+		if strings.HasPrefix(item.OriginalPath, t.root+"/") {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// Look up the item named name, checking that it is visible from this view.
+// Must be called with t.config.trashLock held.
+func (t *trashView) lookup(name string) (trashItem, error) {
+	if !fsutil.ValidFileName(name) {
+		return trashItem{}, IllegalFileName
+	}
+	item, err := t.config.readTrashItem(name)
+	if err != nil || !strings.HasPrefix(item.OriginalPath, t.root+"/") {
+		return trashItem{}, NoSuchItem
+	}
+	return item, nil
+}
+
+func (t *trashView) Items(ctx context.Context, p filesystem.Trash_items) error {
+	items, err := t.items()
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	list, err := res.NewItems(int32(len(items)))
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		ent := list.At(i)
+		if err = ent.SetName(item.id); err != nil {
+			return err
+		}
+		err = ent.SetOriginalPath(strings.TrimPrefix(item.OriginalPath, t.root+"/"))
+		if err != nil {
+			return err
+		}
+		ent.SetDeletedAt(item.DeletedAt)
+	}
+	return nil
+}
+
+func (t *trashView) Restore(ctx context.Context, p filesystem.Trash_restore) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	t.config.trashLock.Lock()
+	defer t.config.trashLock.Unlock()
+	item, err := t.lookup(name)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(item.OriginalPath), 0700); err != nil {
+		return fserrors.Censor(err)
+	}
+	// Checking for something at the original path first would race
+	// with anything created there in between.
+	err = renameNoReplace(t.config.trashItemPath(item.id), item.OriginalPath)
+	if os.IsExist(err) {
+		return AlreadyExists
+	} else if err != nil {
+		return fserrors.Censor(err)
+	}
+	if err = t.config.moveSidecar(t.config.trashItemPath(item.id), item.OriginalPath); err != nil {
+		return fserrors.Censor(err)
+	}
+	return os.Remove(t.config.trashMetaPath(item.id))
+}
+
+func (t *trashView) Purge(ctx context.Context, p filesystem.Trash_purge) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	t.config.trashLock.Lock()
+	defer t.config.trashLock.Unlock()
+	item, err := t.lookup(name)
+	if err != nil {
+		return err
+	}
+	if err = t.config.purgeTrashItem(item.id); err != nil {
+		return fserrors.Censor(err)
+	}
+	return nil
+}
+
+func (t *trashView) List(ctx context.Context, p filesystem.Directory_list) error {
+	p.Ack() // See Node.List.
+	stream := p.Args().Stream()
+	items, err := t.items()
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	stream.Push(ctx, func(p filesystem.Directory_Entry_Stream_push_Params) error {
+		list, err := p.NewEntries(int32(len(items)))
+		if err != nil {
+			return err
+		}
+		for i, item := range items {
+			fi, err := os.Lstat(t.config.trashItemPath(item.id))
+			if err != nil {
+				return fserrors.Censor(err)
+			}
+			ent := list.At(i)
+			ent.SetName(item.id)
+			info, err := ent.NewInfo()
+			if err != nil {
+				return err
+			}
+			info.SetExecutable(fi.Mode()&0100 != 0)
+			if fi.IsDir() {
+				info.SetDir()
+			} else {
+				info.SetFile()
+				info.File().SetSize(fi.Size())
+			}
+		}
+		return nil
+	})
+	stream.Done(ctx, func(filesystem.Directory_Entry_Stream_done_Params) error {
+		return nil
+	})
+	return nil
+}
+
+func (t *trashView) Walk(ctx context.Context, p filesystem.Directory_walk) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	t.config.trashLock.Lock()
+	item, err := t.lookup(name)
+	t.config.trashLock.Unlock()
+	if err != nil {
+		return err
+	}
+	path := t.config.trashItemPath(item.id)
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	node := &Node{
+		Path:       path,
+		IsDir:      fi.IsDir(),
+		Executable: fi.Mode()&0100 != 0,
+		Config:     t.config,
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	res.SetNode(node.MakeClient())
+	return nil
+}
+
+func (t *trashView) Snapshots(ctx context.Context, p filesystem.Directory_snapshots) error {
+	_, err := p.AllocResults()
+	return err
+}
+
+func (t *trashView) Watch(ctx context.Context, p filesystem.Directory_watch) error {
+	return NotImplemented
+}
+
+func (t *trashView) Stat(ctx context.Context, p filesystem.Node_stat) error {
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	info, err := res.NewInfo()
+	if err != nil {
+		return err
+	}
+	info.SetDir()
+	return nil
+}
+
+func (t *trashView) GetXattr(ctx context.Context, p filesystem.Node_getXattr) error {
+	return NoSuchAttribute
+}
+
+func (t *trashView) ListXattrs(ctx context.Context, p filesystem.Node_listXattrs) error {
+	_, err := p.AllocResults()
+	return err
+}
@@ -0,0 +1,417 @@
+package local
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+// Old versions of files, and snapshots of directories, are kept under
+// Config.VersionDir, laid out like:
+//
+//	files/<pathKey>/<time>
+//	snapshots/<pathKey>/<time>/...
+//
+// where the times are in nanoseconds since the unix epoch. A file version
+// is named for the time it was superseded, and keeps the modification time
+// of the contents it holds; a snapshot is named for the time it was taken.
+// If VersionDir is empty, no versions are kept and snapshots are
+// unavailable.
+var (
+	SnapshotsNotSupported = capnp.Unimplemented("Snapshots not supported")
+	VersionFailed         = fserrors.New(filesystem.ErrorCode_io, "Failed to save previous version")
+)
+
+// A RetentionPolicy says which old versions of a file (or snapshots of a
+// directory) to keep. Anything which exceeds either limit is discarded;
+// zero values mean no limit.
+type RetentionPolicy struct {
+	// The maximum number of versions to keep for each file (or
+	// snapshots for each directory).
+	MaxCount int
+
+	// The maximum age of a version.
+	MaxAge time.Duration
+}
+
+// An entry in one of the per-path directories under Config.VersionDir.
+type version struct {
+	path string
+	time int64
+}
+
+func (c *Config) versionsPath(kind, path string) (string, error) {
+	if c.VersionDir == "" {
+		return "", SnapshotsNotSupported
+	}
+	key, err := pathKey(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(c.VersionDir, kind, key), nil
+}
+
+// List the versions stored in dir, most recent first. Anything not named
+// like a version (e.g. partially written ones) is skipped.
+func listVersions(dir string) ([]version, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]version, 0, len(fis))
+	for _, fi := range fis {
+		t, err := strconv.ParseInt(fi.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		ret = append(ret, version{
+			path: filepath.Join(dir, fi.Name()),
+			time: t,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].time > ret[j].time
+	})
+	return ret, nil
+}
+
+// Discard versions in dir that c.VersionRetention says we shouldn't keep,
+// and return the rest. Must be called with c.versionLock held.
+func (c *Config) pruneVersions(dir string) ([]version, error) {
+	versions, err := listVersions(dir)
+	if err != nil {
+		return nil, err
+	}
+	policy := c.VersionRetention
+	cutoff := int64(0)
+	if policy.MaxAge != 0 {
+		cutoff = time.Now().Add(-policy.MaxAge).UnixNano()
+	}
+	keep := versions[:0]
+	for i, v := range versions {
+		if (policy.MaxCount != 0 && i >= policy.MaxCount) || v.time < cutoff {
+			if err = c.removeTree(v.path); err != nil {
+				return nil, err
+			}
+		} else {
+			keep = append(keep, v)
+		}
+	}
+	return keep, nil
+}
+
+// Save a copy of the current contents of the file at path, if we're
+// keeping versions. This should be called before anything that modifies
+// the file.
+func (c *Config) saveVersion(path string) error {
+	if c.VersionDir == "" {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil
+	}
+	dir, err := c.versionsPath("files", path)
+	if err != nil {
+		return err
+	}
+
+	c.versionLock.Lock()
+	defer c.versionLock.Unlock()
+
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	versions, err := listVersions(dir)
+	if err != nil {
+		return err
+	}
+	if len(versions) > 0 {
+		latest, err := os.Stat(versions[0].path)
+		if err == nil && latest.ModTime().Equal(fi.ModTime()) && latest.Size() == fi.Size() {
+			// Already saved; the file hasn't changed since.
+			return nil
+		}
+	}
+	dest := filepath.Join(dir, strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err = copyFile(path, dest, fi.Mode()); err != nil {
+		return err
+	}
+	if err = os.Chtimes(dest, fi.ModTime(), fi.ModTime()); err != nil {
+		os.Remove(dest)
+		return err
+	}
+	_, err = c.pruneVersions(dir)
+	return err
+}
+
+// Copy the file at src to dest, via a temporary file so that a partial
+// copy is never visible under dest's name.
+func copyFile(src, dest string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmpPath := filepath.Join(filepath.Dir(dest), ".tmp-"+filepath.Base(dest))
+	out, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()&^0222)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dest)
+}
+
+// Copy the tree rooted at src to dest, along with its extended
+// attributes. Anything other than regular files and directories is
+// skipped. Attributes kept in sidecars are keyed on the path under
+// final rather than dest, since that is where the copy will end up.
+func (c *Config) copyTree(src, dest, final string) error {
+	return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		switch {
+		case fi.IsDir():
+			err = os.MkdirAll(target, 0700)
+		case fi.Mode().IsRegular():
+			err = copyFile(path, target, fi.Mode())
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return c.copyXattrs(path, target, filepath.Join(final, rel))
+	})
+}
+
+// Copy the extended attributes of the node at src to the node at dest,
+// keeping any which dest's filesystem can't store in the sidecar for
+// sidecarPath.
+func (c *Config) copyXattrs(src, dest, sidecarPath string) error {
+	n := &Node{Path: src, Config: c}
+	names, err := n.listXattrs()
+	if err != nil {
+		return err
+	}
+	sidecar := make(map[string][]byte)
+	for _, name := range names {
+		value, err := n.getXattr(name)
+		if err == NoSuchAttribute {
+			// Removed since we listed it.
+			continue
+		} else if err != nil {
+			return err
+		}
+		err = setXattr(dest, xattrPrefix+name, value)
+		if err == errXattrNotSupported {
+			sidecar[name] = value
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(sidecar) == 0 {
+		return nil
+	}
+	err = c.sidecarUpdate(sidecarPath, func(attrs map[string][]byte) {
+		for name, value := range sidecar {
+			attrs[name] = value
+		}
+	})
+	if err == errXattrNotSupported {
+		// Nowhere to keep them.
+		return nil
+	}
+	return err
+}
+
+// Remove the tree rooted at path, along with the sidecars of everything
+// in it.
+func (c *Config) removeTree(path string) error {
+	if err := c.removeSidecars(path, path); err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+// Remove the sidecars of everything in the tree rooted at path, as if
+// that tree were at keyPath.
+func (c *Config) removeSidecars(path, keyPath string) error {
+	if c.XattrSidecarDir == "" {
+		return nil
+	}
+	err := filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		return c.moveSidecar(filepath.Join(keyPath, rel), "")
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (f *Node) Versions(ctx context.Context, p filesystem.File_versions) error {
+	var versions []version
+	config := f.config()
+	if config.VersionDir != "" {
+		dir, err := config.versionsPath("files", f.Path)
+		if err != nil {
+			return err
+		}
+		config.versionLock.Lock()
+		versions, err = config.pruneVersions(dir)
+		config.versionLock.Unlock()
+		if err != nil {
+			return fserrors.Censor(err)
+		}
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	list, err := res.NewVersions(int32(len(versions)))
+	if err != nil {
+		return err
+	}
+	for i, v := range versions {
+		node := &Node{
+			Path:       v.path,
+			Executable: f.Executable,
+			Config:     f.Config,
+		}
+		item := list.At(i)
+		// The schema wants the time the contents were last modified,
+		// which the copy keeps, rather than the name's.
+		if fi, err := os.Stat(v.path); err == nil {
+			item.SetTime(fi.ModTime().UnixNano())
+		} else {
+			item.SetTime(v.time)
+		}
+		err = item.SetFile(filesystem.File{Client: node.MakeClient().Client})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Node) Snapshots(ctx context.Context, p filesystem.Directory_snapshots) error {
+	var snapshots []version
+	config := d.config()
+	if config.VersionDir != "" {
+		dir, err := config.versionsPath("snapshots", d.Path)
+		if err != nil {
+			return err
+		}
+		config.versionLock.Lock()
+		snapshots, err = config.pruneVersions(dir)
+		config.versionLock.Unlock()
+		if err != nil {
+			return fserrors.Censor(err)
+		}
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	list, err := res.NewSnapshots(int32(len(snapshots)))
+	if err != nil {
+		return err
+	}
+	for i, s := range snapshots {
+		if err = setSnapshot(list.At(i), s, d.Config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Node) Snapshot(ctx context.Context, p filesystem.RwDirectory_snapshot) error {
+	config := d.config()
+	dir, err := config.versionsPath("snapshots", d.Path)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return fserrors.Censor(err)
+	}
+	now := time.Now().UnixNano()
+	name := strconv.FormatInt(now, 10)
+
+	// Copying may take a while, so we don't hold versionLock while we
+	// do; listVersions ignores the copy until we rename it into place.
+	tmpPath := filepath.Join(dir, ".tmp-"+name)
+	snapPath := filepath.Join(dir, name)
+	if err = config.copyTree(d.Path, tmpPath, snapPath); err != nil {
+		config.removeSidecars(tmpPath, snapPath)
+		os.RemoveAll(tmpPath)
+		return fserrors.Censor(err)
+	}
+
+	config.versionLock.Lock()
+	defer config.versionLock.Unlock()
+
+	if err = os.Rename(tmpPath, snapPath); err != nil {
+		config.removeSidecars(tmpPath, snapPath)
+		os.RemoveAll(tmpPath)
+		return fserrors.Censor(err)
+	}
+	if _, err = config.pruneVersions(dir); err != nil {
+		return fserrors.Censor(err)
+	}
+
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	snapshot, err := res.NewSnapshot()
+	if err != nil {
+		return err
+	}
+	return setSnapshot(snapshot, version{path: snapPath, time: now}, d.Config)
+}
+
+func setSnapshot(s filesystem.Directory_Snapshot, v version, config *Config) error {
+	node := &Node{
+		Path:   v.path,
+		IsDir:  true,
+		Config: config,
+	}
+	s.SetTime(v.time)
+	return s.SetDir(filesystem.Directory{Client: node.MakeClient().Client})
+}
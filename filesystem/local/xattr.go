@@ -0,0 +1,305 @@
+package local
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/internal/fsutil"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+// The name of the extended attribute holding a node's MIME type.
+const MimeTypeXattr = "mime_type"
+
+// Attributes are stored in the host filesystem under this namespace, so
+// that clients can't touch system or security attributes.
+const xattrPrefix = "user."
+
+var (
+	NoSuchAttribute      = fserrors.New(filesystem.ErrorCode_notFound, "No such attribute")
+	IllegalAttributeName = fserrors.New(filesystem.ErrorCode_invalidName, "Illegal attribute name")
+
+	// Returned when setting an attribute on a filesystem which can't
+	// store them, with no sidecar directory to fall back on. Such nodes
+	// have no attributes, so reading them isn't an error.
+	XattrsNotSupported = capnp.Unimplemented("Extended attributes not supported")
+
+	// Returned by the platform specific functions if the underlying
+	// filesystem can't store extended attributes.
+	errXattrNotSupported = errors.New("Extended attributes not supported")
+)
+
+func (n *Node) getXattr(name string) ([]byte, error) {
+	value, err := getXattr(n.Path, xattrPrefix+name)
+	if err == errXattrNotSupported {
+		value, err = n.config().sidecarGet(n.Path, name)
+		if err == errXattrNotSupported {
+			return nil, NoSuchAttribute
+		}
+	}
+	return value, err
+}
+
+func (n *Node) listXattrs() ([]string, error) {
+	names, err := listXattrs(n.Path)
+	if err == errXattrNotSupported {
+		names, err = n.config().sidecarList(n.Path)
+		if err == errXattrNotSupported {
+			return nil, nil
+		}
+		return names, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	ret := names[:0]
+	for _, name := range names {
+		if strings.HasPrefix(name, xattrPrefix) {
+			ret = append(ret, name[len(xattrPrefix):])
+		}
+	}
+	return ret, nil
+}
+
+func (n *Node) setXattr(name string, value []byte) error {
+	err := setXattr(n.Path, xattrPrefix+name, value)
+	if err == errXattrNotSupported {
+		err = n.config().sidecarUpdate(n.Path, func(attrs map[string][]byte) {
+			attrs[name] = value
+		})
+		if err == errXattrNotSupported {
+			return XattrsNotSupported
+		}
+	}
+	return err
+}
+
+func (n *Node) removeXattr(name string) error {
+	err := removeXattr(n.Path, xattrPrefix+name)
+	if err == errXattrNotSupported {
+		err = n.config().sidecarUpdate(n.Path, func(attrs map[string][]byte) {
+			delete(attrs, name)
+		})
+	}
+	if err == NoSuchAttribute || err == errXattrNotSupported {
+		return nil
+	}
+	return err
+}
+
+// Return the node's MIME type, or "" if it doesn't have one.
+func (n *Node) mimeType() string {
+	value, err := n.getXattr(MimeTypeXattr)
+	if err != nil {
+		return ""
+	}
+	return string(value)
+}
+
+func (c *Config) sidecarPath(path string) (string, error) {
+	if c.XattrSidecarDir == "" {
+		return "", errXattrNotSupported
+	}
+	key, err := pathKey(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(c.XattrSidecarDir, key+".json"), nil
+}
+
+// Return a string identifying path, suitable for use as a file name when
+// storing information about it elsewhere.
+func pathKey(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (c *Config) readSidecar(path string) (map[string][]byte, error) {
+	scPath, err := c.sidecarPath(path)
+	if err != nil {
+		return nil, err
+	}
+	attrs := map[string][]byte{}
+	data, err := ioutil.ReadFile(scPath)
+	if os.IsNotExist(err) {
+		return attrs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &attrs)
+	return attrs, err
+}
+
+func (c *Config) sidecarGet(path, name string) ([]byte, error) {
+	c.sidecarLock.Lock()
+	defer c.sidecarLock.Unlock()
+	attrs, err := c.readSidecar(path)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := attrs[name]
+	if !ok {
+		return nil, NoSuchAttribute
+	}
+	return value, nil
+}
+
+func (c *Config) sidecarList(path string) ([]string, error) {
+	c.sidecarLock.Lock()
+	defer c.sidecarLock.Unlock()
+	attrs, err := c.readSidecar(path)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *Config) sidecarUpdate(path string, update func(map[string][]byte)) error {
+	c.sidecarLock.Lock()
+	defer c.sidecarLock.Unlock()
+	attrs, err := c.readSidecar(path)
+	if err != nil {
+		return err
+	}
+	update(attrs)
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		return err
+	}
+	scPath, err := c.sidecarPath(path)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file and rename, so a crash can't leave us
+	// with a truncated sidecar.
+	tmpPath := scPath + ".tmp"
+	if err = ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, scPath)
+}
+
+// Make the sidecar for the node at from, if any, the sidecar for the node
+// at to instead, or remove it if to is "". Sidecars are keyed by path, so
+// this must be done whenever a node is moved or removed, or its attributes
+// would turn up on whatever is next created at the same path.
+func (c *Config) moveSidecar(from, to string) error {
+	if c.XattrSidecarDir == "" {
+		return nil
+	}
+	c.sidecarLock.Lock()
+	defer c.sidecarLock.Unlock()
+	fromPath, err := c.sidecarPath(from)
+	if err != nil {
+		return err
+	}
+	if to == "" {
+		err = os.Remove(fromPath)
+	} else {
+		var toPath string
+		if toPath, err = c.sidecarPath(to); err != nil {
+			return err
+		}
+		err = os.Rename(fromPath, toPath)
+	}
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (n *Node) GetXattr(ctx context.Context, p filesystem.Node_getXattr) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidXattrName(name) {
+		return IllegalAttributeName
+	}
+	value, err := n.getXattr(name)
+	if err == NoSuchAttribute {
+		return err
+	} else if err != nil {
+		// Avoid leaking details about the host.
+		return fserrors.Censor(err)
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetValue(value)
+}
+
+func (n *Node) ListXattrs(ctx context.Context, p filesystem.Node_listXattrs) error {
+	names, err := n.listXattrs()
+	if err != nil {
+		return fserrors.Censor(err)
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	list, err := res.NewNames(int32(len(names)))
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		if err = list.Set(i, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Node) SetXattr(ctx context.Context, p filesystem.RwFile_setXattr) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidXattrName(name) {
+		return IllegalAttributeName
+	}
+	value, err := p.Args().Value()
+	if err != nil {
+		return err
+	}
+	if err = f.setXattr(name, value); err != nil {
+		return fserrors.Censor(err)
+	}
+	return nil
+}
+
+func (f *Node) RemoveXattr(ctx context.Context, p filesystem.RwFile_removeXattr) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidXattrName(name) {
+		return IllegalAttributeName
+	}
+	if err = f.removeXattr(name); err != nil {
+		return fserrors.Censor(err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package local
+
+import (
+	"strings"
+	"syscall"
+)
+
+func xattrError(err error) error {
+	switch err {
+	case syscall.ENOTSUP:
+		return errXattrNotSupported
+	case syscall.ENODATA:
+		return NoSuchAttribute
+	}
+	return err
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	for {
+		size, err := syscall.Getxattr(path, name, nil)
+		if err != nil {
+			return nil, xattrError(err)
+		}
+		buf := make([]byte, size)
+		size, err = syscall.Getxattr(path, name, buf)
+		if err == syscall.ERANGE {
+			// The value grew in between the two calls; try again.
+			continue
+		}
+		if err != nil {
+			return nil, xattrError(err)
+		}
+		return buf[:size], nil
+	}
+}
+
+func listXattrs(path string) ([]string, error) {
+	for {
+		size, err := syscall.Listxattr(path, nil)
+		if err != nil {
+			return nil, xattrError(err)
+		}
+		buf := make([]byte, size)
+		size, err = syscall.Listxattr(path, buf)
+		if err == syscall.ERANGE {
+			continue
+		}
+		if err != nil {
+			return nil, xattrError(err)
+		}
+		names := strings.Split(string(buf[:size]), "\x00")
+		// The list is NUL-terminated, so the last element is empty.
+		return names[:len(names)-1], nil
+	}
+}
+
+func setXattr(path, name string, value []byte) error {
+	return xattrError(syscall.Setxattr(path, name, value, 0))
+}
+
+func removeXattr(path, name string) error {
+	return xattrError(syscall.Removexattr(path, name))
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package local
+
+// On other platforms we don't bother with native extended attributes,
+// and always use sidecar files.
+
+func getXattr(path, name string) ([]byte, error) {
+	return nil, errXattrNotSupported
+}
+
+func listXattrs(path string) ([]string, error) {
+	return nil, errXattrNotSupported
+}
+
+func setXattr(path, name string, value []byte) error {
+	return errXattrNotSupported
+}
+
+func removeXattr(path, name string) error {
+	return errXattrNotSupported
+}
@@ -0,0 +1,659 @@
+package membrane
+
+import (
+	"context"
+	"path"
+	"strconv"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+func (p *proxy) dir() filesystem.Directory {
+	return filesystem.Directory{Client: p.client}
+}
+
+func (p *proxy) rwDir() filesystem.RwDirectory {
+	return filesystem.RwDirectory{Client: p.client}
+}
+
+func (p *proxy) List(ctx context.Context, call filesystem.Directory_list) (err error) {
+	ctx, o, err := p.enter(ctx, "list", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	stream := call.Args().Stream()
+	if p.kind == KindTrash && p.m.hooks.Filter != nil {
+		return p.listTrash(ctx, stream)
+	}
+	s := &entryStream{
+		m:      p.m,
+		target: filesystem.Directory_Entry_Stream{Client: stream.Client.AddRef()},
+	}
+	res, release := p.dir().List(ctx, func(params filesystem.Directory_list_Params) error {
+		return params.SetStream(filesystem.Directory_Entry_Stream_ServerToClient(s, nil))
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (p *proxy) Walk(ctx context.Context, call filesystem.Directory_walk) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	ctx, o, err := p.enter(ctx, "walk", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	node, info, release, err := p.walk(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer release()
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetNode(filesystem.Node{
+		Client: p.m.wrap(node.Client.AddRef(), childPath(p.path, name), childKind(p.kind, info)),
+	})
+}
+
+// Walk to the child name of the underlying directory, checking that it is
+// visible. Also returns the child's stat info, which tells us what kind
+// of proxy it needs; the stat is pipelined on the walk, so this takes one
+// round trip.
+//
+// In a trash, the filter looks at the name the item had before it was
+// deleted. There's no way to ask for a single item, so we get the list of
+// items alongside the walk and look for this one.
+func (p *proxy) walk(ctx context.Context, name string) (filesystem.Node, filesystem.StatInfo, func(), error) {
+	res, release := p.dir().Walk(ctx, func(params filesystem.Directory_walk_Params) error {
+		return params.SetName(name)
+	})
+	statRes, statRelease := res.Node().Stat(ctx, nil)
+	defer statRelease()
+	var itemsRes filesystem.Trash_items_Results_Future
+	if p.kind == KindTrash && p.m.hooks.Filter != nil {
+		var itemsRelease capnp.ReleaseFunc
+		itemsRes, itemsRelease = p.trash().Items(ctx, nil)
+		defer itemsRelease()
+	}
+	fail := func(err error) (filesystem.Node, filesystem.StatInfo, func(), error) {
+		release()
+		return filesystem.Node{}, filesystem.StatInfo{}, nil, err
+	}
+	results, err := res.Struct()
+	if err != nil {
+		return fail(err)
+	}
+	statResults, err := statRes.Struct()
+	if err != nil {
+		return fail(err)
+	}
+	info, err := statResults.Info()
+	if err != nil {
+		return fail(err)
+	}
+	info = cloneStatInfo(info)
+	if p.m.hooks.Filter == nil {
+		return results.Node(), info, release, nil
+	}
+
+	filterName := name
+	if p.kind == KindTrash {
+		itemsResults, err := itemsRes.Struct()
+		if err != nil {
+			return fail(err)
+		}
+		items, err := itemsResults.Items()
+		if err != nil {
+			return fail(err)
+		}
+		if filterName, err = originalName(items, name); err != nil {
+			return fail(err)
+		}
+	}
+	if !p.m.visible(filterName, info) {
+		return fail(NotFound)
+	}
+	return results.Node(), info, release, nil
+}
+
+// Check that the child name is visible, for operations which don't
+// otherwise need to walk to it.
+func (p *proxy) checkVisible(ctx context.Context, name string) error {
+	if p.m.hooks.Filter == nil {
+		return nil
+	}
+	_, _, release, err := p.walk(ctx, name)
+	if err != nil {
+		return err
+	}
+	release()
+	return nil
+}
+
+func (p *proxy) Snapshots(ctx context.Context, call filesystem.Directory_snapshots) (err error) {
+	ctx, o, err := p.enter(ctx, "snapshots", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.dir().Snapshots(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	snapshots, err := results.Snapshots()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	list, err := out.NewSnapshots(int32(snapshots.Len()))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < snapshots.Len(); i++ {
+		if err = p.copySnapshot(list.At(i), snapshots.At(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Like lock handles, the watch handle is released when the membrane's Done
+// channel is closed. The watcher is wrapped, so that notifications respect
+// the membrane's hooks.
+func (p *proxy) Watch(ctx context.Context, call filesystem.Directory_watch) (err error) {
+	ctx, o, err := p.enter(ctx, "watch", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	w := &watcher{
+		m:      p.m,
+		target: filesystem.Directory_Watcher{Client: call.Args().Watcher().Client.AddRef()},
+	}
+	if p.m.hooks.Filter != nil {
+		w.dir = filesystem.Directory{Client: p.client.AddRef()}
+	}
+	res, release := p.dir().Watch(ctx, func(params filesystem.Directory_watch_Params) error {
+		return params.SetWatcher(filesystem.Directory_Watcher_ServerToClient(w, nil))
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetHandle(filesystem.WatchHandle{Client: p.m.wrapHandle(results.Handle().Client.AddRef())})
+}
+
+func (p *proxy) copySnapshot(dst, src filesystem.Directory_Snapshot) error {
+	dst.SetTime(src.Time())
+	snapPath := p.path + "@snapshot:" + strconv.FormatInt(src.Time(), 10)
+	return dst.SetDir(filesystem.Directory{
+		Client: p.m.wrap(src.Dir().Client.AddRef(), snapPath, KindDirectory),
+	})
+}
+
+func (p *proxy) Create(ctx context.Context, call filesystem.RwDirectory_create) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	executable := call.Args().Executable()
+	ctx, o, err := p.enter(ctx, "create", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	if !p.m.visible(name, newStatInfo(false, executable)) {
+		return PermissionDenied
+	}
+	res, release := p.rwDir().Create(ctx, func(params filesystem.RwDirectory_create_Params) error {
+		params.SetExecutable(executable)
+		return params.SetName(name)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetFile(filesystem.RwFile{
+		Client: p.m.wrap(results.File().Client.AddRef(), childPath(p.path, name), KindRwFile),
+	})
+}
+
+func (p *proxy) Mkdir(ctx context.Context, call filesystem.RwDirectory_mkdir) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	ctx, o, err := p.enter(ctx, "mkdir", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	if !p.m.visible(name, newStatInfo(true, false)) {
+		return PermissionDenied
+	}
+	res, release := p.rwDir().Mkdir(ctx, func(params filesystem.RwDirectory_mkdir_Params) error {
+		return params.SetName(name)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	if !results.HasDir() {
+		return nil
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetDir(filesystem.RwDirectory{
+		Client: p.m.wrap(results.Dir().Client.AddRef(), childPath(p.path, name), KindRwDirectory),
+	})
+}
+
+func (p *proxy) Delete(ctx context.Context, call filesystem.RwDirectory_delete) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	ctx, o, err := p.enter(ctx, "delete", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	if err = p.checkVisible(ctx, name); err != nil {
+		return err
+	}
+	res, release := p.rwDir().Delete(ctx, func(params filesystem.RwDirectory_delete_Params) error {
+		return params.SetName(name)
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (p *proxy) Snapshot(ctx context.Context, call filesystem.RwDirectory_snapshot) (err error) {
+	ctx, o, err := p.enter(ctx, "snapshot", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.rwDir().Snapshot(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	snapshot, err := results.Snapshot()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	dst, err := out.NewSnapshot()
+	if err != nil {
+		return err
+	}
+	return p.copySnapshot(dst, snapshot)
+}
+
+func (p *proxy) Trash(ctx context.Context, call filesystem.RwDirectory_trash) (err error) {
+	ctx, o, err := p.enter(ctx, "trash", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.rwDir().Trash(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetTrash(filesystem.Trash{
+		Client: p.m.wrap(results.Trash().Client.AddRef(), p.path+"@trash", KindTrash),
+	})
+}
+
+// Implements AppendOnlyDirectory and AppendOnlyFile, whose create and
+// mkdir methods differ from RwDirectory's.
+type appendOnlyProxy struct {
+	*proxy
+}
+
+func (p appendOnlyProxy) Create(ctx context.Context, call filesystem.AppendOnlyDirectory_create) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	executable := call.Args().Executable()
+	ctx, o, err := p.enter(ctx, "create", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	if !p.m.visible(name, newStatInfo(false, executable)) {
+		return PermissionDenied
+	}
+	dir := filesystem.AppendOnlyDirectory{Client: p.client}
+	res, release := dir.Create(ctx, func(params filesystem.AppendOnlyDirectory_create_Params) error {
+		params.SetExecutable(executable)
+		return params.SetName(name)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetFile(filesystem.AppendOnlyFile{
+		Client: p.m.wrap(results.File().Client.AddRef(), childPath(p.path, name), KindAppendOnlyFile),
+	})
+}
+
+func (p appendOnlyProxy) Mkdir(ctx context.Context, call filesystem.AppendOnlyDirectory_mkdir) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	ctx, o, err := p.enter(ctx, "mkdir", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	if !p.m.visible(name, newStatInfo(true, false)) {
+		return PermissionDenied
+	}
+	dir := filesystem.AppendOnlyDirectory{Client: p.client}
+	res, release := dir.Mkdir(ctx, func(params filesystem.AppendOnlyDirectory_mkdir_Params) error {
+		return params.SetName(name)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetDir(filesystem.AppendOnlyDirectory{
+		Client: p.m.wrap(results.Dir().Client.AddRef(), childPath(p.path, name), KindAppendOnlyDirectory),
+	})
+}
+
+// Trash
+
+func (p *proxy) trash() filesystem.Trash {
+	return filesystem.Trash{Client: p.client}
+}
+
+// Return a map from the names of items in the trash to the names they had
+// before they were deleted, which is what the filter should look at.
+func (p *proxy) trashNames(ctx context.Context) (map[string]string, error) {
+	res, release := p.trash().Items(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return nil, err
+	}
+	items, err := results.Items()
+	if err != nil {
+		return nil, err
+	}
+	return originalNames(items)
+}
+
+// Return the name the item called name had before it was deleted, or
+// NotFound if there is no such item.
+func originalName(items filesystem.Trash_Item_List, name string) (string, error) {
+	for i := 0; i < items.Len(); i++ {
+		itemName, err := items.At(i).Name()
+		if err != nil {
+			return "", err
+		}
+		if itemName != name {
+			continue
+		}
+		originalPath, err := items.At(i).OriginalPath()
+		if err != nil {
+			return "", err
+		}
+		return path.Base(originalPath), nil
+	}
+	return "", NotFound
+}
+
+func originalNames(items filesystem.Trash_Item_List) (map[string]string, error) {
+	names := make(map[string]string, items.Len())
+	for i := 0; i < items.Len(); i++ {
+		name, err := items.At(i).Name()
+		if err != nil {
+			return nil, err
+		}
+		originalPath, err := items.At(i).OriginalPath()
+		if err != nil {
+			return nil, err
+		}
+		names[name] = path.Base(originalPath)
+	}
+	return names, nil
+}
+
+// Return the set of names of visible items in the trash, given their
+// original names.
+func (p *proxy) visibleTrashItems(ctx context.Context, names map[string]string) (map[string]bool, error) {
+	c := &collector{}
+	res, release := p.dir().List(ctx, func(params filesystem.Directory_list_Params) error {
+		return params.SetStream(filesystem.Directory_Entry_Stream_ServerToClient(c, nil))
+	})
+	defer release()
+	if _, err := res.Struct(); err != nil {
+		return nil, err
+	}
+	visible := make(map[string]bool, len(c.entries))
+	for _, ent := range c.entries {
+		if p.m.visible(names[ent.name], ent.info) {
+			visible[ent.name] = true
+		}
+	}
+	return visible, nil
+}
+
+// Like List, but for a trash in a membrane with a filter.
+func (p *proxy) listTrash(ctx context.Context, stream filesystem.Directory_Entry_Stream) error {
+	names, err := p.trashNames(ctx)
+	if err != nil {
+		return err
+	}
+	c := &collector{}
+	res, release := p.dir().List(ctx, func(params filesystem.Directory_list_Params) error {
+		return params.SetStream(filesystem.Directory_Entry_Stream_ServerToClient(c, nil))
+	})
+	defer release()
+	if _, err = res.Struct(); err != nil {
+		return err
+	}
+	var visible []entry
+	for _, ent := range c.entries {
+		if p.m.visible(names[ent.name], ent.info) {
+			visible = append(visible, ent)
+		}
+	}
+
+	pushRes, pushRelease := stream.Push(ctx, func(params filesystem.Directory_Entry_Stream_push_Params) error {
+		list, err := params.NewEntries(int32(len(visible)))
+		if err != nil {
+			return err
+		}
+		for i, ent := range visible {
+			if err = list.At(i).SetName(ent.name); err != nil {
+				return err
+			}
+			if err = list.At(i).SetInfo(ent.info); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	defer pushRelease()
+	if _, err = pushRes.Struct(); err != nil {
+		return err
+	}
+	doneRes, doneRelease := stream.Done(ctx, nil)
+	defer doneRelease()
+	_, err = doneRes.Struct()
+	return err
+}
+
+func (p *proxy) Items(ctx context.Context, call filesystem.Trash_items) (err error) {
+	ctx, o, err := p.enter(ctx, "items", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.trash().Items(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	items, err := results.Items()
+	if err != nil {
+		return err
+	}
+	var visible map[string]bool
+	if p.m.hooks.Filter != nil {
+		names, err := originalNames(items)
+		if err != nil {
+			return err
+		}
+		if visible, err = p.visibleTrashItems(ctx, names); err != nil {
+			return err
+		}
+	}
+	keep := make([]filesystem.Trash_Item, 0, items.Len())
+	for i := 0; i < items.Len(); i++ {
+		name, err := items.At(i).Name()
+		if err != nil {
+			return err
+		}
+		if visible == nil || visible[name] {
+			keep = append(keep, items.At(i))
+		}
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	list, err := out.NewItems(int32(len(keep)))
+	if err != nil {
+		return err
+	}
+	for i, item := range keep {
+		name, err := item.Name()
+		if err != nil {
+			return err
+		}
+		originalPath, err := item.OriginalPath()
+		if err != nil {
+			return err
+		}
+		if err = list.At(i).SetName(name); err != nil {
+			return err
+		}
+		if err = list.At(i).SetOriginalPath(originalPath); err != nil {
+			return err
+		}
+		list.At(i).SetDeletedAt(item.DeletedAt())
+	}
+	return nil
+}
+
+func (p *proxy) Restore(ctx context.Context, call filesystem.Trash_restore) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	ctx, o, err := p.enter(ctx, "restore", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	if err = p.checkVisible(ctx, name); err != nil {
+		return err
+	}
+	res, release := p.trash().Restore(ctx, func(params filesystem.Trash_restore_Params) error {
+		return params.SetName(name)
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (p *proxy) Purge(ctx context.Context, call filesystem.Trash_purge) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	ctx, o, err := p.enter(ctx, "purge", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	if err = p.checkVisible(ctx, name); err != nil {
+		return err
+	}
+	res, release := p.trash().Purge(ctx, func(params filesystem.Trash_purge_Params) error {
+		return params.SetName(name)
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
@@ -0,0 +1,424 @@
+package membrane
+
+import (
+	"context"
+	"strconv"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+
+	"zenhack.net/go/sandstorm/capnp/util"
+)
+
+func (p *proxy) file() filesystem.File {
+	return filesystem.File{Client: p.client}
+}
+
+func (p *proxy) rwFile() filesystem.RwFile {
+	return filesystem.RwFile{Client: p.client}
+}
+
+func (p *proxy) Read(ctx context.Context, call filesystem.File_read) (err error) {
+	ctx, o, err := p.enter(ctx, "read", "")
+	if err != nil {
+		return err
+	}
+	s := &byteStream{
+		m:      p.m,
+		target: util.ByteStream{Client: call.Args().Sink().Client.AddRef()},
+	}
+	defer func() {
+		o.Bytes = s.bytes()
+		o.finish(err)
+	}()
+
+	startAt := call.Args().StartAt()
+	amount := call.Args().Amount()
+	res, release := p.file().Read(ctx, func(params filesystem.File_read_Params) error {
+		params.SetStartAt(startAt)
+		params.SetAmount(amount)
+		return params.SetSink(s.client())
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (p *proxy) ReadBytes(ctx context.Context, call filesystem.File_readBytes) (err error) {
+	ctx, o, err := p.enter(ctx, "readBytes", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	startAt := call.Args().StartAt()
+	amount := call.Args().Amount()
+	res, release := p.file().ReadBytes(ctx, func(params filesystem.File_readBytes_Params) error {
+		params.SetStartAt(startAt)
+		params.SetAmount(amount)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	data, err := results.Data()
+	if err != nil {
+		return err
+	}
+	o.Bytes = int64(len(data))
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetEof(results.Eof())
+	return out.SetData(data)
+}
+
+func (p *proxy) Hash(ctx context.Context, call filesystem.File_hash) (err error) {
+	ctx, o, err := p.enter(ctx, "hash", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	args := call.Args()
+	res, release := p.file().Hash(ctx, func(params filesystem.File_hash_Params) error {
+		params.SetAlgorithm(args.Algorithm())
+		params.SetStartAt(args.StartAt())
+		params.SetAmount(args.Amount())
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	digest, err := results.Digest()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetDigest(digest)
+}
+
+func (p *proxy) SeekData(ctx context.Context, call filesystem.File_seekData) (err error) {
+	ctx, o, err := p.enter(ctx, "seekData", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	offset := call.Args().Offset()
+	res, release := p.file().SeekData(ctx, func(params filesystem.File_seekData_Params) error {
+		params.SetOffset(offset)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetOffset(results.Offset())
+	return nil
+}
+
+func (p *proxy) SeekHole(ctx context.Context, call filesystem.File_seekHole) (err error) {
+	ctx, o, err := p.enter(ctx, "seekHole", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	offset := call.Args().Offset()
+	res, release := p.file().SeekHole(ctx, func(params filesystem.File_seekHole_Params) error {
+		params.SetOffset(offset)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetOffset(results.Offset())
+	return nil
+}
+
+func (p *proxy) Versions(ctx context.Context, call filesystem.File_versions) (err error) {
+	ctx, o, err := p.enter(ctx, "versions", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.file().Versions(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	versions, err := results.Versions()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	list, err := out.NewVersions(int32(versions.Len()))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < versions.Len(); i++ {
+		src := versions.At(i)
+		dst := list.At(i)
+		dst.SetTime(src.Time())
+		versionPath := p.path + "@version:" + strconv.FormatInt(src.Time(), 10)
+		err = dst.SetFile(filesystem.File{
+			Client: p.m.wrap(src.File().Client.AddRef(), versionPath, KindFile),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *proxy) Write(ctx context.Context, call filesystem.RwFile_write) error {
+	ctx, o, err := p.enter(ctx, "write", "")
+	if err != nil {
+		return err
+	}
+	startAt := call.Args().StartAt()
+	res, release := p.rwFile().Write(ctx, func(params filesystem.RwFile_write_Params) error {
+		params.SetStartAt(startAt)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		o.finish(err)
+		return err
+	}
+	s := p.wrapSink(results.Sink(), o)
+	out, err := call.AllocResults()
+	if err != nil {
+		s.finish(err)
+		return err
+	}
+	return out.SetSink(s.client())
+}
+
+func (p appendOnlyProxy) Append(ctx context.Context, call filesystem.AppendOnlyFile_append) error {
+	ctx, o, err := p.enter(ctx, "append", "")
+	if err != nil {
+		return err
+	}
+	file := filesystem.AppendOnlyFile{Client: p.client}
+	res, release := file.Append(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		o.finish(err)
+		return err
+	}
+	s := p.wrapSink(results.Sink(), o)
+	out, err := call.AllocResults()
+	if err != nil {
+		s.finish(err)
+		return err
+	}
+	return out.SetSink(s.client())
+}
+
+// Wrap sink, returned by write or append. o is finished when the caller
+// is done with the sink.
+func (p *proxy) wrapSink(sink util.ByteStream, o *op) *byteStream {
+	// The call returns before the sink is used, so the op's context
+	// can't be the one writes through the sink are forwarded with.
+	o.cancel()
+	return &byteStream{
+		m:      p.m,
+		target: util.ByteStream{Client: sink.Client.AddRef()},
+		o:      o,
+	}
+}
+
+func (p *proxy) Truncate(ctx context.Context, call filesystem.RwFile_truncate) (err error) {
+	size := call.Args().Size()
+	ctx, o, err := p.enter(ctx, "truncate", strconv.FormatUint(size, 10))
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.rwFile().Truncate(ctx, func(params filesystem.RwFile_truncate_Params) error {
+		params.SetSize(size)
+		return nil
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (p *proxy) SetExec(ctx context.Context, call filesystem.RwFile_setExec) (err error) {
+	exec := call.Args().Exec()
+	ctx, o, err := p.enter(ctx, "setExec", strconv.FormatBool(exec))
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.rwFile().SetExec(ctx, func(params filesystem.RwFile_setExec_Params) error {
+		params.SetExec(exec)
+		return nil
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (p *proxy) SetXattr(ctx context.Context, call filesystem.RwFile_setXattr) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	value, err := call.Args().Value()
+	if err != nil {
+		return err
+	}
+	ctx, o, err := p.enter(ctx, "setXattr", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.rwFile().SetXattr(ctx, func(params filesystem.RwFile_setXattr_Params) error {
+		if err := params.SetName(name); err != nil {
+			return err
+		}
+		return params.SetValue(value)
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (p *proxy) RemoveXattr(ctx context.Context, call filesystem.RwFile_removeXattr) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	ctx, o, err := p.enter(ctx, "removeXattr", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.rwFile().RemoveXattr(ctx, func(params filesystem.RwFile_removeXattr_Params) error {
+		return params.SetName(name)
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (p *proxy) Allocate(ctx context.Context, call filesystem.RwFile_allocate) (err error) {
+	offset, length := call.Args().Offset(), call.Args().Length()
+	ctx, o, err := p.enter(ctx, "allocate", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.rwFile().Allocate(ctx, func(params filesystem.RwFile_allocate_Params) error {
+		params.SetOffset(offset)
+		params.SetLength(length)
+		return nil
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (p *proxy) PunchHole(ctx context.Context, call filesystem.RwFile_punchHole) (err error) {
+	offset, length := call.Args().Offset(), call.Args().Length()
+	ctx, o, err := p.enter(ctx, "punchHole", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.rwFile().PunchHole(ctx, func(params filesystem.RwFile_punchHole_Params) error {
+		params.SetOffset(offset)
+		params.SetLength(length)
+		return nil
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+// The lock is released when the caller drops the handle, as usual, or when
+// the membrane's Done channel is closed, whichever comes first.
+func (p *proxy) Lock(ctx context.Context, call filesystem.RwFile_lock) (err error) {
+	exclusive := call.Args().Exclusive()
+	ctx, o, err := p.enter(ctx, "lock", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.rwFile().Lock(ctx, func(params filesystem.RwFile_lock_Params) error {
+		params.SetExclusive(exclusive)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetHandle(filesystem.LockHandle{Client: p.m.wrapHandle(results.Handle().Client.AddRef())})
+}
+
+func (p *proxy) TryLock(ctx context.Context, call filesystem.RwFile_tryLock) (err error) {
+	exclusive := call.Args().Exclusive()
+	ctx, o, err := p.enter(ctx, "tryLock", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.rwFile().TryLock(ctx, func(params filesystem.RwFile_tryLock_Params) error {
+		params.SetExclusive(exclusive)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	if !results.HasHandle() {
+		return nil
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetHandle(filesystem.LockHandle{Client: p.m.wrapHandle(results.Handle().Client.AddRef())})
+}
@@ -0,0 +1,296 @@
+// Package membrane implements a generic membrane for filesystem
+// capabilities.
+//
+// A membrane is a proxy which forwards calls to an underlying capability,
+// wrapping any capabilities that pass through it (the results of walk,
+// create & mkdir, streams passed to list & read, and so on) in the same
+// membrane. This way, everything derived from the proxy stays inside the
+// membrane, and Hooks get to see (and veto) every call that crosses it.
+//
+// This is the basis for the audit, revocable and filter packages.
+//
+// Capabilities inside a membrane can only be saved if Hooks.Save is set.
+package membrane
+
+import (
+	"context"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+
+	grain_capnp "zenhack.net/go/sandstorm/capnp/grain"
+
+	"zombiezen.com/go/capnproto2"
+	"zombiezen.com/go/capnproto2/server"
+)
+
+var (
+	// Returned by walk for nodes hidden by Hooks.Filter.
+	NotFound = fserrors.New(filesystem.ErrorCode_notFound, "No such file or directory")
+
+	// Returned by create & mkdir for names hidden by Hooks.Filter.
+	PermissionDenied = fserrors.New(filesystem.ErrorCode_permissionDenied, "Permission denied")
+)
+
+// Hooks customize a membrane. All fields are optional.
+type Hooks struct {
+	// Called before each call on a capability inside the membrane,
+	// including calls on streams passed through it. If it returns an
+	// error, the call fails with that error.
+	Check func() error
+
+	// If non-nil, in-flight calls through the membrane are cancelled,
+	// and lock and watch handles obtained through it released, when Done
+	// is closed.
+	Done <-chan struct{}
+
+	// Called when each operation on a node inside the membrane completes.
+	// Note that for write and append, this is when the returned sink is
+	// finished with, not when the call returns.
+	Log func(Op)
+
+	// If non-nil, only children for which Filter returns true are visible
+	// inside the membrane: others are left out of list results, walk
+	// pretends they don't exist, and create and mkdir refuse to make them.
+	// For create and mkdir, info describes what would be created.
+	Filter func(name string, info filesystem.StatInfo) bool
+
+	// If non-nil, capabilities inside the membrane implement
+	// AppPersistent, by calling Save with the path (see Op.Path) and
+	// kind of the node being saved.
+	Save func(ctx context.Context, path string, kind Kind, call grain_capnp.AppPersistent_save) error
+}
+
+// An Op records an operation on a node inside a membrane.
+type Op struct {
+	// When the operation started.
+	Time time.Time
+
+	// The path to the node, relative to the root of the membrane; the
+	// root itself is "". Nodes reached other than by walking (e.g.
+	// previous versions of a file) are marked with an '@', like
+	// "notes.txt@version:<time>".
+	Path string
+
+	// The name of the method, as in the schema, e.g. "walk".
+	Method string
+
+	// For methods which take a name (e.g. walk, create, getXattr), the
+	// name. Otherwise empty.
+	Arg string
+
+	// The number of bytes of file content read or written.
+	Bytes int64
+
+	// The error the operation failed with, if any.
+	Err error
+}
+
+// A Kind is the interface a capability inside a membrane implements. Its
+// proxy implements the same one, so that it offers the same methods.
+type Kind int
+
+const (
+	KindDirectory Kind = iota
+	KindRwDirectory
+	KindAppendOnlyDirectory
+	KindTrash
+	KindFile
+	KindRwFile
+	KindAppendOnlyFile
+)
+
+type membrane struct {
+	hooks Hooks
+}
+
+// Wrap returns a proxy for node, which implements the interface given by
+// kind, inside a membrane governed by hooks. Wrap takes ownership of
+// node.Client; it is released when the proxy is.
+func Wrap(node filesystem.Node, kind Kind, hooks Hooks) filesystem.Node {
+	m := &membrane{hooks: hooks}
+	return filesystem.Node{Client: m.wrap(node.Client, "", kind)}
+}
+
+func (m *membrane) check() error {
+	if m.hooks.Check == nil {
+		return nil
+	}
+	return m.hooks.Check()
+}
+
+// Derive a context for a call forwarded through the membrane.
+func (m *membrane) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if m.hooks.Done != nil {
+		go func() {
+			select {
+			case <-m.hooks.Done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
+
+func (m *membrane) visible(name string, info filesystem.StatInfo) bool {
+	return m.hooks.Filter == nil || m.hooks.Filter(name, info)
+}
+
+// A proxy for a single node (or trash) inside the membrane.
+type proxy struct {
+	m      *membrane
+	client *capnp.Client
+	path   string
+	kind   Kind
+}
+
+// Wrap client, which implements the interface given by kind, in the
+// membrane.
+func (m *membrane) wrap(client *capnp.Client, path string, kind Kind) *capnp.Client {
+	if client == nil {
+		return nil
+	}
+	p := &proxy{
+		m:      m,
+		client: client,
+		path:   path,
+		kind:   kind,
+	}
+	var methods []server.Method
+	switch kind {
+	case KindDirectory:
+		methods = filesystem.Directory_Methods(nil, p)
+	case KindRwDirectory:
+		methods = filesystem.RwDirectory_Methods(nil, p)
+	case KindAppendOnlyDirectory:
+		methods = filesystem.AppendOnlyDirectory_Methods(nil, appendOnlyProxy{p})
+	case KindTrash:
+		methods = filesystem.Trash_Methods(nil, p)
+	case KindFile:
+		methods = filesystem.File_Methods(nil, p)
+	case KindRwFile:
+		methods = filesystem.RwFile_Methods(nil, p)
+	case KindAppendOnlyFile:
+		methods = filesystem.AppendOnlyFile_Methods(nil, appendOnlyProxy{p})
+	default:
+		panic("membrane: unknown kind")
+	}
+	if m.hooks.Save != nil {
+		methods = grain_capnp.AppPersistent_Methods(methods, p)
+	}
+	return capnp.NewClient(server.New(ackMethods(methods), p, p, nil))
+}
+
+// Return the kind of a child of a directory of kind parent, which info
+// describes. Nodes reached by walking are writable only if both they and
+// their parent are, and only directories stay append-only.
+func childKind(parent Kind, info filesystem.StatInfo) Kind {
+	isDir := info.Which() == filesystem.StatInfo_Which_dir
+	switch {
+	case parent == KindAppendOnlyDirectory && isDir:
+		return KindAppendOnlyDirectory
+	case parent == KindRwDirectory && info.Writable() && isDir:
+		return KindRwDirectory
+	case parent == KindRwDirectory && info.Writable():
+		return KindRwFile
+	case isDir:
+		return KindDirectory
+	default:
+		return KindFile
+	}
+}
+
+// Every method of a proxy waits on a call to the capability behind it.
+// Until a call is acknowledged, the server won't deliver any others, so
+// without this a call which waits on the client (e.g. a read, which
+// writes to the client's sink) would hold up everything else on the
+// connection, and could deadlock it.
+func ackMethods(methods []server.Method) []server.Method {
+	for i := range methods {
+		impl := methods[i].Impl
+		methods[i].Impl = func(ctx context.Context, call *server.Call) error {
+			call.Ack()
+			return impl(ctx, call)
+		}
+	}
+	return methods
+}
+
+func (p *proxy) Shutdown() {
+	p.client.Release()
+}
+
+func (p *proxy) Save(ctx context.Context, call grain_capnp.AppPersistent_save) (err error) {
+	ctx, o, err := p.enter(ctx, "save", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+	return p.m.hooks.Save(ctx, p.path, p.kind, call)
+}
+
+func childPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "/" + name
+}
+
+// An operation in progress.
+type op struct {
+	Op
+	m      *membrane
+	cancel context.CancelFunc
+}
+
+// Start an operation on p. If this returns an error, the call should fail
+// with it. Otherwise, the returned context should be used for forwarding
+// the call, and o.finish must be called when the operation is complete.
+func (p *proxy) enter(ctx context.Context, method, arg string) (_ context.Context, o *op, err error) {
+	if err = p.m.check(); err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := p.m.context(ctx)
+	return ctx, &op{
+		Op: Op{
+			Time:   time.Now(),
+			Path:   p.path,
+			Method: method,
+			Arg:    arg,
+		},
+		m:      p.m,
+		cancel: cancel,
+	}, nil
+}
+
+func (o *op) finish(err error) {
+	o.cancel()
+	if o.m.hooks.Log != nil {
+		o.Err = err
+		o.m.hooks.Log(o.Op)
+	}
+}
+
+// Return a StatInfo describing a node that create or mkdir would make, for
+// the benefit of Hooks.Filter.
+func newStatInfo(dir, executable bool) filesystem.StatInfo {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		panic(err)
+	}
+	info, err := filesystem.NewRootStatInfo(seg)
+	if err != nil {
+		panic(err)
+	}
+	if dir {
+		info.SetDir()
+	} else {
+		info.SetFile()
+	}
+	info.SetExecutable(executable)
+	info.SetWritable(true)
+	return info
+}
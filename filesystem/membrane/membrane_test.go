@@ -0,0 +1,64 @@
+package membrane
+
+import (
+	"context"
+	"testing"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+// Proxies only offer the methods of the interface they were made for,
+// and so do the proxies for nodes reached through them.
+func TestKinds(t *testing.T) {
+	ctx := context.Background()
+	root := memfs.New(0).Root()
+	defer root.Client.Release()
+	if err := client.WriteFile(ctx, root, "d/a.txt", []byte("a"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		kind     Kind
+		writable bool
+	}{
+		{KindDirectory, false},
+		{KindRwDirectory, true},
+	} {
+		proxy := Wrap(filesystem.Node{Client: root.Client.AddRef()}, test.kind, Hooks{})
+		defer proxy.Client.Release()
+
+		sub, err := client.Walk(ctx, filesystem.Directory{Client: proxy.Client}, "d")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, release := filesystem.RwDirectory{Client: sub.Client}.Create(ctx, func(p filesystem.RwDirectory_create_Params) error {
+			return p.SetName("b.txt")
+		})
+		_, err = res.Struct()
+		release()
+		sub.Client.Release()
+		if test.writable && err != nil {
+			t.Errorf("kind %v: create: %v", test.kind, err)
+		} else if !test.writable && !capnp.IsUnimplemented(err) {
+			t.Errorf("kind %v: create: got %v, want unimplemented", test.kind, err)
+		}
+
+		node, err := client.Walk(ctx, filesystem.Directory{Client: proxy.Client}, "d/a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		truncRes, release := filesystem.RwFile{Client: node.Client}.Truncate(ctx, nil)
+		_, err = truncRes.Struct()
+		release()
+		node.Client.Release()
+		if test.writable && err != nil {
+			t.Errorf("kind %v: truncate: %v", test.kind, err)
+		} else if !test.writable && !capnp.IsUnimplemented(err) {
+			t.Errorf("kind %v: truncate: got %v, want unimplemented", test.kind, err)
+		}
+	}
+}
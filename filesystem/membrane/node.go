@@ -0,0 +1,89 @@
+package membrane
+
+import (
+	"context"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+)
+
+func (p *proxy) node() filesystem.Node {
+	return filesystem.Node{Client: p.client}
+}
+
+func (p *proxy) Stat(ctx context.Context, call filesystem.Node_stat) (err error) {
+	ctx, o, err := p.enter(ctx, "stat", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.node().Stat(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	info, err := results.Info()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetInfo(info)
+}
+
+func (p *proxy) GetXattr(ctx context.Context, call filesystem.Node_getXattr) (err error) {
+	name, err := call.Args().Name()
+	if err != nil {
+		return err
+	}
+	ctx, o, err := p.enter(ctx, "getXattr", name)
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.node().GetXattr(ctx, func(params filesystem.Node_getXattr_Params) error {
+		return params.SetName(name)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	value, err := results.Value()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetValue(value)
+}
+
+func (p *proxy) ListXattrs(ctx context.Context, call filesystem.Node_listXattrs) (err error) {
+	ctx, o, err := p.enter(ctx, "listXattrs", "")
+	if err != nil {
+		return err
+	}
+	defer func() { o.finish(err) }()
+
+	res, release := p.node().ListXattrs(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	names, err := results.Names()
+	if err != nil {
+		return err
+	}
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	return out.SetNames(names)
+}
@@ -0,0 +1,407 @@
+package membrane
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+
+	"zenhack.net/go/sandstorm/capnp/util"
+
+	"zombiezen.com/go/capnproto2"
+	"zombiezen.com/go/capnproto2/server"
+)
+
+// Proxy for a Directory.Entry.Stream passed to list, which applies the
+// membrane's filter to the entries pushed through it.
+type entryStream struct {
+	m      *membrane
+	target filesystem.Directory_Entry_Stream
+}
+
+func (s *entryStream) Push(ctx context.Context, call filesystem.Directory_Entry_Stream_push) error {
+	if err := s.m.check(); err != nil {
+		return err
+	}
+	ctx, cancel := s.m.context(ctx)
+	defer cancel()
+
+	entries, err := call.Args().Entries()
+	if err != nil {
+		return err
+	}
+	keep := make([]filesystem.Directory_Entry, 0, entries.Len())
+	for i := 0; i < entries.Len(); i++ {
+		ent := entries.At(i)
+		name, err := ent.Name()
+		if err != nil {
+			return err
+		}
+		info, err := ent.Info()
+		if err != nil {
+			return err
+		}
+		if s.m.visible(name, info) {
+			keep = append(keep, ent)
+		}
+	}
+	res, release := s.target.Push(ctx, func(params filesystem.Directory_Entry_Stream_push_Params) error {
+		return copyEntries(params, keep)
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+func (s *entryStream) Done(ctx context.Context, call filesystem.Directory_Entry_Stream_done) error {
+	if err := s.m.check(); err != nil {
+		return err
+	}
+	ctx, cancel := s.m.context(ctx)
+	defer cancel()
+
+	res, release := s.target.Done(ctx, nil)
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func (s *entryStream) Shutdown() {
+	s.target.Client.Release()
+}
+
+// Proxy for a Directory.Watcher passed to watch.
+type watcher struct {
+	m      *membrane
+	target filesystem.Directory_Watcher
+
+	// With a filter, the directory being watched, for checking which of
+	// the changed paths are visible. Otherwise null.
+	dir filesystem.Directory
+}
+
+func (w *watcher) Changed(ctx context.Context, call filesystem.Directory_Watcher_changed) error {
+	if err := w.m.check(); err != nil {
+		return err
+	}
+	ctx, cancel := w.m.context(ctx)
+	defer cancel()
+
+	paths, err := call.Args().Paths()
+	if err != nil {
+		return err
+	}
+	keep := make([]string, 0, paths.Len())
+	for i := 0; i < paths.Len(); i++ {
+		p, err := paths.At(i)
+		if err != nil {
+			return err
+		}
+		keep = append(keep, p)
+	}
+	if w.m.hooks.Filter != nil && len(keep) > 0 {
+		keep, err = w.visiblePaths(ctx, keep)
+		if err != nil {
+			// We can't tell which paths are visible, and the names of
+			// hidden nodes mustn't leak, so just report that something
+			// changed.
+			keep = nil
+		} else if len(keep) == 0 {
+			return nil
+		}
+	}
+	res, release := w.target.Changed(ctx, func(params filesystem.Directory_Watcher_changed_Params) error {
+		list, err := params.NewPaths(int32(len(keep)))
+		if err != nil {
+			return err
+		}
+		for i, p := range keep {
+			if err = list.Set(i, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	defer release()
+	_, err = res.Struct()
+	return err
+}
+
+// Return those of paths which are visible through the membrane. Paths
+// which no longer exist are left out too: whatever removed them also
+// changed their parent's entries, which is reported separately.
+func (w *watcher) visiblePaths(ctx context.Context, paths []string) ([]string, error) {
+	var keep []string
+	for _, p := range paths {
+		ok, err := w.visible(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			keep = append(keep, p)
+		}
+	}
+	return keep, nil
+}
+
+// Report whether every node along p is visible. The walks and stats are
+// pipelined, so this takes one round trip.
+func (w *watcher) visible(ctx context.Context, p string) (bool, error) {
+	if p == "" {
+		return true, nil
+	}
+	names := strings.Split(p, "/")
+	stats := make([]filesystem.Node_stat_Results_Future, len(names))
+	var releases []capnp.ReleaseFunc
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+	dir := w.dir
+	for i, name := range names {
+		name := name
+		res, release := dir.Walk(ctx, func(params filesystem.Directory_walk_Params) error {
+			return params.SetName(name)
+		})
+		releases = append(releases, release)
+		stats[i], release = res.Node().Stat(ctx, nil)
+		releases = append(releases, release)
+		dir = filesystem.Directory{Client: res.Node().Client}
+	}
+	for i, name := range names {
+		results, err := stats[i].Struct()
+		if fserrors.CodeOf(err) == filesystem.ErrorCode_notFound || capnp.IsUnimplemented(err) {
+			// It's gone, or something along the way is no longer a
+			// directory.
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		info, err := results.Info()
+		if err != nil {
+			return false, err
+		}
+		if !w.m.visible(name, info) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (w *watcher) Shutdown() {
+	w.target.Client.Release()
+	w.dir.Client.Release()
+}
+
+func copyEntries(params filesystem.Directory_Entry_Stream_push_Params, entries []filesystem.Directory_Entry) error {
+	list, err := params.NewEntries(int32(len(entries)))
+	if err != nil {
+		return err
+	}
+	for i, ent := range entries {
+		name, err := ent.Name()
+		if err != nil {
+			return err
+		}
+		info, err := ent.Info()
+		if err != nil {
+			return err
+		}
+		dst := list.At(i)
+		if err = dst.SetName(name); err != nil {
+			return err
+		}
+		if err = dst.SetInfo(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Proxy for a ByteStream, either one passed to read, or one returned by
+// write or append. Counts the bytes written through it.
+type byteStream struct {
+	m      *membrane
+	target util.ByteStream
+
+	mu    sync.Mutex
+	count int64
+
+	// If non-nil, the operation which returned this stream; it is finished
+	// when the stream is done or dropped.
+	o *op
+}
+
+func (s *byteStream) bytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+func (s *byteStream) Write(ctx context.Context, call util.ByteStream_write) error {
+	if err := s.m.check(); err != nil {
+		return err
+	}
+	ctx, cancel := s.m.context(ctx)
+	defer cancel()
+
+	data, err := call.Args().Data()
+	if err != nil {
+		return err
+	}
+	res, release := s.target.Write(ctx, func(params util.ByteStream_write_Params) error {
+		return params.SetData(data)
+	})
+	defer release()
+	if _, err = res.Struct(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.count += int64(len(data))
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *byteStream) Done(ctx context.Context, call util.ByteStream_done) error {
+	if err := s.m.check(); err != nil {
+		return err
+	}
+	ctx, cancel := s.m.context(ctx)
+	defer cancel()
+
+	res, release := s.target.Done(ctx, nil)
+	defer release()
+	_, err := res.Struct()
+	s.finish(err)
+	return err
+}
+
+func (s *byteStream) ExpectSize(ctx context.Context, call util.ByteStream_expectSize) error {
+	if err := s.m.check(); err != nil {
+		return err
+	}
+	ctx, cancel := s.m.context(ctx)
+	defer cancel()
+
+	size := call.Args().Size()
+	res, release := s.target.ExpectSize(ctx, func(params util.ByteStream_expectSize_Params) error {
+		params.SetSize(size)
+		return nil
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func (s *byteStream) Shutdown() {
+	s.target.Client.Release()
+	s.finish(nil)
+}
+
+func (s *byteStream) finish(err error) {
+	s.mu.Lock()
+	o := s.o
+	s.o = nil
+	count := s.count
+	s.mu.Unlock()
+	if o != nil {
+		o.Bytes = count
+		o.finish(err)
+	}
+}
+
+func (s *byteStream) client() util.ByteStream {
+	return util.ByteStream_ServerToClient(s, nil)
+}
+
+// A stream which just collects the entries pushed into it, for when we
+// need to see a whole listing before passing it on.
+type collector struct {
+	entries []entry
+}
+
+type entry struct {
+	name string
+	info filesystem.StatInfo
+}
+
+func (c *collector) Push(ctx context.Context, call filesystem.Directory_Entry_Stream_push) error {
+	entries, err := call.Args().Entries()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < entries.Len(); i++ {
+		ent := entries.At(i)
+		name, err := ent.Name()
+		if err != nil {
+			return err
+		}
+		info, err := ent.Info()
+		if err != nil {
+			return err
+		}
+		c.entries = append(c.entries, entry{
+			name: name,
+			info: cloneStatInfo(info),
+		})
+	}
+	return nil
+}
+
+func (c *collector) Done(ctx context.Context, call filesystem.Directory_Entry_Stream_done) error {
+	return nil
+}
+
+// Copy info into its own message, since the original will be reclaimed
+// once the call that delivered it returns.
+func cloneStatInfo(info filesystem.StatInfo) filesystem.StatInfo {
+	msg, _, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		panic(err)
+	}
+	msg.SetRoot(info.Struct.ToPtr())
+	ret, err := filesystem.ReadRootStatInfo(msg)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// Proxy for a handle (from lock or watch) returned through the membrane.
+// Handles carry no methods, but holding one keeps a lock or watch in
+// place, so once the membrane's Done channel is closed the handle behind
+// it is released, even though the caller still holds the proxy.
+type handle struct {
+	once   sync.Once
+	target *capnp.Client
+	stop   chan struct{}
+}
+
+// Wrap a handle returned by the capability behind the membrane. Takes
+// ownership of target.
+func (m *membrane) wrapHandle(target *capnp.Client) *capnp.Client {
+	if m.hooks.Done == nil {
+		return target
+	}
+	h := &handle{target: target, stop: make(chan struct{})}
+	go func() {
+		select {
+		case <-m.hooks.Done:
+			h.release()
+		case <-h.stop:
+		}
+	}()
+	return capnp.NewClient(server.New(nil, h, h, nil))
+}
+
+func (h *handle) release() {
+	h.once.Do(h.target.Release)
+}
+
+func (h *handle) Shutdown() {
+	close(h.stop)
+	h.release()
+}
@@ -0,0 +1,308 @@
+package memfs
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/internal/fsutil"
+)
+
+// The maximum number of entries sent in one push by List.
+const maxListBatch = 1024
+
+// A snapshot of a directory tree.
+type snapshot struct {
+	root *inode
+	time int64
+
+	// The total size of the files in the snapshot, which counts
+	// against the filesystem's limit.
+	size int64
+}
+
+// Add child to directory n, as name. Must be called with the FS's mu
+// held.
+func (n *inode) addChild(name string, child *inode) {
+	n.children[name] = child
+	child.parent = n
+	child.name = name
+}
+
+// Return the child of directory h.n named name. Must be called with
+// fs.mu held.
+func (h *handle) lookup(name string) (*inode, error) {
+	if !fsutil.ValidFileName(name) {
+		return nil, IllegalFileName
+	}
+	child, ok := h.n.children[name]
+	if !ok {
+		return nil, NotFound
+	}
+	return child, nil
+}
+
+func (h *handle) List(ctx context.Context, p filesystem.Directory_list) error {
+	// Pushing to the stream waits on the client; acknowledge the call
+	// so that others can be delivered in the meantime.
+	p.Ack()
+	stream := p.Args().Stream()
+
+	type entry struct {
+		name string
+		stat stat
+	}
+	h.fs.mu.Lock()
+	names := make([]string, 0, len(h.n.children))
+	for name := range h.n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries := make([]entry, len(names))
+	for i, name := range names {
+		entries[i] = entry{name: name, stat: h.n.children[name].stat()}
+	}
+	h.fs.mu.Unlock()
+
+	for len(entries) > 0 && ctx.Err() == nil {
+		batch := entries
+		if len(batch) > maxListBatch {
+			batch = batch[:maxListBatch]
+		}
+		entries = entries[len(batch):]
+		res, release := stream.Push(ctx, func(p filesystem.Directory_Entry_Stream_push_Params) error {
+			list, err := p.NewEntries(int32(len(batch)))
+			if err != nil {
+				return err
+			}
+			for i := range batch {
+				ent := list.At(i)
+				if err = ent.SetName(batch[i].name); err != nil {
+					return err
+				}
+				info, err := ent.NewInfo()
+				if err != nil {
+					return err
+				}
+				if err = h.setStatInfo(info, batch[i].stat); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		_, err := res.Struct()
+		release()
+		if err != nil {
+			return err
+		}
+	}
+
+	res, release := stream.Done(ctx, nil)
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func (h *handle) Walk(ctx context.Context, p filesystem.Directory_walk) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	h.fs.mu.Lock()
+	child, err := h.lookup(name)
+	h.fs.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	ch := &handle{fs: h.fs, n: child, writable: h.writable}
+	return res.SetNode(filesystem.Node{Client: ch.makeClient()})
+}
+
+func (h *handle) Create(ctx context.Context, p filesystem.RwDirectory_create) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidFileName(name) {
+		return IllegalFileName
+	}
+	executable := p.Args().Executable()
+
+	h.fs.mu.Lock()
+	child, ok := h.n.children[name]
+	if !ok {
+		child = newFile(executable)
+		h.n.addChild(name, child)
+		h.fs.changed(h.n)
+	}
+	h.fs.mu.Unlock()
+
+	// Like package local, creating a file that already exists just
+	// opens it.
+	if child.isDir {
+		return AlreadyExists
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	ch := &handle{fs: h.fs, n: child, writable: true}
+	return res.SetFile(filesystem.RwFile{Client: ch.makeClient()})
+}
+
+func (h *handle) Mkdir(ctx context.Context, p filesystem.RwDirectory_mkdir) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidFileName(name) {
+		return IllegalFileName
+	}
+
+	h.fs.mu.Lock()
+	if _, ok := h.n.children[name]; ok {
+		h.fs.mu.Unlock()
+		return AlreadyExists
+	}
+	child := newDir()
+	h.n.addChild(name, child)
+	h.fs.changed(h.n)
+	h.fs.mu.Unlock()
+
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	ch := &handle{fs: h.fs, n: child, writable: true}
+	return res.SetDir(filesystem.RwDirectory{Client: ch.makeClient()})
+}
+
+func (h *handle) Delete(ctx context.Context, p filesystem.RwDirectory_delete) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	child, err := h.lookup(name)
+	if err != nil {
+		return err
+	}
+	if child.isDir && len(child.children) != 0 {
+		return DirectoryNotEmpty
+	}
+	delete(h.n.children, name)
+	child.deleted = true
+	child.parent = nil
+	h.fs.changed(h.n)
+	h.fs.used -= int64(len(child.data))
+	for _, s := range child.snapshots {
+		h.fs.used -= s.size
+	}
+	return nil
+}
+
+func (h *handle) Snapshots(ctx context.Context, p filesystem.Directory_snapshots) error {
+	h.fs.mu.Lock()
+	snapshots := append([]snapshot(nil), h.n.snapshots...)
+	h.fs.mu.Unlock()
+
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	list, err := res.NewSnapshots(int32(len(snapshots)))
+	if err != nil {
+		return err
+	}
+	for i := range snapshots {
+		// Most recent first.
+		s := snapshots[len(snapshots)-1-i]
+		if err = setSnapshot(list.At(i), h.fs, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshots are copies of the tree, which count against the filesystem's
+// limit for as long as the directory exists.
+func (h *handle) Snapshot(ctx context.Context, p filesystem.RwDirectory_snapshot) error {
+	h.fs.mu.Lock()
+	size := treeSize(h.n)
+	if !h.n.deleted {
+		if h.fs.limit != 0 && h.fs.used+size > h.fs.limit {
+			h.fs.mu.Unlock()
+			return NoSpace
+		}
+		h.fs.used += size
+	}
+	s := snapshot{
+		root: copyTree(h.n),
+		time: time.Now().UnixNano(),
+		size: size,
+	}
+	h.n.snapshots = append(h.n.snapshots, s)
+	h.fs.mu.Unlock()
+
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	dst, err := res.NewSnapshot()
+	if err != nil {
+		return err
+	}
+	return setSnapshot(dst, h.fs, s)
+}
+
+func setSnapshot(dst filesystem.Directory_Snapshot, fs *FS, s snapshot) error {
+	dst.SetTime(s.time)
+	h := &handle{fs: fs, n: s.root}
+	return dst.SetDir(filesystem.Directory{Client: h.makeClient()})
+}
+
+// Return the total size of the files in the tree rooted at n, not
+// including snapshots. Must be called with fs.mu held.
+func treeSize(n *inode) int64 {
+	size := int64(len(n.data))
+	for _, child := range n.children {
+		size += treeSize(child)
+	}
+	return size
+}
+
+// Return a deep copy of the tree rooted at n, not including snapshots.
+// The copy is marked as deleted, so that its files don't count against
+// the filesystem's limit individually; Snapshot accounts for them as a
+// whole. Must be called with fs.mu held.
+func copyTree(n *inode) *inode {
+	ret := &inode{
+		isDir:      n.isDir,
+		executable: n.executable,
+		xattrs:     make(map[string][]byte, len(n.xattrs)),
+		deleted:    true,
+	}
+	for k, v := range n.xattrs {
+		ret.xattrs[k] = v
+	}
+	if n.isDir {
+		ret.children = make(map[string]*inode, len(n.children))
+		for name, child := range n.children {
+			ret.children[name] = copyTree(child)
+		}
+	} else {
+		ret.data = append([]byte(nil), n.data...)
+	}
+	return ret
+}
+
+// Deleted nodes are gone immediately; there is no trash.
+func (h *handle) Trash(ctx context.Context, p filesystem.RwDirectory_trash) error {
+	return NotImplemented
+}
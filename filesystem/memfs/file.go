@@ -0,0 +1,289 @@
+package memfs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/internal/fsutil"
+
+	"zenhack.net/go/sandstorm/exp/util/bytestream"
+)
+
+// Return a copy of the part of the file's data described by startAt and
+// amount, where amount == 0 means "to the end of the file", and whether
+// it reaches the end of the file.
+func (h *handle) readRange(startAt int64, amount uint64) (data []byte, eof bool) {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	size := int64(len(h.n.data))
+	if startAt >= size {
+		return nil, true
+	}
+	end := size
+	if amount != 0 && amount < uint64(size-startAt) {
+		end = startAt + int64(amount)
+	}
+	return append([]byte(nil), h.n.data[startAt:end]...), end == size
+}
+
+func (h *handle) Read(ctx context.Context, p filesystem.File_read) error {
+	p.Ack() // As in List.
+	startAt := p.Args().StartAt()
+	if startAt < 0 {
+		return InvalidArgument
+	}
+	data, _ := h.readRange(startAt, p.Args().Amount())
+	wc := bytestream.ToWriteCloser(ctx, p.Args().Sink())
+	if _, err := io.Copy(wc, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return wc.Close()
+}
+
+func (h *handle) ReadBytes(ctx context.Context, p filesystem.File_readBytes) error {
+	startAt := p.Args().StartAt()
+	if startAt < 0 {
+		return InvalidArgument
+	}
+	amount := p.Args().Amount()
+	if amount == 0 || amount > MaxReadBytes {
+		amount = MaxReadBytes
+	}
+	data, eof := h.readRange(startAt, amount)
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	res.SetEof(eof)
+	return res.SetData(data)
+}
+
+func (h *handle) Hash(ctx context.Context, p filesystem.File_hash) error {
+	newHash, ok := fsutil.HashAlgorithms[p.Args().Algorithm()]
+	if !ok {
+		return InvalidArgument
+	}
+	startAt := p.Args().StartAt()
+	if startAt < 0 {
+		return InvalidArgument
+	}
+	amount := p.Args().Amount()
+	if amount > math.MaxInt64 {
+		// As in Read, this means the whole file.
+		amount = 0
+	}
+	data, _ := h.readRange(startAt, amount)
+	hash := newHash()
+	hash.Write(data)
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetDigest(hash.Sum(nil))
+}
+
+// We don't keep track of holes, so the whole file counts as data.
+
+func (h *handle) SeekData(ctx context.Context, p filesystem.File_seekData) error {
+	offset := p.Args().Offset()
+	if offset < 0 {
+		return InvalidArgument
+	}
+	h.fs.mu.Lock()
+	size := int64(len(h.n.data))
+	h.fs.mu.Unlock()
+	if offset >= size {
+		offset = -1
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	res.SetOffset(offset)
+	return nil
+}
+
+func (h *handle) SeekHole(ctx context.Context, p filesystem.File_seekHole) error {
+	offset := p.Args().Offset()
+	if offset < 0 {
+		return InvalidArgument
+	}
+	h.fs.mu.Lock()
+	size := int64(len(h.n.data))
+	h.fs.mu.Unlock()
+	if offset >= size {
+		offset = -1
+	} else {
+		offset = size
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	res.SetOffset(offset)
+	return nil
+}
+
+// We don't keep old versions of files, so this is always empty.
+func (h *handle) Versions(ctx context.Context, p filesystem.File_versions) error {
+	_, err := p.AllocResults()
+	return err
+}
+
+func (h *handle) Write(ctx context.Context, p filesystem.RwFile_write) error {
+	startAt := p.Args().StartAt()
+	if startAt <= -2 {
+		return InvalidArgument
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetSink(bytestream.FromWriteCloser(&fileWriter{
+		h:      h,
+		offset: startAt,
+		append: startAt == -1,
+	}, nil))
+}
+
+// An io.WriteCloser which writes to a file, for the sink returned by
+// Write.
+type fileWriter struct {
+	h      *handle
+	offset int64
+
+	// If true, ignore offset and always write at the end of the file.
+	append bool
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	fs := w.h.fs
+	n := w.h.n
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if w.append {
+		w.offset = int64(len(n.data))
+	}
+	if w.offset > math.MaxInt64-int64(len(p)) {
+		return 0, InvalidArgument
+	}
+	end := w.offset + int64(len(p))
+	if end > int64(len(n.data)) {
+		if err := fs.resize(n, end); err != nil {
+			return 0, err
+		}
+	}
+	copy(n.data[w.offset:], p)
+	w.offset = end
+	fs.changed(n)
+	return len(p), nil
+}
+
+func (w *fileWriter) Close() error {
+	return nil
+}
+
+func (h *handle) Truncate(ctx context.Context, p filesystem.RwFile_truncate) error {
+	size := p.Args().Size()
+	if size > math.MaxInt64 {
+		return InvalidArgument
+	}
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	h.fs.changed(h.n)
+	return h.fs.resize(h.n, int64(size))
+}
+
+func (h *handle) SetExec(ctx context.Context, p filesystem.RwFile_setExec) error {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	h.n.executable = p.Args().Exec()
+	h.fs.changed(h.n)
+	return nil
+}
+
+func (h *handle) SetXattr(ctx context.Context, p filesystem.RwFile_setXattr) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidXattrName(name) {
+		return IllegalAttributeName
+	}
+	value, err := p.Args().Value()
+	if err != nil {
+		return err
+	}
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	h.n.xattrs[name] = append([]byte(nil), value...)
+	h.fs.changed(h.n)
+	return nil
+}
+
+func (h *handle) RemoveXattr(ctx context.Context, p filesystem.RwFile_removeXattr) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidXattrName(name) {
+		return IllegalAttributeName
+	}
+	// Like package local, removing an attribute which isn't set
+	// succeeds.
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	delete(h.n.xattrs, name)
+	h.fs.changed(h.n)
+	return nil
+}
+
+// Check that a range passed to allocate or punchHole is sensible, i.e.
+// non-negative, and its end is representable.
+func validRange(offset, length int64) bool {
+	return offset >= 0 && length >= 0 && offset <= math.MaxInt64-length
+}
+
+// All of a file's data is allocated, so this only needs to extend the
+// file if the range goes past the end. That still counts against the
+// filesystem's limit, so later writes to the range can't run out of
+// space.
+func (h *handle) Allocate(ctx context.Context, p filesystem.RwFile_allocate) error {
+	offset, length := p.Args().Offset(), p.Args().Length()
+	if !validRange(offset, length) {
+		return InvalidArgument
+	}
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	if offset+length <= int64(len(h.n.data)) {
+		return nil
+	}
+	h.fs.changed(h.n)
+	return h.fs.resize(h.n, offset+length)
+}
+
+// Since we don't track holes, this just zeroes the range.
+func (h *handle) PunchHole(ctx context.Context, p filesystem.RwFile_punchHole) error {
+	offset, length := p.Args().Offset(), p.Args().Length()
+	if !validRange(offset, length) {
+		return InvalidArgument
+	}
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	data := h.n.data
+	if offset >= int64(len(data)) {
+		return nil
+	}
+	end := offset + length
+	if end > int64(len(data)) {
+		end = int64(len(data))
+	}
+	for i := offset; i < end; i++ {
+		data[i] = 0
+	}
+	h.fs.changed(h.n)
+	return nil
+}
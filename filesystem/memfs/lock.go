@@ -0,0 +1,107 @@
+package memfs
+
+import (
+	"context"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+)
+
+// The advisory locks held on a file.
+type lockState struct {
+	shared    int
+	exclusive bool
+
+	// Closed whenever a lock on the file is released, to wake up
+	// anyone waiting for it. Created lazily.
+	released chan struct{}
+}
+
+// A held lock. Implements filesystem.LockHandle_Server; the lock is
+// released when the client is shut down.
+type lockHandle struct {
+	fs        *FS
+	n         *inode
+	exclusive bool
+}
+
+func (h *lockHandle) Shutdown() {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	st := &h.n.lock
+	if h.exclusive {
+		st.exclusive = false
+	} else {
+		st.shared--
+	}
+	if st.released != nil {
+		close(st.released)
+		st.released = nil
+	}
+}
+
+// Acquire a lock on the file. If wait is false and the lock is not
+// immediately available, returns (nil, nil).
+func (h *handle) acquireLock(ctx context.Context, exclusive, wait bool) (*lockHandle, error) {
+	for {
+		h.fs.mu.Lock()
+		st := &h.n.lock
+		if !st.exclusive && !(exclusive && st.shared > 0) {
+			if exclusive {
+				st.exclusive = true
+			} else {
+				st.shared++
+			}
+			h.fs.mu.Unlock()
+			return &lockHandle{fs: h.fs, n: h.n, exclusive: exclusive}, nil
+		}
+		if !wait {
+			h.fs.mu.Unlock()
+			return nil, nil
+		}
+		if st.released == nil {
+			st.released = make(chan struct{})
+		}
+		retry := st.released
+		h.fs.mu.Unlock()
+
+		select {
+		case <-retry:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (h *handle) Lock(ctx context.Context, p filesystem.RwFile_lock) error {
+	// Let other calls, including unlocks, through while we wait.
+	p.Ack()
+	lh, err := h.acquireLock(ctx, p.Args().Exclusive(), true)
+	if err != nil {
+		return err
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		lh.Shutdown()
+		return err
+	}
+	return res.SetHandle(filesystem.LockHandle_ServerToClient(lh, nil))
+}
+
+func (h *handle) TryLock(ctx context.Context, p filesystem.RwFile_tryLock) error {
+	lh, err := h.acquireLock(ctx, p.Args().Exclusive(), false)
+	if err != nil {
+		return err
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		if lh != nil {
+			lh.Shutdown()
+		}
+		return err
+	}
+	if lh == nil {
+		// Leave the handle null.
+		return nil
+	}
+	return res.SetHandle(filesystem.LockHandle_ServerToClient(lh, nil))
+}
@@ -0,0 +1,270 @@
+// Package memfs implements the filesystem interfaces entirely in memory.
+//
+// This is useful for tests, and for shares which don't need to outlive
+// the process. It validates its arguments the same way package local
+// does, so it can serve as a reference implementation. Unlike package
+// local, it supports Directory.watch.
+package memfs
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/internal/fsutil"
+
+	"zombiezen.com/go/capnproto2"
+	"zombiezen.com/go/capnproto2/server"
+)
+
+// The maximum number of bytes returned by a single call to ReadBytes.
+const MaxReadBytes = fsutil.MaxReadBytes
+
+// The extended attribute holding a file's MIME type, as in package local.
+const MimeTypeXattr = "mime_type"
+
+var (
+	InvalidArgument      = fserrors.New(filesystem.ErrorCode_invalidArgument, "Invalid argument")
+	IllegalFileName      = fserrors.New(filesystem.ErrorCode_invalidName, "Illegal file name")
+	NotFound             = fserrors.New(filesystem.ErrorCode_notFound, "No such file or directory")
+	AlreadyExists        = fserrors.New(filesystem.ErrorCode_exists, "File already exists")
+	DirectoryNotEmpty    = fserrors.New(filesystem.ErrorCode_notEmpty, "Directory not empty")
+	NoSpace              = fserrors.New(filesystem.ErrorCode_quota, "No space left on filesystem")
+	NoSuchAttribute      = fserrors.New(filesystem.ErrorCode_notFound, "No such attribute")
+	IllegalAttributeName = fserrors.New(filesystem.ErrorCode_invalidName, "Illegal attribute name")
+	NotImplemented       = capnp.Unimplemented("Not implemented")
+)
+
+// An FS is an in-memory filesystem.
+type FS struct {
+	// Held while accessing anything in the filesystem.
+	mu sync.Mutex
+
+	// The maximum total size of the files in the filesystem, or zero for
+	// no limit.
+	limit int64
+
+	// The total size of the files in the filesystem, including those
+	// in snapshots.
+	used int64
+
+	root *inode
+
+	// Watches on directories in the filesystem; see watch.go.
+	watches map[*watch]bool
+}
+
+// New returns an empty filesystem. If limit is non-zero, the total size of
+// the files in it, including those in snapshots, may not exceed limit
+// bytes; writes and snapshots which would exceed this fail with NoSpace.
+func New(limit int64) *FS {
+	return &FS{
+		limit: limit,
+		root:  newDir(),
+	}
+}
+
+// Root returns a capability for the filesystem's root directory.
+func (fs *FS) Root() filesystem.RwDirectory {
+	h := &handle{fs: fs, n: fs.root, writable: true}
+	return filesystem.RwDirectory{Client: h.makeClient()}
+}
+
+// ReadOnlyRoot returns a read-only capability for the filesystem's root
+// directory.
+func (fs *FS) ReadOnlyRoot() filesystem.Directory {
+	h := &handle{fs: fs, n: fs.root}
+	return filesystem.Directory{Client: h.makeClient()}
+}
+
+// Used returns the total size of the files in the filesystem, including
+// those in snapshots.
+func (fs *FS) Used() int64 {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.used
+}
+
+// A file or directory. All fields are protected by the FS's mu.
+type inode struct {
+	isDir      bool
+	executable bool
+	xattrs     map[string][]byte
+
+	// For directories:
+	children  map[string]*inode
+	snapshots []snapshot
+
+	// For files:
+	data []byte
+	lock lockState
+
+	// Whether the node has been deleted. Deleted files can still be
+	// accessed via existing capabilities, but no longer count against
+	// the filesystem's limit.
+	deleted bool
+
+	// The directory containing the node, and its name there; for
+	// watches. parent is nil for the root, deleted nodes and those in
+	// snapshots.
+	parent *inode
+	name   string
+}
+
+func newDir() *inode {
+	return &inode{
+		isDir:    true,
+		children: make(map[string]*inode),
+		xattrs:   make(map[string][]byte),
+	}
+}
+
+func newFile(executable bool) *inode {
+	return &inode{
+		executable: executable,
+		xattrs:     make(map[string][]byte),
+	}
+}
+
+// Resize the data of file n to size bytes, accounting for the change
+// against fs's limit. Must be called with fs.mu held.
+func (fs *FS) resize(n *inode, size int64) error {
+	delta := size - int64(len(n.data))
+	if !n.deleted {
+		if delta > 0 && fs.limit != 0 && fs.used+delta > fs.limit {
+			return NoSpace
+		}
+		fs.used += delta
+	}
+	if size <= int64(cap(n.data)) {
+		old := len(n.data)
+		n.data = n.data[:size]
+		for i := old; i < len(n.data); i++ {
+			n.data[i] = 0
+		}
+		return nil
+	}
+	data := make([]byte, size)
+	copy(data, n.data)
+	n.data = data
+	return nil
+}
+
+// A capability to an inode. Implements all of the filesystem interfaces
+// (though makeClient only exposes the ones that apply).
+type handle struct {
+	fs       *FS
+	n        *inode
+	writable bool
+}
+
+func (h *handle) makeClient() *capnp.Client {
+	var methods []server.Method
+	if h.n.isDir {
+		if h.writable {
+			methods = filesystem.RwDirectory_Methods(nil, h)
+		} else {
+			methods = filesystem.Directory_Methods(nil, h)
+		}
+	} else {
+		if h.writable {
+			methods = filesystem.RwFile_Methods(nil, h)
+		} else {
+			methods = filesystem.File_Methods(nil, h)
+		}
+	}
+	return capnp.NewClient(server.New(methods, h, nil, nil))
+}
+
+// What a StatInfo says about a node, copied out so that it can be used
+// without holding the lock.
+type stat struct {
+	isDir      bool
+	executable bool
+	size       int64
+	mimeType   string
+}
+
+// Must be called with fs.mu held.
+func (n *inode) stat() stat {
+	return stat{
+		isDir:      n.isDir,
+		executable: n.executable,
+		size:       int64(len(n.data)),
+		mimeType:   string(n.xattrs[MimeTypeXattr]),
+	}
+}
+
+func (h *handle) setStatInfo(info filesystem.StatInfo, st stat) error {
+	if st.isDir {
+		info.SetDir()
+	} else {
+		info.SetFile()
+		info.File().SetSize(st.size)
+	}
+	info.SetWritable(h.writable)
+	info.SetExecutable(st.executable)
+	return info.SetMimeType(st.mimeType)
+}
+
+func (h *handle) Stat(ctx context.Context, p filesystem.Node_stat) error {
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	info, err := res.NewInfo()
+	if err != nil {
+		return err
+	}
+	h.fs.mu.Lock()
+	st := h.n.stat()
+	h.fs.mu.Unlock()
+	return h.setStatInfo(info, st)
+}
+
+func (h *handle) GetXattr(ctx context.Context, p filesystem.Node_getXattr) error {
+	name, err := p.Args().Name()
+	if err != nil {
+		return err
+	}
+	if !fsutil.ValidXattrName(name) {
+		return IllegalAttributeName
+	}
+	h.fs.mu.Lock()
+	value, ok := h.n.xattrs[name]
+	h.fs.mu.Unlock()
+	if !ok {
+		return NoSuchAttribute
+	}
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	return res.SetValue(value)
+}
+
+func (h *handle) ListXattrs(ctx context.Context, p filesystem.Node_listXattrs) error {
+	h.fs.mu.Lock()
+	names := make([]string, 0, len(h.n.xattrs))
+	for name := range h.n.xattrs {
+		names = append(names, name)
+	}
+	h.fs.mu.Unlock()
+	sort.Strings(names)
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	list, err := res.NewNames(int32(len(names)))
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		if err = list.Set(i, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
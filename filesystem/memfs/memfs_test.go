@@ -0,0 +1,120 @@
+package memfs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fstest"
+)
+
+func TestConformance(t *testing.T) {
+	fstest.TestRwDirectory(t, func(t *testing.T) filesystem.RwDirectory {
+		return New(0).Root()
+	})
+}
+
+// A Directory_Watcher which passes on the paths it's told about.
+type testWatcher struct {
+	paths chan []string
+
+	// The paths from the last call to expect. A single change may
+	// notify the same path more than once (creating a file, then writing
+	// to it), so these may turn up again afterwards.
+	last map[string]bool
+}
+
+func newTestWatcher() *testWatcher {
+	return &testWatcher{paths: make(chan []string, 10)}
+}
+
+func (w *testWatcher) Changed(ctx context.Context, p filesystem.Directory_Watcher_changed) error {
+	list, err := p.Args().Paths()
+	if err != nil {
+		return err
+	}
+	paths := make([]string, list.Len())
+	for i := range paths {
+		if paths[i], err = list.At(i); err != nil {
+			return err
+		}
+	}
+	w.paths <- paths
+	return nil
+}
+
+// Wait until w is told about each of want, failing if it's told about
+// anything else first.
+func (w *testWatcher) expect(t *testing.T, want ...string) {
+	t.Helper()
+	pending := make(map[string]bool, len(want))
+	for _, p := range want {
+		pending[p] = true
+	}
+	last := w.last
+	w.last = make(map[string]bool, len(want))
+	for _, p := range want {
+		w.last[p] = true
+	}
+	timeout := time.After(5 * time.Second)
+	for len(pending) > 0 {
+		select {
+		case paths := <-w.paths:
+			for _, p := range paths {
+				if !w.last[p] && !last[p] {
+					t.Fatalf("got a notification for %q, want %v", p, want)
+				}
+				delete(pending, p)
+			}
+		case <-timeout:
+			t.Fatalf("no notification for %v", pending)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+	root := New(0).Root()
+	defer root.Client.Release()
+	sub, err := client.MkdirAll(ctx, root, "sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Client.Release()
+
+	w := newTestWatcher()
+	res, release := filesystem.Directory{Client: sub.Client}.Watch(ctx, func(p filesystem.Directory_watch_Params) error {
+		return p.SetWatcher(filesystem.Directory_Watcher_ServerToClient(w, nil))
+	})
+	defer release()
+	if _, err = res.Struct(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes outside the watched directory aren't reported, so the
+	// first notification is for the file created after this one.
+	if err = client.WriteFile(ctx, root, "outside.txt", []byte("x"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.WriteFile(ctx, root, "sub/a.txt", []byte("a"), false); err != nil {
+		t.Fatal(err)
+	}
+	w.expect(t, "", "a.txt")
+
+	dir, err := client.MkdirAll(ctx, root, "sub/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir.Client.Release()
+	w.expect(t, "")
+	if err = client.WriteFile(ctx, root, "sub/dir/b.txt", []byte("b"), false); err != nil {
+		t.Fatal(err)
+	}
+	w.expect(t, "dir", "dir/b.txt")
+	if err = client.Remove(ctx, root, "sub/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	w.expect(t, "")
+}
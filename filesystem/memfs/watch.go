@@ -0,0 +1,135 @@
+package memfs
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+)
+
+// A watch on a directory, made by Directory.watch. Implements
+// filesystem.WatchHandle_Server; the watch is cancelled when the handle
+// is dropped.
+type watch struct {
+	fs      *FS
+	dir     *inode
+	watcher filesystem.Directory_Watcher
+
+	// Paths which have changed but haven't been sent yet. Guarded by
+	// fs.mu.
+	pending map[string]bool
+
+	// Signalled when there is something in pending.
+	wake chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (h *handle) Watch(ctx context.Context, p filesystem.Directory_watch) error {
+	w := &watch{
+		fs:      h.fs,
+		dir:     h.n,
+		watcher: filesystem.Directory_Watcher{Client: p.Args().Watcher().Client.AddRef()},
+		pending: make(map[string]bool),
+		wake:    make(chan struct{}, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	res, err := p.AllocResults()
+	if err != nil {
+		w.watcher.Client.Release()
+		return err
+	}
+	h.fs.mu.Lock()
+	if h.fs.watches == nil {
+		h.fs.watches = make(map[*watch]bool)
+	}
+	h.fs.watches[w] = true
+	h.fs.mu.Unlock()
+	go w.run()
+	return res.SetHandle(filesystem.WatchHandle_ServerToClient(w, nil))
+}
+
+// Send notifications until the watch is cancelled, one call at a time.
+func (w *watch) run() {
+	defer w.watcher.Client.Release()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-w.wake:
+		}
+		w.fs.mu.Lock()
+		paths := make([]string, 0, len(w.pending))
+		for p := range w.pending {
+			paths = append(paths, p)
+		}
+		w.pending = make(map[string]bool)
+		w.fs.mu.Unlock()
+		sort.Strings(paths)
+
+		res, release := w.watcher.Changed(w.ctx, func(p filesystem.Directory_Watcher_changed_Params) error {
+			list, err := p.NewPaths(int32(len(paths)))
+			if err != nil {
+				return err
+			}
+			for i, path := range paths {
+				if err = list.Set(i, path); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		_, err := res.Struct()
+		release()
+		if err != nil {
+			// The watcher is gone, or broken; either way, there's
+			// no point carrying on.
+			w.Shutdown()
+			return
+		}
+	}
+}
+
+func (w *watch) Shutdown() {
+	w.fs.mu.Lock()
+	delete(w.fs.watches, w)
+	w.fs.mu.Unlock()
+	w.cancel()
+}
+
+// Record that n has changed, for any watches on directories containing
+// it. Must be called with fs.mu held.
+func (fs *FS) changed(n *inode) {
+	if len(fs.watches) == 0 {
+		return
+	}
+	for w := range fs.watches {
+		path, ok := pathFrom(w.dir, n)
+		if !ok {
+			continue
+		}
+		w.pending[path] = true
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Return the path to n from dir, or false if n isn't in the tree rooted
+// at dir. Must be called with fs.mu held.
+func pathFrom(dir, n *inode) (string, bool) {
+	var names []string
+	for ; n != dir; n = n.parent {
+		if n == nil {
+			return "", false
+		}
+		names = append(names, n.name)
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, "/"), true
+}
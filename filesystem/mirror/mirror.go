@@ -0,0 +1,401 @@
+// Package mirror makes one directory tree a copy of another.
+//
+// Mirror copies everything under a source Directory into a destination
+// RwDirectory, replacing files whose contents differ. Files which are
+// already the same on both sides are left alone, so mirroring into a
+// previous copy only transfers what has changed; in particular, running
+// Mirror again after it was interrupted picks up where it left off. The
+// schema doesn't carry modification times, so files are compared by size
+// and then by hash, which both servers compute without any data passing
+// through the caller. A destination file which matches the start of the
+// source file, as one copied partially by an interrupted run would, is
+// completed rather than copied again.
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+
+	"zenhack.net/go/sandstorm/capnp/util"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+type Options struct {
+	// Delete nodes in the destination which aren't in the source.
+	Delete bool
+
+	// Treat files of the same size as unchanged, without comparing
+	// their hashes. This is faster, but will miss changes which don't
+	// change a file's size, and disables resuming partial copies.
+	SizeOnly bool
+
+	// If not nil, Progress is called for each node, once Mirror has
+	// dealt with it.
+	Progress func(Event)
+}
+
+// What Mirror did with a node.
+type Op int
+
+const (
+	// The file was copied, or the directory created.
+	Copied Op = iota
+
+	// The rest of a partially copied file was copied.
+	Resumed
+
+	// The file was already the same on both sides.
+	Skipped
+
+	// The node was only in the destination, and was deleted (see
+	// Options.Delete).
+	Deleted
+
+	// Something went wrong; see Event.Err.
+	Failed
+)
+
+func (op Op) String() string {
+	switch op {
+	case Copied:
+		return "copy"
+	case Resumed:
+		return "resume"
+	case Skipped:
+		return "skip"
+	case Deleted:
+		return "delete"
+	case Failed:
+		return "fail"
+	}
+	return fmt.Sprintf("Op(%d)", int(op))
+}
+
+// An Event reports progress, to Options.Progress.
+type Event struct {
+	Op Op
+
+	// The path of the node, relative to the roots.
+	Path  string
+	IsDir bool
+
+	// For Copied and Resumed files, the number of bytes copied.
+	Bytes int64
+
+	// For Failed, the error.
+	Err error
+}
+
+// Totals for a call to Mirror. Directories are counted along with
+// files.
+type Stats struct {
+	Copied, Resumed, Skipped, Deleted, Failed int
+
+	// The number of bytes copied.
+	Bytes int64
+}
+
+// Mirror makes dst a copy of src. It carries on past errors, reporting
+// each to opts.Progress, and returns the first one. If the contents of a
+// source directory can't be listed, nothing is deleted from the
+// corresponding destination directory. opts may be nil.
+func Mirror(ctx context.Context, src filesystem.Directory, dst filesystem.RwDirectory, opts *Options) (Stats, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	m := &mirrorer{ctx: ctx, opts: opts}
+	m.mirrorDir(src, dst, "")
+	if m.err == nil {
+		m.err = ctx.Err()
+	}
+	return m.stats, m.err
+}
+
+type mirrorer struct {
+	ctx   context.Context
+	opts  *Options
+	stats Stats
+	err   error
+}
+
+func (m *mirrorer) report(e Event) {
+	switch e.Op {
+	case Copied:
+		m.stats.Copied++
+	case Resumed:
+		m.stats.Resumed++
+	case Skipped:
+		m.stats.Skipped++
+	case Deleted:
+		m.stats.Deleted++
+	case Failed:
+		m.stats.Failed++
+		if m.err == nil {
+			m.err = e.Err
+		}
+	}
+	m.stats.Bytes += e.Bytes
+	if m.opts.Progress != nil {
+		m.opts.Progress(e)
+	}
+}
+
+func (m *mirrorer) fail(p string, isDir bool, err error) {
+	m.report(Event{Op: Failed, Path: p, IsDir: isDir, Err: err})
+}
+
+// Mirror the contents of src into dst, which are both the directory at p.
+func (m *mirrorer) mirrorDir(src filesystem.Directory, dst filesystem.RwDirectory, p string) {
+	srcEntries, err := client.List(m.ctx, src)
+	if err != nil {
+		m.fail(p, true, &fs.PathError{Op: "readdir", Path: p, Err: fserrors.Decode(err)})
+		return
+	}
+	dstEntries, err := client.List(m.ctx, filesystem.Directory{Client: dst.Client})
+	if err != nil {
+		m.fail(p, true, &fs.PathError{Op: "readdir", Path: p, Err: fserrors.Decode(err)})
+		return
+	}
+	existing := make(map[string]fs.DirEntry, len(dstEntries))
+	for _, ent := range dstEntries {
+		existing[ent.Name()] = ent
+	}
+
+	for _, ent := range srcEntries {
+		if m.ctx.Err() != nil {
+			return
+		}
+		name := ent.Name()
+		if ent.IsDir() {
+			m.mirrorSubdir(src, dst, name, path.Join(p, name), existing[name])
+		} else {
+			m.mirrorFile(src, dst, name, path.Join(p, name), ent, existing[name])
+		}
+		delete(existing, name)
+	}
+
+	if !m.opts.Delete {
+		return
+	}
+	for _, ent := range dstEntries {
+		if _, ok := existing[ent.Name()]; !ok || m.ctx.Err() != nil {
+			continue
+		}
+		childPath := path.Join(p, ent.Name())
+		if err := client.RemoveAll(m.ctx, dst, ent.Name()); err != nil {
+			m.fail(childPath, ent.IsDir(), err)
+			continue
+		}
+		m.report(Event{Op: Deleted, Path: childPath, IsDir: ent.IsDir()})
+	}
+}
+
+// Mirror the directory name in src, at p, into dst, where it is
+// described by old (which is nil if it doesn't exist).
+func (m *mirrorer) mirrorSubdir(src filesystem.Directory, dst filesystem.RwDirectory, name, p string, old fs.DirEntry) {
+	if old != nil && !old.IsDir() {
+		if err := client.Remove(m.ctx, dst, name); err != nil {
+			m.fail(p, true, err)
+			return
+		}
+		old = nil
+	}
+	srcDir, err := client.WalkDir(m.ctx, src, name)
+	if err != nil {
+		m.fail(p, true, err)
+		return
+	}
+	defer srcDir.Client.Release()
+	var dstDir filesystem.RwDirectory
+	if old == nil {
+		dstDir, err = client.MkdirAll(m.ctx, dst, name)
+	} else {
+		dstDir, err = client.WalkRwDir(m.ctx, dst, name)
+	}
+	if err != nil {
+		m.fail(p, true, err)
+		return
+	}
+	defer dstDir.Client.Release()
+	if old == nil {
+		m.report(Event{Op: Copied, Path: p, IsDir: true})
+	}
+	m.mirrorDir(srcDir, dstDir, p)
+}
+
+// Like mirrorSubdir, but for a file described by ent.
+func (m *mirrorer) mirrorFile(src filesystem.Directory, dst filesystem.RwDirectory, name, p string, ent, old fs.DirEntry) {
+	op, n, err := m.copyFile(src, dst, name, ent, old)
+	if err != nil {
+		m.fail(p, false, &fs.PathError{Op: "copy", Path: p, Err: fserrors.Decode(err)})
+		return
+	}
+	m.report(Event{Op: op, Path: p, Bytes: n})
+}
+
+func (m *mirrorer) copyFile(src filesystem.Directory, dst filesystem.RwDirectory, name string, ent, old fs.DirEntry) (Op, int64, error) {
+	info, err := ent.Info()
+	if err != nil {
+		return Failed, 0, err
+	}
+	size, exec := info.Size(), info.Mode()&0111 != 0
+
+	if old != nil && old.IsDir() {
+		if err := client.RemoveAll(m.ctx, dst, name); err != nil {
+			return Failed, 0, err
+		}
+		old = nil
+	}
+
+	srcNode, err := client.Walk(m.ctx, src, name)
+	if err != nil {
+		return Failed, 0, err
+	}
+	defer srcNode.Client.Release()
+	srcFile := filesystem.File{Client: srcNode.Client}
+
+	var dstFile filesystem.RwFile
+	op, startAt := Copied, int64(0)
+	if old == nil {
+		res, release := dst.Create(m.ctx, func(p filesystem.RwDirectory_create_Params) error {
+			p.SetExecutable(exec)
+			return p.SetName(name)
+		})
+		defer release()
+		results, err := res.Struct()
+		if err != nil {
+			return Failed, 0, err
+		}
+		dstFile = filesystem.RwFile{Client: results.File().Client}
+	} else {
+		dstNode, err := client.Walk(m.ctx, filesystem.Directory{Client: dst.Client}, name)
+		if err != nil {
+			return Failed, 0, err
+		}
+		defer dstNode.Client.Release()
+		dstFile = filesystem.RwFile{Client: dstNode.Client}
+
+		oldInfo, err := old.Info()
+		if err != nil {
+			return Failed, 0, err
+		}
+		oldSize, oldExec := oldInfo.Size(), oldInfo.Mode()&0111 != 0
+		switch {
+		case oldSize == size:
+			same := m.opts.SizeOnly
+			if !same {
+				if same, err = m.sameContents(srcFile, filesystem.File{Client: dstFile.Client}, 0); err != nil {
+					return Failed, 0, err
+				}
+			}
+			if same {
+				if oldExec != exec {
+					if err = setExec(m.ctx, dstFile, exec); err != nil {
+						return Failed, 0, err
+					}
+				}
+				return Skipped, 0, nil
+			}
+		case oldSize > 0 && oldSize < size && !m.opts.SizeOnly:
+			same, err := m.sameContents(srcFile, filesystem.File{Client: dstFile.Client}, oldSize)
+			if err != nil {
+				return Failed, 0, err
+			}
+			if same {
+				op, startAt = Resumed, oldSize
+			}
+		}
+		if startAt == 0 {
+			if err = truncate(m.ctx, dstFile); err != nil {
+				return Failed, 0, err
+			}
+		}
+		if oldExec != exec {
+			if err = setExec(m.ctx, dstFile, exec); err != nil {
+				return Failed, 0, err
+			}
+		}
+	}
+
+	// The data goes straight from src to dst; see client.Copy.
+	writeRes, releaseWrite := dstFile.Write(m.ctx, func(p filesystem.RwFile_write_Params) error {
+		p.SetStartAt(startAt)
+		return nil
+	})
+	defer releaseWrite()
+	readRes, releaseRead := srcFile.Read(m.ctx, func(p filesystem.File_read_Params) error {
+		p.SetStartAt(startAt)
+		return p.SetSink(util.ByteStream{Client: writeRes.Sink().Client.AddRef()})
+	})
+	defer releaseRead()
+	if _, err = readRes.Struct(); err != nil {
+		return Failed, 0, err
+	}
+	if _, err = writeRes.Struct(); err != nil {
+		return Failed, 0, err
+	}
+	// The source may have changed since it was listed, and the data
+	// doesn't pass through us, so see how much actually arrived.
+	fi, err := client.StatNode(m.ctx, filesystem.Node{Client: dstFile.Client}, name)
+	if err != nil {
+		return Failed, 0, err
+	}
+	return op, fi.Size() - startAt, nil
+}
+
+// Report whether the first amount bytes of a and b are the same, or
+// their whole contents if amount is zero. If either side can't compute
+// hashes, they are assumed to differ.
+func (m *mirrorer) sameContents(a, b filesystem.File, amount int64) (bool, error) {
+	hash := func(p filesystem.File_hash_Params) error {
+		p.SetAlgorithm(filesystem.File_HashAlgorithm_sha256)
+		p.SetAmount(uint64(amount))
+		return nil
+	}
+	// Both servers hash at the same time.
+	resA, releaseA := a.Hash(m.ctx, hash)
+	defer releaseA()
+	resB, releaseB := b.Hash(m.ctx, hash)
+	defer releaseB()
+	var digests [2][]byte
+	for i, res := range []filesystem.File_hash_Results_Future{resA, resB} {
+		results, err := res.Struct()
+		if capnp.IsUnimplemented(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if digests[i], err = results.Digest(); err != nil {
+			return false, err
+		}
+	}
+	return bytes.Equal(digests[0], digests[1]), nil
+}
+
+func truncate(ctx context.Context, file filesystem.RwFile) error {
+	res, release := file.Truncate(ctx, func(p filesystem.RwFile_truncate_Params) error {
+		p.SetSize(0)
+		return nil
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func setExec(ctx context.Context, file filesystem.RwFile, exec bool) error {
+	res, release := file.SetExec(ctx, func(p filesystem.RwFile_setExec_Params) error {
+		p.SetExec(exec)
+		return nil
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
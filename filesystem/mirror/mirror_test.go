@@ -0,0 +1,242 @@
+package mirror
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+)
+
+// Make a memfs containing files, by path. Paths ending in a slash are
+// directories.
+func newTree(ctx context.Context, t *testing.T, files map[string]string) filesystem.RwDirectory {
+	t.Helper()
+	root := memfs.New(0).Root()
+	t.Cleanup(root.Client.Release)
+	for p, data := range files {
+		if strings.HasSuffix(p, "/") {
+			dir, err := client.MkdirAll(ctx, root, strings.TrimSuffix(p, "/"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir.Client.Release()
+			continue
+		}
+		if err := client.WriteFile(ctx, root, p, []byte(data), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// Check that root contains exactly the files in want, which is as for
+// newTree.
+func checkTree(ctx context.Context, t *testing.T, root filesystem.RwDirectory, want map[string]string) {
+	t.Helper()
+	dir := filesystem.Directory{Client: root.Client}
+	got := make(map[string]string)
+	err := client.WalkTree(ctx, dir, "", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == "" {
+			return err
+		}
+		if d.IsDir() {
+			got[p+"/"] = ""
+			return nil
+		}
+		data, err := client.ReadFile(ctx, dir, p)
+		got[p] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p, data := range want {
+		if g, ok := got[p]; !ok {
+			t.Errorf("%s is missing", p)
+		} else if g != data {
+			t.Errorf("%s contains %q, want %q", p, g, data)
+		}
+	}
+	for p := range got {
+		if _, ok := want[p]; !ok {
+			t.Errorf("unexpected %s", p)
+		}
+	}
+}
+
+// Mirror src into dst, returning the stats and what happened to each
+// path.
+func mirror(ctx context.Context, t *testing.T, src, dst filesystem.RwDirectory, opts Options) (Stats, map[string]Event) {
+	t.Helper()
+	events := make(map[string]Event)
+	progress := opts.Progress
+	opts.Progress = func(e Event) {
+		events[e.Path] = e
+		if progress != nil {
+			progress(e)
+		}
+	}
+	stats, err := Mirror(ctx, filesystem.Directory{Client: src.Client}, dst, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats, events
+}
+
+func checkEvents(t *testing.T, got map[string]Event, want map[string]Op) {
+	t.Helper()
+	for p, op := range want {
+		if e, ok := got[p]; !ok {
+			t.Errorf("%s: no event, want %v", p, op)
+		} else if e.Op != op {
+			t.Errorf("%s: got %v, want %v", p, e.Op, op)
+		}
+	}
+	for p, e := range got {
+		if _, ok := want[p]; !ok {
+			t.Errorf("%s: unexpected %v", p, e.Op)
+		}
+	}
+}
+
+var srcFiles = map[string]string{
+	"a.txt":       "hello",
+	"dir/":        "",
+	"dir/b.txt":   "some more text",
+	"dir/sub/":    "",
+	"dir/sub/c":   "c",
+	"empty-dir/":  "",
+	"empty-file":  "",
+	"other/":      "",
+	"other/d.txt": "d",
+}
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+	src := newTree(ctx, t, srcFiles)
+	dst := newTree(ctx, t, nil)
+
+	stats, events := mirror(ctx, t, src, dst, Options{})
+	checkTree(ctx, t, dst, srcFiles)
+	checkEvents(t, events, map[string]Op{
+		"a.txt":       Copied,
+		"dir":         Copied,
+		"dir/b.txt":   Copied,
+		"dir/sub":     Copied,
+		"dir/sub/c":   Copied,
+		"empty-dir":   Copied,
+		"empty-file":  Copied,
+		"other":       Copied,
+		"other/d.txt": Copied,
+	})
+	if want := int64(len("hello") + len("some more text") + 2); stats.Bytes != want {
+		t.Errorf("copied %d bytes, want %d", stats.Bytes, want)
+	}
+
+	// Again, with nothing to do.
+	stats, events = mirror(ctx, t, src, dst, Options{})
+	checkTree(ctx, t, dst, srcFiles)
+	checkEvents(t, events, map[string]Op{
+		"a.txt":       Skipped,
+		"dir/b.txt":   Skipped,
+		"dir/sub/c":   Skipped,
+		"empty-file":  Skipped,
+		"other/d.txt": Skipped,
+	})
+	if stats.Bytes != 0 {
+		t.Errorf("copied %d bytes, want none", stats.Bytes)
+	}
+}
+
+func TestMirrorChanges(t *testing.T) {
+	ctx := context.Background()
+	src := newTree(ctx, t, srcFiles)
+	dst := newTree(ctx, t, map[string]string{
+		// Partly copied.
+		"dir/b.txt": "some ",
+		// The same size, but different.
+		"a.txt": "HELLO",
+		// Longer than the source, but starting the same.
+		"other/d.txt": "dd",
+		// The wrong types.
+		"empty-dir":   "x",
+		"empty-file/": "",
+		// Only in the destination.
+		"extra.txt":       "x",
+		"extra-dir/":      "",
+		"extra-dir/e.txt": "e",
+		"dir/sub/extra":   "x",
+	})
+
+	stats, events := mirror(ctx, t, src, dst, Options{})
+	want := make(map[string]string)
+	for p, data := range srcFiles {
+		want[p] = data
+	}
+	want["extra.txt"] = "x"
+	want["extra-dir/"] = ""
+	want["extra-dir/e.txt"] = "e"
+	want["dir/sub/extra"] = "x"
+	checkTree(ctx, t, dst, want)
+	checkEvents(t, events, map[string]Op{
+		"a.txt":       Copied,
+		"dir/b.txt":   Resumed,
+		"dir/sub/c":   Copied,
+		"empty-dir":   Copied,
+		"empty-file":  Copied,
+		"other/d.txt": Copied,
+	})
+	if got, want := events["dir/b.txt"].Bytes, int64(len("more text")); got != want {
+		t.Errorf("resumed dir/b.txt with %d bytes, want %d", got, want)
+	}
+	if want := int64(len("hello") + len("more text") + 2); stats.Bytes != want {
+		t.Errorf("copied %d bytes, want %d", stats.Bytes, want)
+	}
+}
+
+func TestMirrorDelete(t *testing.T) {
+	ctx := context.Background()
+	src := newTree(ctx, t, srcFiles)
+	dst := newTree(ctx, t, map[string]string{
+		"extra.txt":       "x",
+		"extra-dir/":      "",
+		"extra-dir/e.txt": "e",
+		"dir/sub/extra":   "x",
+	})
+
+	stats, events := mirror(ctx, t, src, dst, Options{Delete: true})
+	checkTree(ctx, t, dst, srcFiles)
+	for _, p := range []string{"extra.txt", "extra-dir", "dir/sub/extra"} {
+		if events[p].Op != Deleted {
+			t.Errorf("%s: got %v, want %v", p, events[p].Op, Deleted)
+		}
+	}
+	if stats.Deleted != 3 {
+		t.Errorf("deleted %d nodes, want 3", stats.Deleted)
+	}
+}
+
+// The bytes reported are those copied, even if the source has changed
+// since it was listed.
+func TestMirrorBytes(t *testing.T) {
+	ctx := context.Background()
+	src := newTree(ctx, t, map[string]string{"a": "a", "b": "b"})
+	dst := newTree(ctx, t, nil)
+
+	// memfs lists in order, so b hasn't been copied yet.
+	_, events := mirror(ctx, t, src, dst, Options{Progress: func(e Event) {
+		if e.Path != "a" {
+			return
+		}
+		if err := client.WriteFile(ctx, src, "b", []byte("grown"), false); err != nil {
+			t.Error(err)
+		}
+	}})
+	if got, want := events["b"].Bytes, int64(len("grown")); got != want {
+		t.Errorf("reported %d bytes for b, want %d", got, want)
+	}
+}
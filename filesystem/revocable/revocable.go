@@ -0,0 +1,55 @@
+// Package revocable provides revocable proxies for filesystem capabilities.
+//
+// A proxy forwards calls to the underlying capability until it is revoked;
+// after that, every call on it, or on anything derived from it (files and
+// directories reached by walk, streams passed to list and read, sinks
+// returned by write, etc.), fails with Revoked. Calls in progress at the
+// time of revocation are cancelled, and locks and watches taken out
+// through the proxy are dropped.
+package revocable
+
+import (
+	"sync"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/membrane"
+)
+
+var (
+	Revoked = fserrors.New(filesystem.ErrorCode_permissionDenied, "Capability has been revoked")
+)
+
+// New returns a proxy for node, which implements the interface given by
+// kind, and a function which revokes it. The revoke function may be called
+// more than once. New takes ownership of node.Client.
+func New(node filesystem.Node, kind membrane.Kind) (filesystem.Node, func()) {
+	var once sync.Once
+	done := make(chan struct{})
+	proxy := membrane.Wrap(node, kind, membrane.Hooks{
+		Check: func() error {
+			select {
+			case <-done:
+				return Revoked
+			default:
+				return nil
+			}
+		},
+		Done: done,
+	})
+	return proxy, func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// Like New, but for a Directory.
+func NewDirectory(dir filesystem.Directory) (filesystem.Directory, func()) {
+	node, revoke := New(filesystem.Node{Client: dir.Client}, membrane.KindDirectory)
+	return filesystem.Directory{Client: node.Client}, revoke
+}
+
+// Like New, but for an RwDirectory.
+func NewRwDirectory(dir filesystem.RwDirectory) (filesystem.RwDirectory, func()) {
+	node, revoke := New(filesystem.Node{Client: dir.Client}, membrane.KindRwDirectory)
+	return filesystem.RwDirectory{Client: node.Client}, revoke
+}
@@ -0,0 +1,284 @@
+package revocable
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+
+	"zenhack.net/go/sandstorm/capnp/util"
+
+	"zombiezen.com/go/capnproto2/rpc"
+)
+
+// Serve c over an in-process RPC connection, and return a client for the
+// other end, so that the proxy is used as other grains would use it.
+func serve(t *testing.T, c filesystem.RwDirectory) filesystem.RwDirectory {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conns [2]net.Conn
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socketpair")
+		conns[i], err = net.FileConn(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := rpc.NewConn(rpc.NewStreamTransport(conns[0]), &rpc.Options{
+		BootstrapClient: c.Client,
+	})
+	conn := rpc.NewConn(rpc.NewStreamTransport(conns[1]), nil)
+	remote := conn.Bootstrap(context.Background())
+	t.Cleanup(func() {
+		remote.Release()
+		conn.Close()
+		server.Close()
+	})
+	return filesystem.RwDirectory{Client: remote}
+}
+
+// A sink for read, and a stream for list, which block on their first call
+// until unblock is closed, so that revocation happens mid-call. Calls are
+// acknowledged first, so that a blocked one doesn't hold up the rest of
+// the connection.
+type blocker struct {
+	started chan struct{}
+	unblock chan struct{}
+
+	mu    sync.Mutex
+	calls int
+}
+
+func newBlocker() *blocker {
+	return &blocker{
+		started: make(chan struct{}),
+		unblock: make(chan struct{}),
+	}
+}
+
+func (b *blocker) wait() {
+	b.mu.Lock()
+	b.calls++
+	first := b.calls == 1
+	b.mu.Unlock()
+	if first {
+		close(b.started)
+		<-b.unblock
+	}
+}
+
+func (b *blocker) numCalls() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls
+}
+
+func (b *blocker) Write(ctx context.Context, p util.ByteStream_write) error {
+	p.Ack()
+	b.wait()
+	return nil
+}
+
+func (b *blocker) Done(ctx context.Context, p util.ByteStream_done) error {
+	p.Ack()
+	b.wait()
+	return nil
+}
+
+func (b *blocker) ExpectSize(ctx context.Context, p util.ByteStream_expectSize) error {
+	return nil
+}
+
+func (b *blocker) Push(ctx context.Context, p filesystem.Directory_Entry_Stream_push) error {
+	p.Ack()
+	b.wait()
+	return nil
+}
+
+// Adapts blocker to Directory.Entry.Stream, whose done method clashes
+// with ByteStream's.
+type entryStream struct{ *blocker }
+
+func (s entryStream) Done(ctx context.Context, p filesystem.Directory_Entry_Stream_done) error {
+	p.Ack()
+	s.wait()
+	return nil
+}
+
+func isRevoked(err error) bool {
+	return err != nil && strings.Contains(err.Error(), Revoked.Message)
+}
+
+func stat(ctx context.Context, c filesystem.Node) error {
+	res, release := c.Stat(ctx, nil)
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func tryLock(ctx context.Context, f filesystem.RwFile) (filesystem.LockHandle, error) {
+	res, release := f.TryLock(ctx, func(p filesystem.RwFile_tryLock_Params) error {
+		p.SetExclusive(true)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return filesystem.LockHandle{}, err
+	}
+	return filesystem.LockHandle{Client: results.Handle().Client.AddRef()}, nil
+}
+
+// Revoking in the middle of a read and a list fails them, and everything
+// derived from the proxy fails with Revoked from then on.
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	root := memfs.New(0).Root()
+	defer root.Client.Release()
+	if err := client.WriteFile(ctx, root, "d/a.txt", bytes.Repeat([]byte("a"), 1<<20), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.WriteFile(ctx, root, "d/b.txt", []byte("b"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	proxy, revoke := NewRwDirectory(filesystem.RwDirectory{Client: root.Client.AddRef()})
+	defer revoke()
+	remote := serve(t, proxy)
+
+	// Capabilities derived from the proxy in various ways.
+	sub, err := client.WalkRwDir(ctx, remote, "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Client.Release()
+	node, err := client.Walk(ctx, filesystem.Directory{Client: sub.Client}, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Client.Release()
+	file := filesystem.RwFile{Client: node.Client}
+	created, err := client.Create(ctx, remote, "new.txt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer created.Client.Release()
+	w := client.NewWriter(ctx, created, 0)
+	if _, err = w.Write([]byte("before")); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := tryLock(ctx, file)
+	if err != nil {
+		t.Fatal(err)
+	} else if !lock.Client.IsValid() {
+		t.Fatal("couldn't lock a.txt")
+	}
+	defer lock.Client.Release()
+
+	// A read and a list, each stuck on its first call to our stream.
+	sink := newBlocker()
+	stream := newBlocker()
+	readErr := make(chan error, 1)
+	listErr := make(chan error, 1)
+	go func() {
+		res, release := file.Read(ctx, func(p filesystem.File_read_Params) error {
+			return p.SetSink(util.ByteStream_ServerToClient(sink, nil))
+		})
+		defer release()
+		_, err := res.Struct()
+		readErr <- err
+	}()
+	go func() {
+		res, release := sub.List(ctx, func(p filesystem.Directory_list_Params) error {
+			return p.SetStream(filesystem.Directory_Entry_Stream_ServerToClient(entryStream{stream}, nil))
+		})
+		defer release()
+		_, err := res.Struct()
+		listErr <- err
+	}()
+	<-sink.started
+	<-stream.started
+
+	revoke()
+	close(sink.unblock)
+	close(stream.unblock)
+	if err := <-readErr; err == nil {
+		t.Error("read in progress succeeded after revocation")
+	}
+	if err := <-listErr; err == nil {
+		t.Error("list in progress succeeded after revocation")
+	}
+	if n := sink.numCalls(); n != 1 {
+		t.Errorf("the read sink got %d calls, want just the one in progress", n)
+	}
+	if n := stream.numCalls(); n != 1 {
+		t.Errorf("the list stream got %d calls, want just the one in progress", n)
+	}
+
+	for _, c := range []struct {
+		name string
+		node filesystem.Node
+	}{
+		{"root", filesystem.Node{Client: remote.Client}},
+		{"walked directory", filesystem.Node{Client: sub.Client}},
+		{"walked file", node},
+		{"created file", filesystem.Node{Client: created.Client}},
+	} {
+		if err := stat(ctx, c.node); !isRevoked(err) {
+			t.Errorf("stat of %s: got %v, want %v", c.name, err, Revoked)
+		}
+	}
+	if _, err := client.List(ctx, filesystem.Directory{Client: sub.Client}); !isRevoked(err) {
+		t.Errorf("list: got %v, want %v", err, Revoked)
+	}
+	var buf bytes.Buffer
+	if err := client.ReadTo(ctx, filesystem.File{Client: file.Client}, nopCloser{&buf}); !isRevoked(err) {
+		t.Errorf("read: got %v, want %v", err, Revoked)
+	}
+	_, err = w.Write([]byte("after"))
+	if err == nil {
+		err = w.Close()
+	}
+	if !isRevoked(err) {
+		t.Errorf("write to sink: got %v, want %v", err, Revoked)
+	}
+
+	// The lock taken through the proxy is dropped, even though we still
+	// hold the handle.
+	rawNode, err := client.Walk(ctx, filesystem.Directory{Client: root.Client}, "d/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rawNode.Client.Release()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		h, err := tryLock(ctx, filesystem.RwFile{Client: rawNode.Client})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Client.IsValid() {
+			h.Client.Release()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the lock taken through the proxy survived revocation")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type nopCloser struct{ *bytes.Buffer }
+
+func (nopCloser) Close() error { return nil }
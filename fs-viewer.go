@@ -72,7 +72,7 @@ func initHTTPFS(bridge bridge_capnp.SandstormHttpBridge) {
 				w.WriteHeader(http.StatusNotFound)
 				return
 			}
-			http.FileServer(rootDir).ServeHTTP(w, req)
+			httpfs.FileServer(rootDir).ServeHTTP(w, req)
 		})
 
 	r.Methods("GET").Path("/pb-request.js").
@@ -0,0 +1,93 @@
+package main
+
+// fsmirror makes one remote directory a copy of another, using package
+// mirror. Each directory is the bootstrap capability of a unix socket or
+// TCP address, such as one served by fsserver; the destination must be
+// writable.
+//
+// Files which are already the same on both sides are skipped, so if
+// fsmirror is interrupted, running it again carries on where it left
+// off.
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/mirror"
+
+	"zombiezen.com/go/capnproto2/rpc"
+)
+
+var (
+	srcNetwork = flag.String("src-network", "unix", `network to connect to for the source ("unix" or "tcp")`)
+	srcAddr    = flag.String("src", "", "address of the source directory")
+	dstNetwork = flag.String("dst-network", "unix", `network to connect to for the destination ("unix" or "tcp")`)
+	dstAddr    = flag.String("dst", "", "address of the destination directory")
+
+	del      = flag.Bool("delete", false, "delete files in the destination which aren't in the source")
+	sizeOnly = flag.Bool("size-only", false, "skip files whose size matches, without comparing hashes")
+	verbose  = flag.Bool("v", false, "also report files which are skipped")
+	quiet    = flag.Bool("q", false, "only report errors and the totals")
+)
+
+func main() {
+	flag.Parse()
+	if *srcAddr == "" || *dstAddr == "" {
+		log.Fatal("usage: fsmirror [-src-network unix|tcp] -src <address> [-dst-network unix|tcp] -dst <address> [-delete] [-size-only] [-v|-q]")
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	src, closeSrc := dial(ctx, *srcNetwork, *srcAddr)
+	defer closeSrc()
+	dst, closeDst := dial(ctx, *dstNetwork, *dstAddr)
+	defer closeDst()
+
+	stats, err := mirror.Mirror(ctx, filesystem.Directory{Client: src.Client}, filesystem.RwDirectory{Client: dst.Client}, &mirror.Options{
+		Delete:   *del,
+		SizeOnly: *sizeOnly,
+		Progress: progress,
+	})
+	fmt.Printf("%d copied, %d resumed, %d skipped, %d deleted, %d failed; %d bytes copied\n",
+		stats.Copied, stats.Resumed, stats.Skipped, stats.Deleted, stats.Failed, stats.Bytes)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Connect to addr, and return its bootstrap capability and a function
+// which closes the connection.
+func dial(ctx context.Context, network, addr string) (filesystem.Node, func()) {
+	c, err := net.Dial(network, addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conn := rpc.NewConn(rpc.NewStreamTransport(c), nil)
+	return filesystem.Node{Client: conn.Bootstrap(ctx)}, func() { conn.Close() }
+}
+
+func progress(e mirror.Event) {
+	p := e.Path
+	if e.IsDir {
+		p += "/"
+	}
+	switch {
+	case e.Op == mirror.Failed:
+		log.Print(e.Err)
+	case e.Op == mirror.Skipped && !*verbose, *quiet:
+	case e.Op == mirror.Copied || e.Op == mirror.Resumed:
+		if e.IsDir {
+			fmt.Println(e.Op, p)
+		} else {
+			fmt.Println(e.Op, p, e.Bytes, "bytes")
+		}
+	default:
+		fmt.Println(e.Op, p)
+	}
+}
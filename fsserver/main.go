@@ -0,0 +1,77 @@
+package main
+
+// fsserver exports a local directory over capnp RPC, on a unix socket or
+// a TCP port, for use with fuseclient. Each connection gets the directory
+// as its bootstrap capability.
+//
+// There is no authentication; anyone who can connect gets the directory,
+// so this is for development use. Prefer unix sockets, which can be
+// protected with filesystem permissions.
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem/local"
+
+	"zombiezen.com/go/capnproto2/rpc"
+)
+
+var (
+	dir      = flag.String("dir", "", "directory to serve")
+	network  = flag.String("network", "unix", `network to listen on ("unix" or "tcp")`)
+	addr     = flag.String("addr", "", "address to listen on")
+	readOnly = flag.Bool("readonly", false, "only allow reading the directory")
+)
+
+func main() {
+	flag.Parse()
+	if *dir == "" || *addr == "" {
+		log.Fatal("usage: fsserver -dir <directory> [-network unix|tcp] -addr <address> [-readonly]")
+	}
+	node, err := local.NewNode(*dir, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !node.IsDir {
+		log.Fatalf("%s is not a directory", *dir)
+	}
+	if *readOnly {
+		node.Writable = false
+	}
+	if *network == "unix" {
+		removeStaleSocket(*addr)
+	}
+	l, err := net.Listen(*network, *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Serving %s on %s %s", *dir, *network, l.Addr())
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go serve(node, c)
+	}
+}
+
+func serve(node *local.Node, c net.Conn) {
+	log.Printf("Connection from %v", c.RemoteAddr())
+	conn := rpc.NewConn(rpc.NewStreamTransport(c), &rpc.Options{
+		BootstrapClient: node.MakeClient().Client,
+	})
+	<-conn.Done()
+	log.Printf("Connection from %v closed", c.RemoteAddr())
+}
+
+// Remove the socket at path, if it's left over from a previous run.
+// Anything other than a socket is left alone, so listening fails.
+func removeStaleSocket(path string) {
+	fi, err := os.Lstat(path)
+	if err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+}
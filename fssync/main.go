@@ -0,0 +1,109 @@
+package main
+
+// fssync keeps a local directory and a remote RwDirectory in sync, in
+// both directions. The remote directory is the bootstrap capability of a
+// unix socket or TCP address, such as one served by fsserver.
+//
+// Changes are found by comparing each side against the state recorded at
+// the end of the previous sync: a file has changed if its size or
+// executable bit differ, or if its contents hash differently. Local
+// files whose size and modification time are unchanged are assumed not
+// to have changed, so that they needn't be read every time; the schema
+// has no modification times, so remote files are always hashed, by the
+// server. Nodes which are in the state but have gone from one side were
+// deleted there, and the deletion is carried over to the other side.
+//
+// If a file has changed differently on both sides, the remote version
+// wins, and the local version is kept alongside it, on both sides, as a
+// conflict copy named e.g. "notes.conflict-20060102-150405.txt".
+//
+// The first sync has no state to go on, so it never deletes anything;
+// files which exist on both sides and differ become conflicts.
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+
+	"zombiezen.com/go/capnproto2/rpc"
+)
+
+// The default name of the state file, which lives in the root of the
+// local directory and is not itself synced.
+const defaultStateName = ".fssync-state.json"
+
+var (
+	network   = flag.String("network", "unix", `network to connect to ("unix" or "tcp")`)
+	addr      = flag.String("addr", "", "address to connect to")
+	localDir  = flag.String("local", "", "local directory to sync")
+	statePath = flag.String("state", "", "file to keep the sync state in (default <local>/"+defaultStateName+")")
+	dryRun    = flag.Bool("dry-run", false, "print what would be done, without changing anything")
+)
+
+func main() {
+	flag.Parse()
+	if *addr == "" || *localDir == "" {
+		log.Fatal("usage: fssync [-network unix|tcp] -addr <address> -local <directory> [-state <file>] [-dry-run]")
+	}
+	root, err := filepath.Abs(*localDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if fi, err := os.Stat(root); err != nil {
+		log.Fatal(err)
+	} else if !fi.IsDir() {
+		log.Fatalf("%s is not a directory", root)
+	}
+	if *statePath == "" {
+		*statePath = filepath.Join(root, defaultStateName)
+	}
+	if *statePath, err = filepath.Abs(*statePath); err != nil {
+		log.Fatal(err)
+	}
+
+	c, err := net.Dial(*network, *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conn := rpc.NewConn(rpc.NewStreamTransport(c), nil)
+	defer conn.Close()
+	ctx := context.Background()
+	remote := filesystem.RwDirectory{Client: conn.Bootstrap(ctx)}
+	defer remote.Client.Release()
+
+	fi, err := client.Stat(ctx, filesystem.Directory{Client: remote.Client}, "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !fi.IsDir() {
+		log.Fatalf("%s:%s is not a directory", *network, *addr)
+	}
+	if !fi.Writable() && !*dryRun {
+		log.Fatalf("%s:%s is read-only", *network, *addr)
+	}
+
+	base, err := loadState(*statePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := &syncer{
+		ctx:       ctx,
+		localRoot: root,
+		remote:    remote,
+		statePath: *statePath,
+		dryRun:    *dryRun,
+		base:      base,
+	}
+	if err = s.run(); err != nil {
+		log.Fatal(err)
+	}
+	if s.failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Bumped on incompatible changes to the state file's format.
+const stateVersion = 1
+
+// The state file, as stored on disk.
+type stateFile struct {
+	Version int               `json:"version"`
+	Entries map[string]*entry `json:"entries"`
+}
+
+// What we know about a node, by slash-separated path relative to the
+// roots. The state records each node as it was on both sides at the end
+// of the last sync; the same type describes what we find on each side
+// now.
+type entry struct {
+	Dir bool `json:"dir,omitempty"`
+
+	// The rest are only used for files.
+
+	Size int64 `json:"size,omitempty"`
+	Exec bool  `json:"exec,omitempty"`
+
+	// The local file's modification time, in nanoseconds since the
+	// epoch. Zero for remote files.
+	ModTime int64 `json:"mtime,omitempty"`
+
+	// The hex-encoded SHA-256 of the contents. For nodes found during a
+	// sync, this is computed only when needed, and empty until then.
+	Hash string `json:"hash,omitempty"`
+}
+
+// Load the state saved at path. If there is none, this is the first
+// sync, and the state is empty.
+func loadState(path string) (map[string]*entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]*entry{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var sf stateFile
+	if err = json.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if sf.Version != stateVersion {
+		return nil, fmt.Errorf("%s: unsupported version %d", path, sf.Version)
+	}
+	if sf.Entries == nil {
+		sf.Entries = map[string]*entry{}
+	}
+	return sf.Entries, nil
+}
+
+// Save entries to path. The new state is written to a temporary file
+// first, so that if we're interrupted the old state survives intact.
+func saveState(path string, entries map[string]*entry) error {
+	data, err := json.MarshalIndent(stateFile{
+		Version: stateVersion,
+		Entries: entries,
+	}, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err2 := tmp.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,720 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"log"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+)
+
+// Files being downloaded or uploaded are written to a temporary file
+// with this prefix (and the suffix ".tmp") first, and then moved (or, on
+// the remote side, copied) into place. Such files are never synced, in
+// case we're interrupted and leave one behind.
+const tempPrefix = ".fssync-"
+
+type syncer struct {
+	ctx       context.Context
+	localRoot string
+	remote    filesystem.RwDirectory
+	statePath string
+	dryRun    bool
+
+	// The state saved by the last sync.
+	base map[string]*entry
+
+	// The path of the state file relative to localRoot, or "" if it's
+	// somewhere else.
+	stateRel string
+
+	// What's on each side now.
+	localEntries  map[string]*entry
+	remoteEntries map[string]*entry
+
+	// The state to save once we're done.
+	next map[string]*entry
+
+	// Set if anything went wrong.
+	failed bool
+}
+
+type op int
+
+const (
+	// Nothing to do, other than record the state.
+	opNone op = iota
+
+	// Copy the local node to the remote side, or vice versa.
+	opUpload
+	opDownload
+
+	opDeleteRemote
+	opDeleteLocal
+
+	// The node changed differently on each side. The local version is
+	// moved aside to conflictPath, and both are then copied over.
+	opConflict
+)
+
+type action struct {
+	op   op
+	path string
+
+	// The node on each side; nil if it doesn't exist there.
+	local, remote *entry
+
+	conflictPath string
+}
+
+func (a *action) String() string {
+	switch a.op {
+	case opUpload:
+		if a.local.Dir {
+			return "mkdir remote " + a.path
+		}
+		return "upload " + a.path
+	case opDownload:
+		if a.remote.Dir {
+			return "mkdir local " + a.path
+		}
+		return "download " + a.path
+	case opDeleteRemote:
+		return "delete remote " + a.path
+	case opDeleteLocal:
+		return "delete local " + a.path
+	case opConflict:
+		return "conflict " + a.path + ": saving local version as " + a.conflictPath
+	}
+	return "keep " + a.path
+}
+
+func (a *action) isDelete() bool {
+	return a.op == opDeleteRemote || a.op == opDeleteLocal
+}
+
+func (s *syncer) run() error {
+	rel, err := filepath.Rel(s.localRoot, s.statePath)
+	if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		s.stateRel = filepath.ToSlash(rel)
+	}
+	if err := s.scanLocal(); err != nil {
+		return err
+	}
+	if err := s.scanRemote(); err != nil {
+		return err
+	}
+	s.next = make(map[string]*entry)
+	actions := s.plan()
+
+	// Deletions go first, deepest first, so that directories have been
+	// emptied by the time we come to them, and nothing is left in the
+	// way of nodes replaced by one of a different type. Everything else
+	// goes parents first.
+	for i := len(actions) - 1; i >= 0; i-- {
+		if actions[i].isDelete() {
+			s.do(actions[i])
+		}
+	}
+	for _, a := range actions {
+		if !a.isDelete() {
+			s.do(a)
+		}
+	}
+	if s.dryRun {
+		return nil
+	}
+	return saveState(s.statePath, s.next)
+}
+
+// Report whether the node at rel (on either side) should be left out of
+// the sync.
+func (s *syncer) ignored(rel string) bool {
+	name := path.Base(rel)
+	if strings.HasPrefix(name, tempPrefix) && strings.HasSuffix(name, ".tmp") {
+		return true
+	}
+	return s.stateRel != "" && (rel == s.stateRel || strings.HasPrefix(rel, s.stateRel+".tmp"))
+}
+
+func (s *syncer) localPath(rel string) string {
+	return filepath.Join(s.localRoot, filepath.FromSlash(rel))
+}
+
+// Any error while scanning either side is fatal: nodes we failed to see
+// would look like they had been deleted.
+
+func (s *syncer) scanLocal() error {
+	s.localEntries = make(map[string]*entry)
+	return filepath.WalkDir(s.localRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.localRoot, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if s.ignored(rel) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			s.localEntries[rel] = &entry{Dir: true}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			log.Printf("Skipping %s: not a regular file or directory", p)
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		s.localEntries[rel] = localEntry(fi)
+		return nil
+	})
+}
+
+func localEntry(fi fs.FileInfo) *entry {
+	return &entry{
+		Size:    fi.Size(),
+		Exec:    fi.Mode()&0111 != 0,
+		ModTime: fi.ModTime().UnixNano(),
+	}
+}
+
+func (s *syncer) scanRemote() error {
+	s.remoteEntries = make(map[string]*entry)
+	root := filesystem.Directory{Client: s.remote.Client}
+	return client.WalkTree(s.ctx, root, "", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == "" {
+			return nil
+		}
+		if s.ignored(p) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			s.remoteEntries[p] = &entry{Dir: true}
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		s.remoteEntries[p] = &entry{Size: fi.Size(), Exec: fi.Mode()&0111 != 0}
+		return nil
+	})
+}
+
+// Decide what to do with each path found on either side, or in the
+// state. The actions are returned parents first.
+func (s *syncer) plan() []*action {
+	all := make(map[string]bool)
+	for _, m := range []map[string]*entry{s.base, s.localEntries, s.remoteEntries} {
+		for p := range m {
+			all[p] = true
+		}
+	}
+	paths := make([]string, 0, len(all))
+	for p := range all {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	// Local directories which are in conflict with a remote file. Their
+	// contents go along with them to the conflict copy, so aren't synced
+	// individually.
+	movedAside := make(map[string]bool)
+
+	var actions []*action
+	for _, p := range paths {
+		if underAny(p, movedAside) {
+			continue
+		}
+		a, err := s.decide(p)
+		if err != nil {
+			s.fail(p, err)
+			continue
+		}
+		if a.op == opConflict {
+			a.conflictPath = s.conflictPath(p)
+			if a.local.Dir {
+				movedAside[p] = true
+			}
+		}
+		actions = append(actions, a)
+	}
+	return actions
+}
+
+// Report whether p is inside one of dirs.
+func underAny(p string, dirs map[string]bool) bool {
+	for p = path.Dir(p); p != "."; p = path.Dir(p) {
+		if dirs[p] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *syncer) decide(p string) (*action, error) {
+	base := s.base[p]
+	a := &action{path: p, local: s.localEntries[p], remote: s.remoteEntries[p]}
+	localSame, err := s.unchanged(p, base, a.local, s.localHash)
+	if err != nil {
+		return nil, err
+	}
+	remoteSame, err := s.unchanged(p, base, a.remote, s.remoteHash)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case localSame && remoteSame:
+		a.op = opNone
+	case remoteSame:
+		a.op = opUpload
+		if a.local == nil {
+			a.op = opDeleteRemote
+		}
+	case localSame:
+		a.op = opDownload
+		if a.remote == nil {
+			a.op = opDeleteLocal
+		}
+	default:
+		same, err := s.same(p, a.local, a.remote)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case same:
+			a.op = opNone
+		case a.local == nil:
+			// Deleted here but changed there; keep the changes.
+			a.op = opDownload
+		case a.remote == nil:
+			a.op = opUpload
+		default:
+			a.op = opConflict
+		}
+	}
+	return a, nil
+}
+
+// Report whether cur, the node at p on one side, is the same as base,
+// which is what was there after the last sync. hash computes the hash
+// of cur, if it comes to that.
+func (s *syncer) unchanged(p string, base, cur *entry, hash func(string, *entry) (string, error)) (bool, error) {
+	switch {
+	case base == nil || cur == nil:
+		return base == nil && cur == nil, nil
+	case base.Dir || cur.Dir:
+		return base.Dir && cur.Dir, nil
+	case cur.Size != base.Size || cur.Exec != base.Exec:
+		return false, nil
+	case cur.ModTime != 0 && cur.ModTime == base.ModTime:
+		cur.Hash = base.Hash
+		return true, nil
+	}
+	h, err := hash(p, cur)
+	return h == base.Hash, err
+}
+
+// Report whether the local and remote nodes at p are the same.
+func (s *syncer) same(p string, local, remote *entry) (bool, error) {
+	switch {
+	case local == nil || remote == nil:
+		return local == nil && remote == nil, nil
+	case local.Dir || remote.Dir:
+		return local.Dir && remote.Dir, nil
+	case local.Size != remote.Size || local.Exec != remote.Exec:
+		return false, nil
+	}
+	lh, err := s.localHash(p, local)
+	if err != nil {
+		return false, err
+	}
+	rh, err := s.remoteHash(p, remote)
+	return lh == rh, err
+}
+
+func (s *syncer) localHash(p string, e *entry) (string, error) {
+	if e.Hash != "" {
+		return e.Hash, nil
+	}
+	f, err := os.Open(s.localPath(p))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	e.Hash = hex.EncodeToString(h.Sum(nil))
+	return e.Hash, nil
+}
+
+func (s *syncer) remoteHash(p string, e *entry) (string, error) {
+	if e.Hash != "" {
+		return e.Hash, nil
+	}
+	root := filesystem.Directory{Client: s.remote.Client}
+	digest, err := client.Hash(s.ctx, root, p, filesystem.File_HashAlgorithm_sha256)
+	if err != nil {
+		return "", err
+	}
+	e.Hash = hex.EncodeToString(digest)
+	return e.Hash, nil
+}
+
+// Pick a name for the local version of p, which is in conflict with the
+// remote version, that isn't already taken on either side.
+func (s *syncer) conflictPath(p string) string {
+	ext := path.Ext(p)
+	stem := strings.TrimSuffix(p, ext) + ".conflict-" + time.Now().Format("20060102-150405")
+	cp := stem + ext
+	for i := 2; s.exists(cp); i++ {
+		cp = stem + "-" + strconv.Itoa(i) + ext
+	}
+	return cp
+}
+
+func (s *syncer) exists(p string) bool {
+	if s.localEntries[p] != nil || s.remoteEntries[p] != nil {
+		return true
+	}
+	_, err := os.Lstat(s.localPath(p))
+	return err == nil
+}
+
+// Log an error with p, and keep its old state, so that whatever we were
+// trying to do is tried again next time.
+func (s *syncer) fail(p string, err error) {
+	log.Printf("%s: %v", p, err)
+	s.failed = true
+	if base := s.base[p]; base != nil {
+		s.next[p] = base
+	} else {
+		delete(s.next, p)
+	}
+}
+
+func (s *syncer) do(a *action) {
+	if a.op != opNone {
+		fmt.Println(a)
+	}
+	if s.dryRun {
+		return
+	}
+	var err error
+	switch a.op {
+	case opNone:
+		if a.local != nil {
+			s.next[a.path] = a.local
+		}
+	case opUpload:
+		err = s.upload(a.path, a.local, a.remote)
+	case opDownload:
+		err = s.download(a.path, a.local, a.remote)
+	case opDeleteRemote:
+		err = s.deleteRemote(a.path)
+	case opDeleteLocal:
+		err = s.deleteLocal(a.path)
+	case opConflict:
+		err = s.conflict(a)
+	}
+	if err != nil {
+		s.fail(a.path, err)
+	}
+}
+
+// Copy the local node at p to the remote side, replacing remote, which
+// is what's there now.
+func (s *syncer) upload(p string, local, remote *entry) error {
+	if remote != nil && remote.Dir != local.Dir {
+		// If it's a directory, its contents were deleted already.
+		if err := client.Remove(s.ctx, s.remote, p); err != nil {
+			return err
+		}
+	}
+	if local.Dir {
+		dir, err := client.MkdirAll(s.ctx, s.remote, p)
+		if err != nil {
+			return err
+		}
+		dir.Client.Release()
+		s.next[p] = &entry{Dir: true}
+		return nil
+	}
+	e, err := s.uploadFile(p)
+	if err != nil {
+		return err
+	}
+	s.next[p] = e
+	return nil
+}
+
+// Copy the local file at p to the remote side, and return its entry.
+//
+// The file is uploaded under a temporary name first, so that if we're
+// interrupted or the upload fails, what was at p is left alone. The schema
+// has no rename, so it is then copied over p on the server, and only then
+// is the temporary file removed; p is never missing, and only if that
+// copy is interrupted is it left part-written.
+func (s *syncer) uploadFile(p string) (*entry, error) {
+	f, err := os.Open(s.localPath(p))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	e := localEntry(fi)
+
+	tmp := path.Join(path.Dir(p), tempPrefix+strconv.FormatInt(rand.Int63(), 36)+".tmp")
+	if err = s.uploadTemp(tmp, f, e); err != nil {
+		client.Remove(s.ctx, s.remote, tmp)
+		return nil, err
+	}
+	err = client.Copy(s.ctx, filesystem.Directory{Client: s.remote.Client}, tmp, s.remote, p)
+	if err == nil {
+		// Copying over an existing file keeps its executable bit.
+		err = s.setRemoteExec(p, e.Exec)
+	}
+	client.Remove(s.ctx, s.remote, tmp)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (s *syncer) setRemoteExec(p string, exec bool) error {
+	node, err := client.Walk(s.ctx, filesystem.Directory{Client: s.remote.Client}, p)
+	if err != nil {
+		return err
+	}
+	defer node.Client.Release()
+	res, release := filesystem.RwFile{Client: node.Client}.SetExec(s.ctx, func(params filesystem.RwFile_setExec_Params) error {
+		params.SetExec(exec)
+		return nil
+	})
+	defer release()
+	_, err = res.Struct()
+	return fserrors.Decode(err)
+}
+
+// Upload the contents of f, whose entry is e, to a new remote file at
+// tmp, and fill in e's size and hash from what was sent.
+func (s *syncer) uploadTemp(tmp string, f *os.File, e *entry) error {
+	file, err := client.Create(s.ctx, s.remote, tmp, e.Exec)
+	if err != nil {
+		return err
+	}
+	defer file.Client.Release()
+
+	h := sha256.New()
+	w := client.NewWriter(s.ctx, file, 0)
+	// The file may be changing under us; what we record is what we
+	// actually sent, so that if it was, the next sync notices.
+	e.Size, err = io.Copy(w, io.TeeReader(f, h))
+	if err2 := w.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return fserrors.Decode(err)
+	}
+	e.Hash = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// Copy the remote node at p to the local side, replacing local, which is
+// what's there now.
+func (s *syncer) download(p string, local, remote *entry) error {
+	if local != nil && local.Dir != remote.Dir {
+		// As in upload.
+		if err := os.Remove(s.localPath(p)); err != nil {
+			return err
+		}
+	}
+	if remote.Dir {
+		if err := os.MkdirAll(s.localPath(p), 0755); err != nil {
+			return err
+		}
+		s.next[p] = &entry{Dir: true}
+		return nil
+	}
+	e, err := s.downloadFile(p, remote.Exec)
+	if err != nil {
+		return err
+	}
+	s.next[p] = e
+	return nil
+}
+
+// Copy the remote file at p to the local side, and return its entry.
+func (s *syncer) downloadFile(p string, exec bool) (*entry, error) {
+	dst := s.localPath(p)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, err
+	}
+	node, err := client.Walk(s.ctx, filesystem.Directory{Client: s.remote.Client}, p)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Client.Release()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), tempPrefix+"*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once it's renamed.
+	sink := &hashingWriter{w: tmp, h: sha256.New()}
+	err = client.ReadTo(s.ctx, filesystem.File{Client: node.Client}, sink)
+	if err == nil {
+		mode := os.FileMode(0644)
+		if exec {
+			mode |= 0111
+		}
+		err = tmp.Chmod(mode)
+	}
+	if err2 := tmp.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return nil, fserrors.Decode(err)
+	}
+	if err = os.Rename(tmp.Name(), dst); err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(dst)
+	if err != nil {
+		return nil, err
+	}
+	e := localEntry(fi)
+	e.Hash = hex.EncodeToString(sink.h.Sum(nil))
+	return e, nil
+}
+
+// Writes to w, and hashes what's written. The stream may deliver writes
+// from more than one goroutine, hence the lock.
+type hashingWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+	h  hash.Hash
+}
+
+func (w *hashingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.w.Write(p)
+	w.h.Write(p[:n])
+	return n, err
+}
+
+// The caller closes the underlying file, once it knows the read worked.
+func (w *hashingWriter) Close() error {
+	return nil
+}
+
+// A directory which still has something in it, after its contents were
+// deleted, was added to on the other side; the additions are copied over
+// later, so it's kept.
+
+func (s *syncer) deleteRemote(p string) error {
+	err := client.Remove(s.ctx, s.remote, p)
+	switch fserrors.CodeOf(err) {
+	case filesystem.ErrorCode_notFound:
+		return nil
+	case filesystem.ErrorCode_notEmpty:
+		log.Printf("Keeping %s: not empty", p)
+		s.next[p] = &entry{Dir: true}
+		return nil
+	}
+	return err
+}
+
+func (s *syncer) deleteLocal(p string) error {
+	err := os.Remove(s.localPath(p))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case fserrors.CodeOf(err) == filesystem.ErrorCode_notEmpty:
+		log.Printf("Keeping %s: not empty", p)
+		s.next[p] = &entry{Dir: true}
+		return nil
+	}
+	return err
+}
+
+func (s *syncer) conflict(a *action) error {
+	if err := os.Rename(s.localPath(a.path), s.localPath(a.conflictPath)); err != nil {
+		return err
+	}
+	if err := s.uploadTree(a.conflictPath); err != nil {
+		return err
+	}
+	return s.download(a.path, nil, a.remote)
+}
+
+// Copy the local node at p, recursively, to the remote side, which
+// doesn't have it.
+func (s *syncer) uploadTree(p string) error {
+	return filepath.WalkDir(s.localPath(p), func(lp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.localRoot, lp)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case d.IsDir():
+			dir, err := client.MkdirAll(s.ctx, s.remote, rel)
+			if err != nil {
+				return err
+			}
+			dir.Client.Release()
+			s.next[rel] = &entry{Dir: true}
+		case d.Type().IsRegular():
+			e, err := s.uploadFile(rel)
+			if err != nil {
+				return err
+			}
+			s.next[rel] = e
+		}
+		return nil
+	})
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+)
+
+// A file with the given contents, standing in for its hash, which is
+// filled in so that planning never has to look at either side.
+func file(contents string) *entry {
+	return &entry{Size: int64(len(contents)), Hash: contents}
+}
+
+func dir() *entry {
+	return &entry{Dir: true}
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name                string
+		base, local, remote map[string]*entry
+		want                map[string]op
+	}{
+		{
+			name:   "first sync",
+			local:  map[string]*entry{"d": dir(), "d/a": file("a")},
+			remote: map[string]*entry{"b": file("b")},
+			want:   map[string]op{"d": opUpload, "d/a": opUpload, "b": opDownload},
+		},
+		{
+			name:   "first sync, same on both sides",
+			local:  map[string]*entry{"d": dir(), "d/a": file("a")},
+			remote: map[string]*entry{"d": dir(), "d/a": file("a")},
+			want:   map[string]op{"d": opNone, "d/a": opNone},
+		},
+		{
+			name:   "first sync, different on each side",
+			local:  map[string]*entry{"a": file("aa")},
+			remote: map[string]*entry{"a": file("bb")},
+			want:   map[string]op{"a": opConflict},
+		},
+		{
+			name:   "unchanged",
+			base:   map[string]*entry{"d": dir(), "d/a": file("a")},
+			local:  map[string]*entry{"d": dir(), "d/a": file("a")},
+			remote: map[string]*entry{"d": dir(), "d/a": file("a")},
+			want:   map[string]op{"d": opNone, "d/a": opNone},
+		},
+		{
+			name:   "unchanged by modification time",
+			base:   map[string]*entry{"a": {Size: 1, ModTime: 5, Hash: "a"}},
+			local:  map[string]*entry{"a": {Size: 1, ModTime: 5}},
+			remote: map[string]*entry{"a": file("a")},
+			want:   map[string]op{"a": opNone},
+		},
+		{
+			name:   "deleted locally",
+			base:   map[string]*entry{"d": dir(), "d/a": file("a")},
+			remote: map[string]*entry{"d": dir(), "d/a": file("a")},
+			want:   map[string]op{"d": opDeleteRemote, "d/a": opDeleteRemote},
+		},
+		{
+			name:  "deleted remotely",
+			base:  map[string]*entry{"d": dir(), "d/a": file("a")},
+			local: map[string]*entry{"d": dir(), "d/a": file("a")},
+			want:  map[string]op{"d": opDeleteLocal, "d/a": opDeleteLocal},
+		},
+		{
+			name: "deleted on both sides",
+			base: map[string]*entry{"a": file("a")},
+			want: map[string]op{"a": opNone},
+		},
+		{
+			name:   "edited locally",
+			base:   map[string]*entry{"a": file("aa"), "b": file("b")},
+			local:  map[string]*entry{"a": file("xx"), "b": file("bbb")},
+			remote: map[string]*entry{"a": file("aa"), "b": file("b")},
+			want:   map[string]op{"a": opUpload, "b": opUpload},
+		},
+		{
+			name:   "edited remotely",
+			base:   map[string]*entry{"a": file("aa"), "b": file("b")},
+			local:  map[string]*entry{"a": file("aa"), "b": file("b")},
+			remote: map[string]*entry{"a": file("xx"), "b": file("bbb")},
+			want:   map[string]op{"a": opDownload, "b": opDownload},
+		},
+		{
+			name:   "made executable locally",
+			base:   map[string]*entry{"a": file("a")},
+			local:  map[string]*entry{"a": {Size: 1, Exec: true, Hash: "a"}},
+			remote: map[string]*entry{"a": file("a")},
+			want:   map[string]op{"a": opUpload},
+		},
+		{
+			name:   "edited the same way on both sides",
+			base:   map[string]*entry{"a": file("a")},
+			local:  map[string]*entry{"a": file("new")},
+			remote: map[string]*entry{"a": file("new")},
+			want:   map[string]op{"a": opNone},
+		},
+		{
+			name:   "edited differently on each side",
+			base:   map[string]*entry{"a": file("a")},
+			local:  map[string]*entry{"a": file("xx")},
+			remote: map[string]*entry{"a": file("yy")},
+			want:   map[string]op{"a": opConflict},
+		},
+		{
+			name:   "deleted locally, edited remotely",
+			base:   map[string]*entry{"a": file("a")},
+			remote: map[string]*entry{"a": file("new")},
+			want:   map[string]op{"a": opDownload},
+		},
+		{
+			name:  "edited locally, deleted remotely",
+			base:  map[string]*entry{"a": file("a")},
+			local: map[string]*entry{"a": file("new")},
+			want:  map[string]op{"a": opUpload},
+		},
+		{
+			name:   "file replaced by a directory locally",
+			base:   map[string]*entry{"a": file("a")},
+			local:  map[string]*entry{"a": dir(), "a/b": file("b")},
+			remote: map[string]*entry{"a": file("a")},
+			want:   map[string]op{"a": opUpload, "a/b": opUpload},
+		},
+		{
+			name:   "directory replaced by a file remotely",
+			base:   map[string]*entry{"a": dir(), "a/b": file("b")},
+			local:  map[string]*entry{"a": dir(), "a/b": file("b")},
+			remote: map[string]*entry{"a": file("a")},
+			want:   map[string]op{"a": opDownload, "a/b": opDeleteLocal},
+		},
+		{
+			// The local directory is moved aside with its contents,
+			// which aren't synced separately.
+			name:   "directory in conflict with a file",
+			local:  map[string]*entry{"a": dir(), "a/b": file("b")},
+			remote: map[string]*entry{"a": file("a")},
+			want:   map[string]op{"a": opConflict},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &syncer{
+				localRoot:     t.TempDir(),
+				base:          test.base,
+				localEntries:  test.local,
+				remoteEntries: test.remote,
+				next:          make(map[string]*entry),
+			}
+			got := make(map[string]op)
+			for _, a := range s.plan() {
+				got[a.path] = a.op
+				if a.op == opConflict && a.conflictPath == "" {
+					t.Errorf("%s: no conflict path", a.path)
+				}
+			}
+			if s.failed {
+				t.Fatal("planning failed")
+			}
+			if len(got) != len(test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+			for p, want := range test.want {
+				if op, ok := got[p]; !ok || op != want {
+					t.Errorf("%s: got %v, want %v", p, op, want)
+				}
+			}
+		})
+	}
+}
+
+// Uploading replaces the remote file, or creates it, and leaves nothing
+// else behind.
+func TestUploadFile(t *testing.T) {
+	ctx := context.Background()
+	remote := memfs.New(0).Root()
+	defer remote.Client.Release()
+	if err := client.WriteFile(ctx, remote, "d/a.txt", []byte("longer old contents"), false); err != nil {
+		t.Fatal(err)
+	}
+	s := &syncer{ctx: ctx, localRoot: t.TempDir(), remote: remote}
+	if err := os.Mkdir(s.localPath("d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"d/a.txt", "d/new.txt"} {
+		if err := os.WriteFile(s.localPath(p), []byte("new"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		e, err := s.uploadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Size != 3 || !e.Exec {
+			t.Errorf("%s: got entry %+v, want a size of 3 and exec", p, e)
+		}
+		got, err := client.ReadFile(ctx, filesystem.Directory{Client: remote.Client}, p)
+		if err != nil {
+			t.Fatal(err)
+		} else if string(got) != "new" {
+			t.Errorf("remote %s contains %q, want %q", p, got, "new")
+		}
+		fi, err := client.Stat(ctx, filesystem.Directory{Client: remote.Client}, p)
+		if err != nil {
+			t.Fatal(err)
+		} else if !fi.Executable() {
+			t.Errorf("remote %s isn't executable", p)
+		}
+	}
+	entries, err := client.ReadDir(ctx, filesystem.Directory{Client: remote.Client}, "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ent := range entries {
+		if strings.HasPrefix(ent.Name(), tempPrefix) {
+			t.Errorf("left d/%s behind", ent.Name())
+		}
+	}
+	if len(entries) != 2 {
+		t.Errorf("d contains %d entries, want 2", len(entries))
+	}
+}
@@ -0,0 +1,386 @@
+package main
+
+// Caching of attributes, directory listings and file contents, so that
+// the many stats, lookups and small reads made by e.g. ls -R or a build
+// don't each cost a round trip.
+//
+// Everything cached expires after a configurable time, so that we
+// notice changes made by other clients. If the server supports
+// Directory.watch, those changes also invalidate the affected entries as
+// soon as we're told about them; see watcher. Changes made through this
+// mount invalidate the affected entries straight away; see
+// Node.invalidate*.
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+// File contents are cached in chunks of this size, aligned to it.
+const chunkSize = maxReadBytes
+
+type cacheConfig struct {
+	// How long attributes, directory listings and file contents stay
+	// valid. Zero disables the respective cache.
+	attrTTL time.Duration
+	dirTTL  time.Duration
+	dataTTL time.Duration
+
+	// The most file data to keep, in bytes.
+	maxData int64
+}
+
+// State shared by all the nodes of a mount. Cached file contents are
+// kept here, in a single LRU list, so that the limit applies to the
+// mount as a whole.
+type cache struct {
+	cacheConfig
+
+	mu sync.Mutex
+
+	// All cached chunks, most recently used at the front.
+	lru *list.List
+
+	// The total size of the chunks in lru.
+	size int64
+}
+
+func newCache(config cacheConfig) *cache {
+	return &cache{cacheConfig: config, lru: list.New()}
+}
+
+type chunk struct {
+	node    *Node
+	index   int64
+	data    []byte
+	eof     bool
+	expires time.Time
+	elem    *list.Element
+}
+
+// A node's share of the cache. The fields are guarded by the cache's
+// mutex.
+type nodeData struct {
+	chunks map[int64]*chunk
+
+	// Bumped whenever the node's contents are invalidated, so that a
+	// read which started before then doesn't cache what it got.
+	gen uint64
+}
+
+// Return the chunk of n's contents with the given index, if it's cached.
+func (c *cache) get(n *Node, index int64) *chunk {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch, ok := n.data.chunks[index]
+	if !ok {
+		return nil
+	}
+	if !time.Now().Before(ch.expires) {
+		c.remove(ch)
+		return nil
+	}
+	c.lru.MoveToFront(ch.elem)
+	return ch
+}
+
+// Return n's current generation.
+func (c *cache) gen(n *Node) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return n.data.gen
+}
+
+// Cache a chunk of n's contents, which was read in generation gen.
+func (c *cache) put(n *Node, gen uint64, index int64, data []byte, eof bool) {
+	if c.dataTTL <= 0 || int64(len(data)) > c.maxData {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n.data.gen != gen {
+		return
+	}
+	if old, ok := n.data.chunks[index]; ok {
+		c.remove(old)
+	}
+	if n.data.chunks == nil {
+		n.data.chunks = make(map[int64]*chunk)
+	}
+	ch := &chunk{
+		node:    n,
+		index:   index,
+		data:    data,
+		eof:     eof,
+		expires: time.Now().Add(c.dataTTL),
+	}
+	ch.elem = c.lru.PushFront(ch)
+	n.data.chunks[index] = ch
+	c.size += int64(len(data))
+	for c.size > c.maxData {
+		c.remove(c.lru.Back().Value.(*chunk))
+	}
+}
+
+func (c *cache) remove(ch *chunk) {
+	c.lru.Remove(ch.elem)
+	delete(ch.node.data.chunks, ch.index)
+	c.size -= int64(len(ch.data))
+}
+
+// Drop all of n's cached contents.
+func (c *cache) drop(n *Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range n.data.chunks {
+		c.remove(ch)
+	}
+	n.data.gen++
+}
+
+// Report whether n's cached contents still match file's. Their size may
+// be the same even if they've changed, so we compare hashes of each
+// cached chunk; the server computes its side, and the calls are
+// pipelined, so this takes one round trip rather than a read of what's
+// cached. Servers which can't hash count as a mismatch.
+func (n *Node) cachedDataValid(ctx context.Context, file filesystem.File) bool {
+	n.cache.mu.Lock()
+	chunks := make([]*chunk, 0, len(n.data.chunks))
+	for _, ch := range n.data.chunks {
+		if len(ch.data) > 0 {
+			chunks = append(chunks, ch)
+		}
+	}
+	n.cache.mu.Unlock()
+	if len(chunks) == 0 {
+		return true
+	}
+
+	futures := make([]filesystem.File_hash_Results_Future, len(chunks))
+	for i, ch := range chunks {
+		ch := ch
+		var release capnp.ReleaseFunc
+		futures[i], release = file.Hash(ctx, func(p filesystem.File_hash_Params) error {
+			p.SetAlgorithm(filesystem.File_HashAlgorithm_sha256)
+			p.SetStartAt(ch.index * chunkSize)
+			p.SetAmount(uint64(len(ch.data)))
+			return nil
+		})
+		defer release()
+	}
+	for i, ch := range chunks {
+		results, err := futures[i].Struct()
+		if err != nil {
+			return false
+		}
+		digest, err := results.Digest()
+		if err != nil {
+			return false
+		}
+		want := sha256.Sum256(ch.data)
+		if !bytes.Equal(digest, want[:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the chunk of file's contents with the given index, from the
+// cache if possible. file is n's capability, or a handle to it.
+func (n *Node) chunk(ctx context.Context, file filesystem.File, index int64) ([]byte, bool, error) {
+	if ch := n.cache.get(n, index); ch != nil {
+		return ch.data, ch.eof, nil
+	}
+	gen := n.cache.gen(n)
+	data, eof, err := readChunk(ctx, file, index*chunkSize)
+	if err != nil {
+		return nil, false, err
+	}
+	n.cache.put(n, gen, index, data, eof)
+	return data, eof, nil
+}
+
+// Read up to chunkSize bytes of file, starting at startAt. Servers may
+// return less than we ask for in one call, so this may take several.
+func readChunk(ctx context.Context, file filesystem.File, startAt int64) ([]byte, bool, error) {
+	buf := make([]byte, 0, chunkSize)
+	for len(buf) < chunkSize {
+		res, release := file.ReadBytes(ctx, func(p filesystem.File_readBytes_Params) error {
+			p.SetStartAt(startAt + int64(len(buf)))
+			p.SetAmount(uint64(chunkSize - len(buf)))
+			return nil
+		})
+		results, err := res.Struct()
+		if err != nil {
+			release()
+			return nil, false, err
+		}
+		data, err := results.Data()
+		if err != nil {
+			release()
+			return nil, false, err
+		}
+		buf = append(buf, data...)
+		eof := results.Eof() || len(data) == 0
+		release()
+		if eof {
+			return buf, true, nil
+		}
+	}
+	return buf, false, nil
+}
+
+// Fill in out from the cached attributes, if they're still valid.
+func (n *Node) cachedAttr(out *fuse.Attr) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !time.Now().Before(n.attrExpires) {
+		return false
+	}
+	*out = n.attr
+	return true
+}
+
+func (n *Node) setCachedAttr(attr *fuse.Attr) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.attr = *attr
+	n.attrExpires = time.Now().Add(n.cache.attrTTL)
+}
+
+// Return the cached listing of the directory, if it's still valid.
+func (n *Node) cachedEntries() ([]fuse.DirEntry, map[string]bool, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !time.Now().Before(n.entriesExpire) {
+		return nil, nil, false
+	}
+	return n.entries, n.names, true
+}
+
+func (n *Node) setCachedEntries(entries []fuse.DirEntry) {
+	names := make(map[string]bool, len(entries))
+	for _, ent := range entries {
+		names[ent.Name] = true
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.entries = entries
+	n.names = names
+	n.entriesExpire = time.Now().Add(n.cache.dirTTL)
+}
+
+// Forget the node's attributes, e.g. because we changed them.
+func (n *Node) invalidateAttr() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.attrExpires = time.Time{}
+}
+
+// Forget the directory's listing, because we've added or removed
+// something.
+func (n *Node) invalidateEntries() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.entries = nil
+	n.names = nil
+	n.entriesExpire = time.Time{}
+}
+
+// Forget the file's contents, and its attributes, which include its
+// size.
+func (n *Node) invalidateData() {
+	n.invalidateAttr()
+	n.cache.drop(n)
+}
+
+// Receives notifications of changes under the root of the mount from the
+// server, and drops what we have cached for the nodes which changed.
+type watcher struct {
+	root *Node
+}
+
+// Ask the server to tell us about changes under n, which must be mounted.
+// The returned handle
+// must be kept for as long as we want to hear about them. Servers needn't
+// support this, in which case the error is unimplemented, and the TTLs are
+// all we have.
+func (n *Node) watch(ctx context.Context) (filesystem.WatchHandle, error) {
+	dir := n.dir()
+	defer dir.Client.Release()
+	res, release := dir.Watch(ctx, func(p filesystem.Directory_watch_Params) error {
+		return p.SetWatcher(filesystem.Directory_Watcher_ServerToClient(&watcher{root: n}, nil))
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return filesystem.WatchHandle{}, err
+	}
+	return filesystem.WatchHandle{Client: results.Handle().Client.AddRef()}, nil
+}
+
+func (w *watcher) Changed(ctx context.Context, p filesystem.Directory_Watcher_changed) error {
+	paths, err := p.Args().Paths()
+	if err != nil {
+		return err
+	}
+	if paths.Len() == 0 {
+		invalidateTree(&w.root.Inode)
+		return nil
+	}
+	for i := 0; i < paths.Len(); i++ {
+		path, err := paths.At(i)
+		if err != nil {
+			return err
+		}
+		if node := w.root.find(path); node != nil {
+			node.invalidate()
+		}
+	}
+	return nil
+}
+
+// Return the Node at path, relative to n, if the kernel knows about it.
+// If it doesn't, we have nothing cached for it.
+func (n *Node) find(path string) *Node {
+	in := &n.Inode
+	if path != "" {
+		for _, name := range strings.Split(path, "/") {
+			if in = in.GetChild(name); in == nil {
+				return nil
+			}
+		}
+	}
+	node, _ := in.Operations().(*Node)
+	return node
+}
+
+// Drop everything cached for in and everything under it.
+func invalidateTree(in *fs.Inode) {
+	if node, ok := in.Operations().(*Node); ok {
+		node.invalidate()
+	}
+	for _, ch := range in.Children() {
+		invalidateTree(ch)
+	}
+}
+
+// Drop everything cached for the node, here and in the kernel.
+func (n *Node) invalidate() {
+	n.invalidateData()
+	n.invalidateEntries()
+	// This fails if the kernel has nothing cached, which is fine.
+	n.NotifyContent(0, 0)
+}
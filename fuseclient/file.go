@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"syscall"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+// The most we ask for in one call to readBytes; servers may cap it lower.
+const maxReadBytes = 64 * 1024
+
+// An open file.
+type File struct {
+	node     *Node
+	rwfile   filesystem.RwFile
+	writable bool
+
+	mu sync.Mutex
+
+	// Locks we hold, by lock owner.
+	locks map[uint64]heldLock
+
+	wmu sync.Mutex
+
+	// The stream for the write in progress, if any. Consecutive writes
+	// to the end of the previous one go to the same stream, rather than
+	// each making a round trip; the data is known to be written only
+	// once the stream is closed, by flush. The stream outlives the
+	// request which started it, so it isn't bound to that request's
+	// context.
+	w    io.WriteCloser
+	wpos int64
+}
+
+var (
+	_ fs.FileReader   = (*File)(nil)
+	_ fs.FileWriter   = (*File)(nil)
+	_ fs.FileFlusher  = (*File)(nil)
+	_ fs.FileFsyncer  = (*File)(nil)
+	_ fs.FileReleaser = (*File)(nil)
+	_ fs.FileGetlker  = (*File)(nil)
+	_ fs.FileSetlker  = (*File)(nil)
+	_ fs.FileSetlkwer = (*File)(nil)
+)
+
+func (n *Node) newFile() *File {
+	return &File{
+		node:     n,
+		rwfile:   filesystem.RwFile{Client: n.cap().Client},
+		writable: n.isWritable(),
+		locks:    make(map[uint64]heldLock),
+	}
+}
+
+func (n *Node) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	accMode := flags & syscall.O_ACCMODE
+	wantWrite := accMode == syscall.O_WRONLY || accMode == syscall.O_RDWR
+	if wantWrite && !n.isWritable() {
+		return nil, 0, syscall.EROFS
+	}
+	f := n.newFile()
+	if wantWrite && flags&syscall.O_TRUNC != 0 {
+		err := truncate(ctx, f.rwfile, 0)
+		n.invalidateData()
+		if err != nil {
+			f.Release(ctx)
+			return nil, 0, writeErrno(err)
+		}
+	}
+	return f, 0, 0
+}
+
+// Finish the write in progress, if any.
+func (f *File) flush() error {
+	f.wmu.Lock()
+	defer f.wmu.Unlock()
+	return f.flushLocked()
+}
+
+func (f *File) flushLocked() error {
+	if f.w == nil {
+		return nil
+	}
+	err := f.w.Close()
+	f.w = nil
+	// Write invalidated the cache too, but reads which started before
+	// the data landed may have refilled it since.
+	f.node.invalidateData()
+	return err
+}
+
+func (f *File) Read(ctx context.Context, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	if err := f.flush(); err != nil {
+		return nil, writeErrno(err)
+	}
+	file := filesystem.File{Client: f.rwfile.Client}
+	n := 0
+	for n < len(dest) {
+		pos := off + int64(n)
+		index := pos / chunkSize
+		data, eof, err := f.node.chunk(ctx, file, index)
+		if err != nil {
+			return nil, toErrno(err)
+		}
+		start := int(pos - index*chunkSize)
+		if start >= len(data) {
+			break
+		}
+		n += copy(dest[n:], data[start:])
+		if eof && n < len(dest) {
+			break
+		}
+	}
+	return fuse.ReadResultData(dest[:n]), 0
+}
+
+func (f *File) Write(ctx context.Context, data []byte, off int64) (uint32, syscall.Errno) {
+	if !f.writable {
+		return 0, syscall.EROFS
+	}
+	f.wmu.Lock()
+	defer f.wmu.Unlock()
+	if f.w != nil && f.wpos != off {
+		if err := f.flushLocked(); err != nil {
+			return 0, writeErrno(err)
+		}
+	}
+	if f.w == nil {
+		f.w = client.NewWriter(context.Background(), f.rwfile, off)
+		f.wpos = off
+	}
+	f.node.invalidateData()
+	n, err := f.w.Write(data)
+	f.wpos += int64(n)
+	if err != nil {
+		f.flushLocked()
+		return uint32(n), writeErrno(err)
+	}
+	return uint32(n), 0
+}
+
+func (f *File) Flush(ctx context.Context) syscall.Errno {
+	return writeErrno(f.flush())
+}
+
+func (f *File) Fsync(ctx context.Context, flags uint32) syscall.Errno {
+	return writeErrno(f.flush())
+}
+
+func (f *File) Release(ctx context.Context) syscall.Errno {
+	err := f.flush()
+	f.mu.Lock()
+	for owner, held := range f.locks {
+		held.handle.Client.Release()
+		delete(f.locks, owner)
+	}
+	f.mu.Unlock()
+	f.rwfile.Client.Release()
+	return writeErrno(err)
+}
@@ -0,0 +1,136 @@
+package main
+
+// Support for flock/fcntl locks, via RwFile.lock.
+//
+// The protocol only knows about whole-file locks, so byte-range (fcntl)
+// locks are treated as locking the whole file. This is coarser than
+// what the caller asked for, but never lets two conflicting locks be
+// held at once. Likewise, unlocking any range drops the whole lock.
+//
+// There's also no way to ask who holds a lock, so F_GETLK tries to take
+// the lock asked about, and reports a conflict if it can't. A caller
+// holding a shared lock and asking about an exclusive one would only find
+// their own lock that way, so in that case we look for exclusive locks
+// held by others instead; shared locks held by other clients can't be
+// told apart from the caller's, and aren't reported.
+
+import (
+	"context"
+	"syscall"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+type heldLock struct {
+	exclusive bool
+	handle    filesystem.LockHandle
+}
+
+// Take a lock on the file, returning a handle, or a null handle if wait
+// is false and the lock is held by someone else.
+func (f *File) lock(ctx context.Context, exclusive, wait bool) (filesystem.LockHandle, error) {
+	if wait {
+		res, release := f.rwfile.Lock(ctx, func(p filesystem.RwFile_lock_Params) error {
+			p.SetExclusive(exclusive)
+			return nil
+		})
+		defer release()
+		results, err := res.Struct()
+		if err != nil {
+			return filesystem.LockHandle{}, err
+		}
+		return filesystem.LockHandle{Client: results.Handle().Client.AddRef()}, nil
+	}
+	res, release := f.rwfile.TryLock(ctx, func(p filesystem.RwFile_tryLock_Params) error {
+		p.SetExclusive(exclusive)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil || !results.HasHandle() {
+		return filesystem.LockHandle{}, err
+	}
+	return filesystem.LockHandle{Client: results.Handle().Client.AddRef()}, nil
+}
+
+func (f *File) Getlk(ctx context.Context, owner uint64, lk *fuse.FileLock, flags uint32, out *fuse.FileLock) syscall.Errno {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	exclusive := lk.Typ == syscall.F_WRLCK
+	if held, ok := f.locks[owner]; ok {
+		if held.exclusive || !exclusive {
+			// Our own locks never conflict with us.
+			out.Typ = syscall.F_UNLCK
+			return 0
+		}
+		for other := range f.locks {
+			if other != owner {
+				// Another owner shares the lock with us.
+				*out = *lk
+				out.Pid = 0
+				return 0
+			}
+		}
+		exclusive = false
+	}
+	handle, err := f.lock(ctx, exclusive, false)
+	if err != nil {
+		return toErrno(err)
+	}
+	if handle.Client != nil {
+		handle.Client.Release()
+		out.Typ = syscall.F_UNLCK
+	} else {
+		*out = *lk
+		out.Pid = 0
+	}
+	return 0
+}
+
+func (f *File) Setlk(ctx context.Context, owner uint64, lk *fuse.FileLock, flags uint32) syscall.Errno {
+	return f.setLock(ctx, owner, lk, false)
+}
+
+func (f *File) Setlkw(ctx context.Context, owner uint64, lk *fuse.FileLock, flags uint32) syscall.Errno {
+	// ctx is canceled if the caller is interrupted, which abandons
+	// the wait.
+	return f.setLock(ctx, owner, lk, true)
+}
+
+func (f *File) setLock(ctx context.Context, owner uint64, lk *fuse.FileLock, wait bool) syscall.Errno {
+	exclusive := lk.Typ == syscall.F_WRLCK
+
+	f.mu.Lock()
+	held, ok := f.locks[owner]
+	if ok && lk.Typ != syscall.F_UNLCK && held.exclusive == exclusive {
+		f.mu.Unlock()
+		return 0
+	}
+	if ok {
+		// Either unlocking, or changing the type of the lock. The
+		// protocol can't do the latter atomically, so drop the old
+		// lock first; otherwise upgrading a shared lock would deadlock
+		// against ourselves.
+		held.handle.Client.Release()
+		delete(f.locks, owner)
+	}
+	f.mu.Unlock()
+	if lk.Typ == syscall.F_UNLCK {
+		return 0
+	}
+
+	// Don't hold f.mu while waiting, so other owners can still unlock.
+	handle, err := f.lock(ctx, exclusive, wait)
+	if err != nil {
+		return toErrno(err)
+	}
+	if handle.Client == nil {
+		return syscall.EAGAIN
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.locks[owner] = heldLock{exclusive: exclusive, handle: handle}
+	return 0
+}
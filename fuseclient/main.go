@@ -1,166 +1,112 @@
 package main
 
 // This is a fuse filesystem that speaks the filesystem capnp protocol.
-// It is still a work in progress.
+// It connects to a unix socket or TCP address, such as one served by
+// fsserver, and mounts the bootstrap capability of the connection, which
+// must be a Directory.
+//
+// If the capability we're given isn't writable (i.e. it is a Directory
+// rather than an RwDirectory), the filesystem is mounted read-only, and
+// individual read-only nodes further down report EROFS for anything that
+// would modify them.
 
 import (
 	"context"
 	"flag"
-	"io"
 	"log"
+	"net"
+	"os"
+	"time"
 
 	"zenhack.net/go/sandstorm-filesystem/filesystem"
-	"zenhack.net/go/sandstorm-filesystem/filesystem/local"
 
-	"github.com/hanwen/go-fuse/fuse"
-	"github.com/hanwen/go-fuse/fuse/nodefs"
-)
-
-var (
-	// For now we just export the filesystem to ourselves via capnp; will
-	// actually do a network connection later.
-	src = flag.String("src", "", "directory to serve")
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
 
-	dst = flag.String("dst", "", "mountpoint")
+	"zombiezen.com/go/capnproto2"
+	"zombiezen.com/go/capnproto2/rpc"
 )
 
-type Node struct {
-	ctx context.Context
-	nodefs.Node
-	capnode filesystem.Node
-}
-
-type dirEntStream struct {
-	haveDone bool
-	onDone   chan error
-	ents     []fuse.DirEntry
-}
-
-func newDirEntStream() *dirEntStream {
-	return &dirEntStream{
-		haveDone: false,
-		onDone:   make(chan error, 1),
-		ents:     []fuse.DirEntry{},
-	}
-}
-
-func modeFromStatInfo(info filesystem.StatInfo) uint32 {
-	mode := uint32(0400)
-	if info.Executable() {
-		mode |= 0100
-	}
-	if info.Writable() {
-		mode |= 0200
-	}
-	switch info.Which() {
-	case filesystem.StatInfo_Which_dir:
-		mode |= fuse.S_IFDIR
-	case filesystem.StatInfo_Which_file:
-		mode |= fuse.S_IFREG
-	}
-	return mode
-}
-
-func convertDirEntry(ent filesystem.Directory_Entry) (fuse.DirEntry, error) {
-	info, err := ent.Info()
-	if err != nil {
-		return fuse.DirEntry{}, err
-	}
-	name, err := ent.Name()
-	if err != nil {
-		return fuse.DirEntry{}, err
-	}
-	return fuse.DirEntry{
-		Mode: modeFromStatInfo(info),
-		Name: name,
-	}, nil
-}
-
-func (s *dirEntStream) Push(p filesystem.Directory_Entry_Stream_push) error {
-	if s.haveDone {
-		return nil // TODO: error
-	}
-	ents, err := p.Params.Entries()
-	if err != nil {
-		return err
-	}
-	for i := 0; i < ents.Len(); i++ {
-		ent := ents.At(i)
-		fuseEnt, err := convertDirEntry(ent)
-		if err != nil {
-			return err
-		}
-		s.ents = append(s.ents, fuseEnt)
-	}
-	return nil
-}
-
-func (s *dirEntStream) Done(filesystem.Directory_Entry_Stream_done) error {
-	if s.haveDone {
-		return nil // TODO: error
-	}
-	s.haveDone = true
-	s.onDone <- io.EOF
-	return nil
-}
+var (
+	network = flag.String("network", "unix", `network to connect to ("unix" or "tcp")`)
+	addr    = flag.String("addr", "", "address to connect to")
+	dst     = flag.String("dst", "", "mountpoint")
 
-func (s *dirEntStream) Close() error {
-	if !s.haveDone {
-		s.haveDone = true
-		s.onDone <- io.ErrUnexpectedEOF
-	}
-	return nil
-}
+	// Other clients may change the filesystem behind our back, so
+	// these shouldn't be too long.
+	entryTTL = flag.Duration("entry-ttl", time.Second, "how long the kernel may cache names")
+	attrTTL  = flag.Duration("attr-ttl", time.Second, "how long the kernel and fuseclient may cache attributes")
+	negTTL   = flag.Duration("negative-ttl", time.Second, "how long the kernel may cache failed lookups")
+	dirTTL   = flag.Duration("dir-ttl", time.Second, "how long fuseclient may cache directory listings")
+	dataTTL  = flag.Duration("data-ttl", 5*time.Second, "how long fuseclient may cache file contents")
 
-func (n *Node) OpenDir(ctx *fuse.Context) ([]fuse.DirEntry, fuse.Status) {
-	stream := newDirEntStream()
-	_, err := filesystem.Directory{n.capnode.Client}.
-		List(n.ctx, func(p filesystem.Directory_list_Params) error {
-			p.SetStream(filesystem.Directory_Entry_Stream_ServerToClient(stream))
-			return nil
-		}).Struct()
-	if err != nil {
-		return nil, fuse.ToStatus(err)
-	}
-	err = <-stream.onDone
-	if err == io.EOF {
-		err = nil
-	}
-	return stream.ents, fuse.ToStatus(err)
-}
+	cacheSize = flag.Int64("cache-size", 64<<20, "the most file contents to cache, in bytes")
 
-func (n *Node) GetAttr(out *fuse.Attr, file nodefs.File, context *fuse.Context) fuse.Status {
-	info, err := n.capnode.Stat(n.ctx, nil).Info().Struct()
-	if err != nil {
-		return fuse.ToStatus(err)
-	}
-	out.Mode = modeFromStatInfo(info)
-	out.Owner = context.Owner
-	switch info.Which() {
-	case filesystem.StatInfo_Which_dir:
-	case filesystem.StatInfo_Which_file:
-		out.Size = uint64(info.File().Size())
-	}
-	return fuse.OK
-}
+	debug = flag.Bool("debug", false, "log each fuse request")
+)
 
 func main() {
 	flag.Parse()
-	if *src == "" || *dst == "" {
-		log.Fatal("usage")
+	if *addr == "" || *dst == "" {
+		log.Fatal("usage: fuseclient [-network unix|tcp] -addr <address> -dst <mountpoint>")
 	}
-	node, err := local.NewNode(*src)
+	c, err := net.Dial(*network, *addr)
 	if err != nil {
 		log.Fatal(err)
 	}
-	root := &Node{
-		ctx:     context.Background(),
-		Node:    nodefs.NewDefaultNode(),
-		capnode: node.MakeClient(),
+	conn := rpc.NewConn(rpc.NewStreamTransport(c), nil)
+	defer conn.Close()
+	ctx := context.Background()
+	cache := newCache(cacheConfig{
+		attrTTL: *attrTTL,
+		dirTTL:  *dirTTL,
+		dataTTL: *dataTTL,
+		maxData: *cacheSize,
+	})
+	root, err := newRoot(ctx, filesystem.Node{Client: conn.Bootstrap(ctx)}, cache)
+	if err != nil {
+		log.Fatal(err)
 	}
-	srv, _, err := nodefs.MountRoot(*dst, root, nil)
+	opts := &fs.Options{
+		EntryTimeout:    entryTTL,
+		AttrTimeout:     attrTTL,
+		NegativeTimeout: negTTL,
+		UID:             uint32(os.Getuid()),
+		GID:             uint32(os.Getgid()),
+		MountOptions: fuse.MountOptions{
+			// Needed for flock & fcntl locks to be passed through
+			// to us.
+			EnableLocks: true,
+			FsName:      *network + ":" + *addr,
+			Name:        "sandstorm-filesystem",
+			// Try mount(2) first, so that we work without
+			// fusermount when we're root (e.g. in a container).
+			DirectMount: true,
+			Debug:       *debug,
+		},
+	}
+	if !root.writable {
+		opts.Options = append(opts.Options, "ro")
+	}
+	srv, err := fs.Mount(*dst, root, opts)
 	if err != nil {
 		log.Fatal(err)
 	}
-	srv.Serve()
+	// Only once we're mounted, since invalidating the kernel's caches
+	// needs the mount.
+	if handle, err := root.watch(ctx); err == nil {
+		defer handle.Client.Release()
+	} else if !capnp.IsUnimplemented(err) {
+		log.Print("Can't watch for changes: ", err)
+	}
+	go func() {
+		// Nothing works once the connection is gone, so unmount
+		// rather than leave a mountpoint that only returns errors.
+		<-conn.Done()
+		log.Print("Connection closed; unmounting")
+		if err := srv.Unmount(); err != nil {
+			log.Print(err)
+		}
+	}()
+	srv.Wait()
 }
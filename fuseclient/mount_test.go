@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+
+	fusefs "github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"zombiezen.com/go/capnproto2/rpc"
+)
+
+// Serve a fresh memfs over a unix socket, as fsserver would, mount it,
+// and return the mountpoint and the memfs root, for checking what
+// arrived at the server. If watch is false, the mount doesn't watch for
+// changes, and has only its TTLs to go on.
+func mountMemfs(t *testing.T, watch bool) (string, filesystem.RwDirectory) {
+	if _, err := os.Stat("/dev/fuse"); err != nil {
+		t.Skip("fuse isn't available: ", err)
+	}
+	ctx := context.Background()
+	root := memfs.New(0).Root()
+	t.Cleanup(root.Client.Release)
+
+	tmp := t.TempDir()
+	l, err := net.Listen("unix", filepath.Join(tmp, "sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		conn := rpc.NewConn(rpc.NewStreamTransport(c), &rpc.Options{
+			BootstrapClient: root.Client.AddRef(),
+		})
+		<-conn.Done()
+	}()
+
+	c, err := net.Dial("unix", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := rpc.NewConn(rpc.NewStreamTransport(c), nil)
+	t.Cleanup(func() { conn.Close() })
+	cache := newCache(cacheConfig{
+		attrTTL: time.Second,
+		dirTTL:  time.Second,
+		dataTTL: 5 * time.Second,
+		maxData: 1 << 20,
+	})
+	node, err := newRoot(ctx, filesystem.Node{Client: conn.Bootstrap(ctx)}, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mnt := filepath.Join(tmp, "mnt")
+	if err = os.Mkdir(mnt, 0700); err != nil {
+		t.Fatal(err)
+	}
+	ttl := time.Second
+	srv, err := fusefs.Mount(mnt, node, &fusefs.Options{
+		EntryTimeout: &ttl,
+		AttrTimeout:  &ttl,
+		MountOptions: fuse.MountOptions{
+			EnableLocks: true,
+			DirectMount: true,
+		},
+	})
+	if err != nil {
+		// Having /dev/fuse doesn't mean we're allowed to mount.
+		t.Skip("can't mount: ", err)
+	}
+	t.Cleanup(func() {
+		if err := srv.Unmount(); err != nil {
+			t.Error("unmount: ", err)
+		}
+	})
+	if watch {
+		handle, err := node.watch(ctx)
+		if err != nil {
+			t.Fatal("watch: ", err)
+		}
+		t.Cleanup(handle.Client.Release)
+	}
+	return mnt, root
+}
+
+// Check that the file at p under root on the server contains want.
+func checkServerFile(ctx context.Context, t *testing.T, root filesystem.RwDirectory, p string, want []byte) {
+	t.Helper()
+	got, err := client.ReadFile(ctx, filesystem.Directory{Client: root.Client}, p)
+	if err != nil {
+		t.Errorf("server: %v", err)
+	} else if !bytes.Equal(got, want) {
+		t.Errorf("server: %s contains %q, want %q", p, got, want)
+	}
+}
+
+func checkGone(t *testing.T, p string) {
+	t.Helper()
+	if _, err := os.Lstat(p); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("%s: got %v, want it to be gone", p, err)
+	}
+}
+
+func TestMount(t *testing.T) {
+	ctx := context.Background()
+	mnt, root := mountMemfs(t, true)
+
+	// Read a file written on the server.
+	if err := client.WriteFile(ctx, root, "from-server.txt", []byte("hello"), false); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(mnt, "from-server.txt"))
+	if err != nil {
+		t.Fatal(err)
+	} else if string(got) != "hello" {
+		t.Errorf("read %q, want %q", got, "hello")
+	}
+
+	// Write, including at an offset.
+	p := filepath.Join(mnt, "a.txt")
+	if err = os.WriteFile(p, []byte("abcdef"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.WriteAt([]byte("XY"), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	checkServerFile(ctx, t, root, "a.txt", []byte("abXYef"))
+	if got, err = os.ReadFile(p); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "abXYef" {
+		t.Errorf("read %q back, want %q", got, "abXYef")
+	}
+
+	// Mkdir.
+	if err = os.Mkdir(filepath.Join(mnt, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := client.Stat(ctx, filesystem.Directory{Client: root.Client}, "dir")
+	if err != nil {
+		t.Fatal("server: ", err)
+	} else if !fi.IsDir() {
+		t.Error("server: dir isn't a directory")
+	}
+
+	// Rename into the new directory, and then over an existing file,
+	// as editors do when saving.
+	if err = os.Rename(p, filepath.Join(mnt, "dir", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	checkGone(t, p)
+	checkServerFile(ctx, t, root, "dir/b.txt", []byte("abXYef"))
+	if err = os.WriteFile(filepath.Join(mnt, "dir", ".b.txt.swp"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Rename(filepath.Join(mnt, "dir", ".b.txt.swp"), filepath.Join(mnt, "dir", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	checkServerFile(ctx, t, root, "dir/b.txt", []byte("new"))
+	dirEntries, err := client.ReadDir(ctx, filesystem.Directory{Client: root.Client}, "dir")
+	if err != nil {
+		t.Fatal("server: ", err)
+	}
+	if len(dirEntries) != 1 || dirEntries[0].Name() != "b.txt" {
+		t.Errorf("server: dir contains %v after renaming, want just b.txt", dirEntries)
+	}
+	if got, err = os.ReadFile(filepath.Join(mnt, "dir", "b.txt")); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "new" {
+		t.Errorf("read %q after rename, want %q", got, "new")
+	}
+
+	// Unlink and rmdir.
+	if err = os.Remove(filepath.Join(mnt, "dir")); err == nil {
+		t.Error("removed a non-empty directory")
+	}
+	if err = os.Remove(filepath.Join(mnt, "dir", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Remove(filepath.Join(mnt, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	checkGone(t, filepath.Join(mnt, "dir"))
+	entries, err := client.ReadDir(ctx, filesystem.Directory{Client: root.Client}, "")
+	if err != nil {
+		t.Fatal("server: ", err)
+	}
+	var names []string
+	for _, ent := range entries {
+		names = append(names, ent.Name())
+	}
+	sort.Strings(names)
+	if len(names) != 1 || names[0] != "from-server.txt" {
+		t.Errorf("server has %v, want only from-server.txt", names)
+	}
+}
+
+// Changes made on the server show up before the cached contents expire.
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+	mnt, root := mountMemfs(t, true)
+	p := filepath.Join(mnt, "f.txt")
+	if err := client.WriteFile(ctx, root, "f.txt", []byte("old"), false); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(p); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "old" {
+		t.Fatalf("read %q, want %q", got, "old")
+	}
+
+	// The same size, so that only the contents differ. The data TTL is
+	// five seconds, and the entry TTL, after which the file is looked up
+	// again and its contents checked, is one, so seeing the change sooner
+	// than that means we were told.
+	if err := client.WriteFile(ctx, root, "f.txt", []byte("new"), false); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for {
+		got, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) == "new" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("still reading %q", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Looking a name up again, once the kernel's entry has expired, gets the
+// inode we already have, along with whatever is cached for it.
+func TestRelookup(t *testing.T) {
+	ctx := context.Background()
+	mnt, root := mountMemfs(t, false)
+	if err := client.WriteFile(ctx, root, "f.txt", []byte("data"), false); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(mnt, "f.txt")
+	before, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Longer than the entry TTL.
+	time.Sleep(1100 * time.Millisecond)
+	after, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ino := func(fi os.FileInfo) uint64 { return fi.Sys().(*syscall.Stat_t).Ino }
+	if ino(before) != ino(after) {
+		t.Errorf("inode changed from %d to %d", ino(before), ino(after))
+	}
+
+	// Cached contents are dropped when the file is looked up again, if
+	// they've changed, even if the size hasn't.
+	if got, err := os.ReadFile(p); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "data" {
+		t.Fatalf("read %q, want %q", got, "data")
+	}
+	if err = client.WriteFile(ctx, root, "f.txt", []byte("DATA"), false); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if got, err := os.ReadFile(p); err != nil {
+		t.Fatal(err)
+	} else if string(got) != "DATA" {
+		t.Errorf("read %q after a change of the same size, want %q", got, "DATA")
+	}
+}
+
+func getLock(t *testing.T, f *os.File, typ int16) int16 {
+	t.Helper()
+	lk := syscall.Flock_t{Type: typ, Whence: io.SeekStart}
+	if err := syscall.FcntlFlock(f.Fd(), syscall.F_GETLK, &lk); err != nil {
+		t.Fatal(err)
+	}
+	return lk.Type
+}
+
+// F_GETLK reports locks held by other clients, but not the caller's own,
+// even when they hold a shared lock and ask about an exclusive one.
+func TestGetlk(t *testing.T) {
+	ctx := context.Background()
+	mnt, root := mountMemfs(t, false)
+	if err := client.WriteFile(ctx, root, "f.txt", []byte("data"), false); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(filepath.Join(mnt, "f.txt"), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	node, err := client.Walk(ctx, filesystem.Directory{Client: root.Client}, "f.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Client.Release()
+	res, release := filesystem.RwFile{Client: node.Client}.TryLock(ctx, func(p filesystem.RwFile_tryLock_Params) error {
+		p.SetExclusive(true)
+		return nil
+	})
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := results.Handle().Client.AddRef()
+	release()
+	if typ := getLock(t, f, syscall.F_RDLCK); typ == syscall.F_UNLCK {
+		t.Error("another client's exclusive lock wasn't reported")
+	}
+	other.Release()
+
+	lk := syscall.Flock_t{Type: syscall.F_RDLCK, Whence: io.SeekStart}
+	if err = syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, &lk); err != nil {
+		t.Fatal(err)
+	}
+	if typ := getLock(t, f, syscall.F_WRLCK); typ != syscall.F_UNLCK {
+		t.Errorf("asking about an exclusive lock while holding a shared one reported a conflict of type %d", typ)
+	}
+}
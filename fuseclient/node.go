@@ -0,0 +1,571 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"golang.org/x/sys/unix"
+
+	"zombiezen.com/go/capnproto2"
+)
+
+// A Node is a file or directory in the mounted filesystem. Each Node
+// holds the capability for the node it represents.
+type Node struct {
+	fs.Inode
+
+	cache *cache
+
+	// Cached contents; guarded by cache.mu.
+	data nodeData
+
+	mu sync.Mutex
+
+	// The capability for this node. This is replaced if the node is
+	// renamed, so use cap() to get at it.
+	capnode filesystem.Node
+
+	// Whether capnode is an RwDirectory or RwFile.
+	writable bool
+
+	// Cached attributes.
+	attr        fuse.Attr
+	attrExpires time.Time
+
+	// For directories, the cached listing, and the names in it.
+	entries       []fuse.DirEntry
+	names         map[string]bool
+	entriesExpire time.Time
+}
+
+var (
+	_ fs.NodeLookuper    = (*Node)(nil)
+	_ fs.NodeGetattrer   = (*Node)(nil)
+	_ fs.NodeSetattrer   = (*Node)(nil)
+	_ fs.NodeReaddirer   = (*Node)(nil)
+	_ fs.NodeOpener      = (*Node)(nil)
+	_ fs.NodeCreater     = (*Node)(nil)
+	_ fs.NodeMkdirer     = (*Node)(nil)
+	_ fs.NodeUnlinker    = (*Node)(nil)
+	_ fs.NodeRmdirer     = (*Node)(nil)
+	_ fs.NodeRenamer     = (*Node)(nil)
+	_ fs.NodeOnForgetter = (*Node)(nil)
+)
+
+// Return the Node for the root of the filesystem, which is capnode. capnode
+// is consumed.
+func newRoot(ctx context.Context, capnode filesystem.Node, cache *cache) (*Node, error) {
+	var attr fuse.Attr
+	which, writable, err := stat(ctx, capnode, &attr)
+	if err != nil {
+		capnode.Client.Release()
+		return nil, err
+	}
+	if which != filesystem.StatInfo_Which_dir {
+		capnode.Client.Release()
+		return nil, errNotADirectory
+	}
+	n := &Node{cache: cache, capnode: capnode, writable: writable}
+	n.setCachedAttr(&attr)
+	return n, nil
+}
+
+var errNotADirectory = fserrors.New(filesystem.ErrorCode_notADirectory, "Not a directory")
+
+// Return a reference to the node's capability. The caller must release
+// it.
+func (n *Node) cap() filesystem.Node {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return filesystem.Node{Client: n.capnode.Client.AddRef()}
+}
+
+func (n *Node) dir() filesystem.Directory {
+	return filesystem.Directory{Client: n.cap().Client}
+}
+
+func (n *Node) rwDir() filesystem.RwDirectory {
+	return filesystem.RwDirectory{Client: n.cap().Client}
+}
+
+// Replace the node's capability with c, which is consumed.
+func (n *Node) setCap(c filesystem.Node, writable bool) {
+	n.mu.Lock()
+	old := n.capnode
+	n.capnode = c
+	n.writable = writable
+	n.mu.Unlock()
+	old.Client.Release()
+	n.invalidateData()
+	n.invalidateEntries()
+}
+
+// Replace the node's capability with c, which is consumed and was just
+// looked up again, and its attributes with attr. Unlike setCap, this
+// keeps the cached contents, as long as they still match the file's.
+func (n *Node) relookup(ctx context.Context, c filesystem.Node, writable bool, attr *fuse.Attr) {
+	n.mu.Lock()
+	old := n.capnode
+	n.capnode = c
+	n.writable = writable
+	stale := n.attr.Size != attr.Size
+	n.mu.Unlock()
+	old.Client.Release()
+	if !stale {
+		file := n.cap()
+		stale = !n.cachedDataValid(ctx, filesystem.File{Client: file.Client})
+		file.Client.Release()
+	}
+	if stale {
+		n.cache.drop(n)
+	}
+	n.setCachedAttr(attr)
+}
+
+func (n *Node) isWritable() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.writable
+}
+
+func (n *Node) OnForget() {
+	n.cache.drop(n)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.capnode.Client.Release()
+}
+
+// Convert an error from a call into an errno, using the error code the
+// server sent, if any.
+func toErrno(err error) syscall.Errno {
+	return fserrors.Errno(err)
+}
+
+// Like toErrno, but for calls which modify the filesystem. If the
+// capability doesn't support the call, it's read-only.
+func writeErrno(err error) syscall.Errno {
+	if capnp.IsUnimplemented(err) {
+		return syscall.EROFS
+	}
+	return toErrno(err)
+}
+
+func modeFromStatInfo(info filesystem.StatInfo) uint32 {
+	mode := uint32(0400)
+	if info.Executable() {
+		mode |= 0100
+	}
+	if info.Writable() {
+		mode |= 0200
+	}
+	switch info.Which() {
+	case filesystem.StatInfo_Which_dir:
+		mode |= syscall.S_IFDIR | 0100
+	case filesystem.StatInfo_Which_file:
+		mode |= syscall.S_IFREG
+	}
+	return mode
+}
+
+func setAttr(out *fuse.Attr, info filesystem.StatInfo) {
+	out.Mode = modeFromStatInfo(info)
+	out.Nlink = 1
+	if info.Which() == filesystem.StatInfo_Which_file {
+		out.Size = uint64(info.File().Size())
+		out.Blocks = (out.Size + 511) / 512
+	}
+}
+
+// Stat capnode, filling in out.
+func stat(ctx context.Context, capnode filesystem.Node, out *fuse.Attr) (filesystem.StatInfo_Which, bool, error) {
+	res, release := capnode.Stat(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return 0, false, err
+	}
+	info, err := results.Info()
+	if err != nil {
+		return 0, false, err
+	}
+	setAttr(out, info)
+	return info.Which(), info.Writable(), nil
+}
+
+// Return the inode for n's child called name, which is capnode, and fill
+// in out. capnode is consumed. If we already have an inode for the child,
+// it's reused, so that what's cached for it isn't thrown away.
+func (n *Node) newChild(ctx context.Context, name string, capnode filesystem.Node, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	which, writable, err := stat(ctx, capnode, &out.Attr)
+	if err != nil {
+		capnode.Client.Release()
+		return nil, toErrno(err)
+	}
+	mode := uint32(syscall.S_IFREG)
+	if which == filesystem.StatInfo_Which_dir {
+		mode = syscall.S_IFDIR
+	}
+	if ch := n.GetChild(name); ch != nil && ch.Mode() == mode {
+		if child, ok := ch.Operations().(*Node); ok {
+			child.relookup(ctx, capnode, writable, &out.Attr)
+			return ch, 0
+		}
+	}
+	child := &Node{cache: n.cache, capnode: capnode, writable: writable}
+	child.setCachedAttr(&out.Attr)
+	return n.NewInode(ctx, child, fs.StableAttr{Mode: mode}), 0
+}
+
+func (n *Node) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if _, names, ok := n.cachedEntries(); ok && !names[name] {
+		// Builds look for lots of files that aren't there; save them
+		// the round trip.
+		return nil, syscall.ENOENT
+	}
+	dir := n.dir()
+	defer dir.Client.Release()
+	child, err := client.Walk(ctx, dir, name)
+	if err != nil {
+		return nil, toErrno(err)
+	}
+	return n.newChild(ctx, name, child, out)
+}
+
+func (n *Node) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	if f, ok := fh.(*File); ok {
+		// Make sure the size reflects anything we've written.
+		if err := f.flush(); err != nil {
+			return writeErrno(err)
+		}
+	}
+	if n.cachedAttr(&out.Attr) {
+		return 0
+	}
+	capnode := n.cap()
+	defer capnode.Client.Release()
+	_, _, err := stat(ctx, capnode, &out.Attr)
+	if err != nil {
+		return toErrno(err)
+	}
+	n.setCachedAttr(&out.Attr)
+	return 0
+}
+
+// Only the size and the exec bits mean anything to the protocol. We
+// truncate files, set or clear the executable flag according to the
+// mode, and ignore everything else; in particular there are no
+// timestamps, but things like touch(1) and cp -p want to set them, so
+// we pretend that worked.
+func (n *Node) Setattr(ctx context.Context, fh fs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	size, setSize := in.GetSize()
+	mode, setMode := in.GetMode()
+	if (setSize || setMode) && !n.IsDir() {
+		if !n.isWritable() {
+			return syscall.EROFS
+		}
+		capnode := n.cap()
+		defer capnode.Client.Release()
+		file := filesystem.RwFile{Client: capnode.Client}
+		if setSize {
+			if f, ok := fh.(*File); ok {
+				if err := f.flush(); err != nil {
+					return writeErrno(err)
+				}
+			}
+			err := truncate(ctx, file, size)
+			n.invalidateData()
+			if err != nil {
+				return writeErrno(err)
+			}
+		}
+		if setMode {
+			err := setExec(ctx, file, mode&0111 != 0)
+			n.invalidateAttr()
+			if err != nil {
+				return writeErrno(err)
+			}
+		}
+	}
+	return n.Getattr(ctx, fh, out)
+}
+
+func truncate(ctx context.Context, file filesystem.RwFile, size uint64) error {
+	res, release := file.Truncate(ctx, func(p filesystem.RwFile_truncate_Params) error {
+		p.SetSize(size)
+		return nil
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func setExec(ctx context.Context, file filesystem.RwFile, exec bool) error {
+	res, release := file.SetExec(ctx, func(p filesystem.RwFile_setExec_Params) error {
+		p.SetExec(exec)
+		return nil
+	})
+	defer release()
+	_, err := res.Struct()
+	return err
+}
+
+func (n *Node) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	if entries, _, ok := n.cachedEntries(); ok {
+		return fs.NewListDirStream(entries), 0
+	}
+	dir := n.dir()
+	defer dir.Client.Release()
+	entries, err := client.List(ctx, dir)
+	if err != nil {
+		return nil, toErrno(err)
+	}
+	ret := make([]fuse.DirEntry, len(entries))
+	for i, ent := range entries {
+		mode := uint32(syscall.S_IFREG)
+		if ent.IsDir() {
+			mode = syscall.S_IFDIR
+		}
+		ret[i] = fuse.DirEntry{Name: ent.Name(), Mode: mode}
+	}
+	n.setCachedEntries(ret)
+	return fs.NewListDirStream(ret), 0
+}
+
+func (n *Node) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if !n.isWritable() {
+		return nil, syscall.EROFS
+	}
+	defer n.invalidateEntries()
+	dir := n.rwDir()
+	defer dir.Client.Release()
+	res, release := dir.Mkdir(ctx, func(p filesystem.RwDirectory_mkdir_Params) error {
+		return p.SetName(name)
+	})
+	defer release()
+	if _, err := res.Struct(); err != nil {
+		return nil, writeErrno(err)
+	}
+	// Not all implementations return the new directory, so walk to it.
+	child, err := client.Walk(ctx, filesystem.Directory{Client: dir.Client}, name)
+	if err != nil {
+		return nil, toErrno(err)
+	}
+	return n.newChild(ctx, name, child, out)
+}
+
+func (n *Node) Create(ctx context.Context, name string, flags uint32, mode uint32, out *fuse.EntryOut) (*fs.Inode, fs.FileHandle, uint32, syscall.Errno) {
+	if !n.isWritable() {
+		return nil, nil, 0, syscall.EROFS
+	}
+	defer n.invalidateEntries()
+	dir := n.rwDir()
+	defer dir.Client.Release()
+	res, release := dir.Create(ctx, func(p filesystem.RwDirectory_create_Params) error {
+		p.SetExecutable(mode&0111 != 0)
+		return p.SetName(name)
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return nil, nil, 0, writeErrno(err)
+	}
+	file := filesystem.RwFile{Client: results.File().Client.AddRef()}
+	if flags&syscall.O_TRUNC != 0 {
+		// Create opens existing files rather than failing.
+		if err = truncate(ctx, file, 0); err != nil {
+			file.Client.Release()
+			return nil, nil, 0, writeErrno(err)
+		}
+	}
+	ch, errno := n.newChild(ctx, name, filesystem.Node{Client: file.Client}, out)
+	if errno != 0 {
+		return nil, nil, 0, errno
+	}
+	return ch, ch.Operations().(*Node).newFile(), 0, 0
+}
+
+// Delete the child called name, which must be a directory if isDir is
+// true and a file otherwise.
+func (n *Node) remove(ctx context.Context, name string, isDir bool) syscall.Errno {
+	if !n.isWritable() {
+		return syscall.EROFS
+	}
+	dir := n.rwDir()
+	defer dir.Client.Release()
+	fi, err := client.Stat(ctx, filesystem.Directory{Client: dir.Client}, name)
+	if err != nil {
+		return toErrno(err)
+	}
+	if isDir && !fi.IsDir() {
+		return syscall.ENOTDIR
+	} else if !isDir && fi.IsDir() {
+		return syscall.EISDIR
+	}
+	defer n.invalidateEntries()
+	if ch := n.GetChild(name); ch != nil {
+		if node, ok := ch.Operations().(*Node); ok {
+			node.invalidateData()
+		}
+	}
+	return writeErrno(client.Remove(ctx, dir, name))
+}
+
+func (n *Node) Unlink(ctx context.Context, name string) syscall.Errno {
+	return n.remove(ctx, name, false)
+}
+
+func (n *Node) Rmdir(ctx context.Context, name string) syscall.Errno {
+	return n.remove(ctx, name, true)
+}
+
+// The protocol has no rename, so this copies the node and then deletes
+// the original, which is slow for large directories, and not atomic.
+// If the target doesn't exist, the copy goes straight to it. If it does,
+// the copy goes to a temporary name first, and only replaces the target
+// once it is complete, so that a failed rename leaves the target as it
+// was; editors save files by writing a temporary file and renaming it
+// over the original, and we don't want a failure to lose both. Replacing
+// the target is itself a copy, from the temporary name, though one which
+// is unlikely to fail if the first succeeded.
+//
+// client.Copy keeps files' extended attributes and MIME types, but not
+// directories', since the protocol has no way to set them.
+func (n *Node) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	np, ok := newParent.(*Node)
+	if !ok {
+		return syscall.EXDEV
+	}
+	if flags&^unix.RENAME_NOREPLACE != 0 {
+		// RENAME_EXCHANGE can't even be approximated.
+		return syscall.EINVAL
+	}
+	if !n.isWritable() || !np.isWritable() {
+		return syscall.EROFS
+	}
+	if np == n && name == newName {
+		return 0
+	}
+	src := n.rwDir()
+	defer src.Client.Release()
+	dst := np.rwDir()
+	defer dst.Client.Release()
+	defer n.invalidateEntries()
+	defer np.invalidateEntries()
+
+	srcInfo, err := client.Stat(ctx, filesystem.Directory{Client: src.Client}, name)
+	if err != nil {
+		return toErrno(err)
+	}
+	// Like rename(2), replace the target if it exists, as long as it's
+	// the same type as the source and, if it's a directory, empty.
+	dstInfo, err := client.Stat(ctx, filesystem.Directory{Client: dst.Client}, newName)
+	exists := err == nil
+	if err != nil && fserrors.CodeOf(err) != filesystem.ErrorCode_notFound {
+		return toErrno(err)
+	}
+	if exists {
+		if flags&unix.RENAME_NOREPLACE != 0 {
+			return syscall.EEXIST
+		}
+		if dstInfo.IsDir() && !srcInfo.IsDir() {
+			return syscall.EISDIR
+		} else if !dstInfo.IsDir() && srcInfo.IsDir() {
+			return syscall.ENOTDIR
+		} else if dstInfo.IsDir() {
+			entries, err := client.ReadDir(ctx, filesystem.Directory{Client: dst.Client}, newName)
+			if err != nil {
+				return toErrno(err)
+			}
+			if len(entries) != 0 {
+				return syscall.ENOTEMPTY
+			}
+		}
+	}
+
+	if exists {
+		err = n.replace(ctx, name, np, newName)
+	} else {
+		err = client.Copy(ctx, filesystem.Directory{Client: src.Client}, name, dst, newName)
+		if err != nil {
+			client.RemoveAll(ctx, dst, newName)
+		}
+	}
+	if err != nil {
+		return writeErrno(err)
+	}
+	if err = client.RemoveAll(ctx, src, name); err != nil {
+		return writeErrno(err)
+	}
+
+	// The kernel still refers to the node by its old inode, which the
+	// bridge moves to its new name once we return, so point it at the
+	// copy.
+	if ch := n.GetChild(name); ch != nil {
+		if node, ok := ch.Operations().(*Node); ok {
+			node.refresh(ctx, filesystem.Directory{Client: dst.Client}, newName)
+		}
+	}
+	return 0
+}
+
+// Copy name in n over the existing newName in np, by way of a temporary
+// copy; see Rename.
+func (n *Node) replace(ctx context.Context, name string, np *Node, newName string) error {
+	src := n.rwDir()
+	defer src.Client.Release()
+	dst := np.rwDir()
+	defer dst.Client.Release()
+
+	tmpName := ".fuseclient-" + strconv.FormatInt(rand.Int63(), 36) + ".tmp"
+	err := client.Copy(ctx, filesystem.Directory{Client: src.Client}, name, dst, tmpName)
+	if err != nil {
+		client.RemoveAll(ctx, dst, tmpName)
+		return err
+	}
+	if ch := np.GetChild(newName); ch != nil {
+		if node, ok := ch.Operations().(*Node); ok {
+			node.invalidateData()
+		}
+	}
+	if err = client.Remove(ctx, dst, newName); err != nil {
+		client.RemoveAll(ctx, dst, tmpName)
+		return err
+	}
+	err = client.Copy(ctx, filesystem.Directory{Client: dst.Client}, tmpName, dst, newName)
+	client.RemoveAll(ctx, dst, tmpName)
+	return err
+}
+
+// Re-walk to the node, which is now called name in parent, along with any
+// children we know about.
+func (n *Node) refresh(ctx context.Context, parent filesystem.Directory, name string) {
+	c, err := client.Walk(ctx, parent, name)
+	if err != nil {
+		return
+	}
+	var attr fuse.Attr
+	_, writable, err := stat(ctx, c, &attr)
+	if err != nil {
+		c.Client.Release()
+		return
+	}
+	n.setCap(c, writable)
+	dir := n.dir()
+	defer dir.Client.Release()
+	for childName, ch := range n.Children() {
+		if child, ok := ch.Operations().(*Node); ok {
+			child.refresh(ctx, dir, childName)
+		}
+	}
+}
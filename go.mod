@@ -1,10 +1,13 @@
 module zenhack.net/go/sandstorm-filesystem
 
-go 1.13
+go 1.21
 
 require (
 	github.com/gorilla/mux v1.7.3
+	github.com/hanwen/go-fuse/v2 v2.11.0
+	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2
 	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553
+	golang.org/x/sys v0.28.0
 	zenhack.net/go/sandstorm v0.0.0-20191213192830-2294f25e6742
 	zombiezen.com/go/capnproto2 v2.17.1-0.20180404044107-e89f9b7f0213+incompatible
 )
@@ -2,13 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
 
 	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/audit"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
 	"zenhack.net/go/sandstorm-filesystem/filesystem/local"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/membrane"
 
 	grain_capnp "zenhack.net/go/sandstorm/capnp/grain"
+	"zenhack.net/go/sandstorm/capnp/powerbox"
 	bridge_capnp "zenhack.net/go/sandstorm/capnp/sandstormhttpbridge"
 	sandstormhttpbridge "zenhack.net/go/sandstorm/exp/sandstormhttpbridge"
 
@@ -16,8 +33,145 @@ import (
 	"zombiezen.com/go/capnproto2/pogs"
 )
 
+const (
+	sharedDir     = "/var/shared-dir"
+	versionDir    = "/var/versions"
+	trashDir      = "/var/trash"
+	retentionFile = "/var/retention.json"
+	auditLogFile  = "/var/audit.log"
+)
+
+var (
+	// Returned when trying to save a capability to something other than
+	// a node under the shared directory, e.g. an old version of a file.
+	errCannotSave = errors.New("This capability cannot be saved")
+)
+
 type LocalFS struct {
 	bridgePromise *BridgePromise
+	ui            http.Handler
+	auditLog      *audit.Log
+
+	// Used by all of the nodes we hand out.
+	config *local.Config
+}
+
+// What we save for capabilities handed out to other grains.
+type sharedNode struct {
+	local.Node
+
+	// The share the capability belongs to; see audit.Record.Share. This
+	// is empty for capabilities saved before we kept an audit log.
+	Share string `json:",omitempty"`
+}
+
+// Return a capability for n to hand out to another grain. Everything done
+// through it is recorded in the audit log, as having been done through
+// share.
+func (fs *LocalFS) shareNode(n local.Node, share string) filesystem.Node {
+	hooks := audit.Hooks(share, fs.auditLog)
+	hooks.Save = func(ctx context.Context, path string, kind membrane.Kind, p grain_capnp.AppPersistent_save) error {
+		if strings.Contains(path, "@") {
+			return errCannotSave
+		}
+		saved := sharedNode{Node: n, Share: share}
+		if path != "" {
+			// Work out what walking to the node from n would have
+			// given us; see local.Node.Walk. Files are only
+			// append-only if they were reached by create, which the
+			// path doesn't tell us, but the kind does.
+			child, err := local.NewNode(filepath.Join(n.Path, path), n.Config)
+			if err != nil {
+				return err
+			}
+			child.Writable = n.Writable && child.Writable
+			child.AppendOnly = kind == membrane.KindAppendOnlyDirectory ||
+				kind == membrane.KindAppendOnlyFile
+			saved.Node = *child
+		}
+		data, err := json.Marshal(saved)
+		if err != nil {
+			return err
+		}
+		res, err := p.AllocResults()
+		if err != nil {
+			return err
+		}
+		objectId, err := capnp.NewData(res.Struct.Segment(), data)
+		if err != nil {
+			return err
+		}
+		return res.SetObjectId(objectId.List.ToPtr())
+	}
+	return membrane.Wrap(n.MakeClient(), nodeKind(n), hooks)
+}
+
+// Return the kind of capability n.MakeClient returns.
+func nodeKind(n local.Node) membrane.Kind {
+	switch {
+	case n.AppendOnly && n.IsDir:
+		return membrane.KindAppendOnlyDirectory
+	case n.AppendOnly:
+		return membrane.KindAppendOnlyFile
+	case n.IsDir && n.Writable:
+		return membrane.KindRwDirectory
+	case n.IsDir:
+		return membrane.KindDirectory
+	case n.Writable:
+		return membrane.KindRwFile
+	default:
+		return membrane.KindFile
+	}
+}
+
+// Return a name for a new share, made on behalf of the user making req.
+func newShareName(req *http.Request) string {
+	var buf [6]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+	user, err := url.PathUnescape(req.Header.Get("X-Sandstorm-Username"))
+	if err != nil || user == "" {
+		user = "Anonymous"
+	}
+	return user + "#" + hex.EncodeToString(buf[:])
+}
+
+// How long to keep old versions of files, and snapshots. This is
+// configured by the user via the grain's UI, and saved in retentionFile.
+type retentionSettings struct {
+	MaxVersions int
+	MaxAgeDays  int
+}
+
+func (s retentionSettings) apply(config *local.Config) {
+	config.SetVersionRetention(local.RetentionPolicy{
+		MaxCount: s.MaxVersions,
+		MaxAge:   time.Duration(s.MaxAgeDays) * 24 * time.Hour,
+	})
+}
+
+func loadRetentionSettings() retentionSettings {
+	settings := retentionSettings{
+		MaxVersions: 20,
+		MaxAgeDays:  30,
+	}
+	data, err := ioutil.ReadFile(retentionFile)
+	if err == nil {
+		err = json.Unmarshal(data, &settings)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Print("Error loading retention settings: ", err)
+	}
+	return settings
+}
+
+func saveRetentionSettings(s retentionSettings) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(retentionFile, data, 0600)
 }
 
 func (fs *LocalFS) getBridge() bridge_capnp.SandstormHttpBridge {
@@ -34,14 +188,32 @@ func (fs *LocalFS) GetViewInfo(ctx context.Context, p bridge_capnp.AppHooks_getV
 			{Id: filesystem.Node_TypeID},
 			{Id: filesystem.Directory_TypeID},
 			{Id: filesystem.RwDirectory_TypeID},
+			{Id: filesystem.AppendOnlyDirectory_TypeID},
 		}}},
 	})
 	return nil
 }
 
 func (fs *LocalFS) Restore(ctx context.Context, p bridge_capnp.AppHooks_restore) error {
-	node := &local.Node{}
-	return node.Restore(p)
+	ptr, err := p.Args().ObjectId()
+	if err != nil {
+		return err
+	}
+	var saved sharedNode
+	if err = json.Unmarshal(ptr.Data(), &saved); err != nil {
+		return err
+	}
+	if saved.Share == "" {
+		saved.Share = "(unnamed)"
+	}
+	saved.Node.Config = fs.config
+	res, err := p.AllocResults()
+	if err != nil {
+		return err
+	}
+	node := fs.shareNode(saved.Node, saved.Share)
+	capId := res.Struct.Segment().Message().AddCap(node.Client)
+	return res.SetCap(capnp.NewInterface(res.Struct.Segment(), capId).ToPtr())
 }
 
 func (fs *LocalFS) Drop(ctx context.Context, p bridge_capnp.AppHooks_drop) error {
@@ -52,11 +224,7 @@ func (fs *LocalFS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	if req.Header.Get("X-Sandstorm-Session-Type") != "request" {
 		// Not a request session.
-		w.Write([]byte("This grain doesn't provide much of a user " +
-			"interface (this is it), but you can request a " +
-			"filesystem via other grains, and have this grain " +
-			"fulfill them. Try out the zip uploader and filesystem " +
-			"viewer grain types from this app."))
+		fs.ui.ServeHTTP(w, req)
 		return
 	}
 
@@ -71,17 +239,23 @@ func (fs *LocalFS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 	descriptor := requestInfo.At(0)
+	share := newShareName(req)
 	res, release := sessionCtx.FulfillRequest(
 		ctx,
 		func(p grain_capnp.SessionContext_fulfillRequest_Params) error {
 			// TODO: limit to the thing the user actually asked for; if they didn't ask
 			// for write, don't give it to them.
-			n, err := local.NewNode("/var/shared-dir")
+			n, err := local.NewNode(sharedDir, fs.config)
 			if err != nil {
 				// This should never happen; we create the above dir on first start.
 				panic(err)
 			}
-			capId := p.Struct.Segment().Message().AddCap(n.MakeClient().Client)
+			if isAppendOnlyRequest(descriptor) {
+				n.Writable = false
+				n.AppendOnly = true
+			}
+			node := fs.shareNode(*n, share)
+			capId := p.Struct.Segment().Message().AddCap(node.Client)
 			p.SetCap(capnp.NewInterface(p.Struct.Segment(), capId).ToPtr())
 			p.SetDescriptor(descriptor)
 			p.NewRequiredPermissions(0)
@@ -110,12 +284,225 @@ func (fs *LocalFS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// Report whether the powerbox request described by desc is for an
+// AppendOnlyDirectory.
+func isAppendOnlyRequest(desc powerbox.PowerboxDescriptor) bool {
+	tags, err := desc.Tags()
+	if err != nil {
+		return false
+	}
+	for i := 0; i < tags.Len(); i++ {
+		if tags.At(i).Id() == filesystem.AppendOnlyDirectory_TypeID {
+			return true
+		}
+	}
+	return false
+}
+
 // Returns a "local fs" grain, which allows other grains to access
 // its files.
 func initLocalFS(p *BridgePromise) *LocalFS {
 	// Make sure our shared directory exists.
-	chkfatal(os.MkdirAll("/var/shared-dir", 0700))
-	localFS := &LocalFS{bridgePromise: p}
+	chkfatal(os.MkdirAll(sharedDir, 0700))
+
+	// Extended attributes fall back to this if the filesystem backing
+	// /var doesn't support them.
+	chkfatal(os.MkdirAll("/var/xattrs", 0700))
+	chkfatal(os.MkdirAll(versionDir, 0700))
+	chkfatal(os.MkdirAll(trashDir, 0700))
+	config := &local.Config{
+		XattrSidecarDir: "/var/xattrs",
+		VersionDir:      versionDir,
+		TrashDir:        trashDir,
+		TrashExpiry:     30 * 24 * time.Hour,
+	}
+	loadRetentionSettings().apply(config)
+
+	go func() {
+		// Deleting something also expires old items, but we don't
+		// want to rely on that happening.
+		for {
+			if err := config.ExpireTrash(); err != nil {
+				log.Print("Error expiring trash: ", err)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+
+	auditLog, err := audit.Open(auditLogFile)
+	chkfatal(err)
+
+	localFS := &LocalFS{
+		bridgePromise: p,
+		ui:            localFSUI(auditLog, config),
+		auditLog:      auditLog,
+		config:        config,
+	}
 	http.Handle("/", localFS)
 	return localFS
 }
+
+// Returns the handler for the grain's own (non-request) UI, which lets the
+// user take snapshots of the shared directory, configure how long old
+// versions are kept, manage the trash, and view the audit log.
+func localFSUI(auditLog *audit.Log, config *local.Config) http.Handler {
+	r := mux.NewRouter()
+
+	sharedRwDir := func() filesystem.RwDirectory {
+		n, err := local.NewNode(sharedDir, config)
+		chkfatal(err)
+		return filesystem.RwDirectory{Client: n.MakeClient().Client}
+	}
+
+	r.Methods("GET").Path("/").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			dir := sharedRwDir()
+			defer dir.Client.Release()
+			res, release := dir.Snapshots(req.Context(), nil)
+			defer release()
+			results, err := res.Struct()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			snapshots, err := results.Snapshots()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			times := make([]time.Time, snapshots.Len())
+			for i := range times {
+				times[i] = time.Unix(0, snapshots.At(i).Time())
+			}
+			tpls.ExecuteTemplate(w, "localfs-index.html", struct {
+				Retention retentionSettings
+				Snapshots []time.Time
+			}{
+				Retention: loadRetentionSettings(),
+				Snapshots: times,
+			})
+		})
+
+	r.Methods("POST").Path("/snapshot").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			dir := sharedRwDir()
+			defer dir.Client.Release()
+			res, release := dir.Snapshot(req.Context(), nil)
+			defer release()
+			if _, err := res.Struct(); err != nil {
+				w.WriteHeader(fserrors.HTTPStatus(err))
+				w.Write([]byte(err.Error()))
+				return
+			}
+			http.Redirect(w, req, "/", http.StatusSeeOther)
+		})
+
+	r.Methods("POST").Path("/retention").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			maxVersions, err1 := strconv.Atoi(req.FormValue("max-versions"))
+			maxAgeDays, err2 := strconv.Atoi(req.FormValue("max-age-days"))
+			if err1 != nil || err2 != nil || maxVersions < 0 || maxAgeDays < 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("Bad Request"))
+				return
+			}
+			settings := retentionSettings{
+				MaxVersions: maxVersions,
+				MaxAgeDays:  maxAgeDays,
+			}
+			if err := saveRetentionSettings(settings); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			settings.apply(config)
+			http.Redirect(w, req, "/", http.StatusSeeOther)
+		})
+
+	sharedTrash := func(ctx context.Context) filesystem.Trash {
+		dir := sharedRwDir()
+		defer dir.Client.Release()
+		res, release := dir.Trash(ctx, nil)
+		defer release()
+		return filesystem.Trash{Client: res.Trash().Client.AddRef()}
+	}
+
+	r.Methods("GET").Path("/trash").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			trash := sharedTrash(req.Context())
+			defer trash.Client.Release()
+			res, release := trash.Items(req.Context(), nil)
+			defer release()
+			results, err := res.Struct()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			list, err := results.Items()
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			type item struct {
+				Name, OriginalPath string
+				DeletedAt          time.Time
+			}
+			items := make([]item, list.Len())
+			for i := range items {
+				ent := list.At(i)
+				items[i].Name, _ = ent.Name()
+				items[i].OriginalPath, _ = ent.OriginalPath()
+				items[i].DeletedAt = time.Unix(0, ent.DeletedAt())
+			}
+			tpls.ExecuteTemplate(w, "localfs-trash.html", items)
+		})
+
+	r.Methods("POST").Path("/trash/restore").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			trash := sharedTrash(req.Context())
+			defer trash.Client.Release()
+			res, release := trash.Restore(req.Context(), func(p filesystem.Trash_restore_Params) error {
+				return p.SetName(req.FormValue("name"))
+			})
+			defer release()
+			if _, err := res.Struct(); err != nil {
+				w.WriteHeader(fserrors.HTTPStatus(err))
+				w.Write([]byte(err.Error()))
+				return
+			}
+			http.Redirect(w, req, "/trash", http.StatusSeeOther)
+		})
+
+	r.Methods("POST").Path("/trash/purge").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			trash := sharedTrash(req.Context())
+			defer trash.Client.Release()
+			res, release := trash.Purge(req.Context(), func(p filesystem.Trash_purge_Params) error {
+				return p.SetName(req.FormValue("name"))
+			})
+			defer release()
+			if _, err := res.Struct(); err != nil {
+				w.WriteHeader(fserrors.HTTPStatus(err))
+				w.Write([]byte(err.Error()))
+				return
+			}
+			http.Redirect(w, req, "/trash", http.StatusSeeOther)
+		})
+
+	r.Methods("GET").Path("/audit").
+		HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			records, err := auditLog.Tail(500)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			tpls.ExecuteTemplate(w, "localfs-audit.html", records)
+		})
+
+	return withLock(r)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/audit"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/local"
+
+	grain_capnp "zenhack.net/go/sandstorm/capnp/grain"
+)
+
+// Save node via AppPersistent, and decode what it saved.
+func saveShared(ctx context.Context, t *testing.T, node filesystem.Node) sharedNode {
+	t.Helper()
+	res, release := grain_capnp.AppPersistent{Client: node.Client}.Save(ctx, nil)
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		t.Fatal("save: ", err)
+	}
+	ptr, err := results.ObjectId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved sharedNode
+	if err = json.Unmarshal(ptr.Data(), &saved); err != nil {
+		t.Fatal(err)
+	}
+	return saved
+}
+
+func TestSharedNodeSave(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	auditLog, err := audit.Open(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+	fs := &LocalFS{auditLog: auditLog}
+
+	n, err := local.NewNode(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := fs.shareNode(*n, "alice#1")
+	defer node.Client.Release()
+
+	saved := saveShared(ctx, t, node)
+	if saved.Path != root || saved.Share != "alice#1" || !saved.Writable {
+		t.Errorf("saved root as %+v", saved)
+	}
+
+	// Nodes reached from a shared one are saved as themselves.
+	sub, err := client.Walk(ctx, filesystem.Directory{Client: node.Client}, "sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Client.Release()
+	saved = saveShared(ctx, t, sub)
+	if saved.Path != filepath.Join(root, "sub") || saved.Share != "alice#1" || !saved.IsDir {
+		t.Errorf("saved sub as %+v", saved)
+	}
+
+	// In an append-only share, files made by create are append-only,
+	// but those reached by walking are read-only.
+	n.Writable = false
+	n.AppendOnly = true
+	appendOnly := fs.shareNode(*n, "bob#2")
+	defer appendOnly.Client.Release()
+	createRes, release := filesystem.AppendOnlyDirectory{Client: appendOnly.Client}.Create(ctx, func(p filesystem.AppendOnlyDirectory_create_Params) error {
+		return p.SetName("log.txt")
+	})
+	defer release()
+	saved = saveShared(ctx, t, filesystem.Node{Client: createRes.File().Client})
+	if saved.Path != filepath.Join(root, "log.txt") || saved.IsDir || !saved.AppendOnly {
+		t.Errorf("saved a created file as %+v", saved)
+	}
+	walked, err := client.Walk(ctx, filesystem.Directory{Client: appendOnly.Client}, "log.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer walked.Client.Release()
+	saved = saveShared(ctx, t, walked)
+	if saved.AppendOnly || saved.Writable {
+		t.Errorf("saved a walked-to file as %+v", saved)
+	}
+	if saved = saveShared(ctx, t, appendOnly); !saved.AppendOnly || !saved.IsDir {
+		t.Errorf("saved the append-only root as %+v", saved)
+	}
+}
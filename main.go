@@ -57,6 +57,10 @@ func main() {
 		bridge, err := sandstormhttpbridge.Connect(ctx)
 		chkfatal(err)
 		initZipUploader(bridge)
+	case "backup":
+		bridge, err := sandstormhttpbridge.Connect(ctx)
+		chkfatal(err)
+		initBackup(bridge)
 	default:
 		panic("Unexpected action type: " + action)
 	}
@@ -7,13 +7,32 @@ import (
 	"zenhack.net/go/sandstorm/capnp/powerbox"
 )
 
+// Returns a handler for a script which makes the button with id
+// "request-btn" request desc from the powerbox, and posts the resulting
+// token to /filesystem-cap.
 func PbRequest(desc powerbox.PowerboxDescriptor) http.Handler {
+	return PbRequestButton(desc, 0, "request-btn", "/filesystem-cap")
+}
+
+// Like PbRequest, but for the button with the given id, posting the token
+// to path. Pages with more than one such button must give each a
+// different rpcId.
+func PbRequestButton(desc powerbox.PowerboxDescriptor, rpcId int, button, path string) http.Handler {
 	msg, err := desc.Segment().Message().MarshalPacked()
 	if err != nil {
 		panic(err)
 	}
-	b64msg := base64.URLEncoding.EncodeToString(msg)
+	data := struct {
+		Query        string
+		RpcId        int
+		Button, Path string
+	}{
+		Query:  base64.URLEncoding.EncodeToString(msg),
+		RpcId:  rpcId,
+		Button: button,
+		Path:   path,
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		tpls.ExecuteTemplate(w, "pb-request.js", b64msg)
+		tpls.ExecuteTemplate(w, "pb-request.js", data)
 	})
 }
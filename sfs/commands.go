@@ -0,0 +1,528 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+)
+
+var errIsADirectory = fserrors.New(filesystem.ErrorCode_invalidArgument, "Is a directory")
+
+// How ls, stat and tree describe a node in JSON.
+type entry struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Type       string `json:"type"` // "file" or "dir"
+	Size       int64  `json:"size"`
+	Executable bool   `json:"executable"`
+	Writable   bool   `json:"writable"`
+	MimeType   string `json:"mimeType,omitempty"`
+
+	// For tree, the contents of a directory.
+	Children []*entry `json:"children,omitempty"`
+
+	mode fs.FileMode
+}
+
+func newEntry(p string, fi fs.FileInfo) *entry {
+	e := &entry{
+		Name:       fi.Name(),
+		Path:       p,
+		Type:       "file",
+		Size:       fi.Size(),
+		Executable: !fi.IsDir() && fi.Mode()&0111 != 0,
+		Writable:   fi.Mode()&0200 != 0,
+		mode:       fi.Mode(),
+	}
+	if fi.IsDir() {
+		e.Type = "dir"
+	}
+	if cfi, ok := fi.(*client.FileInfo); ok {
+		e.MimeType = cfi.MimeType()
+	}
+	return e
+}
+
+// The name to show in text output, with a slash after directories.
+func (e *entry) displayName() string {
+	if e.Type == "dir" {
+		return e.Name + "/"
+	}
+	return e.Name
+}
+
+func dir(root filesystem.RwDirectory) filesystem.Directory {
+	return filesystem.Directory{Client: root.Client}
+}
+
+// Returns a flag set for a command, which leaves reporting usage errors to
+// main.
+func newFlagSet(name string) *flag.FlagSet {
+	fl := flag.NewFlagSet(name, flag.ContinueOnError)
+	fl.Usage = func() {}
+	return fl
+}
+
+func ls(ctx context.Context, root filesystem.RwDirectory, args []string) error {
+	fl := newFlagSet("ls")
+	long := fl.Bool("l", false, "")
+	if fl.Parse(args) != nil || fl.NArg() > 1 {
+		return errUsage
+	}
+	p := "."
+	if fl.NArg() == 1 {
+		p = fl.Arg(0)
+	}
+	fi, err := client.Stat(ctx, dir(root), p)
+	if err != nil {
+		return err
+	}
+	entries := []*entry{}
+	if !fi.IsDir() {
+		entries = append(entries, newEntry(p, fi))
+	} else {
+		dirEntries, err := client.ReadDir(ctx, dir(root), p)
+		if err != nil {
+			return err
+		}
+		for _, ent := range dirEntries {
+			info, err := ent.Info()
+			if err != nil {
+				return err
+			}
+			entries = append(entries, newEntry(path.Join(p, ent.Name()), info))
+		}
+	}
+
+	if *jsonOut {
+		return printJSON(entries)
+	}
+	for _, e := range entries {
+		if *long {
+			fmt.Printf("%s %12d %s\n", e.mode, e.Size, e.displayName())
+		} else {
+			fmt.Println(e.displayName())
+		}
+	}
+	return nil
+}
+
+func stat(ctx context.Context, root filesystem.RwDirectory, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	fi, err := client.Stat(ctx, dir(root), args[0])
+	if err != nil {
+		return err
+	}
+	e := newEntry(args[0], fi)
+	if *jsonOut {
+		return printJSON(e)
+	}
+	fmt.Println("path:", e.Path)
+	fmt.Println("type:", e.Type)
+	fmt.Println("size:", e.Size)
+	fmt.Println("mode:", e.mode)
+	if e.MimeType != "" {
+		fmt.Println("mime type:", e.MimeType)
+	}
+	return nil
+}
+
+// Returns the file at p, or an error if it is a directory.
+func openFile(ctx context.Context, root filesystem.RwDirectory, p string) (filesystem.File, error) {
+	node, err := client.Walk(ctx, dir(root), p)
+	if err != nil {
+		return filesystem.File{}, err
+	}
+	fi, err := client.StatNode(ctx, node, path.Base(p))
+	if err == nil && fi.IsDir() {
+		err = errIsADirectory
+	}
+	if err != nil {
+		node.Client.Release()
+		return filesystem.File{}, &fs.PathError{Op: "open", Path: p, Err: fserrors.Decode(err)}
+	}
+	return filesystem.File{Client: node.Client}, nil
+}
+
+// An io.WriteCloser for which Close does nothing, to stop client.ReadTo
+// closing stdout.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func cat(ctx context.Context, root filesystem.RwDirectory, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	for _, p := range args {
+		file, err := openFile(ctx, root, p)
+		if err != nil {
+			return err
+		}
+		err = client.ReadTo(ctx, file, nopCloser{os.Stdout})
+		file.Client.Release()
+		if err != nil {
+			return &fs.PathError{Op: "read", Path: p, Err: fserrors.Decode(err)}
+		}
+	}
+	return nil
+}
+
+// get and put copy into an existing directory if given one, like cp;
+// otherwise they create or replace the destination.
+
+func get(ctx context.Context, root filesystem.RwDirectory, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	p, local := args[0], args[1]
+	if fi, err := os.Stat(local); err == nil && fi.IsDir() {
+		local = filepath.Join(local, path.Base(p))
+	}
+	node, err := client.Walk(ctx, dir(root), p)
+	if err != nil {
+		return err
+	}
+	defer node.Client.Release()
+	fi, err := client.StatNode(ctx, node, path.Base(p))
+	if err != nil {
+		return &fs.PathError{Op: "stat", Path: p, Err: fserrors.Decode(err)}
+	}
+	if !fi.IsDir() {
+		return download(ctx, filesystem.File{Client: node.Client}, p, local, fi.Executable())
+	}
+	if err = os.MkdirAll(local, 0755); err != nil {
+		return err
+	}
+	return getDir(ctx, filesystem.Directory{Client: node.Client}, p, local)
+}
+
+// Download the contents of d, which is at p, into the local directory
+// local.
+func getDir(ctx context.Context, d filesystem.Directory, p, local string) error {
+	entries, err := client.List(ctx, d)
+	if err != nil {
+		return &fs.PathError{Op: "readdir", Path: p, Err: fserrors.Decode(err)}
+	}
+	for _, ent := range entries {
+		childPath := path.Join(p, ent.Name())
+		childLocal := filepath.Join(local, ent.Name())
+		node, err := client.Walk(ctx, d, ent.Name())
+		if err != nil {
+			return &fs.PathError{Op: "walk", Path: childPath, Err: errors.Unwrap(err)}
+		}
+		if ent.IsDir() {
+			if err = os.MkdirAll(childLocal, 0755); err == nil {
+				err = getDir(ctx, filesystem.Directory{Client: node.Client}, childPath, childLocal)
+			}
+		} else {
+			var info fs.FileInfo
+			if info, err = ent.Info(); err == nil {
+				err = download(ctx, filesystem.File{Client: node.Client}, childPath, childLocal, info.Mode()&0111 != 0)
+			}
+		}
+		node.Client.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Download file, which is at p, to the local file local.
+func download(ctx context.Context, file filesystem.File, p, local string, exec bool) error {
+	mode := fs.FileMode(0644)
+	if exec {
+		mode = 0755
+	}
+	f, err := os.OpenFile(local, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	// ReadTo closes f once the data has all arrived, but not if reading
+	// fails part way.
+	defer f.Close()
+	if err = client.ReadTo(ctx, file, f); err != nil {
+		return &fs.PathError{Op: "read", Path: p, Err: fserrors.Decode(err)}
+	}
+	return nil
+}
+
+func put(ctx context.Context, root filesystem.RwDirectory, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	local, p := args[0], args[1]
+	if local == "-" {
+		return upload(ctx, root, os.Stdin, p, false)
+	}
+	fi, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	if rfi, err := client.Stat(ctx, dir(root), p); err == nil && rfi.IsDir() {
+		p = path.Join(p, filepath.Base(local))
+	}
+	if !fi.IsDir() {
+		return uploadFile(ctx, root, local, p)
+	}
+	return filepath.WalkDir(local, func(lp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(local, lp)
+		if err != nil {
+			return err
+		}
+		rp := path.Join(p, filepath.ToSlash(rel))
+		switch {
+		case d.IsDir():
+			rdir, err := client.MkdirAll(ctx, root, rp)
+			if err != nil {
+				return err
+			}
+			rdir.Client.Release()
+		case d.Type().IsRegular():
+			return uploadFile(ctx, root, lp, rp)
+		default:
+			log.Printf("skipping %s: not a regular file", lp)
+		}
+		return nil
+	})
+}
+
+func uploadFile(ctx context.Context, root filesystem.RwDirectory, local, p string) error {
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return upload(ctx, root, f, p, fi.Mode()&0111 != 0)
+}
+
+// Write everything from r to the remote file at p, replacing it if it
+// exists.
+func upload(ctx context.Context, root filesystem.RwDirectory, r io.Reader, p string, exec bool) error {
+	file, err := client.Create(ctx, root, p, exec)
+	if err != nil {
+		return err
+	}
+	defer file.Client.Release()
+	// Create leaves the mode of existing files alone.
+	res, release := file.SetExec(ctx, func(p filesystem.RwFile_setExec_Params) error {
+		p.SetExec(exec)
+		return nil
+	})
+	defer release()
+	if _, err = res.Struct(); err != nil {
+		return &fs.PathError{Op: "chmod", Path: p, Err: fserrors.Decode(err)}
+	}
+	w := client.NewWriter(ctx, file, 0)
+	_, err = io.Copy(w, r)
+	if err2 := w.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return &fs.PathError{Op: "write", Path: p, Err: fserrors.Decode(err)}
+	}
+	return nil
+}
+
+func mkdir(ctx context.Context, root filesystem.RwDirectory, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	for _, p := range args {
+		d, err := client.MkdirAll(ctx, root, p)
+		if err != nil {
+			return err
+		}
+		d.Client.Release()
+	}
+	return nil
+}
+
+func rm(ctx context.Context, root filesystem.RwDirectory, args []string) error {
+	fl := newFlagSet("rm")
+	recursive := fl.Bool("r", false, "")
+	if fl.Parse(args) != nil || fl.NArg() == 0 {
+		return errUsage
+	}
+	for _, p := range fl.Args() {
+		var err error
+		if !*recursive {
+			err = client.Remove(ctx, root, p)
+		} else if _, err = client.Stat(ctx, dir(root), p); err == nil {
+			// RemoveAll doesn't mind p not existing, but we do.
+			err = client.RemoveAll(ctx, root, p)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mv(ctx context.Context, root filesystem.RwDirectory, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	oldPath, newPath := args[0], args[1]
+	if _, err := client.Stat(ctx, dir(root), oldPath); err != nil {
+		return err
+	}
+	if fi, err := client.Stat(ctx, dir(root), newPath); err == nil && fi.IsDir() {
+		newPath = path.Join(newPath, path.Base(oldPath))
+	}
+	return client.Rename(ctx, root, oldPath, newPath)
+}
+
+// Fill in e.Children, recursively, from d, which is the directory e
+// describes.
+func buildTree(ctx context.Context, d filesystem.Directory, e *entry) error {
+	entries, err := client.List(ctx, d)
+	if err != nil {
+		return &fs.PathError{Op: "readdir", Path: e.Path, Err: fserrors.Decode(err)}
+	}
+	for _, ent := range entries {
+		info, err := ent.Info()
+		if err != nil {
+			return err
+		}
+		child := newEntry(path.Join(e.Path, ent.Name()), info)
+		e.Children = append(e.Children, child)
+		if !ent.IsDir() {
+			continue
+		}
+		sub, err := client.WalkDir(ctx, d, ent.Name())
+		if err != nil {
+			return &fs.PathError{Op: "walk", Path: child.Path, Err: errors.Unwrap(err)}
+		}
+		err = buildTree(ctx, sub, child)
+		sub.Client.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns an entry for the node at p, with the whole tree under it if it
+// is a directory.
+func walkTree(ctx context.Context, root filesystem.RwDirectory, p string) (*entry, error) {
+	node, err := client.Walk(ctx, dir(root), p)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Client.Release()
+	fi, err := client.StatNode(ctx, node, path.Base(p))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: p, Err: fserrors.Decode(err)}
+	}
+	e := newEntry(p, fi)
+	if fi.IsDir() {
+		err = buildTree(ctx, filesystem.Directory{Client: node.Client}, e)
+	}
+	return e, err
+}
+
+func tree(ctx context.Context, root filesystem.RwDirectory, args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	p := "."
+	if len(args) == 1 {
+		p = args[0]
+	}
+	e, err := walkTree(ctx, root, p)
+	if err != nil {
+		return err
+	}
+	if *jsonOut {
+		return printJSON(e)
+	}
+	fmt.Println(e.Path)
+	printTree(e, "")
+	return nil
+}
+
+func printTree(e *entry, prefix string) {
+	for i, child := range e.Children {
+		branch, indent := "├── ", "│   "
+		if i == len(e.Children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Println(prefix + branch + child.displayName())
+		printTree(child, prefix+indent)
+	}
+}
+
+// What du reports for each path.
+type diskUsage struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+	Files int    `json:"files"`
+
+	// Directories under Path, not counting itself.
+	Dirs int `json:"dirs"`
+}
+
+func (u *diskUsage) add(e *entry) {
+	for _, child := range e.Children {
+		if child.Type == "dir" {
+			u.Dirs++
+			u.add(child)
+		} else {
+			u.Files++
+			u.Bytes += child.Size
+		}
+	}
+}
+
+func du(ctx context.Context, root filesystem.RwDirectory, args []string) error {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	usages := []*diskUsage{}
+	for _, p := range args {
+		e, err := walkTree(ctx, root, p)
+		if err != nil {
+			return err
+		}
+		u := &diskUsage{Path: p}
+		if e.Type == "dir" {
+			u.add(e)
+		} else {
+			u.Files, u.Bytes = 1, e.Size
+		}
+		usages = append(usages, u)
+	}
+	if *jsonOut {
+		return printJSON(usages)
+	}
+	for _, u := range usages {
+		fmt.Printf("%d\t%s\n", u.Bytes, u.Path)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+)
+
+// Make a memfs with a few files in it, one executable.
+func newRoot(ctx context.Context, t *testing.T) filesystem.RwDirectory {
+	t.Helper()
+	root := memfs.New(0).Root()
+	t.Cleanup(root.Client.Release)
+	for p, data := range map[string]string{
+		"top.txt":       "top",
+		"docs/a.txt":    "hello",
+		"docs/sub/b.sh": "#!/bin/sh\n",
+	} {
+		if err := client.WriteFile(ctx, root, p, []byte(data), p == "docs/sub/b.sh"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// Run the named command with args against root, and return what it
+// printed to stdout.
+func run(ctx context.Context, t *testing.T, root filesystem.RwDirectory, jsonFlag bool, name string, args ...string) (string, error) {
+	t.Helper()
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		t.Fatalf("no command %q", name)
+	}
+	out, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	*jsonOut = jsonFlag
+	defer func() {
+		os.Stdout = stdout
+		*jsonOut = false
+	}()
+	err = cmd.run(ctx, root, args)
+	data, readErr := os.ReadFile(out.Name())
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return string(data), err
+}
+
+// Run a command with -json, which must succeed, and decode its output
+// into v.
+func runJSON(ctx context.Context, t *testing.T, root filesystem.RwDirectory, v interface{}, name string, args ...string) {
+	t.Helper()
+	out, err := run(ctx, t, root, true, name, args...)
+	if err != nil {
+		t.Fatalf("%s %v: %v", name, args, err)
+	}
+	if err = json.Unmarshal([]byte(out), v); err != nil {
+		t.Fatalf("%s %v printed %q: %v", name, args, out, err)
+	}
+}
+
+// Clear the fields of e, and its children, which depend on the
+// filesystem rather than on what's in it: MIME types, and the sizes of
+// directories.
+func normalize(e *entry) {
+	e.MimeType = ""
+	if e.Type == "dir" {
+		e.Size = 0
+	}
+	for _, child := range e.Children {
+		normalize(child)
+	}
+}
+
+// List the paths of the files under e, in order, with a "*" after
+// executables.
+func files(e *entry) []string {
+	var ret []string
+	for _, child := range e.Children {
+		if child.Type == "dir" {
+			ret = append(ret, files(child)...)
+		} else if child.Executable {
+			ret = append(ret, child.Path+"*")
+		} else {
+			ret = append(ret, child.Path)
+		}
+	}
+	return ret
+}
+
+var (
+	aEntry   = &entry{Name: "a.txt", Path: "docs/a.txt", Type: "file", Size: 5, Writable: true}
+	bEntry   = &entry{Name: "b.sh", Path: "docs/sub/b.sh", Type: "file", Size: 10, Executable: true, Writable: true}
+	subEntry = &entry{Name: "sub", Path: "docs/sub", Type: "dir", Writable: true}
+)
+
+func TestLs(t *testing.T) {
+	ctx := context.Background()
+	root := newRoot(ctx, t)
+
+	var got []*entry
+	runJSON(ctx, t, root, &got, "ls", "docs")
+	for _, e := range got {
+		normalize(e)
+	}
+	if want := []*entry{aEntry, subEntry}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ls docs: got %+v, want %+v", got, want)
+	}
+
+	got = nil
+	runJSON(ctx, t, root, &got, "ls", "docs/sub/b.sh")
+	for _, e := range got {
+		normalize(e)
+	}
+	if want := []*entry{bEntry}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ls docs/sub/b.sh: got %+v, want %+v", got, want)
+	}
+
+	if out, err := run(ctx, t, root, false, "ls", "docs"); err != nil {
+		t.Error(err)
+	} else if want := "a.txt\nsub/\n"; out != want {
+		t.Errorf("ls docs printed %q, want %q", out, want)
+	}
+	if _, err := run(ctx, t, root, true, "ls", "missing"); fserrors.CodeOf(err) != filesystem.ErrorCode_notFound {
+		t.Errorf("ls missing: got %v, want not found", err)
+	}
+}
+
+func TestStat(t *testing.T) {
+	ctx := context.Background()
+	root := newRoot(ctx, t)
+
+	var got entry
+	runJSON(ctx, t, root, &got, "stat", "docs/sub/b.sh")
+	normalize(&got)
+	if !reflect.DeepEqual(&got, bEntry) {
+		t.Errorf("got %+v, want %+v", got, bEntry)
+	}
+}
+
+func TestCat(t *testing.T) {
+	ctx := context.Background()
+	root := newRoot(ctx, t)
+
+	if out, err := run(ctx, t, root, true, "cat", "docs/a.txt", "top.txt"); err != nil {
+		t.Error(err)
+	} else if out != "hellotop" {
+		t.Errorf("printed %q, want %q", out, "hellotop")
+	}
+	if _, err := run(ctx, t, root, true, "cat", "docs"); fserrors.CodeOf(err) != filesystem.ErrorCode_invalidArgument {
+		t.Errorf("cat of a directory: got %v, want %v", err, errIsADirectory)
+	}
+}
+
+func TestGetPut(t *testing.T) {
+	ctx := context.Background()
+	root := newRoot(ctx, t)
+	local := t.TempDir()
+
+	// Into an existing directory, and then to a new name.
+	if _, err := run(ctx, t, root, true, "get", "docs", local); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(ctx, t, root, true, "get", "top.txt", filepath.Join(local, "renamed.txt")); err != nil {
+		t.Fatal(err)
+	}
+	for p, want := range map[string]string{
+		"docs/a.txt":    "hello",
+		"docs/sub/b.sh": "#!/bin/sh\n",
+		"renamed.txt":   "top",
+	} {
+		data, err := os.ReadFile(filepath.Join(local, p))
+		if err != nil {
+			t.Error(err)
+		} else if string(data) != want {
+			t.Errorf("got %s containing %q, want %q", p, data, want)
+		}
+	}
+	if fi, err := os.Stat(filepath.Join(local, "docs/sub/b.sh")); err != nil {
+		t.Error(err)
+	} else if fi.Mode()&0111 == 0 {
+		t.Error("got docs/sub/b.sh without its executable bit")
+	}
+
+	// And back again, as a copy.
+	if _, err := run(ctx, t, root, true, "put", filepath.Join(local, "docs"), "copy"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(ctx, t, root, true, "put", filepath.Join(local, "renamed.txt"), "copy/sub"); err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	go func() {
+		io.WriteString(w, "from stdin")
+		w.Close()
+	}()
+	_, err = run(ctx, t, root, true, "put", "-", "copy/stdin.txt")
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got entry
+	runJSON(ctx, t, root, &got, "tree", "copy")
+	want := []string{"copy/a.txt", "copy/stdin.txt", "copy/sub/b.sh*", "copy/sub/renamed.txt"}
+	if files := files(&got); !reflect.DeepEqual(files, want) {
+		t.Errorf("copied %v, want %v", files, want)
+	}
+	data, err := client.ReadFile(ctx, dir(root), "copy/stdin.txt")
+	if err != nil {
+		t.Error(err)
+	} else if string(data) != "from stdin" {
+		t.Errorf("copy/stdin.txt contains %q, want %q", data, "from stdin")
+	}
+}
+
+func TestMkdirRmMv(t *testing.T) {
+	ctx := context.Background()
+	root := newRoot(ctx, t)
+
+	if _, err := run(ctx, t, root, true, "mkdir", "x/y", "z"); err != nil {
+		t.Fatal(err)
+	}
+	// Into an existing directory, and then to a new name.
+	if _, err := run(ctx, t, root, true, "mv", "top.txt", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(ctx, t, root, true, "mv", "docs", "x/y/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(ctx, t, root, true, "mv", "missing", "x"); fserrors.CodeOf(err) != filesystem.ErrorCode_notFound {
+		t.Errorf("mv missing: got %v, want not found", err)
+	}
+	var got []diskUsage
+	runJSON(ctx, t, root, &got, "du", "x", "x/top.txt", "z")
+	want := []diskUsage{
+		{Path: "x", Bytes: 18, Files: 3, Dirs: 3},
+		{Path: "x/top.txt", Bytes: 3, Files: 1},
+		{Path: "z"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("du: got %+v, want %+v", got, want)
+	}
+
+	if _, err := run(ctx, t, root, true, "rm", "x"); fserrors.CodeOf(err) != filesystem.ErrorCode_notEmpty {
+		t.Errorf("rm of a non-empty directory: got %v, want not empty", err)
+	}
+	if _, err := run(ctx, t, root, true, "rm", "-r", "x", "z"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(ctx, t, root, true, "rm", "-r", "x"); fserrors.CodeOf(err) != filesystem.ErrorCode_notFound {
+		t.Errorf("rm -r of a missing directory: got %v, want not found", err)
+	}
+	var entries []*entry
+	runJSON(ctx, t, root, &entries, "ls")
+	if len(entries) != 0 {
+		t.Errorf("left %d entries behind, want none", len(entries))
+	}
+}
+
+func TestTree(t *testing.T) {
+	ctx := context.Background()
+	root := newRoot(ctx, t)
+
+	var got entry
+	runJSON(ctx, t, root, &got, "tree", "docs")
+	normalize(&got)
+	sub := *subEntry
+	sub.Children = []*entry{bEntry}
+	want := &entry{
+		Name: "docs", Path: "docs", Type: "dir", Writable: true,
+		Children: []*entry{aEntry, &sub},
+	}
+	if !reflect.DeepEqual(&got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		t.Errorf("got %s, want %s", gotJSON, wantJSON)
+	}
+
+	if out, err := run(ctx, t, root, false, "tree", "docs"); err != nil {
+		t.Error(err)
+	} else if want := "docs\n├── a.txt\n└── sub/\n    └── b.sh\n"; out != want {
+		t.Errorf("printed %q, want %q", out, want)
+	}
+}
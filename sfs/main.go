@@ -0,0 +1,121 @@
+package main
+
+// sfs is a command-line client for filesystem capabilities, for looking
+// around in shared directories and scripting against them. It connects to
+// a unix socket or TCP address whose bootstrap capability is a Directory,
+// such as one served by fsserver, and runs one command against it:
+//
+//	sfs -addr /tmp/fs.sock ls -l docs
+//	sfs -addr /tmp/fs.sock put report.pdf docs
+//	sfs -addr /tmp/fs.sock -json du docs | jq .[0].bytes
+//
+// Remote paths are relative to the bootstrap directory. Commands which
+// change anything need it to be an RwDirectory. With -json, ls, stat,
+// tree and du print JSON instead of text; the other commands print
+// nothing when they succeed, either way. Errors are printed to stderr,
+// and make sfs exit with status 1.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+
+	"zombiezen.com/go/capnproto2/rpc"
+)
+
+var (
+	network = flag.String("network", "unix", `network to connect to ("unix" or "tcp")`)
+	addr    = flag.String("addr", "", "address to connect to (default $SFS_ADDR)")
+	jsonOut = flag.Bool("json", false, "print JSON, for scripts")
+)
+
+type command struct {
+	name, args, help string
+
+	run func(ctx context.Context, root filesystem.RwDirectory, args []string) error
+}
+
+var commands = []command{
+	{"ls", "[-l] [path]", "list a directory", ls},
+	{"stat", "path", "describe a file or directory", stat},
+	{"cat", "path...", "print files", cat},
+	{"get", "path local", "download a file or directory", get},
+	{"put", "local|- path", "upload a file or directory, or stdin", put},
+	{"mkdir", "path...", "create directories, and any missing parents", mkdir},
+	{"rm", "[-r] path...", "remove files, or directories", rm},
+	{"mv", "path newpath", "move a file or directory", mv},
+	{"tree", "[path]", "list a directory recursively", tree},
+	{"du", "[path...]", "report the total size of files under each path", du},
+}
+
+// Returned by commands whose arguments are wrong.
+var errUsage = errors.New("usage")
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "usage: sfs [-network unix|tcp] -addr <address> [-json] <command> [arguments]")
+	fmt.Fprintln(out, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-6s %-18s %s\n", c.name, c.args, c.help)
+	}
+	fmt.Fprintln(out, "\noptions:")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("sfs: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *addr == "" {
+		*addr = os.Getenv("SFS_ADDR")
+	}
+	if *addr == "" || flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == flag.Arg(0) {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		log.Printf("unknown command %q", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	c, err := net.Dial(*network, *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conn := rpc.NewConn(rpc.NewStreamTransport(c), nil)
+	root := filesystem.RwDirectory{Client: conn.Bootstrap(ctx)}
+
+	err = cmd.run(ctx, root, flag.Args()[1:])
+	root.Client.Release()
+	conn.Close()
+	if err == errUsage {
+		fmt.Fprintf(os.Stderr, "usage: sfs %s %s\n", cmd.name, cmd.args)
+		os.Exit(2)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -1,9 +1,9 @@
 'use strict';
 
 document.addEventListener('DOMContentLoaded', function() {
-	document.getElementById('request-btn').addEventListener('click', function() {
+	document.getElementById('{{ .Button }}').addEventListener('click', function() {
 		window.addEventListener('message', function(event) {
-			if(event.data.rpcId !== 0) {
+			if(event.data.rpcId !== {{ .RpcId }}) {
 				return;
 			}
 
@@ -18,14 +18,14 @@ document.addEventListener('DOMContentLoaded', function() {
 					window.location.reload(true);
 				}
 			}
-			xhr.open("POST", "/filesystem-cap", true);
+			xhr.open("POST", "{{ .Path }}", true);
 			xhr.overrideMimeType("text/plain; charset=x-user-defined");
 			xhr.send(event.data.token);
 		});
 		window.parent.postMessage({powerboxRequest: {
-			rpcId: 0,
+			rpcId: {{ .RpcId }},
 			// packed, base64-encoded contents of the powerbox descriptor:
-			query: ['{{ . }}'],
+			query: ['{{ .Query }}'],
 		}}, "*");
 	});
 });
@@ -3,27 +3,89 @@ package main
 import (
 	"archive/zip"
 	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"strings"
 
 	"github.com/gorilla/mux"
 
 	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
 
 	grain_capnp "zenhack.net/go/sandstorm/capnp/grain"
 	bridge_capnp "zenhack.net/go/sandstorm/capnp/sandstormhttpbridge"
 	"zenhack.net/go/sandstorm/exp/sandstormhttpbridge"
-
-	"zenhack.net/go/sandstorm/exp/util/bytestream"
 )
 
 var (
 	rootRwDir filesystem.RwDirectory
+
+	ChecksumMismatch = errors.New("Written file does not match the zip's checksum")
 )
 
+// Check that the contents of file match the CRC32 recorded for it in the
+// zip archive, so we know it arrived intact.
+func verifyCRC32(ctx context.Context, file filesystem.RwFile, want uint32) error {
+	res, release := file.Hash(ctx, func(p filesystem.File_hash_Params) error {
+		p.SetAlgorithm(filesystem.File_HashAlgorithm_crc32)
+		return nil
+	})
+	defer release()
+	results, err := res.Struct()
+	if err != nil {
+		return err
+	}
+	digest, err := results.Digest()
+	if err != nil {
+		return err
+	}
+	if len(digest) != 4 || binary.BigEndian.Uint32(digest) != want {
+		return ChecksumMismatch
+	}
+	return nil
+}
+
+// Write the files in r to dir, stopping at the first one that fails.
+func extractZip(ctx context.Context, dir filesystem.RwDirectory, r *zip.Reader) error {
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			// This hasn't happened in my(zenhack) experimentation, but
+			// I don't understand the zip format well enough to know if
+			// it could. We skip directories just in case. They seem to
+			// normally be encoded as files with parent dirs as part of
+			// their name.
+			continue
+		}
+		file, err := f.Open()
+		if err != nil {
+			return err
+		}
+		out, err := client.Create(ctx, dir, f.Name, (f.Mode()&0111) != 0)
+		if err == nil {
+			wc := client.NewWriter(ctx, out, 0)
+			_, err = io.Copy(wc, file)
+			if err2 := wc.Close(); err == nil {
+				err = err2
+			}
+			if err == nil {
+				err = verifyCRC32(ctx, out, f.CRC32)
+			}
+			out.Client.Release()
+		}
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
 func initZipUploader(bridge bridge_capnp.SandstormHttpBridge) {
 	r := mux.NewRouter()
 
@@ -98,68 +160,10 @@ func initZipUploader(bridge bridge_capnp.SandstormHttpBridge) {
 				badReq(err.Error())
 				return
 			}
-			for _, f := range r.File {
-				if f.FileInfo().IsDir() {
-					// This hasn't happened in my(zenhack) experimentation, but
-					// I don't understand the zip format well enough to know if
-					// it could. We skip directories just in case. They seem to
-					// normally be encoded as files with parent dirs as part of
-					// their name.
-					continue
-				}
-				rwDir := rootRwDir
-				parts := strings.Split(f.Name, "/")
-				if len(parts) > 1 {
-					for _, part := range parts[:len(parts)-1] {
-						// Mkdir might fail if the directory already exists, so
-						// instead of using its return value, we just make
-						// another call to walk afterwards.
-						_, release := rwDir.Mkdir(
-							ctx,
-							func(p filesystem.RwDirectory_mkdir_Params) error {
-								p.SetName(part)
-								return nil
-							})
-						release()
-						res, release := rwDir.Walk(
-							ctx,
-							func(p filesystem.Directory_walk_Params) error {
-								p.SetName(part)
-								return nil
-							})
-						rwDir.Client = res.Node().Client
-						// TODO: better place to put this?
-						defer release()
-					}
-				}
-				file, err := f.Open()
-				if err != nil {
-					badReq(err.Error())
-					return
-				}
-				createRes, releaseCreate := rwDir.Create(
-					ctx,
-					func(p filesystem.RwDirectory_create_Params) error {
-						p.SetName(f.Name)
-						p.SetExecutable((f.Mode() & 0111) != 0)
-						return nil
-					})
-				writeRes, releaseWrite := createRes.File().Write(
-					ctx,
-					func(p filesystem.RwFile_write_Params) error {
-						p.SetStartAt(0)
-						return nil
-					})
-				out := writeRes.Sink()
-				wc := bytestream.ToWriteCloser(ctx, out)
-				_, err = io.Copy(wc, file)
-				releaseCreate()
-				releaseWrite()
-				if err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-					log.Print(err)
-					return
-				}
+			if err = extractZip(ctx, rootRwDir, r); err != nil {
+				w.WriteHeader(fserrors.HTTPStatus(err))
+				log.Print(err)
+				return
 			}
 			w.Header().Set("Location", "/")
 			w.WriteHeader(http.StatusSeeOther)
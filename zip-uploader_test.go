@@ -0,0 +1,100 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"zenhack.net/go/sandstorm-filesystem/filesystem"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/client"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/fserrors"
+	"zenhack.net/go/sandstorm-filesystem/filesystem/memfs"
+)
+
+func TestExtractZip(t *testing.T) {
+	ctx := context.Background()
+	files := []struct {
+		name, data string
+		executable bool
+	}{
+		{"README", "read me", false},
+		{"bin/run.sh", "#!/bin/sh\n", true},
+		{"a/b/c.txt", "deep", false},
+	}
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		hdr := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		hdr.SetMode(0644)
+		if f.executable {
+			hdr.SetMode(0755)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := memfs.New(0).Root()
+	defer root.Client.Release()
+	// Existing files are replaced.
+	if err = client.WriteFile(ctx, root, "README", []byte("an older, longer readme"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err = extractZip(ctx, root, r); err != nil {
+		t.Fatal("extractZip: ", err)
+	}
+
+	dir := filesystem.Directory{Client: root.Client}
+	for _, f := range files {
+		data, err := client.ReadFile(ctx, dir, f.name)
+		if err != nil {
+			t.Errorf("%s: %v", f.name, err)
+			continue
+		}
+		if string(data) != f.data {
+			t.Errorf("%s: got %q, want %q", f.name, data, f.data)
+		}
+		fi, err := client.Stat(ctx, dir, f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Executable() != f.executable {
+			t.Errorf("%s: executable = %v, want %v", f.name, fi.Executable(), f.executable)
+		}
+	}
+}
+
+func TestExtractZipFull(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create("big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(make([]byte, 100))
+	zw.Close()
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Errors from the filesystem are passed on, so the handler can
+	// pick the right status.
+	root := memfs.New(10).Root()
+	defer root.Client.Release()
+	err = extractZip(ctx, root, r)
+	if code := fserrors.CodeOf(err); code != filesystem.ErrorCode_quota {
+		t.Errorf("extracting into a full filesystem: got %v (%v), want quota", err, code)
+	}
+}